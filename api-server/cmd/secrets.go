@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/bentoml/yatai/api-server/config"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/common/command"
+	"github.com/bentoml/yatai/common/envelope"
+)
+
+func getKeyProvider() (envelope.KeyProvider, error) {
+	encryptionConfig := config.YataiConfig.Encryption
+	switch encryptionConfig.Provider {
+	case "local":
+		return envelope.NewLocalKeyProvider(encryptionConfig.KeyFile)
+	case "kms":
+		return envelope.NewKMSKeyProvider(encryptionConfig.KMS.Name, encryptionConfig.KMS.KeyId, encryptionConfig.KMS.Options)
+	default:
+		return nil, nil
+	}
+}
+
+func initEncryption() error {
+	provider, err := getKeyProvider()
+	if err != nil {
+		return errors.Wrapf(err, "create %s key provider", config.YataiConfig.Encryption.Provider)
+	}
+	if provider == nil {
+		logrus.Warn("the secret encryption is not configured, the cluster kubeconfigs and the organization credentials are stored as plaintext")
+		envelope.SetDefault(nil)
+		return nil
+	}
+	envelope.SetDefault(envelope.NewEncrypter(provider))
+	return nil
+}
+
+func reencryptSecrets(ctx context.Context) error {
+	res, err := services.ReencryptSecrets(ctx)
+	if err != nil {
+		return errors.Wrap(err, "reencrypt secrets")
+	}
+	logrus.Infof("reencrypted the secrets of %d clusters and %d organizations with key %s", res.Clusters, res.Organizations, envelope.Default().PrimaryKeyId())
+	return nil
+}
+
+type SecretsReencryptOption struct {
+	ConfigPath string
+}
+
+func (opt *SecretsReencryptOption) Complete(ctx context.Context, args []string, argsLenAtDash int) error {
+	return nil
+}
+
+func (opt *SecretsReencryptOption) Validate(ctx context.Context) error {
+	return nil
+}
+
+func (opt *SecretsReencryptOption) Run(ctx context.Context, args []string) error {
+	err := loadConfig(opt.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if config.YataiConfig.Encryption.Provider == "" {
+		return errors.New("the secret encryption is not configured, set encryption.provider in the config file")
+	}
+	err = initEncryption()
+	if err != nil {
+		return errors.Wrap(err, "init encryption")
+	}
+	return reencryptSecrets(ctx)
+}
+
+type SecretsRotateKeyOption struct {
+	ConfigPath string
+}
+
+func (opt *SecretsRotateKeyOption) Complete(ctx context.Context, args []string, argsLenAtDash int) error {
+	return nil
+}
+
+func (opt *SecretsRotateKeyOption) Validate(ctx context.Context) error {
+	return nil
+}
+
+func (opt *SecretsRotateKeyOption) Run(ctx context.Context, args []string) error {
+	err := loadConfig(opt.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if config.YataiConfig.Encryption.Provider != "local" {
+		return errors.New("only the keys of the local encryption provider can be rotated, change encryption.kms.key_id, restart every api server and then run the reencrypt command to rotate the kms key")
+	}
+	keyId, err := envelope.GenerateLocalKey(config.YataiConfig.Encryption.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "generate key")
+	}
+	logrus.Infof("generated key %s in key file %s", keyId, config.YataiConfig.Encryption.KeyFile)
+	// the key is neither promoted nor used to reencrypt here, the api servers which have not loaded it could not decrypt the values it encrypts
+	logrus.Info("the new key is not the primary key yet, finish the rotation in order:")
+	logrus.Infof("1. copy the key file %s to every api server and restart all of them to load the new key", config.YataiConfig.Encryption.KeyFile)
+	logrus.Infof("2. run `yatai-api-server secrets promote-key --key-id %s`, then copy the key file to every api server and restart all of them again", keyId)
+	logrus.Info("3. run `yatai-api-server secrets reencrypt` after all the api servers are restarted")
+	logrus.Info("4. keep the old keys in the key file, they are still needed to decrypt the backups")
+	return nil
+}
+
+type SecretsPromoteKeyOption struct {
+	ConfigPath string
+	KeyId      string
+}
+
+func (opt *SecretsPromoteKeyOption) Complete(ctx context.Context, args []string, argsLenAtDash int) error {
+	return nil
+}
+
+func (opt *SecretsPromoteKeyOption) Validate(ctx context.Context) error {
+	if opt.KeyId == "" {
+		return errors.New("--key-id is required")
+	}
+	return nil
+}
+
+func (opt *SecretsPromoteKeyOption) Run(ctx context.Context, args []string) error {
+	err := loadConfig(opt.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if config.YataiConfig.Encryption.Provider != "local" {
+		return errors.New("only the keys of the local encryption provider can be promoted")
+	}
+	err = envelope.PromoteLocalKey(config.YataiConfig.Encryption.KeyFile, opt.KeyId)
+	if err != nil {
+		return errors.Wrap(err, "promote key")
+	}
+	logrus.Infof("promoted key %s to the primary key in key file %s", opt.KeyId, config.YataiConfig.Encryption.KeyFile)
+	logrus.Infof("copy the key file %s to every api server and restart all of them, then run `yatai-api-server secrets reencrypt`", config.YataiConfig.Encryption.KeyFile)
+	return nil
+}
+
+func getSecretsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage the encryption of the cluster kubeconfigs and the organization credentials",
+		Long:  "",
+	}
+	cmd.AddCommand(getSecretsReencryptCmd())
+	cmd.AddCommand(getSecretsRotateKeyCmd())
+	cmd.AddCommand(getSecretsPromoteKeyCmd())
+	return cmd
+}
+
+func getSecretsReencryptCmd() *cobra.Command {
+	var opt SecretsReencryptOption
+	cmd := &cobra.Command{
+		Use:   "reencrypt",
+		Short: "Encrypt the plaintext secrets and the secrets of the old keys with the primary key",
+		Long:  "",
+		RunE:  command.MakeRunE(&opt),
+	}
+	cmd.Flags().StringVarP(&opt.ConfigPath, "config", "c", "./yatai-config.dev.yaml", "")
+	return cmd
+}
+
+func getSecretsRotateKeyCmd() *cobra.Command {
+	var opt SecretsRotateKeyOption
+	cmd := &cobra.Command{
+		Use:   "rotate-key",
+		Short: "Generate a new key in the local key file, promote it after every api server has loaded it",
+		Long:  "",
+		RunE:  command.MakeRunE(&opt),
+	}
+	cmd.Flags().StringVarP(&opt.ConfigPath, "config", "c", "./yatai-config.dev.yaml", "")
+	return cmd
+}
+
+func getSecretsPromoteKeyCmd() *cobra.Command {
+	var opt SecretsPromoteKeyOption
+	cmd := &cobra.Command{
+		Use:   "promote-key",
+		Short: "Make a key of the local key file the primary key, run reencrypt after every api server has loaded it",
+		Long:  "",
+		RunE:  command.MakeRunE(&opt),
+	}
+	cmd.Flags().StringVarP(&opt.ConfigPath, "config", "c", "./yatai-config.dev.yaml", "")
+	cmd.Flags().StringVar(&opt.KeyId, "key-id", "", "the id of the key generated by rotate-key")
+	return cmd
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,12 +16,16 @@ import (
 	"github.com/tianweidut/cron"
 	"gopkg.in/yaml.v3"
 
+	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai/api-server/config"
+	"github.com/bentoml/yatai/api-server/metrics"
 	"github.com/bentoml/yatai/api-server/routes"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/api-server/services/tracking"
+	"github.com/bentoml/yatai/api-server/version"
 	"github.com/bentoml/yatai/common/command"
 	"github.com/bentoml/yatai/common/sync/errsgroup"
+	"github.com/bentoml/yatai/common/tracing"
 )
 
 func addCron(ctx context.Context) {
@@ -29,12 +35,25 @@ func addCron(ctx context.Context) {
 	// Add cron for tracking lifecycle events
 	tracking.AddLifeCycleTrackingCron(ctx, c)
 
-	err := c.AddFunc("@every 1m", func() {
+	// deployment statuses are synced by the informer event handlers,
+	// this cron is only a backstop for the missed events
+	err := c.AddFunc(fmt.Sprintf("@every %s", services.DeploymentStatusReconcileInterval), func() {
+		begin := time.Now()
+		failed := false
+		defer func() {
+			metrics.ObserveCronRun("sync_deployment_status", begin, failed)
+		}()
+		err := services.DeploymentStatusWatcherService.Start(ctx)
+		if err != nil {
+			failed = true
+			logger.Errorf("start deployment status watcher: %s", err.Error())
+		}
 		ctx, cancel := context.WithTimeout(ctx, time.Minute*5)
 		defer cancel()
 		logger.Info("listing unsynced deployments")
 		deployments, err := services.DeploymentService.ListUnsynced(ctx)
 		if err != nil {
+			failed = true
 			logger.Errorf("list unsynced deployments: %s", err.Error())
 		}
 		logger.Info("updating unsynced deployments syncing_at")
@@ -45,6 +64,7 @@ func addCron(ctx context.Context) {
 				SyncingAt: &nowPtr,
 			})
 			if err != nil {
+				failed = true
 				logger.Errorf("update deployment %d status: %s", deployment.ID, err.Error())
 			}
 		}
@@ -63,6 +83,7 @@ func addCron(ctx context.Context) {
 		err = eg.WaitWithTimeout(10 * time.Minute)
 		logger.Info("synced unsynced app deployment deployments...")
 		if err != nil {
+			failed = true
 			logger.Errorf("sync deployments: %s", err.Error())
 		}
 	})
@@ -71,6 +92,261 @@ func addCron(ctx context.Context) {
 		logger.Errorf("cron add func failed: %s", err.Error())
 	}
 
+	webhookLogger := logrus.New().WithField("cron", "retry webhook deliveries")
+
+	err = c.AddFunc("@every 1m", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("retry_webhook_deliveries", begin, err != nil)
+		}()
+		ctx, cancel := context.WithTimeout(ctx, time.Minute*5)
+		defer cancel()
+		deliveries, err := services.WebhookDeliveryService.ListDue(ctx)
+		if err != nil {
+			webhookLogger.Errorf("list due webhook deliveries: %s", err.Error())
+			return
+		}
+		var eg errsgroup.Group
+		eg.SetPoolSize(100)
+		for _, delivery := range deliveries {
+			delivery := delivery
+			eg.Go(func() error {
+				_, err := services.WebhookDeliveryService.Deliver(ctx, delivery)
+				return err
+			})
+		}
+		err = eg.WaitWithTimeout(5 * time.Minute)
+		if err != nil {
+			webhookLogger.Errorf("deliver webhooks: %s", err.Error())
+		}
+	})
+
+	if err != nil {
+		webhookLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
+	rolloutLogger := logrus.New().WithField("cron", "advance deployment rollouts")
+
+	err = c.AddFunc("@every 30s", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("advance_deployment_rollouts", begin, err != nil)
+		}()
+		ctx, cancel := context.WithTimeout(ctx, time.Minute*5)
+		defer cancel()
+		rollouts, err := services.DeploymentRolloutService.ListDue(ctx)
+		if err != nil {
+			rolloutLogger.Errorf("list due deployment rollouts: %s", err.Error())
+			return
+		}
+		var eg errsgroup.Group
+		eg.SetPoolSize(10)
+		for _, rollout := range rollouts {
+			rollout := rollout
+			eg.Go(func() error {
+				_, err := services.DeploymentRolloutService.Advance(ctx, rollout)
+				return err
+			})
+		}
+		err = eg.WaitWithTimeout(5 * time.Minute)
+		if err != nil {
+			rolloutLogger.Errorf("advance deployment rollouts: %s", err.Error())
+		}
+	})
+
+	if err != nil {
+		rolloutLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
+	scalingScheduleLogger := logrus.New().WithField("cron", "run deployment scaling schedules")
+
+	err = c.AddFunc("@every 1m", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("run_deployment_scaling_schedules", begin, err != nil)
+		}()
+		ctx, cancel := context.WithTimeout(ctx, time.Minute*5)
+		defer cancel()
+		schedules, err := services.DeploymentScalingScheduleService.ListDue(ctx)
+		if err != nil {
+			scalingScheduleLogger.Errorf("list due deployment scaling schedules: %s", err.Error())
+			return
+		}
+		// the schedules are run one by one, several schedules of a deployment may be due at the same time
+		for _, schedule := range schedules {
+			_, err_ := services.DeploymentScalingScheduleService.Run(ctx, schedule)
+			if err_ != nil {
+				err = err_
+				scalingScheduleLogger.Errorf("run deployment scaling schedule %s: %s", schedule.Uid, err_.Error())
+			}
+		}
+	})
+
+	if err != nil {
+		scalingScheduleLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
+	federatedLogger := logrus.New().WithField("cron", "sync federated deployment status")
+
+	err = c.AddFunc("@every 1m", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("sync_federated_deployment_status", begin, err != nil)
+		}()
+		ctx, cancel := context.WithTimeout(ctx, time.Minute*5)
+		defer cancel()
+		federatedDeployments, _, err := services.FederatedDeploymentService.List(ctx, services.ListFederatedDeploymentOption{
+			Statuses: &[]modelschemas.DeploymentStatus{
+				modelschemas.DeploymentStatusUnknown,
+				modelschemas.DeploymentStatusRunning,
+				modelschemas.DeploymentStatusUnhealthy,
+				modelschemas.DeploymentStatusFailed,
+				modelschemas.DeploymentStatusDeploying,
+				modelschemas.DeploymentStatusTerminating,
+				modelschemas.DeploymentStatusImageBuilding,
+				modelschemas.DeploymentStatusImageBuildFailed,
+				modelschemas.DeploymentStatusImageBuildSucceeded,
+			},
+		})
+		if err != nil {
+			federatedLogger.Errorf("list federated deployments: %s", err.Error())
+			return
+		}
+		for _, federatedDeployment := range federatedDeployments {
+			_, err_ := services.FederatedDeploymentService.SyncStatus(ctx, federatedDeployment)
+			if err_ != nil {
+				err = err_
+				federatedLogger.Errorf("sync federated deployment %s status: %s", federatedDeployment.Name, err_.Error())
+			}
+		}
+	})
+
+	if err != nil {
+		federatedLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
+	retentionLogger := logrus.New().WithField("cron", "run retention policies")
+
+	err = c.AddFunc("@every 1h", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("run_retention_policies", begin, err != nil)
+		}()
+		ctx, cancel := context.WithTimeout(ctx, time.Minute*30)
+		defer cancel()
+		err = services.RetentionPolicyService.RunAll(ctx)
+		if err != nil {
+			retentionLogger.Errorf("run retention policies: %s", err.Error())
+		}
+	})
+
+	if err != nil {
+		retentionLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
+	terminalRecordLogger := logrus.New().WithField("cron", "migrate terminal records to object storage")
+
+	err = c.AddFunc("@every 1h", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("migrate_terminal_records", begin, err != nil)
+		}()
+		ctx, cancel := context.WithTimeout(ctx, time.Minute*30)
+		defer cancel()
+		err = services.TerminalRecordService.MigrateContentToObjectStorage(ctx)
+		if err != nil {
+			terminalRecordLogger.Errorf("migrate terminal records to object storage: %s", err.Error())
+		}
+	})
+
+	if err != nil {
+		terminalRecordLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
+	componentHealthLogger := logrus.New().WithField("cron", "check yatai components health")
+
+	err = c.AddFunc("@every 1m", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("check_yatai_components_health", begin, err != nil)
+		}()
+		ctx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		err = services.YataiComponentService.CheckHealth(ctx)
+		if err != nil {
+			componentHealthLogger.Errorf("check yatai components health: %s", err.Error())
+		}
+	})
+
+	if err != nil {
+		componentHealthLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
+	clusterHealthLogger := logrus.New().WithField("cron", "probe clusters health")
+
+	err = c.AddFunc("@every 1m", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("probe_clusters_health", begin, err != nil)
+		}()
+		ctx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		err = services.ClusterService.ProbeAllHealth(ctx)
+		if err != nil {
+			clusterHealthLogger.Errorf("probe clusters health: %s", err.Error())
+		}
+	})
+
+	if err != nil {
+		clusterHealthLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
+	wildcardCertificateLogger := logrus.New().WithField("cron", "sync wildcard certificates")
+
+	err = c.AddFunc("@every 5m", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("sync_wildcard_certificates", begin, err != nil)
+		}()
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		err = services.KubeIngressService.SyncAllWildcardCertificateSecrets(ctx)
+		if err != nil {
+			wildcardCertificateLogger.Errorf("sync wildcard certificates: %s", err.Error())
+		}
+	})
+
+	if err != nil {
+		wildcardCertificateLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
+	apiKeyUsageLogger := logrus.New().WithField("cron", "flush api key usage")
+
+	err = c.AddFunc("@every 1m", func() {
+		begin := time.Now()
+		var err error
+		defer func() {
+			metrics.ObserveCronRun("flush_api_key_usage", begin, err != nil)
+		}()
+		err = services.DeploymentAuthService.FlushUsage(ctx)
+		if err != nil {
+			apiKeyUsageLogger.Errorf("flush api key usage: %s", err.Error())
+		}
+	})
+
+	if err != nil {
+		apiKeyUsageLogger.Errorf("cron add func failed: %s", err.Error())
+	}
+
 	c.Start()
 }
 
@@ -86,6 +362,24 @@ func (opt *ServeOption) Complete(ctx context.Context, args []string, argsLenAtDa
 	return nil
 }
 
+func loadConfig(configPath string) error {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return errors.Wrapf(err, "read config file: %s", configPath)
+	}
+
+	err = yaml.Unmarshal(content, config.YataiConfig)
+	if err != nil {
+		return errors.Wrapf(err, "unmarshal config file: %s", configPath)
+	}
+
+	err = config.PopulateYataiConfig()
+	if err != nil {
+		return errors.Wrapf(err, "populate config file: %s", configPath)
+	}
+	return nil
+}
+
 func initSelfHost(ctx context.Context) error {
 	defaultOrg, err := services.OrganizationService.GetDefault(ctx)
 	if err != nil {
@@ -102,19 +396,36 @@ func (opt *ServeOption) Run(ctx context.Context, args []string) error {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	content, err := os.ReadFile(opt.ConfigPath)
+	err := loadConfig(opt.ConfigPath)
 	if err != nil {
-		return errors.Wrapf(err, "read config file: %s", opt.ConfigPath)
+		return err
 	}
 
-	err = yaml.Unmarshal(content, config.YataiConfig)
+	err = initEncryption()
 	if err != nil {
-		return errors.Wrapf(err, "unmarshal config file: %s", opt.ConfigPath)
+		return errors.Wrap(err, "init encryption")
 	}
 
-	err = config.PopulateYataiConfig()
-	if err != nil {
-		return errors.Wrapf(err, "populate config file: %s", opt.ConfigPath)
+	if config.YataiConfig.Tracing.Enabled {
+		shutdownTracing, err := tracing.Init(ctx, tracing.InitOption{
+			ServiceName:    config.YataiConfig.Tracing.ServiceName,
+			ServiceVersion: version.Version,
+			Protocol:       tracing.ExporterProtocol(config.YataiConfig.Tracing.Protocol),
+			Endpoint:       config.YataiConfig.Tracing.Endpoint,
+			Insecure:       config.YataiConfig.Tracing.Insecure,
+			Headers:        config.YataiConfig.Tracing.Headers,
+			SampleRatio:    config.YataiConfig.Tracing.SampleRatio,
+		})
+		if err != nil {
+			return errors.Wrap(err, "init tracing")
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logrus.Errorf("shutdown tracing: %s", err.Error())
+			}
+		}()
 	}
 
 	err = services.MigrateUp()
@@ -131,6 +442,13 @@ func (opt *ServeOption) Run(ctx context.Context, args []string) error {
 
 	addCron(ctx)
 
+	go func() {
+		err := services.DeploymentStatusWatcherService.Start(ctx)
+		if err != nil {
+			logrus.Errorf("start deployment status watcher: %s", err.Error())
+		}
+	}()
+
 	// nolint: contextcheck
 	router, err := routes.NewRouter()
 	if err != nil {
@@ -142,6 +460,21 @@ func (opt *ServeOption) Run(ctx context.Context, args []string) error {
 		readHeaderTimeout = time.Duration(config.YataiConfig.Server.ReadHeaderTimeout) * time.Second
 	}
 
+	if config.YataiConfig.Server.MetricsPort != 0 {
+		go func() {
+			logrus.Infof("serving metrics on 0.0.0.0:%d", config.YataiConfig.Server.MetricsPort)
+			metricsSrv := &http.Server{
+				Addr:              fmt.Sprintf(":%d", config.YataiConfig.Server.MetricsPort),
+				Handler:           routes.NewMetricsRouter(),
+				ReadHeaderTimeout: readHeaderTimeout,
+			}
+			err := metricsSrv.ListenAndServe()
+			if err != nil {
+				logrus.Errorf("serve metrics: %s", err.Error())
+			}
+		}()
+	}
+
 	logrus.Infof("listening on 0.0.0.0:%d", config.YataiConfig.Server.Port)
 
 	srv := &http.Server{
@@ -149,7 +482,31 @@ func (opt *ServeOption) Run(ctx context.Context, args []string) error {
 		Handler:           router,
 		ReadHeaderTimeout: readHeaderTimeout,
 	}
-	return srv.ListenAndServe()
+
+	// the server is shut down on the termination signals, so the usage of the api keys which is buffered since the last flush is not lost on every restart
+	signalCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-signalCtx.Done()
+		logrus.Info("shutting down the server ...")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			logrus.Errorf("shutdown the server: %s", err.Error())
+		}
+	}()
+
+	err = srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-shutdownDone
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return errors.Wrap(services.DeploymentAuthService.FlushUsage(flushCtx), "flush api key usage")
 }
 
 func getServeCmd() *cobra.Command {
@@ -16,6 +16,10 @@ type YataiServerConfigYaml struct {
 	MigrationDir         string `yaml:"migration_dir"`
 	ReadHeaderTimeout    int    `yaml:"read_header_timeout"`
 	TransmissionStrategy string `yaml:"transmission_strategy"`
+	// the url of yatai which the ingress controllers of the clusters reach, the deployment authentication sends the auth requests to it
+	ExternalURL string `yaml:"external_url"`
+	// the prometheus metrics are served on this port instead of the public port, 0 disables them
+	MetricsPort uint `yaml:"metrics_port"`
 }
 
 type YataiPostgresqlConfigYaml struct {
@@ -49,15 +53,80 @@ type YataiDockerImageBuilderConfigYaml struct {
 	Privileged bool `yaml:"privileged"`
 }
 
+type YataiOIDCGroupRoleMappingYaml struct {
+	Group        string `yaml:"group"`
+	Organization string `yaml:"organization"`
+	Role         string `yaml:"role"`
+}
+
+type YataiOIDCConfigYaml struct {
+	Issuer               string                          `yaml:"issuer"`
+	ClientId             string                          `yaml:"client_id"`
+	ClientSecret         string                          `yaml:"client_secret"`
+	RedirectURL          string                          `yaml:"redirect_url"`
+	Scopes               []string                        `yaml:"scopes"`
+	UsernameClaim        string                          `yaml:"username_claim"`
+	EmailClaim           string                          `yaml:"email_claim"`
+	FirstNameClaim       string                          `yaml:"first_name_claim"`
+	LastNameClaim        string                          `yaml:"last_name_claim"`
+	GroupsClaim          string                          `yaml:"groups_claim"`
+	GroupRoleMappings    []YataiOIDCGroupRoleMappingYaml `yaml:"group_role_mappings"`
+	DisablePasswordLogin bool                            `yaml:"disable_password_login"`
+}
+
+type YataiComponentHealthConfigYaml struct {
+	// a component is stale when it has not sent a heartbeat for StaleAfterSeconds
+	StaleAfterSeconds int `yaml:"stale_after_seconds"`
+	// a component is offline when it has not sent a heartbeat for OfflineAfterSeconds
+	OfflineAfterSeconds int `yaml:"offline_after_seconds"`
+}
+
+type YataiTerminalRecordConfigYaml struct {
+	// the recording of a terminal session stops at MaxSizeBytes, the session itself goes on
+	MaxSizeBytes int64 `yaml:"max_size_bytes"`
+}
+
+type YataiTracingConfigYaml struct {
+	Enabled bool `yaml:"enabled"`
+	// grpc or http
+	Protocol string `yaml:"protocol"`
+	// the OTEL_EXPORTER_OTLP_ENDPOINT environment variable is used if it is empty
+	Endpoint    string            `yaml:"endpoint"`
+	Insecure    bool              `yaml:"insecure"`
+	Headers     map[string]string `yaml:"headers"`
+	ServiceName string            `yaml:"service_name"`
+	// the ratio of the root spans which are sampled, the child spans follow their parents
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type YataiKMSConfigYaml struct {
+	// the name of the registered kms client
+	Name    string            `yaml:"name"`
+	KeyId   string            `yaml:"key_id"`
+	Options map[string]string `yaml:"options"`
+}
+
+type YataiEncryptionConfigYaml struct {
+	// local or kms, the secrets are stored as plaintext if it is empty
+	Provider string             `yaml:"provider"`
+	KeyFile  string             `yaml:"key_file"`
+	KMS      YataiKMSConfigYaml `yaml:"kms"`
+}
+
 type YataiConfigYaml struct {
-	IsSaaS              bool                      `yaml:"is_saas"`
-	SaasDomainSuffix    string                    `yaml:"saas_domain_suffix"`
-	InCluster           bool                      `yaml:"in_cluster"`
-	Server              YataiServerConfigYaml     `yaml:"server"`
-	Postgresql          YataiPostgresqlConfigYaml `yaml:"postgresql"`
-	S3                  *YataiS3ConfigYaml        `yaml:"s3,omitempty"`
-	NewsURL             string                    `yaml:"news_url"`
-	InitializationToken string                    `yaml:"initialization_token"`
+	IsSaaS              bool                           `yaml:"is_saas"`
+	SaasDomainSuffix    string                         `yaml:"saas_domain_suffix"`
+	InCluster           bool                           `yaml:"in_cluster"`
+	Server              YataiServerConfigYaml          `yaml:"server"`
+	Postgresql          YataiPostgresqlConfigYaml      `yaml:"postgresql"`
+	S3                  *YataiS3ConfigYaml             `yaml:"s3,omitempty"`
+	NewsURL             string                         `yaml:"news_url"`
+	InitializationToken string                         `yaml:"initialization_token"`
+	OIDC                *YataiOIDCConfigYaml           `yaml:"oidc,omitempty"`
+	ComponentHealth     YataiComponentHealthConfigYaml `yaml:"component_health"`
+	TerminalRecord      YataiTerminalRecordConfigYaml  `yaml:"terminal_record"`
+	Tracing             YataiTracingConfigYaml         `yaml:"tracing"`
+	Encryption          YataiEncryptionConfigYaml      `yaml:"encryption"`
 }
 
 var YataiConfig = &YataiConfigYaml{}
@@ -112,6 +181,9 @@ func PopulateYataiConfig() error {
 	if YataiConfig.Server.Port == 0 {
 		YataiConfig.Server.Port = 7777
 	}
+	if YataiConfig.Server.MetricsPort != 0 && YataiConfig.Server.MetricsPort == YataiConfig.Server.Port {
+		return errors.New("server.metrics_port should be different from server.port")
+	}
 
 	readHeaderTimeout, ok := os.LookupEnv(consts.EnvReadHeaderTimeout)
 	if ok {
@@ -127,6 +199,47 @@ func PopulateYataiConfig() error {
 		YataiConfig.Server.TransmissionStrategy = transmissionStrategy
 	}
 
+	if YataiConfig.ComponentHealth.StaleAfterSeconds <= 0 {
+		YataiConfig.ComponentHealth.StaleAfterSeconds = 180
+	}
+	if YataiConfig.ComponentHealth.OfflineAfterSeconds <= 0 {
+		YataiConfig.ComponentHealth.OfflineAfterSeconds = 600
+	}
+	if YataiConfig.ComponentHealth.OfflineAfterSeconds <= YataiConfig.ComponentHealth.StaleAfterSeconds {
+		return errors.New("component_health.offline_after_seconds should be greater than component_health.stale_after_seconds")
+	}
+
+	if YataiConfig.TerminalRecord.MaxSizeBytes <= 0 {
+		YataiConfig.TerminalRecord.MaxSizeBytes = 10 * 1024 * 1024
+	}
+
+	if YataiConfig.Tracing.Protocol == "" {
+		YataiConfig.Tracing.Protocol = "grpc"
+	}
+	if YataiConfig.Tracing.Protocol != "grpc" && YataiConfig.Tracing.Protocol != "http" {
+		return errors.Errorf("tracing.protocol should be grpc or http, got %q", YataiConfig.Tracing.Protocol)
+	}
+	if YataiConfig.Tracing.ServiceName == "" {
+		YataiConfig.Tracing.ServiceName = "yatai-api-server"
+	}
+	if YataiConfig.Tracing.SampleRatio <= 0 || YataiConfig.Tracing.SampleRatio > 1 {
+		YataiConfig.Tracing.SampleRatio = 1
+	}
+
+	switch YataiConfig.Encryption.Provider {
+	case "":
+	case "local":
+		if YataiConfig.Encryption.KeyFile == "" {
+			return errors.New("encryption.key_file is required by the local encryption provider")
+		}
+	case "kms":
+		if YataiConfig.Encryption.KMS.Name == "" || YataiConfig.Encryption.KMS.KeyId == "" {
+			return errors.New("encryption.kms.name and encryption.kms.key_id are required by the kms encryption provider")
+		}
+	default:
+		return errors.Errorf("encryption.provider should be local or kms, got %q", YataiConfig.Encryption.Provider)
+	}
+
 	initializationToken, ok := os.LookupEnv(consts.EnvInitializationToken)
 	if ok {
 		YataiConfig.InitializationToken = initializationToken
@@ -170,5 +283,54 @@ func PopulateYataiConfig() error {
 		makesureS3IsNotNil()
 		YataiConfig.S3.BucketName = s3BucketName
 	}
+
+	makesureOIDCIsNotNil := func() {
+		if YataiConfig.OIDC == nil {
+			YataiConfig.OIDC = &YataiOIDCConfigYaml{}
+		}
+	}
+	oidcIssuer, ok := os.LookupEnv(consts.EnvOIDCIssuer)
+	if ok {
+		makesureOIDCIsNotNil()
+		YataiConfig.OIDC.Issuer = oidcIssuer
+	}
+	oidcClientId, ok := os.LookupEnv(consts.EnvOIDCClientId)
+	if ok {
+		makesureOIDCIsNotNil()
+		YataiConfig.OIDC.ClientId = oidcClientId
+	}
+	oidcClientSecret, ok := os.LookupEnv(consts.EnvOIDCClientSecret)
+	if ok {
+		makesureOIDCIsNotNil()
+		YataiConfig.OIDC.ClientSecret = oidcClientSecret
+	}
+	oidcRedirectURL, ok := os.LookupEnv(consts.EnvOIDCRedirectURL)
+	if ok {
+		makesureOIDCIsNotNil()
+		YataiConfig.OIDC.RedirectURL = oidcRedirectURL
+	}
+	if YataiConfig.OIDC != nil {
+		if YataiConfig.OIDC.Issuer == "" || YataiConfig.OIDC.ClientId == "" {
+			return errors.New("oidc issuer and client id are required when oidc is configured")
+		}
+		if len(YataiConfig.OIDC.Scopes) == 0 {
+			YataiConfig.OIDC.Scopes = []string{"openid", "profile", "email"}
+		}
+		if YataiConfig.OIDC.UsernameClaim == "" {
+			YataiConfig.OIDC.UsernameClaim = "preferred_username"
+		}
+		if YataiConfig.OIDC.EmailClaim == "" {
+			YataiConfig.OIDC.EmailClaim = "email"
+		}
+		if YataiConfig.OIDC.FirstNameClaim == "" {
+			YataiConfig.OIDC.FirstNameClaim = "given_name"
+		}
+		if YataiConfig.OIDC.LastNameClaim == "" {
+			YataiConfig.OIDC.LastNameClaim = "family_name"
+		}
+		if YataiConfig.OIDC.GroupsClaim == "" {
+			YataiConfig.OIDC.GroupsClaim = "groups"
+		}
+	}
 	return nil
 }
@@ -69,6 +69,12 @@ func (c *apiTokenController) Create(ctx *gin.Context, schema *CreateApiTokenSche
 	if err != nil {
 		return nil, errors.Wrap(err, "create apiToken")
 	}
+	createEvent(ctx, services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   modelschemas.ResourceTypeApiToken,
+		ResourceId:     apiToken.ID,
+		OperationName:  "created",
+	}, nil)
 	return transformersv1.ToApiTokenFullSchema(ctx, apiToken)
 }
 
@@ -82,6 +88,13 @@ func (c *apiTokenController) Update(ctx *gin.Context, schema *UpdateApiTokenSche
 	if err != nil {
 		return nil, err
 	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &apiToken.OrganizationId,
+		ResourceType:   modelschemas.ResourceTypeApiToken,
+		ResourceId:     apiToken.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	var scopes **modelschemas.ApiTokenScopes
 	if schema.Scopes != nil {
 		scopes = &schema.Scopes
@@ -101,6 +114,25 @@ func (c *apiTokenController) Update(ctx *gin.Context, schema *UpdateApiTokenSche
 	return transformersv1.ToApiTokenSchema(ctx, apiToken)
 }
 
+func (c *apiTokenController) Regenerate(ctx *gin.Context, schema *GetApiTokenSchema) (*schemasv1.ApiTokenFullSchema, error) {
+	apiToken, err := schema.GetApiToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &apiToken.OrganizationId,
+		ResourceType:   modelschemas.ResourceTypeApiToken,
+		ResourceId:     apiToken.ID,
+		OperationName:  "regenerated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	apiToken, err = services.ApiTokenService.Regenerate(ctx, apiToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "regenerate apiToken")
+	}
+	return transformersv1.ToApiTokenFullSchema(ctx, apiToken)
+}
+
 func (c *apiTokenController) Get(ctx *gin.Context, schema *GetApiTokenSchema) (*schemasv1.ApiTokenSchema, error) {
 	apiToken, err := schema.GetApiToken(ctx)
 	if err != nil {
@@ -114,6 +146,14 @@ func (c *apiTokenController) Delete(ctx *gin.Context, schema *GetApiTokenSchema)
 	if err != nil {
 		return nil, err
 	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &apiToken.OrganizationId,
+		ResourceType:   modelschemas.ResourceTypeApiToken,
+		ResourceId:     apiToken.ID,
+		OperationName:  "deleted",
+		ResourceName:   apiToken.Name,
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	apiToken, err = services.ApiTokenService.Delete(ctx, apiToken)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,208 @@
+package controllersv1
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+const auditLogExportBatchSize = 500
+
+type auditLogController struct {
+	organizationController
+}
+
+var AuditLogController = auditLogController{}
+
+func (c *auditLogController) getListOption(ctx context.Context, org *models.Organization, filter schemas.AuditLogFilterSchema) (*services.ListEventOption, error) {
+	listOpt := &services.ListEventOption{
+		OrganizationId: utils.UintPtr(org.ID),
+		ResourceType:   filter.ResourceType,
+		Status:         filter.Status,
+		ApiTokenName:   filter.ApiTokenName,
+	}
+	if filter.Actor != nil {
+		users, err := services.UserService.ListByNames(ctx, []string{*filter.Actor})
+		if err != nil {
+			return nil, errors.Wrap(err, "list users")
+		}
+		userIds := make([]uint, 0, len(users))
+		for _, user := range users {
+			userIds = append(userIds, user.ID)
+		}
+		listOpt.CreatorIds = &userIds
+	}
+	if filter.Operation != nil {
+		listOpt.OperationNames = &[]string{*filter.Operation}
+	}
+	if filter.StartedAt != nil {
+		startedAt, err := time.Parse(time.RFC3339, *filter.StartedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse started_at")
+		}
+		listOpt.StartedAt = &startedAt
+	}
+	if filter.EndedAt != nil {
+		endedAt, err := time.Parse(time.RFC3339, *filter.EndedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse ended_at")
+		}
+		listOpt.EndedAt = &endedAt
+	}
+	return listOpt, nil
+}
+
+type ListAuditLogSchema struct {
+	schemasv1.ListQuerySchema
+	schemas.AuditLogFilterSchema
+	GetOrganizationSchema
+}
+
+func (c *auditLogController) List(ctx *gin.Context, schema *ListAuditLogSchema) (*schemas.AuditLogListSchema, error) {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+
+	listOpt, err := c.getListOption(ctx, org, schema.AuditLogFilterSchema)
+	if err != nil {
+		return nil, err
+	}
+	listOpt.BaseListOption = services.BaseListOption{
+		Start: utils.UintPtr(schema.Start),
+		Count: utils.UintPtr(schema.Count),
+	}
+
+	events, total, err := services.EventService.List(ctx, *listOpt)
+	if err != nil {
+		return nil, errors.Wrap(err, "list events")
+	}
+
+	auditLogSchemas, err := transformersv1.ToAuditLogSchemas(ctx, events)
+	return &schemas.AuditLogListSchema{
+		BaseListSchema: schemasv1.BaseListSchema{
+			Total: total,
+			Start: schema.Start,
+			Count: schema.Count,
+		},
+		Items: auditLogSchemas,
+	}, err
+}
+
+type ExportAuditLogSchema struct {
+	schemas.AuditLogFilterSchema
+	GetOrganizationSchema
+	Format schemas.AuditLogExportFormat `query:"format"`
+}
+
+var auditLogCSVHeader = []string{"uid", "created_at", "actor", "actor_uid", "api_token_name", "cluster", "resource_type", "resource_name", "operation_name", "detail", "status"}
+
+func auditLogToCSVRecord(auditLog *schemas.AuditLogSchema) []string {
+	return []string{
+		auditLog.Uid,
+		auditLog.CreatedAt.Format(time.RFC3339),
+		auditLog.Actor,
+		auditLog.ActorUid,
+		auditLog.ApiTokenName,
+		auditLog.Cluster,
+		string(auditLog.ResourceType),
+		auditLog.ResourceName,
+		auditLog.OperationName,
+		auditLog.Detail,
+		string(auditLog.Status),
+	}
+}
+
+// Export streams all the matched audit logs from the newest to the oldest in batches, so the export never holds the whole audit log in memory
+func (c *auditLogController) Export(ctx *gin.Context, schema *ExportAuditLogSchema) error {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return err
+	}
+
+	format := schema.Format
+	if format == "" {
+		format = schemas.AuditLogExportFormatNDJSON
+	}
+	var contentType string
+	switch format {
+	case schemas.AuditLogExportFormatNDJSON:
+		contentType = "application/x-ndjson"
+	case schemas.AuditLogExportFormatCSV:
+		contentType = "text/csv"
+	default:
+		return errors.Errorf("unsupported audit log export format %s", format)
+	}
+
+	listOpt, err := c.getListOption(ctx, org, schema.AuditLogFilterSchema)
+	if err != nil {
+		return err
+	}
+
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-audit-log.%s", org.Name, format))
+	ctx.Writer.WriteHeader(http.StatusOK)
+
+	var csvWriter *csv.Writer
+	jsonEncoder := json.NewEncoder(ctx.Writer)
+	if format == schemas.AuditLogExportFormatCSV {
+		csvWriter = csv.NewWriter(ctx.Writer)
+		if err = csvWriter.Write(auditLogCSVHeader); err != nil {
+			return err
+		}
+	}
+
+	listOpt.BaseListOption = services.BaseListOption{
+		Count: utils.UintPtr(auditLogExportBatchSize),
+	}
+	for {
+		events, _, err := services.EventService.List(ctx, *listOpt)
+		if err != nil {
+			return errors.Wrap(err, "list events")
+		}
+		auditLogSchemas, err := transformersv1.ToAuditLogSchemas(ctx, events)
+		if err != nil {
+			return err
+		}
+		for _, auditLog := range auditLogSchemas {
+			if csvWriter != nil {
+				err = csvWriter.Write(auditLogToCSVRecord(auditLog))
+			} else {
+				err = jsonEncoder.Encode(auditLog)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err = csvWriter.Error(); err != nil {
+				return err
+			}
+		}
+		ctx.Writer.Flush()
+		if len(events) < auditLogExportBatchSize {
+			return nil
+		}
+		listOpt.BeforeId = utils.UintPtr(events[len(events)-1].ID)
+	}
+}
@@ -23,6 +23,9 @@ type authController struct {
 var AuthController = authController{}
 
 func (*authController) Register(ctx *gin.Context, schema *schemasv1.RegisterUserSchema) (*schemasv1.UserSchema, error) {
+	if services.OIDCService.IsPasswordLoginDisabled() {
+		return nil, errors.New("password login is disabled, please login with sso")
+	}
 	user, err := services.UserService.Create(ctx, services.CreateUserOption{
 		Name:      schema.Name,
 		FirstName: schema.FirstName,
@@ -41,6 +44,9 @@ func (*authController) Register(ctx *gin.Context, schema *schemasv1.RegisterUser
 }
 
 func (*authController) Login(ctx *gin.Context, schema *schemasv1.LoginUserSchema) (*schemasv1.UserSchema, error) {
+	if services.OIDCService.IsPasswordLoginDisabled() {
+		return nil, errors.New("password login is disabled, please login with sso")
+	}
 	isEmail := strings.Contains(schema.NameOrEmail, "@")
 	var err error
 	var user *models.User
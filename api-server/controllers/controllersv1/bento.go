@@ -120,6 +120,17 @@ func (c *bentoController) Update(ctx *gin.Context, schema *UpdateBentoSchema) (*
 	if err = c.canUpdate(ctx, bento); err != nil {
 		return nil, err
 	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   modelschemas.ResourceTypeBento,
+		ResourceId:     bento.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	bento, err = services.BentoService.Update(ctx, bento, services.UpdateBentoOption{
 		Labels:   schema.Labels,
 		Manifest: schema.Manifest,
@@ -594,6 +605,7 @@ func (c *bentoController) ListModel(ctx *gin.Context, schema *ListBentoModelSche
 
 type ListBentoSchema struct {
 	schemasv1.ListQuerySchema
+	LabelSelectorQuerySchema
 	GetBentoRepositorySchema
 }
 
@@ -651,14 +663,6 @@ func (c *bentoController) buildListOpt(ctx context.Context, listOpt *services.Li
 			}
 			listOpt.Order = utils.StringPtr(fmt.Sprintf("bento.%s %s", fieldName, strings.ToUpper(order)))
 		}
-		if k == "label" {
-			labelsSchema := services.ParseQueryLabelsToLabelsList(v.([]string))
-			listOpt.LabelsList = &labelsSchema
-		}
-		if k == "-label" {
-			labelsSchema := services.ParseQueryLabelsToLabelsList(v.([]string))
-			listOpt.LackLabelsList = &labelsSchema
-		}
 	}
 	return nil
 }
@@ -686,6 +690,10 @@ func (c *bentoController) List(ctx *gin.Context, schema *ListBentoSchema) (*sche
 	if err != nil {
 		return nil, err
 	}
+	err = schema.BindListOption(&listOpt.BaseListByLabelsOption, schema.Q)
+	if err != nil {
+		return nil, err
+	}
 
 	bentos, total, err := services.BentoService.List(ctx, listOpt)
 	if err != nil {
@@ -705,6 +713,7 @@ func (c *bentoController) List(ctx *gin.Context, schema *ListBentoSchema) (*sche
 
 type ListAllBentoSchema struct {
 	schemasv1.ListQuerySchema
+	LabelSelectorQuerySchema
 	GetOrganizationSchema
 }
 
@@ -731,6 +740,10 @@ func (c *bentoController) ListAll(ctx *gin.Context, schema *ListAllBentoSchema)
 	if err != nil {
 		return nil, err
 	}
+	err = schema.BindListOption(&listOpt.BaseListByLabelsOption, schema.Q)
+	if err != nil {
+		return nil, err
+	}
 
 	bentos, total, err := services.BentoService.List(ctx, listOpt)
 	if err != nil {
@@ -811,9 +824,10 @@ func (c *bentoController) UpdateBentoImageBuildStatus(ctx *gin.Context, schema *
 		return err
 	}
 
+	oldImageBuildStatus := bento.ImageBuildStatus
 	now := time.Now()
 	nowPtr := &now
-	_, err = services.BentoService.Update(ctx, bento, services.UpdateBentoOption{
+	bento, err = services.BentoService.Update(ctx, bento, services.UpdateBentoOption{
 		ImageBuildStatus:          &schema.ImageBuildStatus,
 		ImageBuildStatusUpdatedAt: &nowPtr,
 	})
@@ -821,5 +835,8 @@ func (c *bentoController) UpdateBentoImageBuildStatus(ctx *gin.Context, schema *
 		return errors.Wrap(err, "update bento")
 	}
 
+	// the gin context is reused by the next request once the handler returns, so the webhooks are dispatched with a detached context
+	go services.WebhookService.DispatchBentoImageBuildStatusChange(context.Background(), bento, oldImageBuildStatus)
+
 	return nil
 }
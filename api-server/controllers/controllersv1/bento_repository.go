@@ -10,6 +10,7 @@ import (
 	"github.com/huandu/xstrings"
 	"github.com/pkg/errors"
 
+	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/api-server/services"
@@ -57,7 +58,6 @@ func (c *bentoRepositoryController) canUpdate(ctx context.Context, bentoReposito
 	return OrganizationController.canUpdate(ctx, organization)
 }
 
-// nolint: unused
 func (c *bentoRepositoryController) canOperate(ctx context.Context, bentoRepository *models.BentoRepository) error {
 	organization, err := services.OrganizationService.GetAssociatedOrganization(ctx, bentoRepository)
 	if err != nil {
@@ -93,6 +93,12 @@ func (c *bentoRepositoryController) Create(ctx *gin.Context, schema *CreateBento
 	if err != nil {
 		return nil, errors.Wrap(err, "create bentoRepository")
 	}
+	createEvent(ctx, services.CreateEventOption{
+		OrganizationId: &organization.ID,
+		ResourceType:   modelschemas.ResourceTypeBentoRepository,
+		ResourceId:     bentoRepository.ID,
+		OperationName:  "created",
+	}, nil)
 	return transformersv1.ToBentoRepositorySchema(ctx, bentoRepository)
 }
 
@@ -109,6 +115,13 @@ func (c *bentoRepositoryController) Update(ctx *gin.Context, schema *UpdateBento
 	if err = c.canUpdate(ctx, bentoRepository); err != nil {
 		return nil, err
 	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &bentoRepository.OrganizationId,
+		ResourceType:   modelschemas.ResourceTypeBentoRepository,
+		ResourceId:     bentoRepository.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	bentoRepository, err = services.BentoRepositoryService.Update(ctx, bentoRepository, services.UpdateBentoRepositoryOption{
 		Description: schema.Description,
 		Labels:      schema.Labels,
@@ -179,6 +192,7 @@ func (c *bentoRepositoryController) ListDeployment(ctx *gin.Context, schema *Lis
 
 type ListBentoRepositorySchema struct {
 	schemasv1.ListQuerySchema
+	LabelSelectorQuerySchema
 	GetOrganizationSchema
 }
 
@@ -216,6 +230,11 @@ func (c *bentoRepositoryController) List(ctx *gin.Context, schema *ListBentoRepo
 		OrganizationId: utils.UintPtr(organization.ID),
 	}
 
+	err = schema.BindListOption(&listOpt.BaseListByLabelsOption, schema.Q)
+	if err != nil {
+		return nil, err
+	}
+
 	queryMap := schema.Q.ToMap()
 	for k, v := range queryMap {
 		if k == schemasv1.KeyQIn {
@@ -283,14 +302,6 @@ func (c *bentoRepositoryController) List(ctx *gin.Context, schema *ListBentoRepo
 			}
 			listOpt.Order = utils.StringPtr(fmt.Sprintf("%s %s", fieldName, strings.ToUpper(order)))
 		}
-		if k == "label" {
-			labelsSchema := services.ParseQueryLabelsToLabelsList(v.([]string))
-			listOpt.LabelsList = &labelsSchema
-		}
-		if k == "-label" {
-			labelsSchema := services.ParseQueryLabelsToLabelsList(v.([]string))
-			listOpt.LackLabelsList = &labelsSchema
-		}
 	}
 
 	bentoRepositories, total, err := services.BentoRepositoryService.List(ctx, listOpt)
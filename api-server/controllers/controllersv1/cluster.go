@@ -18,6 +18,7 @@ import (
 	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
 	"github.com/bentoml/yatai/common/utils"
@@ -110,6 +111,13 @@ func (c *clusterController) Create(ctx *gin.Context, schema *CreateClusterSchema
 	if err != nil {
 		return nil, errors.Wrap(err, "create cluster member")
 	}
+	createEvent(ctx, services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeCluster,
+		ResourceId:     cluster.ID,
+		OperationName:  "created",
+	}, nil)
 	return transformersv1.ToClusterFullSchema(ctx, cluster)
 }
 
@@ -126,6 +134,17 @@ func (c *clusterController) Update(ctx *gin.Context, schema *UpdateClusterSchema
 	if err = c.canOperate(ctx, cluster); err != nil {
 		return nil, err
 	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeCluster,
+		ResourceId:     cluster.ID,
+		OperationName:  "updated",
+	}
+	if schema.KubeConfig != nil && *schema.KubeConfig != services.SecretMask && *schema.KubeConfig != cluster.KubeConfig {
+		createEventOpt.OperationName = "updated kubeconfig"
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	cluster, err = services.ClusterService.Update(ctx, cluster, services.UpdateClusterOption{
 		Description: schema.Description,
 		Config:      schema.Config,
@@ -137,6 +156,62 @@ func (c *clusterController) Update(ctx *gin.Context, schema *UpdateClusterSchema
 	return transformersv1.ToClusterFullSchema(ctx, cluster)
 }
 
+func (c *clusterController) GetIngressConfig(ctx *gin.Context, schema *GetClusterSchema) (*schemas.ClusterIngressConfigSchema, error) {
+	cluster, err := schema.GetCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, cluster); err != nil {
+		return nil, err
+	}
+	return transformersv1.ToClusterIngressConfigSchema(ctx, cluster)
+}
+
+type UpdateClusterIngressConfigSchema struct {
+	schemas.ClusterIngressConfigSchema
+	GetClusterSchema
+}
+
+func (c *clusterController) UpdateIngressConfig(ctx *gin.Context, schema *UpdateClusterIngressConfigSchema) (*schemas.ClusterIngressConfigSchema, error) {
+	cluster, err := schema.GetCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, cluster); err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeCluster,
+		ResourceId:     cluster.ID,
+		OperationName:  "updated ingress config",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	ingressConfig := &models.ClusterIngressConfig{
+		Provider:           models.ClusterIngressProvider(schema.Provider),
+		IngressClassName:   schema.IngressClassName,
+		TraefikEntryPoints: schema.TraefikEntryPoints,
+		GatewayName:        schema.GatewayName,
+		GatewayNamespace:   schema.GatewayNamespace,
+	}
+	if schema.TLS != nil {
+		ingressConfig.TLS = &models.ClusterTLSConfig{
+			CertManagerIssuerName:              schema.TLS.CertManagerIssuerName,
+			CertManagerIssuerKind:              models.ClusterTLSIssuerKind(schema.TLS.CertManagerIssuerKind),
+			WildcardCertificateSecretName:      schema.TLS.WildcardCertificateSecretName,
+			WildcardCertificateSecretNamespace: schema.TLS.WildcardCertificateSecretNamespace,
+		}
+	}
+	cluster, err = services.ClusterService.Update(ctx, cluster, services.UpdateClusterOption{
+		IngressConfig: &ingressConfig,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update cluster ingress config")
+	}
+	return transformersv1.ToClusterIngressConfigSchema(ctx, cluster)
+}
+
 func (c *clusterController) Get(ctx *gin.Context, schema *GetClusterSchema) (*schemasv1.ClusterFullSchema, error) {
 	cluster, err := schema.GetCluster(ctx)
 	if err != nil {
@@ -148,6 +223,43 @@ func (c *clusterController) Get(ctx *gin.Context, schema *GetClusterSchema) (*sc
 	return transformersv1.ToClusterFullSchema(ctx, cluster)
 }
 
+func (c *clusterController) GetHealth(ctx *gin.Context, schema *GetClusterSchema) (*schemas.ClusterHealthSchema, error) {
+	cluster, err := schema.GetCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, cluster); err != nil {
+		return nil, err
+	}
+	ss, err := transformersv1.ToClusterHealthSchemas(ctx, []*models.Cluster{cluster})
+	if err != nil {
+		return nil, err
+	}
+	return ss[0], nil
+}
+
+func (c *clusterController) ListHealth(ctx *gin.Context, schema *ListClusterSchema) ([]*schemas.ClusterHealthSchema, error) {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canView(ctx, org); err != nil {
+		return nil, err
+	}
+	clusters, _, err := services.ClusterService.List(ctx, services.ListClusterOption{
+		BaseListOption: services.BaseListOption{
+			Start:  utils.UintPtr(schema.Start),
+			Count:  utils.UintPtr(schema.Count),
+			Search: schema.Search,
+		},
+		OrganizationId: utils.UintPtr(org.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list clusters")
+	}
+	return transformersv1.ToClusterHealthSchemas(ctx, clusters)
+}
+
 type ListClusterSchema struct {
 	schemasv1.ListQuerySchema
 	GetOrganizationSchema
@@ -310,7 +422,7 @@ func (c *clusterController) WsPods(ctx *gin.Context, schema *GetClusterSchema) (
 		return false
 	}
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	services.AddInformerEventHandler(pollingCtx, informer, cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if !checkPod(obj) {
 				return
@@ -1,9 +1,12 @@
 package controllersv1
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 
+	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
@@ -48,6 +51,14 @@ func (c *clusterMemberController) Create(ctx *gin.Context, schema *CreateCluster
 		if err != nil {
 			return nil, errors.Wrap(err, "create clusterMember")
 		}
+		createEvent(ctx, services.CreateEventOption{
+			Name:           fmt.Sprintf("%s (%s)", u.Name, schema.Role),
+			OrganizationId: &cluster.OrganizationId,
+			ClusterId:      &cluster.ID,
+			ResourceType:   modelschemas.ResourceTypeCluster,
+			ResourceId:     cluster.ID,
+			OperationName:  "added member",
+		}, nil)
 		s, err := transformersv1.ToClusterMemberSchema(ctx, clusterMember)
 		if err != nil {
 			return nil, err
@@ -99,6 +110,16 @@ func (c *clusterMemberController) Delete(ctx *gin.Context, schema *DeleteCluster
 	if err != nil {
 		return nil, errors.Wrap(err, "get member")
 	}
+	defer func() {
+		createEvent(ctx, services.CreateEventOption{
+			Name:           user.Name,
+			OrganizationId: &cluster.OrganizationId,
+			ClusterId:      &cluster.ID,
+			ResourceType:   modelschemas.ResourceTypeCluster,
+			ResourceId:     cluster.ID,
+			OperationName:  "removed member",
+		}, err)
+	}()
 	clusterMember, err := services.ClusterMemberService.Delete(ctx, member, currentUser.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "create clusterMember")
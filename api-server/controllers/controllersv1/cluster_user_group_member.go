@@ -0,0 +1,135 @@
+package controllersv1
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type clusterUserGroupMemberController struct {
+	clusterController
+}
+
+var ClusterUserGroupMemberController = clusterUserGroupMemberController{}
+
+type CreateClusterUserGroupMembersSchema struct {
+	schemas.CreateUserGroupMembersSchema
+	GetClusterSchema
+}
+
+func (c *clusterUserGroupMemberController) Create(ctx *gin.Context, schema *CreateClusterUserGroupMembersSchema) ([]*schemas.ClusterUserGroupMemberSchema, error) {
+	currentUser, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get current user")
+	}
+	cluster, err := schema.GetCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, cluster); err != nil {
+		return nil, err
+	}
+	userGroups, _, err := services.UserGroupService.List(ctx, services.ListUserGroupOption{
+		OrganizationId: utils.UintPtr(cluster.OrganizationId),
+		Names:          &schema.UserGroupNames,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*schemas.ClusterUserGroupMemberSchema, 0, len(userGroups))
+	for _, userGroup := range userGroups {
+		member, err := services.ClusterUserGroupMemberService.Create(ctx, currentUser.ID, services.CreateClusterUserGroupMemberOption{
+			CreatorId:   currentUser.ID,
+			UserGroupId: userGroup.ID,
+			ClusterId:   cluster.ID,
+			Role:        schema.Role,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "create clusterUserGroupMember")
+		}
+		createEvent(ctx, services.CreateEventOption{
+			Name:           fmt.Sprintf("%s (%s)", userGroup.Name, schema.Role),
+			OrganizationId: &cluster.OrganizationId,
+			ClusterId:      &cluster.ID,
+			ResourceType:   modelschemas.ResourceTypeCluster,
+			ResourceId:     cluster.ID,
+			OperationName:  "added user group member",
+		}, nil)
+		s, err := transformersv1.ToClusterUserGroupMemberSchema(ctx, member)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func (c *clusterUserGroupMemberController) List(ctx *gin.Context, schema *GetClusterSchema) ([]*schemas.ClusterUserGroupMemberSchema, error) {
+	cluster, err := schema.GetCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, cluster); err != nil {
+		return nil, err
+	}
+	members, err := services.ClusterUserGroupMemberService.List(ctx, services.ListClusterUserGroupMemberOption{
+		ClusterId: utils.UintPtr(cluster.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list cluster user group members")
+	}
+	return transformersv1.ToClusterUserGroupMemberSchemas(ctx, members)
+}
+
+type DeleteClusterUserGroupMemberSchema struct {
+	schemas.DeleteUserGroupMemberSchema
+	GetClusterSchema
+}
+
+func (c *clusterUserGroupMemberController) Delete(ctx *gin.Context, schema *DeleteClusterUserGroupMemberSchema) (*schemas.ClusterUserGroupMemberSchema, error) {
+	currentUser, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get current user")
+	}
+	cluster, err := schema.GetCluster(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get cluster")
+	}
+	if err = c.canOperate(ctx, cluster); err != nil {
+		return nil, err
+	}
+	userGroup, err := services.UserGroupService.GetByName(ctx, cluster.OrganizationId, schema.UserGroupName)
+	if err != nil {
+		return nil, err
+	}
+	member, err := services.ClusterUserGroupMemberService.GetBy(ctx, userGroup.ID, cluster.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get member")
+	}
+	memberSchema, err := transformersv1.ToClusterUserGroupMemberSchema(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		createEvent(ctx, services.CreateEventOption{
+			Name:           userGroup.Name,
+			OrganizationId: &cluster.OrganizationId,
+			ClusterId:      &cluster.ID,
+			ResourceType:   modelschemas.ResourceTypeCluster,
+			ResourceId:     cluster.ID,
+			OperationName:  "removed user group member",
+		}, err)
+	}()
+	_, err = services.ClusterUserGroupMemberService.Delete(ctx, member, currentUser.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete clusterUserGroupMember")
+	}
+	return memberSchema, nil
+}
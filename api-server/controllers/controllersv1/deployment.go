@@ -23,6 +23,7 @@ import (
 	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/api-server/services/tracking"
 	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
@@ -79,6 +80,15 @@ func (c *deploymentController) canOperate(ctx context.Context, deployment *model
 	return ClusterController.canOperate(ctx, cluster)
 }
 
+// checkNotFederated refuses to change a child deployment of a federated deployment directly,
+// the changes would be overwritten by the next apply of the federated deployment
+func (c *deploymentController) checkNotFederated(deployment *models.Deployment) error {
+	if deployment.FederatedDeploymentId != nil {
+		return errors.Errorf("deployment %s is managed by its federated deployment, change the federated deployment instead", deployment.Name)
+	}
+	return nil
+}
+
 type CreateDeploymentSchema struct {
 	schemasv1.CreateDeploymentSchema
 	GetClusterSchema
@@ -197,11 +207,23 @@ func (c *deploymentController) Update(ctx *gin.Context, schema *UpdateDeployment
 	if err = c.canUpdate(ctx, deployment); err != nil {
 		return nil, err
 	}
+	if err = c.checkNotFederated(deployment); err != nil {
+		return nil, err
+	}
 	org, err := schema.GetOrganization(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ClusterId:      &deployment.ClusterId,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+
 	// nolint: ineffassign, staticcheck
 	_, ctx_, df, err := services.StartTransaction(ctx)
 	if err != nil {
@@ -222,17 +244,98 @@ func (c *deploymentController) Update(ctx *gin.Context, schema *UpdateDeployment
 	return deploymentSchema, err
 }
 
-func (c *deploymentController) doUpdate(ctx context.Context, schema schemasv1.UpdateDeploymentSchema, org *models.Organization, deployment *models.Deployment) (*schemasv1.DeploymentSchema, error) {
+// DryRunUpdate renders the kubernetes objects which the update would apply without deploying them,
+// the deployment revision and targets are created in a transaction which is always rolled back,
+// the live objects are only fetched from the cluster after the transaction is closed
+func (c *deploymentController) DryRunUpdate(ctx *gin.Context, schema *UpdateDeploymentSchema) (*schemas.DeploymentPreviewSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canUpdate(ctx, deployment); err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
 	user, err := services.GetCurrentUser(ctx)
 	if err != nil {
 		return nil, err
 	}
-	bentoRepositoryNames := make([]string, 0, len(schema.Targets))
-	bentoRepositoryNamesSeen := make(map[string]struct{}, len(schema.Targets))
 
-	bentoVersionsMapping := make(map[string][]string, len(schema.Targets))
+	bentosMapping, err := c.getBentosMapping(ctx, schema.Targets, org)
+	if err != nil {
+		return nil, err
+	}
+
+	previews, err := c.previewUpdate(ctx, deployment, user, schema, bentosMapping)
+	if err != nil {
+		return nil, err
+	}
+	err = services.DeploymentPreviewService.DiffLive(ctx, deployment, previews)
+	if err != nil {
+		return nil, errors.Wrap(err, "diff live kube objects")
+	}
+	return transformersv1.ToDeploymentPreviewSchema(ctx, previews)
+}
+
+func (c *deploymentController) previewUpdate(ctx context.Context, deployment *models.Deployment, user *models.User, schema *UpdateDeploymentSchema, bentosMapping map[string]*models.Bento) ([]*services.KubeObjectPreview, error) {
+	_, ctx_, df, err := services.StartTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { df(errors.New("rollback dry run")) }()
 
+	deploymentRevision, err := services.DeploymentRevisionService.Create(ctx_, services.CreateDeploymentRevisionOption{
+		CreatorId:    user.ID,
+		DeploymentId: deployment.ID,
+		Status:       modelschemas.DeploymentRevisionStatusActive,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create deployment revision")
+	}
+
+	deploymentTargets := make([]*models.DeploymentTarget, 0, len(schema.Targets))
 	for _, createDeploymentTargetSchema := range schema.Targets {
+		bento := bentosMapping[fmt.Sprintf("%s:%s", createDeploymentTargetSchema.BentoRepository, createDeploymentTargetSchema.Bento)]
+		if bento == nil {
+			return nil, errors.Errorf("can't find bento: %s:%s", createDeploymentTargetSchema.BentoRepository, createDeploymentTargetSchema.Bento)
+		}
+		if createDeploymentTargetSchema.Config != nil {
+			createDeploymentTargetSchema.Config.KubeResourceVersion = ""
+			createDeploymentTargetSchema.Config.KubeResourceUid = ""
+		}
+
+		deploymentTarget, err := services.DeploymentTargetService.Create(ctx_, services.CreateDeploymentTargetOption{
+			CreatorId:            user.ID,
+			DeploymentId:         deployment.ID,
+			DeploymentRevisionId: deploymentRevision.ID,
+			BentoId:              bento.ID,
+			Type:                 createDeploymentTargetSchema.Type,
+			CanaryRules:          createDeploymentTargetSchema.CanaryRules,
+			Config:               createDeploymentTargetSchema.Config,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "create deployment target")
+		}
+		deploymentTargets = append(deploymentTargets, deploymentTarget)
+	}
+
+	previews, err := services.DeploymentPreviewService.Preview(ctx_, deploymentRevision, deploymentTargets)
+	if err != nil {
+		return nil, errors.Wrap(err, "preview deployment revision")
+	}
+	return previews, nil
+}
+
+func (c *deploymentController) getBentosMapping(ctx context.Context, targets []*schemasv1.CreateDeploymentTargetSchema, org *models.Organization) (map[string]*models.Bento, error) {
+	bentoRepositoryNames := make([]string, 0, len(targets))
+	bentoRepositoryNamesSeen := make(map[string]struct{}, len(targets))
+
+	bentoVersionsMapping := make(map[string][]string, len(targets))
+
+	for _, createDeploymentTargetSchema := range targets {
 		if _, ok := bentoRepositoryNamesSeen[createDeploymentTargetSchema.BentoRepository]; !ok {
 			bentoRepositoryNames = append(bentoRepositoryNames, createDeploymentTargetSchema.BentoRepository)
 			bentoRepositoryNamesSeen[createDeploymentTargetSchema.BentoRepository] = struct{}{}
@@ -272,6 +375,19 @@ func (c *deploymentController) doUpdate(ctx context.Context, schema schemasv1.Up
 		}
 	}
 
+	return bentosMapping, nil
+}
+
+func (c *deploymentController) doUpdate(ctx context.Context, schema schemasv1.UpdateDeploymentSchema, org *models.Organization, deployment *models.Deployment) (*schemasv1.DeploymentSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bentosMapping, err := c.getBentosMapping(ctx, schema.Targets, org)
+	if err != nil {
+		return nil, err
+	}
+
 	status_ := modelschemas.DeploymentRevisionStatusActive
 	deploymentRevisions, _, err := services.DeploymentRevisionService.List(ctx, services.ListDeploymentRevisionOption{
 		DeploymentId: utils.UintPtr(deployment.ID),
@@ -406,6 +522,21 @@ func (c *deploymentController) Terminate(ctx *gin.Context, schema *GetDeployment
 	if err = c.canOperate(ctx, deployment); err != nil {
 		return nil, err
 	}
+	if err = c.checkNotFederated(deployment); err != nil {
+		return nil, err
+	}
+	cluster, err := services.ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "terminated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	deployment, err = services.DeploymentService.Terminate(ctx, deployment)
 	if err != nil {
 		return nil, err
@@ -423,6 +554,22 @@ func (c *deploymentController) Delete(ctx *gin.Context, schema *GetDeploymentSch
 	if err = c.canOperate(ctx, deployment); err != nil {
 		return nil, err
 	}
+	if err = c.checkNotFederated(deployment); err != nil {
+		return nil, err
+	}
+	cluster, err := services.ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "deleted",
+		ResourceName:   deployment.Name,
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	deployment, err = services.DeploymentService.Delete(ctx, deployment)
 	if err != nil {
 		return nil, err
@@ -432,8 +579,85 @@ func (c *deploymentController) Delete(ctx *gin.Context, schema *GetDeploymentSch
 	return deploymentSchema, err
 }
 
+func (c *deploymentController) GetDomain(ctx *gin.Context, schema *GetDeploymentSchema) (*schemas.DeploymentDomainSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, deployment); err != nil {
+		return nil, err
+	}
+	return transformersv1.ToDeploymentDomainSchema(ctx, deployment)
+}
+
+type UpdateDeploymentDomainSchema struct {
+	schemas.UpdateDeploymentDomainSchema
+	GetDeploymentSchema
+}
+
+// UpdateDomain changes the ingress host and the certificate of the deployment
+func (c *deploymentController) UpdateDomain(ctx *gin.Context, schema *UpdateDeploymentDomainSchema) (*schemas.DeploymentDomainSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canUpdate(ctx, deployment); err != nil {
+		return nil, err
+	}
+	if schema.IngressHost != nil && *schema.IngressHost != deployment.IngressHost && deployment.FederatedDeploymentId != nil {
+		return nil, errors.New("the ingress host of the deployment is managed by its federated deployment")
+	}
+	cluster, err := services.ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "updated domain",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	deployment, err = services.DeploymentService.Update(ctx, deployment, services.UpdateDeploymentOption{
+		IngressHost:   schema.IngressHost,
+		TLSSecretName: schema.TLSSecretName,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update deployment domain")
+	}
+	err = redeployActiveRevisions(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	return transformersv1.ToDeploymentDomainSchema(ctx, deployment)
+}
+
+// redeployActiveRevisions deploys the active revision again to apply the changed routing of the deployment, the terminated deployments are skipped
+func redeployActiveRevisions(ctx context.Context, deployment *models.Deployment) error {
+	if deployment.Status == modelschemas.DeploymentStatusTerminated || deployment.Status == modelschemas.DeploymentStatusTerminating {
+		return nil
+	}
+	status := modelschemas.DeploymentRevisionStatusActive
+	deploymentRevisions, _, err := services.DeploymentRevisionService.List(ctx, services.ListDeploymentRevisionOption{
+		DeploymentId: utils.UintPtr(deployment.ID),
+		Status:       &status,
+	})
+	if err != nil {
+		return errors.Wrap(err, "list active deployment revisions")
+	}
+	for _, deploymentRevision := range deploymentRevisions {
+		err = services.DeploymentRevisionService.Redeploy(ctx, deploymentRevision)
+		if err != nil {
+			return errors.Wrapf(err, "redeploy deployment revision %s", deploymentRevision.Uid)
+		}
+	}
+	return nil
+}
+
 type ListClusterDeploymentSchema struct {
 	schemasv1.ListQuerySchema
+	LabelSelectorQuerySchema
 	GetClusterSchema
 }
 
@@ -607,6 +831,10 @@ func (c *deploymentController) ListClusterDeployments(ctx *gin.Context, schema *
 	if err != nil {
 		return nil, err
 	}
+	err = schema.BindListOption(&listOpt.BaseListByLabelsOption, schema.Q)
+	if err != nil {
+		return nil, err
+	}
 
 	deployments, total, err := services.DeploymentService.List(ctx, listOpt)
 	if err != nil {
@@ -626,6 +854,7 @@ func (c *deploymentController) ListClusterDeployments(ctx *gin.Context, schema *
 
 type ListOrganizationDeploymentSchema struct {
 	schemasv1.ListQuerySchema
+	LabelSelectorQuerySchema
 	GetOrganizationSchema
 }
 
@@ -652,6 +881,10 @@ func (c *deploymentController) ListOrganizationDeployments(ctx *gin.Context, sch
 	if err != nil {
 		return nil, err
 	}
+	err = schema.BindListOption(&listOpt.BaseListByLabelsOption, schema.Q)
+	if err != nil {
+		return nil, err
+	}
 
 	deployments, total, err := services.DeploymentService.List(ctx, listOpt)
 	if err != nil {
@@ -961,7 +1194,7 @@ func (c *deploymentController) WsPods(ctx *gin.Context, schema *GetDeploymentSch
 		return true
 	}
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	services.AddInformerEventHandler(pollingCtx, informer, cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if !checkPod(obj) {
 				return
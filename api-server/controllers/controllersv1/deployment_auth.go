@@ -0,0 +1,268 @@
+package controllersv1
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type deploymentAuthController struct {
+	// nolint: unused
+	baseController
+}
+
+var DeploymentAuthController = deploymentAuthController{}
+
+func (c *deploymentAuthController) GetConfig(ctx *gin.Context, schema *GetDeploymentSchema) (*schemas.DeploymentAuthConfigSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = DeploymentController.canView(ctx, deployment); err != nil {
+		return nil, err
+	}
+	return transformersv1.ToDeploymentAuthConfigSchema(ctx, deployment)
+}
+
+type UpdateDeploymentAuthConfigSchema struct {
+	schemas.DeploymentAuthConfigSchema
+	GetDeploymentSchema
+}
+
+// UpdateConfig enables, changes or disables the authentication of the deployment
+func (c *deploymentAuthController) UpdateConfig(ctx *gin.Context, schema *UpdateDeploymentAuthConfigSchema) (*schemas.DeploymentAuthConfigSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = DeploymentController.canOperate(ctx, deployment); err != nil {
+		return nil, err
+	}
+	cluster, err := services.ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "updated auth config",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	var authConfig *models.DeploymentAuthConfig
+	if schema.Mode != "" {
+		authConfig = &models.DeploymentAuthConfig{
+			Mode:        models.DeploymentAuthMode(schema.Mode),
+			JWTIssuer:   schema.JWTIssuer,
+			JWTAudience: schema.JWTAudience,
+		}
+	}
+	deployment, err = services.DeploymentService.Update(ctx, deployment, services.UpdateDeploymentOption{
+		AuthConfig: &authConfig,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update deployment auth config")
+	}
+	err = redeployActiveRevisions(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	return transformersv1.ToDeploymentAuthConfigSchema(ctx, deployment)
+}
+
+type GetDeploymentApiKeySchema struct {
+	GetDeploymentSchema
+	ApiTokenUid string `path:"apiTokenUid"`
+}
+
+func (s *GetDeploymentApiKeySchema) GetDeploymentApiKey(ctx *gin.Context, deployment *models.Deployment) (*models.ApiToken, error) {
+	apiToken, err := services.ApiTokenService.GetByUid(ctx, s.ApiTokenUid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get api key %s", s.ApiTokenUid)
+	}
+	if apiToken.DeploymentId == nil || *apiToken.DeploymentId != deployment.ID {
+		return nil, errors.New("api key not found")
+	}
+	return apiToken, nil
+}
+
+type CreateDeploymentApiKeySchema struct {
+	schemas.CreateDeploymentApiKeySchema
+	GetDeploymentSchema
+}
+
+// CreateApiKey issues an api key which can only invoke the deployment, the plaintext key is only returned by the creation and the regeneration
+func (c *deploymentAuthController) CreateApiKey(ctx *gin.Context, schema *CreateDeploymentApiKeySchema) (*schemasv1.ApiTokenFullSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = DeploymentController.canOperate(ctx, deployment); err != nil {
+		return nil, err
+	}
+	cluster, err := services.ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		Name:           schema.Name,
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "created api key",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	scopes := modelschemas.ApiTokenScopes{
+		models.ApiTokenScopeInvokeDeployment,
+	}
+	apiToken, err := services.ApiTokenService.Create(ctx, services.CreateApiTokenOption{
+		UserId:         user.ID,
+		OrganizationId: cluster.OrganizationId,
+		Name:           schema.Name,
+		Description:    schema.Description,
+		Scopes:         &scopes,
+		ExpiredAt:      schema.ExpiredAt,
+		DeploymentId:   utils.UintPtr(deployment.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create api key")
+	}
+	return transformersv1.ToApiTokenFullSchema(ctx, apiToken)
+}
+
+// RegenerateApiKey rotates the api key, the old key is rejected at once
+func (c *deploymentAuthController) RegenerateApiKey(ctx *gin.Context, schema *GetDeploymentApiKeySchema) (*schemasv1.ApiTokenFullSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = DeploymentController.canOperate(ctx, deployment); err != nil {
+		return nil, err
+	}
+	apiToken, err := schema.GetDeploymentApiKey(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		Name:           apiToken.Name,
+		OrganizationId: &apiToken.OrganizationId,
+		ClusterId:      &deployment.ClusterId,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "regenerated api key",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	apiToken, err = services.ApiTokenService.Regenerate(ctx, apiToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "regenerate api key")
+	}
+	return transformersv1.ToApiTokenFullSchema(ctx, apiToken)
+}
+
+// DeleteApiKey revokes the api key
+func (c *deploymentAuthController) DeleteApiKey(ctx *gin.Context, schema *GetDeploymentApiKeySchema) (*schemasv1.ApiTokenSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = DeploymentController.canOperate(ctx, deployment); err != nil {
+		return nil, err
+	}
+	apiToken, err := schema.GetDeploymentApiKey(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		Name:           apiToken.Name,
+		OrganizationId: &apiToken.OrganizationId,
+		ClusterId:      &deployment.ClusterId,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "deleted api key",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	apiToken, err = services.ApiTokenService.Delete(ctx, apiToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete api key")
+	}
+	return transformersv1.ToApiTokenSchema(ctx, apiToken)
+}
+
+type ListDeploymentApiKeySchema struct {
+	schemasv1.ListQuerySchema
+	GetDeploymentSchema
+}
+
+func (c *deploymentAuthController) ListApiKeys(ctx *gin.Context, schema *ListDeploymentApiKeySchema) (*schemasv1.ApiTokenListSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = DeploymentController.canView(ctx, deployment); err != nil {
+		return nil, err
+	}
+	apiTokens, total, err := services.ApiTokenService.List(ctx, services.ListApiTokenOption{
+		BaseListOption: services.BaseListOption{
+			Start:  utils.UintPtr(schema.Start),
+			Count:  utils.UintPtr(schema.Count),
+			Search: schema.Search,
+		},
+		DeploymentId: utils.UintPtr(deployment.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list api keys")
+	}
+	apiTokenSchemas, err := transformersv1.ToApiTokenSchemas(ctx, apiTokens)
+	return &schemasv1.ApiTokenListSchema{
+		BaseListSchema: schemasv1.BaseListSchema{
+			Total: total,
+			Start: schema.Start,
+			Count: schema.Count,
+		},
+		Items: apiTokenSchemas,
+	}, err
+}
+
+type ListDeploymentApiKeyUsageSchema struct {
+	GetDeploymentApiKeySchema
+	// the usage of the last 30 days is listed by default
+	Days uint `query:"days"`
+}
+
+func (c *deploymentAuthController) ListApiKeyUsage(ctx *gin.Context, schema *ListDeploymentApiKeyUsageSchema) ([]*schemas.DeploymentApiKeyUsageSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = DeploymentController.canView(ctx, deployment); err != nil {
+		return nil, err
+	}
+	apiToken, err := schema.GetDeploymentApiKey(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	days := schema.Days
+	if days == 0 {
+		days = 30
+	}
+	usage, err := services.DeploymentAuthService.ListUsage(ctx, apiToken, time.Now().AddDate(0, 0, -int(days)))
+	if err != nil {
+		return nil, errors.Wrap(err, "list api key usage")
+	}
+	return transformersv1.ToDeploymentApiKeyUsageSchemas(ctx, usage)
+}
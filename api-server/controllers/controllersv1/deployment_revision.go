@@ -3,9 +3,12 @@ package controllersv1
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 
+	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/services/tracking"
 	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
 	"github.com/bentoml/yatai/common/utils"
 )
@@ -81,3 +84,77 @@ func (c *deploymentRevisionController) Get(ctx *gin.Context, schema *GetDeployme
 
 	return transformersv1.ToDeploymentRevisionSchema(ctx, deploymentRevision)
 }
+
+func (c *deploymentRevisionController) Rollback(ctx *gin.Context, schema *GetDeploymentRevisionSchema) (*schemasv1.DeploymentSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canUpdate(ctx, deployment); err != nil {
+		return nil, err
+	}
+	if err = DeploymentController.checkNotFederated(deployment); err != nil {
+		return nil, err
+	}
+
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deploymentRevision, err := services.DeploymentRevisionService.GetByUid(ctx, schema.RevisionUid)
+	if err != nil {
+		return nil, errors.Wrap(err, "get deploymentRevision")
+	}
+
+	if deploymentRevision.DeploymentId != deployment.ID {
+		return nil, errors.New("deploymentRevision not found")
+	}
+
+	// nolint: ineffassign, staticcheck
+	_, ctx_, df, err := services.StartTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { df(err) }()
+
+	defer func() {
+		apiTokenName := ""
+		if user.ApiToken != nil {
+			apiTokenName = user.ApiToken.Name
+		}
+		createEventOpt := services.CreateEventOption{
+			CreatorId:      user.ID,
+			ApiTokenName:   apiTokenName,
+			OrganizationId: &org.ID,
+			ClusterId:      &deployment.ClusterId,
+			ResourceType:   modelschemas.ResourceTypeDeployment,
+			ResourceId:     deployment.ID,
+			Status:         modelschemas.EventStatusSuccess,
+			OperationName:  "rolled back",
+		}
+		if err != nil {
+			createEventOpt.Status = modelschemas.EventStatusFailed
+		}
+
+		if _, err_ := services.EventService.Create(ctx_, createEventOpt); err_ != nil {
+			logrus.Errorf("create event failed: %v", err_)
+		}
+	}()
+
+	_, err = services.DeploymentRevisionService.Rollback(ctx_, deploymentRevision, user.ID)
+	if err != nil {
+		err = errors.Wrapf(err, "rollback to deploymentRevision %s", deploymentRevision.Uid)
+		return nil, err
+	}
+
+	deploymentSchema, err := transformersv1.ToDeploymentSchema(ctx_, deployment)
+	go tracking.TrackDeploymentEvent(ctx, deploymentSchema, tracking.YataiDeploymentUpdate)
+	return deploymentSchema, err
+}
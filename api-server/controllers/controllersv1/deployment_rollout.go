@@ -0,0 +1,171 @@
+package controllersv1
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type deploymentRolloutController struct {
+	// nolint: unused
+	baseController
+}
+
+var DeploymentRolloutController = deploymentRolloutController{}
+
+type GetDeploymentRolloutSchema struct {
+	GetDeploymentSchema
+	RolloutUid string `path:"rolloutUid"`
+}
+
+func (s *GetDeploymentRolloutSchema) GetDeploymentRollout(ctx *gin.Context, deployment *models.Deployment) (*models.DeploymentRollout, error) {
+	rollout, err := services.DeploymentRolloutService.GetByUid(ctx, s.RolloutUid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get deployment rollout %s", s.RolloutUid)
+	}
+	if rollout.DeploymentId != deployment.ID {
+		return nil, errors.New("deployment rollout not found")
+	}
+	return rollout, nil
+}
+
+type CreateDeploymentRolloutSchema struct {
+	schemas.CreateDeploymentRolloutSchema
+	GetDeploymentSchema
+}
+
+func (c *deploymentRolloutController) Create(ctx *gin.Context, schema *CreateDeploymentRolloutSchema) (*schemas.DeploymentRolloutSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canUpdate(ctx, deployment); err != nil {
+		return nil, err
+	}
+	if err = DeploymentController.checkNotFederated(deployment); err != nil {
+		return nil, err
+	}
+
+	rollout, err := services.DeploymentRolloutService.Create(ctx, services.CreateDeploymentRolloutOption{
+		CreatorId:           user.ID,
+		DeploymentId:        deployment.ID,
+		Steps:               schema.Steps,
+		StepIntervalSeconds: schema.StepIntervalSeconds,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create deployment rollout")
+	}
+
+	return transformersv1.ToDeploymentRolloutSchema(ctx, rollout)
+}
+
+type ListDeploymentRolloutSchema struct {
+	schemasv1.ListQuerySchema
+	GetDeploymentSchema
+}
+
+func (c *deploymentRolloutController) List(ctx *gin.Context, schema *ListDeploymentRolloutSchema) (*schemas.DeploymentRolloutListSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canView(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	rollouts, total, err := services.DeploymentRolloutService.List(ctx, services.ListDeploymentRolloutOption{
+		BaseListOption: services.BaseListOption{
+			Start: utils.UintPtr(schema.Start),
+			Count: utils.UintPtr(schema.Count),
+		},
+		DeploymentId: utils.UintPtr(deployment.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list deployment rollouts")
+	}
+
+	rolloutSchemas, err := transformersv1.ToDeploymentRolloutSchemas(ctx, rollouts)
+	return &schemas.DeploymentRolloutListSchema{
+		BaseListSchema: schemasv1.BaseListSchema{
+			Total: total,
+			Start: schema.Start,
+			Count: schema.Count,
+		},
+		Items: rolloutSchemas,
+	}, err
+}
+
+func (c *deploymentRolloutController) Get(ctx *gin.Context, schema *GetDeploymentRolloutSchema) (*schemas.DeploymentRolloutSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canView(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	rollout, err := schema.GetDeploymentRollout(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return transformersv1.ToDeploymentRolloutSchema(ctx, rollout)
+}
+
+func (c *deploymentRolloutController) Cancel(ctx *gin.Context, schema *GetDeploymentRolloutSchema) (*schemas.DeploymentRolloutSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canUpdate(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	rollout, err := schema.GetDeploymentRollout(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		Name:           rollout.Uid,
+		OrganizationId: &org.ID,
+		ClusterId:      &deployment.ClusterId,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "cancelled rollout",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	rollout, err = services.DeploymentRolloutService.Cancel(ctx, rollout, fmt.Sprintf("cancelled by %s", user.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "cancel deployment rollout")
+	}
+
+	return transformersv1.ToDeploymentRolloutSchema(ctx, rollout)
+}
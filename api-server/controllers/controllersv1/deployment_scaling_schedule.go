@@ -0,0 +1,249 @@
+package controllersv1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type deploymentScalingScheduleController struct {
+	// nolint: unused
+	baseController
+}
+
+var DeploymentScalingScheduleController = deploymentScalingScheduleController{}
+
+type GetDeploymentScalingScheduleSchema struct {
+	GetDeploymentSchema
+	ScheduleUid string `path:"scheduleUid"`
+}
+
+func (s *GetDeploymentScalingScheduleSchema) GetDeploymentScalingSchedule(ctx *gin.Context, deployment *models.Deployment) (*models.DeploymentScalingSchedule, error) {
+	schedule, err := services.DeploymentScalingScheduleService.GetByUid(ctx, s.ScheduleUid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get deployment scaling schedule %s", s.ScheduleUid)
+	}
+	if schedule.DeploymentId != deployment.ID {
+		return nil, errors.New("deployment scaling schedule not found")
+	}
+	return schedule, nil
+}
+
+type CreateDeploymentScalingScheduleSchema struct {
+	schemas.CreateDeploymentScalingScheduleSchema
+	GetDeploymentSchema
+}
+
+func (c *deploymentScalingScheduleController) Create(ctx *gin.Context, schema *CreateDeploymentScalingScheduleSchema) (*schemas.DeploymentScalingScheduleSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canUpdate(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		Name:           schema.Name,
+		OrganizationId: &org.ID,
+		ClusterId:      &deployment.ClusterId,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "created scaling schedule",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	scaleApiServer := true
+	if schema.ScaleApiServer != nil {
+		scaleApiServer = *schema.ScaleApiServer
+	}
+	schedule, err := services.DeploymentScalingScheduleService.Create(ctx, services.CreateDeploymentScalingScheduleOption{
+		CreatorId:      user.ID,
+		DeploymentId:   deployment.ID,
+		Name:           schema.Name,
+		Cron:           schema.Cron,
+		Timezone:       schema.Timezone,
+		Action:         models.DeploymentScalingScheduleAction(schema.Action),
+		MinReplicas:    schema.MinReplicas,
+		MaxReplicas:    schema.MaxReplicas,
+		ScaleApiServer: scaleApiServer,
+		RunnerNames:    schema.RunnerNames,
+		Disabled:       schema.Disabled,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create deployment scaling schedule")
+	}
+
+	return transformersv1.ToDeploymentScalingScheduleSchema(ctx, schedule)
+}
+
+type ListDeploymentScalingScheduleSchema struct {
+	schemasv1.ListQuerySchema
+	GetDeploymentSchema
+}
+
+func (c *deploymentScalingScheduleController) List(ctx *gin.Context, schema *ListDeploymentScalingScheduleSchema) (*schemas.DeploymentScalingScheduleListSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canView(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	schedules, total, err := services.DeploymentScalingScheduleService.List(ctx, services.ListDeploymentScalingScheduleOption{
+		BaseListOption: services.BaseListOption{
+			Start: utils.UintPtr(schema.Start),
+			Count: utils.UintPtr(schema.Count),
+		},
+		DeploymentId: utils.UintPtr(deployment.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list deployment scaling schedules")
+	}
+
+	scheduleSchemas, err := transformersv1.ToDeploymentScalingScheduleSchemas(ctx, schedules)
+	return &schemas.DeploymentScalingScheduleListSchema{
+		BaseListSchema: schemasv1.BaseListSchema{
+			Total: total,
+			Start: schema.Start,
+			Count: schema.Count,
+		},
+		Items: scheduleSchemas,
+	}, err
+}
+
+func (c *deploymentScalingScheduleController) Get(ctx *gin.Context, schema *GetDeploymentScalingScheduleSchema) (*schemas.DeploymentScalingScheduleSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canView(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	schedule, err := schema.GetDeploymentScalingSchedule(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return transformersv1.ToDeploymentScalingScheduleSchema(ctx, schedule)
+}
+
+type UpdateDeploymentScalingScheduleSchema struct {
+	schemas.UpdateDeploymentScalingScheduleSchema
+	GetDeploymentScalingScheduleSchema
+}
+
+func (c *deploymentScalingScheduleController) Update(ctx *gin.Context, schema *UpdateDeploymentScalingScheduleSchema) (*schemas.DeploymentScalingScheduleSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canUpdate(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	schedule, err := schema.GetDeploymentScalingSchedule(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := services.UpdateDeploymentScalingScheduleOption{
+		Cron:           schema.Cron,
+		Timezone:       schema.Timezone,
+		ScaleApiServer: schema.ScaleApiServer,
+		RunnerNames:    schema.RunnerNames,
+		Disabled:       schema.Disabled,
+	}
+	if schema.Action != nil {
+		action := models.DeploymentScalingScheduleAction(*schema.Action)
+		opt.Action = &action
+		if action == models.DeploymentScalingScheduleActionScaleToZero {
+			var nilReplicas *int32
+			opt.MinReplicas = &nilReplicas
+			opt.MaxReplicas = &nilReplicas
+		}
+	}
+	if schema.MinReplicas != nil {
+		opt.MinReplicas = &schema.MinReplicas
+	}
+	if schema.MaxReplicas != nil {
+		opt.MaxReplicas = &schema.MaxReplicas
+	}
+
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		Name:           schedule.Name,
+		OrganizationId: &org.ID,
+		ClusterId:      &deployment.ClusterId,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "updated scaling schedule",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	schedule, err = services.DeploymentScalingScheduleService.Update(ctx, schedule, opt)
+	if err != nil {
+		return nil, errors.Wrap(err, "update deployment scaling schedule")
+	}
+
+	return transformersv1.ToDeploymentScalingScheduleSchema(ctx, schedule)
+}
+
+func (c *deploymentScalingScheduleController) Delete(ctx *gin.Context, schema *GetDeploymentScalingScheduleSchema) (*schemas.DeploymentScalingScheduleSchema, error) {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeploymentController.canUpdate(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	schedule, err := schema.GetDeploymentScalingSchedule(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		Name:           schedule.Name,
+		OrganizationId: &org.ID,
+		ClusterId:      &deployment.ClusterId,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		OperationName:  "deleted scaling schedule",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	schedule, err = services.DeploymentScalingScheduleService.Delete(ctx, schedule)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete deployment scaling schedule")
+	}
+
+	return transformersv1.ToDeploymentScalingScheduleSchema(ctx, schedule)
+}
@@ -0,0 +1,344 @@
+package controllersv1
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type federatedDeploymentController struct {
+	organizationController
+}
+
+var FederatedDeploymentController = federatedDeploymentController{}
+
+type GetFederatedDeploymentSchema struct {
+	GetOrganizationSchema
+	FederatedDeploymentName string `path:"federatedDeploymentName"`
+}
+
+func (s *GetFederatedDeploymentSchema) GetFederatedDeployment(ctx context.Context) (*models.FederatedDeployment, error) {
+	org, err := s.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return services.FederatedDeploymentService.GetByName(ctx, org.ID, s.FederatedDeploymentName)
+}
+
+func (c *federatedDeploymentController) toTargets(targetSchemas []*schemasv1.CreateDeploymentTargetSchema) models.FederatedDeploymentTargets {
+	targets := make(models.FederatedDeploymentTargets, 0, len(targetSchemas))
+	for _, targetSchema := range targetSchemas {
+		config := targetSchema.Config
+		if config != nil {
+			config.KubeResourceUid = ""
+			config.KubeResourceVersion = ""
+		}
+		targets = append(targets, &models.FederatedDeploymentTarget{
+			Type:            targetSchema.Type,
+			BentoRepository: targetSchema.BentoRepository,
+			Bento:           targetSchema.Bento,
+			CanaryRules:     targetSchema.CanaryRules,
+			Config:          config,
+		})
+	}
+	return targets
+}
+
+// toClusters resolves the member clusters, the current user should be able to update all of them
+func (c *federatedDeploymentController) toClusters(ctx context.Context, org *models.Organization, clusterSchemas []*schemas.CreateFederatedDeploymentClusterSchema) (models.FederatedDeploymentClusters, error) {
+	clusters := make(models.FederatedDeploymentClusters, 0, len(clusterSchemas))
+	for _, clusterSchema := range clusterSchemas {
+		cluster, err := services.ClusterService.GetByName(ctx, org.ID, clusterSchema.ClusterName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get cluster %s", clusterSchema.ClusterName)
+		}
+		if err = ClusterController.canUpdate(ctx, cluster); err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, &models.FederatedDeploymentCluster{
+			ClusterId:     cluster.ID,
+			KubeNamespace: clusterSchema.KubeNamespace,
+			MinReplicas:   clusterSchema.MinReplicas,
+			MaxReplicas:   clusterSchema.MaxReplicas,
+			Resources:     clusterSchema.Resources,
+			IngressHost:   clusterSchema.IngressHost,
+		})
+	}
+	return clusters, nil
+}
+
+type CreateFederatedDeploymentSchema struct {
+	schemas.CreateFederatedDeploymentSchema
+	GetOrganizationSchema
+}
+
+func (c *federatedDeploymentController) Create(ctx *gin.Context, schema *CreateFederatedDeploymentSchema) (*schemas.FederatedDeploymentFullSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canUpdate(ctx, org); err != nil {
+		return nil, err
+	}
+	clusters, err := c.toClusters(ctx, org, schema.Clusters)
+	if err != nil {
+		return nil, err
+	}
+
+	federatedDeployment, err := services.FederatedDeploymentService.Create(ctx, services.CreateFederatedDeploymentOption{
+		CreatorId:      user.ID,
+		OrganizationId: org.ID,
+		Name:           schema.Name,
+		Description:    schema.Description,
+		KubeNamespace:  schema.KubeNamespace,
+		Targets:        c.toTargets(schema.Targets),
+		Clusters:       clusters,
+		Labels:         schema.Labels,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create federated deployment")
+	}
+
+	createEventOpt := services.CreateEventOption{
+		Name:           federatedDeployment.Name,
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeFederatedDeployment,
+		ResourceId:     federatedDeployment.ID,
+		OperationName:  "created",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+
+	if !schema.DoNotDeploy {
+		federatedDeployment, err = services.FederatedDeploymentService.Apply(ctx, federatedDeployment, user.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "apply federated deployment")
+		}
+	}
+
+	return transformersv1.ToFederatedDeploymentFullSchema(ctx, federatedDeployment)
+}
+
+type UpdateFederatedDeploymentSchema struct {
+	schemas.UpdateFederatedDeploymentSchema
+	GetFederatedDeploymentSchema
+}
+
+func (c *federatedDeploymentController) Update(ctx *gin.Context, schema *UpdateFederatedDeploymentSchema) (*schemas.FederatedDeploymentFullSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	federatedDeployment, err := schema.GetFederatedDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canUpdate(ctx, org); err != nil {
+		return nil, err
+	}
+
+	opt := services.UpdateFederatedDeploymentOption{
+		Description: schema.Description,
+		Labels:      schema.Labels,
+	}
+	if schema.Targets != nil {
+		targets := c.toTargets(*schema.Targets)
+		opt.Targets = &targets
+	}
+	if schema.Clusters != nil {
+		var clusters models.FederatedDeploymentClusters
+		clusters, err = c.toClusters(ctx, org, *schema.Clusters)
+		if err != nil {
+			return nil, err
+		}
+		opt.Clusters = &clusters
+	}
+
+	createEventOpt := services.CreateEventOption{
+		Name:           federatedDeployment.Name,
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeFederatedDeployment,
+		ResourceId:     federatedDeployment.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+
+	federatedDeployment, err = services.FederatedDeploymentService.Update(ctx, federatedDeployment, opt)
+	if err != nil {
+		return nil, errors.Wrap(err, "update federated deployment")
+	}
+
+	if !schema.DoNotDeploy {
+		federatedDeployment, err = services.FederatedDeploymentService.Apply(ctx, federatedDeployment, user.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "apply federated deployment")
+		}
+	}
+
+	return transformersv1.ToFederatedDeploymentFullSchema(ctx, federatedDeployment)
+}
+
+func (c *federatedDeploymentController) Apply(ctx *gin.Context, schema *GetFederatedDeploymentSchema) (*schemas.FederatedDeploymentFullSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	federatedDeployment, err := schema.GetFederatedDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canUpdate(ctx, org); err != nil {
+		return nil, err
+	}
+
+	createEventOpt := services.CreateEventOption{
+		Name:           federatedDeployment.Name,
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeFederatedDeployment,
+		ResourceId:     federatedDeployment.ID,
+		OperationName:  "applied",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+
+	federatedDeployment, err = services.FederatedDeploymentService.Apply(ctx, federatedDeployment, user.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply federated deployment")
+	}
+
+	return transformersv1.ToFederatedDeploymentFullSchema(ctx, federatedDeployment)
+}
+
+func (c *federatedDeploymentController) Terminate(ctx *gin.Context, schema *GetFederatedDeploymentSchema) (*schemas.FederatedDeploymentFullSchema, error) {
+	federatedDeployment, err := schema.GetFederatedDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+
+	createEventOpt := services.CreateEventOption{
+		Name:           federatedDeployment.Name,
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeFederatedDeployment,
+		ResourceId:     federatedDeployment.ID,
+		OperationName:  "terminated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+
+	federatedDeployment, err = services.FederatedDeploymentService.Terminate(ctx, federatedDeployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "terminate federated deployment")
+	}
+
+	return transformersv1.ToFederatedDeploymentFullSchema(ctx, federatedDeployment)
+}
+
+func (c *federatedDeploymentController) Delete(ctx *gin.Context, schema *GetFederatedDeploymentSchema) (*schemas.FederatedDeploymentSchema, error) {
+	federatedDeployment, err := schema.GetFederatedDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	federatedDeploymentSchema, err := transformersv1.ToFederatedDeploymentSchema(ctx, federatedDeployment)
+	if err != nil {
+		return nil, err
+	}
+
+	createEventOpt := services.CreateEventOption{
+		Name:           federatedDeployment.Name,
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeFederatedDeployment,
+		ResourceId:     federatedDeployment.ID,
+		OperationName:  "deleted",
+		ResourceName:   federatedDeployment.Name,
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+
+	_, err = services.FederatedDeploymentService.Delete(ctx, federatedDeployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete federated deployment")
+	}
+	return federatedDeploymentSchema, nil
+}
+
+func (c *federatedDeploymentController) Get(ctx *gin.Context, schema *GetFederatedDeploymentSchema) (*schemas.FederatedDeploymentFullSchema, error) {
+	federatedDeployment, err := schema.GetFederatedDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, org); err != nil {
+		return nil, err
+	}
+	return transformersv1.ToFederatedDeploymentFullSchema(ctx, federatedDeployment)
+}
+
+type ListFederatedDeploymentSchema struct {
+	schemasv1.ListQuerySchema
+	GetOrganizationSchema
+}
+
+func (c *federatedDeploymentController) List(ctx *gin.Context, schema *ListFederatedDeploymentSchema) (*schemas.FederatedDeploymentListSchema, error) {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, org); err != nil {
+		return nil, err
+	}
+
+	federatedDeployments, total, err := services.FederatedDeploymentService.List(ctx, services.ListFederatedDeploymentOption{
+		BaseListOption: services.BaseListOption{
+			Start:  utils.UintPtr(schema.Start),
+			Count:  utils.UintPtr(schema.Count),
+			Search: schema.Search,
+		},
+		OrganizationId: utils.UintPtr(org.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list federated deployments")
+	}
+
+	federatedDeploymentSchemas, err := transformersv1.ToFederatedDeploymentSchemas(ctx, federatedDeployments)
+	return &schemas.FederatedDeploymentListSchema{
+		BaseListSchema: schemasv1.BaseListSchema{
+			Total: total,
+			Start: schema.Start,
+			Count: schema.Count,
+		},
+		Items: federatedDeploymentSchemas,
+	}, err
+}
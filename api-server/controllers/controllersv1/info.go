@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/bentoml/yatai/api-server/config"
+	"github.com/bentoml/yatai/api-server/services"
 )
 
 type infoController struct {
@@ -16,11 +17,16 @@ var InfoController = infoController{}
 type InfoSchema struct {
 	IsSaas           bool   `json:"is_saas"`
 	SaasDomainSuffix string `json:"saas_domain_suffix"`
+	// OIDCEnabled tells the dashboard to offer the sso login through /oidc/login
+	OIDCEnabled           bool `json:"oidc_enabled"`
+	PasswordLoginDisabled bool `json:"password_login_disabled"`
 }
 
 func (c *infoController) GetInfo(ctx *gin.Context) (*InfoSchema, error) {
 	return &InfoSchema{
-		IsSaas:           config.YataiConfig.IsSaaS,
-		SaasDomainSuffix: config.YataiConfig.SaasDomainSuffix,
+		IsSaas:                config.YataiConfig.IsSaaS,
+		SaasDomainSuffix:      config.YataiConfig.SaasDomainSuffix,
+		OIDCEnabled:           services.OIDCService.IsEnabled(),
+		PasswordLoginDisabled: services.OIDCService.IsPasswordLoginDisabled(),
 	}, nil
 }
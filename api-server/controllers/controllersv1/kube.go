@@ -1,6 +1,7 @@
 package controllersv1
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -197,7 +198,10 @@ func (c *kubeController) GetPodKubeEvents(ctx *gin.Context, schema *GetClusterSc
 
 	send()
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	handlerCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+
+	services.AddInformerEventHandler(handlerCtx, informer, cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if event, ok := obj.(*corev1.Event); ok {
 				if !filter(event) {
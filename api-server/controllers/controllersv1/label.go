@@ -2,6 +2,7 @@ package controllersv1
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
@@ -9,6 +10,7 @@ import (
 	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
 	"github.com/bentoml/yatai/common/utils"
@@ -115,3 +117,61 @@ func (c *labelController) List(ctx *gin.Context, schema *ListLabelSchema) ([]*sc
 	}
 	return res, nil
 }
+
+type BulkUpdateLabelsSchema struct {
+	schemas.BulkUpdateLabelsSchema
+	GetOrganizationSchema
+}
+
+func (c *labelController) BulkUpdate(ctx *gin.Context, schema *BulkUpdateLabelsSchema) (*schemas.BulkUpdateLabelsResultSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canUpdate(ctx, org); err != nil {
+		return nil, err
+	}
+
+	var labelSelector *services.LabelSelector
+	if schema.LabelSelector != nil {
+		labelSelector_, err := services.ParseLabelSelector(*schema.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		labelSelector = &labelSelector_
+	}
+
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   modelschemas.ResourceTypeOrganization,
+		ResourceId:     org.ID,
+		OperationName:  fmt.Sprintf("bulk %s %s labels", schema.Operation, schema.ResourceType),
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+
+	resources, err := services.LabelService.BulkUpdate(ctx, services.BulkUpdateLabelsOption{
+		CreatorId:      user.ID,
+		OrganizationId: org.ID,
+		ResourceType:   schema.ResourceType,
+		ResourceUids:   schema.ResourceUids,
+		LabelSelector:  labelSelector,
+		Operation:      services.BulkLabelOperation(schema.Operation),
+		Labels:         schema.Labels,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "bulk update labels")
+	}
+
+	resourceUids := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		resourceUids = append(resourceUids, resource.GetUid())
+	}
+	return &schemas.BulkUpdateLabelsResultSchema{
+		ResourceType: schema.ResourceType,
+		ResourceUids: resourceUids,
+	}, nil
+}
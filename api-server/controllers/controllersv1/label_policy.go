@@ -0,0 +1,100 @@
+package controllersv1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+)
+
+type labelPolicyController struct {
+	// nolint: unused
+	baseController
+}
+
+var LabelPolicyController = labelPolicyController{}
+
+type GetLabelPolicySchema struct {
+	GetOrganizationSchema
+	ResourceType modelschemas.ResourceType `path:"resourceType"`
+}
+
+func (c *labelPolicyController) List(ctx *gin.Context, schema *GetOrganizationSchema) ([]*schemas.LabelPolicySchema, error) {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canView(ctx, org); err != nil {
+		return nil, err
+	}
+	policies, err := services.LabelPolicyService.List(ctx, org.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list label policies")
+	}
+	return transformersv1.ToLabelPolicySchemas(ctx, policies)
+}
+
+type UpdateLabelPolicySchema struct {
+	schemas.UpdateLabelPolicySchema
+	GetLabelPolicySchema
+}
+
+func (c *labelPolicyController) Update(ctx *gin.Context, schema *UpdateLabelPolicySchema) (*schemas.LabelPolicySchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   modelschemas.ResourceTypeOrganization,
+		ResourceId:     org.ID,
+		OperationName:  "updated label policy",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	policy, err := services.LabelPolicyService.Upsert(ctx, services.UpsertLabelPolicyOption{
+		CreatorId:      user.ID,
+		OrganizationId: org.ID,
+		ResourceType:   schema.ResourceType,
+		RequiredKeys:   schema.RequiredKeys,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "upsert label policy")
+	}
+	return transformersv1.ToLabelPolicySchema(ctx, policy)
+}
+
+func (c *labelPolicyController) Delete(ctx *gin.Context, schema *GetLabelPolicySchema) (*schemas.LabelPolicySchema, error) {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	policy, err := services.LabelPolicyService.GetByResourceType(ctx, org.ID, schema.ResourceType)
+	if err != nil {
+		return nil, errors.Wrap(err, "get label policy")
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   modelschemas.ResourceTypeOrganization,
+		ResourceId:     org.ID,
+		OperationName:  "deleted label policy",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	policy, err = services.LabelPolicyService.Delete(ctx, policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete label policy")
+	}
+	return transformersv1.ToLabelPolicySchema(ctx, policy)
+}
@@ -1,14 +1,12 @@
 package controllersv1
 
 import (
-	"bufio"
-	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
-	"strings"
+	"net/http"
 	"sync"
 	"time"
 
@@ -16,12 +14,14 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	v1 "k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	commonconsts "github.com/bentoml/yatai-common/consts"
 	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/metrics"
+	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/api-server/services"
 )
 
@@ -32,10 +32,20 @@ const (
 	logMessageTypeAppend  logMessageType = "append"
 )
 
+type logLineSchema struct {
+	PodName       string    `json:"pod_name"`
+	ContainerName string    `json:"container_name"`
+	Timestamp     time.Time `json:"timestamp"`
+	Severity      string    `json:"severity"`
+	Content       string    `json:"content"`
+}
+
 type logMessage struct {
 	ReqId string         `json:"req_id"`
 	Type  logMessageType `json:"type"`
 	Items []string       `json:"items"`
+	// Lines are the structured Items
+	Lines []*logLineSchema `json:"lines"`
 }
 
 type Tail struct {
@@ -51,8 +61,7 @@ type Tail struct {
 
 	enableLogName bool
 
-	currentReqId string
-	mu           sync.Mutex
+	mu sync.Mutex
 }
 
 type tailRequest struct {
@@ -61,6 +70,10 @@ type tailRequest struct {
 	ContainerName *string `json:"container_name"`
 	SinceTime     *time.Time
 	Follow        bool
+	// Pattern only keeps the lines which match the regular expression
+	Pattern *string `json:"pattern"`
+	// Severity only keeps the lines at or above the severity, such as warning
+	Severity *string `json:"severity"`
 }
 
 type wsTailRequest struct {
@@ -68,7 +81,7 @@ type wsTailRequest struct {
 	Payload *tailRequest `json:"payload"`
 }
 
-// NewTail creates new Tail object
+// NewTail creates new Tail object, the logs of the pods are merged in timestamp order
 func NewTail(conn *websocket.Conn, namespace string, podNames []string, containerName string, timestamps, enableLogName bool) *Tail {
 	return &Tail{
 		Finished:      false,
@@ -101,7 +114,112 @@ func (t *Tail) doClose(err error) {
 	}
 }
 
-// Start starts Pod log streaming
+func (t *Tail) writeLines(reqId string, messageType logMessageType, lines []*services.PodLogLine) error {
+	items := make([]string, 0, len(lines))
+	lineSchemas := make([]*logLineSchema, 0, len(lines))
+	for _, line := range lines {
+		if t.timestamps {
+			items = append(items, line.String(t.enableLogName))
+		} else if t.enableLogName {
+			items = append(items, fmt.Sprintf("[%s] [%s] %s", line.PodName, line.ContainerName, line.Content))
+		} else {
+			items = append(items, line.Content)
+		}
+		lineSchemas = append(lineSchemas, &logLineSchema{
+			PodName:       line.PodName,
+			ContainerName: line.ContainerName,
+			Timestamp:     line.Timestamp,
+			Severity:      string(line.Severity),
+			Content:       line.Content,
+		})
+	}
+	msg := schemasv1.WsRespSchema{
+		Type:    schemasv1.WsRespTypeSuccess,
+		Message: "",
+		Payload: &logMessage{
+			ReqId: reqId,
+			Type:  messageType,
+			Items: items,
+			Lines: lineSchemas,
+		},
+	}
+	msgStr, err := json.Marshal(&msg)
+	if err != nil {
+		return errors.Wrap(err, "error in marshal log message")
+	}
+	err = t.Write(msgStr)
+	if err != nil {
+		return errors.Wrap(err, "error in write log message")
+	}
+	return nil
+}
+
+// serve sends the current logs of the request in replace and append messages, then follows the new logs if requested.
+// The websocket writes block the reading of the logs, so a slow client is never buffered for.
+func (t *Tail) serve(ctx context.Context, clientset *kubernetes.Clientset, req *tailRequest) error {
+	containerName := t.containerName
+	if req.ContainerName != nil {
+		containerName = *req.ContainerName
+	}
+	pattern := ""
+	if req.Pattern != nil {
+		pattern = *req.Pattern
+	}
+	severity := ""
+	if req.Severity != nil {
+		severity = *req.Severity
+	}
+	filter, err := services.NewPodLogFilter(pattern, severity)
+	if err != nil {
+		return err
+	}
+	sources := make([]services.PodLogSource, 0, len(t.podNames))
+	for _, podName := range t.podNames {
+		sources = append(sources, services.PodLogSource{
+			PodName:       podName,
+			ContainerName: containerName,
+		})
+	}
+
+	now := time.Now()
+	messageType := logMessageTypeReplace
+	onLines := func(lines []*services.PodLogLine) error {
+		err := t.writeLines(req.Id, messageType, lines)
+		messageType = logMessageTypeAppend
+		return err
+	}
+
+	// the follow resumes every pod after the last line of the read
+	cursors := make(map[services.PodLogSource]*services.PodLogCursor, len(sources))
+	err = services.PodLogService.Read(ctx, clientset, t.namespace, services.PodLogOption{
+		Sources:   sources,
+		TailLines: req.TailLines,
+		SinceTime: req.SinceTime,
+		Cursors:   cursors,
+		Filter:    filter,
+	}, onLines)
+	if err != nil {
+		return err
+	}
+	if messageType == logMessageTypeReplace {
+		// clear the logs of the previous request even if nothing matches
+		if err = onLines([]*services.PodLogLine{}); err != nil {
+			return err
+		}
+	}
+
+	if !req.Follow {
+		return nil
+	}
+	return services.PodLogService.Follow(ctx, clientset, t.namespace, services.PodLogOption{
+		Sources:   sources,
+		SinceTime: &now,
+		Cursors:   cursors,
+		Filter:    filter,
+	}, onLines)
+}
+
+// Start starts Pod log streaming, a new request cancels the streaming of the previous one
 func (t *Tail) Start(ctx context.Context, clientset *kubernetes.Clientset) error {
 	go func() {
 		<-t.toClose
@@ -144,138 +262,31 @@ func (t *Tail) Start(ctx context.Context, clientset *kubernetes.Clientset) error
 				continue
 			}
 
+			if req.Payload == nil {
+				continue
+			}
+
 			reqCh <- req.Payload
 		}
 	}()
 
 	go func() {
+		cancel := func() {}
+		defer func() { cancel() }()
 		for {
 			select {
 			case <-t.closeCh:
 				return
 			case req := <-reqCh:
-				t.currentReqId = req.Id
-
-				logOptions := &v1.PodLogOptions{
-					Container:  t.containerName,
-					TailLines:  req.TailLines,
-					Timestamps: t.timestamps,
-				}
-
-				if req.ContainerName != nil {
-					logOptions.Container = *req.ContainerName
-				}
-
-				if req.SinceTime != nil {
-					logOptions.SinceTime = &metav1.Time{
-						Time: *req.SinceTime,
-					}
-				}
-
-				now := time.Now()
-
-				for _, podName := range t.podNames {
-					podName := podName
-
-					rs, err := clientset.CoreV1().Pods(t.namespace).GetLogs(podName, logOptions).Stream(ctx)
-					if err != nil {
-						t.doClose(errors.Wrapf(err, "get pod %s log failed", podName))
-						return
-					}
-
-					err = func() error {
-						defer rs.Close()
-						buf := new(bytes.Buffer)
-						_, err = io.Copy(buf, rs)
-						if err != nil {
-							return errors.Wrap(err, "error in copy information from podLogs to buf")
-						}
-						str := buf.String()
-						lines := strings.Split(str, "\n")
-						res := make([]string, 0, len(lines))
-						for _, line := range lines {
-							if t.enableLogName {
-								line = fmt.Sprintf("[%s] [%s] %s", podName, t.containerName, line)
-							}
-							res = append(res, line)
-						}
-						msg := schemasv1.WsRespSchema{
-							Type:    schemasv1.WsRespTypeSuccess,
-							Message: "",
-							Payload: &logMessage{
-								ReqId: req.Id,
-								Type:  logMessageTypeReplace,
-								Items: res,
-							},
-						}
-						msgStr, err := json.Marshal(&msg)
-						if err != nil {
-							return errors.Wrap(err, "error in marshal log message")
-						}
-						err = t.Write(msgStr)
-						if err != nil {
-							return errors.Wrap(err, "error in write log message")
-						}
-						if req.Follow {
-							go func() {
-								logOptions.Follow = true
-								logOptions.TailLines = nil
-								logOptions.SinceTime = &metav1.Time{
-									Time: now,
-								}
-								rs, err := clientset.CoreV1().Pods(t.namespace).GetLogs(podName, logOptions).Stream(ctx)
-								if err != nil {
-									_, _ = fmt.Fprintln(os.Stderr, err)
-									return
-								}
-								defer rs.Close()
-								sc := bufio.NewScanner(rs)
-								for sc.Scan() {
-									select {
-									case <-t.closeCh:
-										return
-									default:
-									}
-
-									if t.currentReqId != req.Id {
-										break
-									}
-
-									content := sc.Text()
-									if t.enableLogName {
-										content = fmt.Sprintf("[%s] [%s] %s", podName, t.containerName, content)
-									}
-									msg := schemasv1.WsRespSchema{
-										Type:    schemasv1.WsRespTypeSuccess,
-										Message: "",
-										Payload: &logMessage{
-											ReqId: req.Id,
-											Type:  logMessageTypeAppend,
-											Items: []string{
-												content,
-											},
-										},
-									}
-									msgStr, err := json.Marshal(&msg)
-									if err != nil {
-										t.doClose(errors.Wrap(err, "error in marshal log message"))
-										return
-									}
-									err = t.Write(msgStr)
-									if err != nil {
-										t.doClose(errors.Wrap(err, "error in write log message"))
-										return
-									}
-								}
-							}()
-						}
-						return nil
-					}()
-					if err != nil {
+				cancel()
+				var reqCtx context.Context
+				reqCtx, cancel = context.WithCancel(ctx)
+				go func() {
+					err := t.serve(reqCtx, clientset, req)
+					if err != nil && reqCtx.Err() == nil {
 						t.doClose(err)
-						return
 					}
-				}
+				}()
 			}
 		}
 	}()
@@ -296,6 +307,39 @@ type logController struct {
 
 var LogController = logController{}
 
+// getDeploymentPodNames returns the pod if podName is specified, otherwise all the pods of the deployment,
+// the container defaults to the first container of the pods
+func (c *logController) getDeploymentPodNames(ctx context.Context, cliset *kubernetes.Clientset, deployment *models.Deployment, kubeNs, podName, containerName string) ([]string, string, error) {
+	podsCli := cliset.CoreV1().Pods(kubeNs)
+	pods := make([]corev1.Pod, 0)
+	if podName != "" {
+		pod, err := podsCli.Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", err
+		}
+		if pod.Labels[commonconsts.KubeLabelYataiBentoDeployment] != deployment.Name {
+			return nil, "", errors.Errorf("pod %s not in this deployment", podName)
+		}
+		pods = append(pods, *pod)
+	} else {
+		podList, err := podsCli.List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", commonconsts.KubeLabelYataiBentoDeployment, deployment.Name),
+		})
+		if err != nil {
+			return nil, "", errors.Wrap(err, "list pods")
+		}
+		pods = append(pods, podList.Items...)
+	}
+	podNames := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		podNames = append(podNames, pod.Name)
+		if containerName == "" && len(pod.Spec.Containers) > 0 {
+			containerName = pod.Spec.Containers[0].Name
+		}
+	}
+	return podNames, containerName, nil
+}
+
 func (c *logController) TailDeploymentPodLog(ctx *gin.Context, schema *GetDeploymentSchema) error {
 	var err error
 
@@ -306,6 +350,7 @@ func (c *logController) TailDeploymentPodLog(ctx *gin.Context, schema *GetDeploy
 		return err
 	}
 	defer conn.Close()
+	defer metrics.TrackWebsocketConnection("log")()
 
 	defer func() {
 		writeWsError(conn, err)
@@ -331,33 +376,128 @@ func (c *logController) TailDeploymentPodLog(ctx *gin.Context, schema *GetDeploy
 	}
 
 	podName := ctx.Query("pod_name")
-	var podNames []string
 	containerName := ctx.Query("container_name")
 
 	kubeNs := services.DeploymentService.GetKubeNamespace(deployment)
 
-	if podName != "" {
-		podNames = append(podNames, podName)
-		podsCli := cliset.CoreV1().Pods(kubeNs)
+	podNames, containerName, err := c.getDeploymentPodNames(ctx, cliset, deployment, kubeNs, podName, containerName)
+	if err != nil {
+		return err
+	}
 
-		pod, err := podsCli.Get(ctx, podName, metav1.GetOptions{})
+	// the logs of all the replicas are merged when no pod is specified
+	t := NewTail(conn, kubeNs, podNames, containerName, true, len(podNames) > 1)
+
+	err = t.Start(ctx, cliset)
+	return err
+}
+
+type DownloadDeploymentPodLogSchema struct {
+	GetDeploymentSchema
+	PodName       string  `query:"pod_name"`
+	ContainerName string  `query:"container_name"`
+	SinceTime     *string `query:"since_time"`
+	UntilTime     *string `query:"until_time"`
+	Pattern       string  `query:"pattern"`
+	Severity      string  `query:"severity"`
+}
+
+// DownloadDeploymentPodLog streams the merged logs of the deployment pods within the time window as a gzip file,
+// the window is the last hour by default
+func (c *logController) DownloadDeploymentPodLog(ctx *gin.Context, schema *DownloadDeploymentPodLogSchema) error {
+	deployment, err := schema.GetDeployment(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = DeploymentController.canView(ctx, deployment); err != nil {
+		return err
+	}
+
+	untilTime := time.Now()
+	if schema.UntilTime != nil {
+		untilTime, err = time.Parse(time.RFC3339, *schema.UntilTime)
 		if err != nil {
-			return err
+			return errors.Wrap(err, "parse until_time")
 		}
-
-		if pod.Labels[commonconsts.KubeLabelYataiBentoDeployment] != deployment.Name {
-			return errors.Errorf("pod %s not in this deployment", podName)
+	}
+	sinceTime := untilTime.Add(-time.Hour)
+	if schema.SinceTime != nil {
+		sinceTime, err = time.Parse(time.RFC3339, *schema.SinceTime)
+		if err != nil {
+			return errors.Wrap(err, "parse since_time")
 		}
+	}
+	if !sinceTime.Before(untilTime) {
+		return errors.New("since_time should be before until_time")
+	}
 
-		if containerName == "" {
-			containerName = pod.Status.ContainerStatuses[0].Name
-		}
+	filter, err := services.NewPodLogFilter(schema.Pattern, schema.Severity)
+	if err != nil {
+		return err
 	}
 
-	t := NewTail(conn, kubeNs, podNames, containerName, true, false)
+	cluster, err := schema.GetCluster(ctx)
+	if err != nil {
+		return err
+	}
 
-	err = t.Start(ctx, cliset)
-	return err
+	cliset, _, err := services.ClusterService.GetKubeCliSet(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	kubeNs := services.DeploymentService.GetKubeNamespace(deployment)
+
+	podNames, containerName, err := c.getDeploymentPodNames(ctx, cliset, deployment, kubeNs, schema.PodName, schema.ContainerName)
+	if err != nil {
+		return err
+	}
+	sources := make([]services.PodLogSource, 0, len(podNames))
+	for _, podName := range podNames {
+		sources = append(sources, services.PodLogSource{
+			PodName:       podName,
+			ContainerName: containerName,
+		})
+	}
+
+	// the status is written with the first lines, so that the errors of opening the logs are still responded as errors
+	var gz *gzip.Writer
+	start := func() {
+		if gz != nil {
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.log.gz", deployment.Name, sinceTime.UTC().Format("20060102T150405Z")))
+		ctx.Header("Content-Type", "application/gzip")
+		ctx.Status(http.StatusOK)
+		gz = gzip.NewWriter(ctx.Writer)
+	}
+	err = services.PodLogService.Read(ctx, cliset, kubeNs, services.PodLogOption{
+		Sources:   sources,
+		SinceTime: &sinceTime,
+		UntilTime: &untilTime,
+		Filter:    filter,
+		BatchSize: 1000,
+	}, func(lines []*services.PodLogLine) error {
+		start()
+		for _, line := range lines {
+			if _, err := io.WriteString(gz, line.String(true)+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if gz == nil {
+			return err
+		}
+		// the error can not be responded after the status, the connection is aborted without closing the gzip stream,
+		// so that the client fails on the broken download instead of keeping a valid looking file which lacks the rest of the logs
+		logrus.Errorf("download deployment %s pod log: %s", deployment.Name, err.Error())
+		panic(http.ErrAbortHandler)
+	}
+	start()
+	return gz.Close()
 }
 
 func (c *logController) TailClusterPodLog(ctx *gin.Context, schema *GetClusterSchema) error {
@@ -370,6 +510,7 @@ func (c *logController) TailClusterPodLog(ctx *gin.Context, schema *GetClusterSc
 		return err
 	}
 	defer conn.Close()
+	defer metrics.TrackWebsocketConnection("log")()
 
 	defer func() {
 		writeWsError(conn, err)
@@ -396,6 +396,17 @@ func (c *modelController) Update(ctx *gin.Context, schema *UpdateModelSchema) (*
 	if err = c.canUpdate(ctx, model); err != nil {
 		return nil, err
 	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   modelschemas.ResourceTypeModel,
+		ResourceId:     model.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	model, err = services.ModelService.Update(ctx, model, services.UpdateModelOption{
 		Labels: schema.Labels,
 	})
@@ -623,6 +634,7 @@ func (c *modelController) List(ctx *gin.Context, schema *ListModelSchema) (*sche
 
 type ListAllModelSchema struct {
 	schemasv1.ListQuerySchema
+	LabelSelectorQuerySchema
 	GetOrganizationSchema
 }
 
@@ -645,6 +657,11 @@ func (c *modelController) ListAll(ctx *gin.Context, schema *ListAllModelSchema)
 		OrganizationId: utils.UintPtr(organization.ID),
 	}
 
+	err = schema.BindListOption(&listOpt.BaseListByLabelsOption, schema.Q)
+	if err != nil {
+		return nil, err
+	}
+
 	queryMap := schema.Q.ToMap()
 	for k, v := range queryMap {
 		if k == schemasv1.KeyQIn {
@@ -701,14 +718,6 @@ func (c *modelController) ListAll(ctx *gin.Context, schema *ListAllModelSchema)
 			}
 			listOpt.Order = utils.StringPtr(fmt.Sprintf("model.%s %s", fieldName, strings.ToUpper(order)))
 		}
-		if k == "label" {
-			labelsSchema := services.ParseQueryLabelsToLabelsList(v.([]string))
-			listOpt.LabelsList = &labelsSchema
-		}
-		if k == "-label" {
-			labelsSchema := services.ParseQueryLabelsToLabelsList(v.([]string))
-			listOpt.LackLabelsList = &labelsSchema
-		}
 	}
 	models_, total, err := services.ModelService.List(ctx, listOpt)
 	if err != nil {
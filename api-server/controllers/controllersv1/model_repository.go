@@ -9,6 +9,7 @@ import (
 	"github.com/huandu/xstrings"
 	"github.com/pkg/errors"
 
+	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/api-server/services"
@@ -56,7 +57,6 @@ func (c *modelRepositoryController) canUpdate(ctx context.Context, modelReposito
 	return OrganizationController.canUpdate(ctx, organization)
 }
 
-// nolint: unused
 func (c *modelRepositoryController) canOperate(ctx context.Context, modelRepository *models.ModelRepository) error {
 	organization, err := services.OrganizationService.GetAssociatedOrganization(ctx, modelRepository)
 	if err != nil {
@@ -91,6 +91,12 @@ func (c *modelRepositoryController) Create(ctx *gin.Context, schema *CreateModel
 	if err != nil {
 		return nil, errors.Wrap(err, "create modelRepository")
 	}
+	createEvent(ctx, services.CreateEventOption{
+		OrganizationId: &organization.ID,
+		ResourceType:   modelschemas.ResourceTypeModelRepository,
+		ResourceId:     modelRepository.ID,
+		OperationName:  "created",
+	}, nil)
 	return transformersv1.ToModelRepositorySchema(ctx, modelRepository)
 }
 
@@ -107,6 +113,13 @@ func (c *modelRepositoryController) Update(ctx *gin.Context, schema *UpdateModel
 	if err = c.canUpdate(ctx, modelRepository); err != nil {
 		return nil, err
 	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &modelRepository.OrganizationId,
+		ResourceType:   modelschemas.ResourceTypeModelRepository,
+		ResourceId:     modelRepository.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	modelRepository, err = services.ModelRepositoryService.Update(ctx, modelRepository, services.UpdateModelRepositoryOption{
 		Description: schema.Description,
 		Labels:      schema.Labels,
@@ -130,6 +143,7 @@ func (c *modelRepositoryController) Get(ctx *gin.Context, schema *GetModelReposi
 
 type ListModelRepositorySchema struct {
 	schemasv1.ListQuerySchema
+	LabelSelectorQuerySchema
 	GetOrganizationSchema
 }
 
@@ -152,6 +166,11 @@ func (c *modelRepositoryController) List(ctx *gin.Context, schema *ListModelRepo
 		OrganizationId: utils.UintPtr(organization.ID),
 	}
 
+	err = schema.BindListOption(&listOpt.BaseListByLabelsOption, schema.Q)
+	if err != nil {
+		return nil, err
+	}
+
 	queryMap := schema.Q.ToMap()
 	for k, v := range queryMap {
 		if k == schemasv1.KeyQIn {
@@ -219,14 +238,6 @@ func (c *modelRepositoryController) List(ctx *gin.Context, schema *ListModelRepo
 			}
 			listOpt.Order = utils.StringPtr(fmt.Sprintf("%s %s", fieldName, strings.ToUpper(order)))
 		}
-		if k == "label" {
-			labelsSchema := services.ParseQueryLabelsToLabelsList(v.([]string))
-			listOpt.LabelsList = &labelsSchema
-		}
-		if k == "-label" {
-			labelsSchema := services.ParseQueryLabelsToLabelsList(v.([]string))
-			listOpt.LackLabelsList = &labelsSchema
-		}
 	}
 
 	modelRepositories, total, err := services.ModelRepositoryService.List(ctx, listOpt)
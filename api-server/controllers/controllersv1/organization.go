@@ -80,6 +80,12 @@ func (c *organizationController) Create(ctx *gin.Context, schema *schemasv1.Crea
 	if err != nil {
 		return nil, errors.Wrap(err, "create organization member")
 	}
+	createEvent(ctx, services.CreateEventOption{
+		OrganizationId: &organization.ID,
+		ResourceType:   modelschemas.ResourceTypeOrganization,
+		ResourceId:     organization.ID,
+		OperationName:  "created",
+	}, nil)
 	return transformersv1.ToOrganizationFullSchema(ctx, organization)
 }
 
@@ -96,6 +102,13 @@ func (c *organizationController) Update(ctx *gin.Context, schema *UpdateOrganiza
 	if err = c.canOperate(ctx, organization); err != nil {
 		return nil, err
 	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &organization.ID,
+		ResourceType:   modelschemas.ResourceTypeOrganization,
+		ResourceId:     organization.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
 	organization, err = services.OrganizationService.Update(ctx, organization, services.UpdateOrganizationOption{
 		Description: schema.Description,
 		Config:      schema.Config,
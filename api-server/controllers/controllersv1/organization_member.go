@@ -1,6 +1,8 @@
 package controllersv1
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 
@@ -66,6 +68,13 @@ func (c *organizationMemberController) Create(ctx *gin.Context, schema *CreateOr
 		if err != nil {
 			return nil, errors.Wrap(err, "create clusterMember")
 		}
+		createEvent(ctx, services.CreateEventOption{
+			Name:           fmt.Sprintf("%s (%s)", u.Name, schema.Role),
+			OrganizationId: &org.ID,
+			ResourceType:   modelschemas.ResourceTypeOrganization,
+			ResourceId:     org.ID,
+			OperationName:  "added member",
+		}, nil)
 		s, err := transformersv1.ToOrganizationMemberSchema(ctx, organizationMember)
 		if err != nil {
 			return nil, err
@@ -117,6 +126,15 @@ func (c *organizationMemberController) Delete(ctx *gin.Context, schema *DeleteOr
 	if err != nil {
 		return nil, errors.Wrap(err, "get member")
 	}
+	defer func() {
+		createEvent(ctx, services.CreateEventOption{
+			Name:           user.Name,
+			OrganizationId: &org.ID,
+			ResourceType:   modelschemas.ResourceTypeOrganization,
+			ResourceId:     org.ID,
+			OperationName:  "removed member",
+		}, err)
+	}()
 	organizationMember, err := services.OrganizationMemberService.Delete(ctx, member, currentUser.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "delete organizationMember")
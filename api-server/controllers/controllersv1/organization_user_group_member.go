@@ -0,0 +1,133 @@
+package controllersv1
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type organizationUserGroupMemberController struct {
+	organizationController
+}
+
+var OrganizationUserGroupMemberController = organizationUserGroupMemberController{}
+
+type CreateOrganizationUserGroupMembersSchema struct {
+	schemas.CreateUserGroupMembersSchema
+	GetOrganizationSchema
+}
+
+func (c *organizationUserGroupMemberController) Create(ctx *gin.Context, schema *CreateOrganizationUserGroupMembersSchema) ([]*schemas.OrganizationUserGroupMemberSchema, error) {
+	currentUser, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get current user")
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	userGroups, _, err := services.UserGroupService.List(ctx, services.ListUserGroupOption{
+		OrganizationId: utils.UintPtr(org.ID),
+		Names:          &schema.UserGroupNames,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*schemas.OrganizationUserGroupMemberSchema, 0, len(userGroups))
+	for _, userGroup := range userGroups {
+		member, err := services.OrganizationUserGroupMemberService.Create(ctx, currentUser.ID, services.CreateOrganizationUserGroupMemberOption{
+			CreatorId:      currentUser.ID,
+			UserGroupId:    userGroup.ID,
+			OrganizationId: org.ID,
+			Role:           schema.Role,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "create organizationUserGroupMember")
+		}
+		createEvent(ctx, services.CreateEventOption{
+			Name:           fmt.Sprintf("%s (%s)", userGroup.Name, schema.Role),
+			OrganizationId: &org.ID,
+			ResourceType:   modelschemas.ResourceTypeOrganization,
+			ResourceId:     org.ID,
+			OperationName:  "added user group member",
+		}, nil)
+		s, err := transformersv1.ToOrganizationUserGroupMemberSchema(ctx, member)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func (c *organizationUserGroupMemberController) List(ctx *gin.Context, schema *GetOrganizationSchema) ([]*schemas.OrganizationUserGroupMemberSchema, error) {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, org); err != nil {
+		return nil, err
+	}
+	members, err := services.OrganizationUserGroupMemberService.List(ctx, services.ListOrganizationUserGroupMemberOption{
+		OrganizationId: utils.UintPtr(org.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list organization user group members")
+	}
+	return transformersv1.ToOrganizationUserGroupMemberSchemas(ctx, members)
+}
+
+type DeleteOrganizationUserGroupMemberSchema struct {
+	schemas.DeleteUserGroupMemberSchema
+	GetOrganizationSchema
+}
+
+func (c *organizationUserGroupMemberController) Delete(ctx *gin.Context, schema *DeleteOrganizationUserGroupMemberSchema) (*schemas.OrganizationUserGroupMemberSchema, error) {
+	currentUser, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get current user")
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get organization")
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	userGroup, err := services.UserGroupService.GetByName(ctx, org.ID, schema.UserGroupName)
+	if err != nil {
+		return nil, err
+	}
+	member, err := services.OrganizationUserGroupMemberService.GetBy(ctx, userGroup.ID, org.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get member")
+	}
+	memberSchema, err := transformersv1.ToOrganizationUserGroupMemberSchema(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		createEvent(ctx, services.CreateEventOption{
+			Name:           userGroup.Name,
+			OrganizationId: &org.ID,
+			ResourceType:   modelschemas.ResourceTypeOrganization,
+			ResourceId:     org.ID,
+			OperationName:  "removed user group member",
+		}, err)
+	}()
+	_, err = services.OrganizationUserGroupMemberService.Delete(ctx, member, currentUser.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete organizationUserGroupMember")
+	}
+	return memberSchema, nil
+}
@@ -0,0 +1,214 @@
+package controllersv1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+)
+
+type retentionPolicyController struct {
+	// nolint: unused
+	baseController
+}
+
+var RetentionPolicyController = retentionPolicyController{}
+
+func (c *retentionPolicyController) get(ctx *gin.Context, resource models.IResource) (*schemas.RetentionPolicySchema, error) {
+	policy, err := services.RetentionPolicyService.GetByResource(ctx, resource)
+	if err != nil {
+		return nil, errors.Wrap(err, "get retention policy")
+	}
+	return transformersv1.ToRetentionPolicySchema(ctx, policy)
+}
+
+func (c *retentionPolicyController) update(ctx *gin.Context, organizationId uint, resource models.IResource, schema schemas.UpdateRetentionPolicySchema) (*schemas.RetentionPolicySchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &organizationId,
+		ResourceType:   resource.GetResourceType(),
+		ResourceId:     resource.GetId(),
+		OperationName:  "updated retention policy",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	policy, err := services.RetentionPolicyService.Upsert(ctx, services.UpsertRetentionPolicyOption{
+		CreatorId:      user.ID,
+		OrganizationId: organizationId,
+		Resource:       resource,
+		KeepLast:       schema.KeepLast,
+		MaxAgeDays:     schema.MaxAgeDays,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "upsert retention policy")
+	}
+	return transformersv1.ToRetentionPolicySchema(ctx, policy)
+}
+
+func (c *retentionPolicyController) delete(ctx *gin.Context, organizationId uint, resource models.IResource) (*schemas.RetentionPolicySchema, error) {
+	policy, err := services.RetentionPolicyService.GetByResource(ctx, resource)
+	if err != nil {
+		return nil, errors.Wrap(err, "get retention policy")
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &organizationId,
+		ResourceType:   resource.GetResourceType(),
+		ResourceId:     resource.GetId(),
+		OperationName:  "deleted retention policy",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	policy, err = services.RetentionPolicyService.Delete(ctx, policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete retention policy")
+	}
+	return transformersv1.ToRetentionPolicySchema(ctx, policy)
+}
+
+func (c *retentionPolicyController) GetBentoRepositoryRetentionPolicy(ctx *gin.Context, schema *GetBentoRepositorySchema) (*schemas.RetentionPolicySchema, error) {
+	bentoRepository, err := schema.GetBentoRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = BentoRepositoryController.canView(ctx, bentoRepository); err != nil {
+		return nil, err
+	}
+	return c.get(ctx, bentoRepository)
+}
+
+type UpdateBentoRepositoryRetentionPolicySchema struct {
+	schemas.UpdateRetentionPolicySchema
+	GetBentoRepositorySchema
+}
+
+func (c *retentionPolicyController) UpdateBentoRepositoryRetentionPolicy(ctx *gin.Context, schema *UpdateBentoRepositoryRetentionPolicySchema) (*schemas.RetentionPolicySchema, error) {
+	bentoRepository, err := schema.GetBentoRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = BentoRepositoryController.canOperate(ctx, bentoRepository); err != nil {
+		return nil, err
+	}
+	return c.update(ctx, bentoRepository.OrganizationId, bentoRepository, schema.UpdateRetentionPolicySchema)
+}
+
+func (c *retentionPolicyController) DeleteBentoRepositoryRetentionPolicy(ctx *gin.Context, schema *GetBentoRepositorySchema) (*schemas.RetentionPolicySchema, error) {
+	bentoRepository, err := schema.GetBentoRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = BentoRepositoryController.canOperate(ctx, bentoRepository); err != nil {
+		return nil, err
+	}
+	return c.delete(ctx, bentoRepository.OrganizationId, bentoRepository)
+}
+
+func (c *retentionPolicyController) GetModelRepositoryRetentionPolicy(ctx *gin.Context, schema *GetModelRepositorySchema) (*schemas.RetentionPolicySchema, error) {
+	modelRepository, err := schema.GetModelRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ModelRepositoryController.canView(ctx, modelRepository); err != nil {
+		return nil, err
+	}
+	return c.get(ctx, modelRepository)
+}
+
+type UpdateModelRepositoryRetentionPolicySchema struct {
+	schemas.UpdateRetentionPolicySchema
+	GetModelRepositorySchema
+}
+
+func (c *retentionPolicyController) UpdateModelRepositoryRetentionPolicy(ctx *gin.Context, schema *UpdateModelRepositoryRetentionPolicySchema) (*schemas.RetentionPolicySchema, error) {
+	modelRepository, err := schema.GetModelRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ModelRepositoryController.canOperate(ctx, modelRepository); err != nil {
+		return nil, err
+	}
+	return c.update(ctx, modelRepository.OrganizationId, modelRepository, schema.UpdateRetentionPolicySchema)
+}
+
+func (c *retentionPolicyController) DeleteModelRepositoryRetentionPolicy(ctx *gin.Context, schema *GetModelRepositorySchema) (*schemas.RetentionPolicySchema, error) {
+	modelRepository, err := schema.GetModelRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ModelRepositoryController.canOperate(ctx, modelRepository); err != nil {
+		return nil, err
+	}
+	return c.delete(ctx, modelRepository.OrganizationId, modelRepository)
+}
+
+func (c *retentionPolicyController) GetTerminalRecordRetentionPolicy(ctx *gin.Context, schema *GetOrganizationSchema) (*schemas.RetentionPolicySchema, error) {
+	organization, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canView(ctx, organization); err != nil {
+		return nil, err
+	}
+	return c.get(ctx, organization)
+}
+
+type UpdateTerminalRecordRetentionPolicySchema struct {
+	schemas.UpdateRetentionPolicySchema
+	GetOrganizationSchema
+}
+
+func (c *retentionPolicyController) UpdateTerminalRecordRetentionPolicy(ctx *gin.Context, schema *UpdateTerminalRecordRetentionPolicySchema) (*schemas.RetentionPolicySchema, error) {
+	organization, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canOperate(ctx, organization); err != nil {
+		return nil, err
+	}
+	return c.update(ctx, organization.ID, organization, schema.UpdateRetentionPolicySchema)
+}
+
+func (c *retentionPolicyController) DeleteTerminalRecordRetentionPolicy(ctx *gin.Context, schema *GetOrganizationSchema) (*schemas.RetentionPolicySchema, error) {
+	organization, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canOperate(ctx, organization); err != nil {
+		return nil, err
+	}
+	return c.delete(ctx, organization.ID, organization)
+}
+
+type RunRetentionPoliciesSchema struct {
+	GetOrganizationSchema
+	DryRun bool `query:"dry_run"`
+}
+
+func (c *retentionPolicyController) Run(ctx *gin.Context, schema *RunRetentionPoliciesSchema) (*schemas.RetentionRunReportSchema, error) {
+	organization, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canOperate(ctx, organization); err != nil {
+		return nil, err
+	}
+	if !schema.DryRun {
+		createEventOpt := services.CreateEventOption{
+			OrganizationId: &organization.ID,
+			ResourceType:   modelschemas.ResourceTypeOrganization,
+			ResourceId:     organization.ID,
+			OperationName:  "ran retention policies",
+		}
+		defer func() { createEvent(ctx, createEventOpt, err) }()
+	}
+	result, err := services.RetentionPolicyService.Run(ctx, organization.ID, schema.DryRun)
+	if err != nil {
+		return nil, errors.Wrap(err, "run retention policies")
+	}
+	return transformersv1.ToRetentionRunReportSchema(ctx, result)
+}
@@ -0,0 +1,128 @@
+package controllersv1
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	jujuerrors "github.com/juju/errors"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type searchController struct {
+	// nolint: unused
+	baseController
+}
+
+var SearchController = searchController{}
+
+type SearchSchema struct {
+	schemasv1.ListQuerySchema
+	LabelSelectorQuerySchema
+	GetOrganizationSchema
+}
+
+// buildSearchOpt parses the q, the bare words are the full text keywords,
+// type:bento,model restricts the result types, api:predict matches the bentos exposing the api
+// and module:sklearn matches the models saved by the module
+func (c *searchController) buildSearchOpt(ctx context.Context, searchOpt *services.SearchOption, q schemasv1.Q) error {
+	queryMap := q.ToMap()
+	for k, v := range queryMap {
+		if k == schemasv1.KeyQKeywords {
+			searchOpt.Keywords = utils.StringSlicePtr(v.([]string))
+		}
+		if k == "type" {
+			types := make([]services.SearchResultType, 0)
+			for _, piece := range v.([]string) {
+				found := false
+				for _, resultType := range services.SearchResultTypes {
+					if string(resultType) == piece {
+						types = append(types, resultType)
+						found = true
+					}
+				}
+				if !found {
+					return jujuerrors.BadRequestf("unknown search result type %s", piece)
+				}
+			}
+			searchOpt.Types = &types
+		}
+		if k == "api" {
+			searchOpt.Apis = utils.StringSlicePtr(v.([]string))
+		}
+		if k == "module" {
+			searchOpt.Modules = utils.StringSlicePtr(v.([]string))
+		}
+		if k == "creator" {
+			userNames, err := processUserNamesFromQ(ctx, v.([]string))
+			if err != nil {
+				return err
+			}
+			users, err := services.UserService.ListByNames(ctx, userNames)
+			if err != nil {
+				return err
+			}
+			userIds := make([]uint, 0, len(users))
+			for _, user := range users {
+				userIds = append(userIds, user.ID)
+			}
+			searchOpt.CreatorIds = utils.UintSlicePtr(userIds)
+		}
+	}
+	return nil
+}
+
+func (c *searchController) Search(ctx *gin.Context, schema *SearchSchema) (*schemas.SearchResultListSchema, error) {
+	organization, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = OrganizationController.canView(ctx, organization); err != nil {
+		return nil, err
+	}
+
+	searchOpt := services.SearchOption{
+		BaseListOption: services.BaseListOption{
+			Start: utils.UintPtr(schema.Start),
+			Count: utils.UintPtr(schema.Count),
+		},
+		OrganizationId: organization.ID,
+	}
+
+	err = c.buildSearchOpt(ctx, &searchOpt, schema.Q)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Search != nil && *schema.Search != "" {
+		keywords := []string{*schema.Search}
+		if searchOpt.Keywords != nil {
+			keywords = append(keywords, *searchOpt.Keywords...)
+		}
+		searchOpt.Keywords = &keywords
+	}
+	err = schema.BindListOption(&searchOpt.BaseListByLabelsOption, schema.Q)
+	if err != nil {
+		return nil, err
+	}
+
+	results, total, err := services.SearchService.Search(ctx, searchOpt)
+	if err != nil {
+		return nil, errors.Wrap(err, "search")
+	}
+
+	resultSchemas, err := transformersv1.ToSearchResultSchemas(ctx, results)
+	return &schemas.SearchResultListSchema{
+		BaseListSchema: schemasv1.BaseListSchema{
+			Total: total,
+			Start: schema.Start,
+			Count: schema.Count,
+		},
+		Items: resultSchemas,
+	}, err
+}
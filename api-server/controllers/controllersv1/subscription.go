@@ -13,6 +13,7 @@ import (
 
 	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/metrics"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
 )
@@ -32,6 +33,7 @@ func (c *subscriptionController) SubscribeResource(ctx *gin.Context) error {
 		return err
 	}
 	defer conn.Close()
+	defer metrics.TrackWebsocketConnection("subscription")()
 
 	currentUser, err := services.GetCurrentUser(ctx)
 	if err != nil {
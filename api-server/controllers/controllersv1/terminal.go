@@ -28,6 +28,7 @@ import (
 
 	commonconsts "github.com/bentoml/yatai-common/consts"
 	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/metrics"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/common/consts"
@@ -229,8 +230,10 @@ func (t *WebTerminal) Next() *remotecommand.TerminalSize {
 	select {
 	case size := <-t.sizeChan:
 		if t.recorder != nil {
-			t.recorder.Meta.Width = size.Width
-			t.recorder.Meta.Height = size.Height
+			err := services.TerminalRecordService.Resize(context.Background(), t.recorder, size.Width, size.Height)
+			if err != nil {
+				logrus.Errorf("record terminal resize error: %v", err)
+			}
 		}
 		return &size
 	case <-t.closeCh:
@@ -313,6 +316,7 @@ func (c *terminalController) GetDeploymentPodTerminal(ctx *gin.Context, schema *
 		return err
 	}
 	defer conn.Close()
+	defer metrics.TrackWebsocketConnection("terminal")()
 
 	defer func() {
 		writeWsError(conn, err)
@@ -383,6 +387,15 @@ func (c *terminalController) GetDeploymentPodTerminal(ctx *gin.Context, schema *
 		return err
 	}
 
+	createEvent(ctx, services.CreateEventOption{
+		Name:           fmt.Sprintf("%s/%s", podName, containerName),
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeTerminalRecord,
+		ResourceId:     recorder.ID,
+		OperationName:  "opened terminal",
+	}, nil)
+
 	t, err := NewWebTerminal(ctx, conn, kubeNs, podName, containerName, recorder)
 	if err != nil {
 		return err
@@ -407,6 +420,7 @@ func (c *terminalController) GetClusterPodTerminal(ctx *gin.Context, schema *Get
 		return err
 	}
 	defer conn.Close()
+	defer metrics.TrackWebsocketConnection("terminal")()
 
 	defer func() {
 		writeWsError(conn, err)
@@ -468,6 +482,15 @@ func (c *terminalController) GetClusterPodTerminal(ctx *gin.Context, schema *Get
 		return err
 	}
 
+	createEvent(ctx, services.CreateEventOption{
+		Name:           fmt.Sprintf("%s/%s", podName, containerName),
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeTerminalRecord,
+		ResourceId:     recorder.ID,
+		OperationName:  "opened terminal",
+	}, nil)
+
 	t, err := NewWebTerminal(ctx, conn, kubeNs, podName, containerName, recorder)
 	if err != nil {
 		return err
@@ -2,15 +2,20 @@ package controllersv1
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 
+	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
 )
@@ -118,26 +123,150 @@ func (c *terminalRecordController) Get(ctx *gin.Context, schema *GetTerminalReco
 	return transformersv1.ToTerminalRecordSchema(ctx, terminalRecord)
 }
 
-func (c *terminalRecordController) Download(ctx *gin.Context) error {
-	uid := ctx.Param("uid")
+func (c *terminalRecordController) createAccessEvent(ctx *gin.Context, terminalRecord *models.TerminalRecord, operationName string, err error) {
+	createEvent(ctx, services.CreateEventOption{
+		Name:           fmt.Sprintf("%s/%s", terminalRecord.PodName, terminalRecord.ContainerName),
+		OrganizationId: terminalRecord.OrganizationId,
+		ClusterId:      terminalRecord.ClusterId,
+		ResourceType:   modelschemas.ResourceTypeTerminalRecord,
+		ResourceId:     terminalRecord.ID,
+		OperationName:  operationName,
+	}, err)
+}
+
+func (c *terminalRecordController) Download(ctx *gin.Context, schema *GetTerminalRecordSchema) error {
+	terminalRecord, err := schema.GetTerminalRecord(ctx)
+	if err != nil {
+		return err
+	}
+	if err = c.canView(ctx, terminalRecord); err != nil {
+		return err
+	}
+	defer func() { c.createAccessEvent(ctx, terminalRecord, "downloaded terminal record", err) }()
+
+	// the record is opened before the status is written, so that its errors are still responded as errors
+	reader, size, err := services.TerminalRecordService.Open(ctx, terminalRecord)
+	if err != nil {
+		return errors.Wrap(err, "open terminal record")
+	}
+	defer reader.Close()
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.cast", terminalRecord.Uid))
+	ctx.Header("Content-Type", "application/x-asciicast")
+	// the clients detect a download which is cut by a read error by its content length
+	ctx.Header("Content-Length", strconv.FormatInt(size, 10))
+	ctx.Status(http.StatusOK)
+	_, err = io.Copy(ctx.Writer, reader)
+	if err != nil {
+		// the status is already written, the error can not be responded and would be appended to the file,
+		// the response is left short of its content length instead
+		logrus.Errorf("download terminal record %s: %s", terminalRecord.Uid, err.Error())
+	}
+	return nil
+}
+
+type ReplayTerminalRecordSchema struct {
+	GetTerminalRecordSchema
+	// Speed multiplies the playback speed, 1 by default
+	Speed float64 `query:"speed"`
+	// MaxIdle caps the pauses between the events in seconds, 0 keeps the original pauses
+	MaxIdle float64 `query:"max_idle"`
+}
+
+// Replay streams the events of the record through the websocket with their original timing
+func (c *terminalRecordController) Replay(ctx *gin.Context, schema *ReplayTerminalRecordSchema) error {
+	var err error
 
-	record, err := services.TerminalRecordService.GetByUid(ctx, uid)
+	ctx.Request.Header.Del("Origin")
+	conn, err := wsUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
+		logrus.Errorf("ws connect failed: %q", err.Error())
 		return err
 	}
+	defer conn.Close()
 
-	ctx.Writer.WriteHeader(http.StatusOK)
-	ctx.Header("Content-Disposition", "attachment; filename=record.cast")
-	ctx.Header("Content-Type", "application/text/plain")
+	defer func() {
+		writeWsError(conn, err)
+	}()
 
-	meta, err := json.Marshal(record.Meta)
+	terminalRecord, err := schema.GetTerminalRecord(ctx)
+	if err != nil {
+		return err
+	}
+	if err = c.canView(ctx, terminalRecord); err != nil {
+		return err
+	}
+	c.createAccessEvent(ctx, terminalRecord, "replayed terminal record", nil)
+
+	speed := schema.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	replayCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// the client closes the websocket to stop the replay
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	send := func(frame *schemas.TerminalRecordReplayFrameSchema) error {
+		return conn.WriteJSON(&schemasv1.WsRespSchema{
+			Type:    schemasv1.WsRespTypeSuccess,
+			Payload: frame,
+		})
+	}
+
+	header, err := services.TerminalRecordService.GetHeader(ctx, terminalRecord)
+	if err != nil {
+		return err
+	}
+	err = send(&schemas.TerminalRecordReplayFrameSchema{
+		Type:   schemas.TerminalRecordReplayFrameTypeHeader,
+		Header: header,
+	})
 	if err != nil {
 		return err
 	}
-	_content := strings.Join(record.Content, "\n")
 
-	content := string(meta) + "\n" + _content
-	ctx.Header("Accept-Length", fmt.Sprintf("%d", len(content)))
-	_, err = ctx.Writer.Write([]byte(content))
+	lastTime := float64(0)
+	err = services.TerminalRecordService.ReadEvents(replayCtx, terminalRecord, func(event services.TerminalRecordEvent) error {
+		pause := event.Time - lastTime
+		if schema.MaxIdle > 0 && pause > schema.MaxIdle {
+			pause = schema.MaxIdle
+		}
+		lastTime = event.Time
+		if pause > 0 {
+			timer := time.NewTimer(time.Duration(pause / speed * float64(time.Second)))
+			select {
+			case <-replayCtx.Done():
+				timer.Stop()
+				return replayCtx.Err()
+			case <-timer.C:
+			}
+		}
+		return send(&schemas.TerminalRecordReplayFrameSchema{
+			Type:      schemas.TerminalRecordReplayFrameTypeEvent,
+			Time:      event.Time,
+			EventType: string(event.Type),
+			Data:      event.Data,
+		})
+	})
+	if errors.Is(err, context.Canceled) {
+		err = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = send(&schemas.TerminalRecordReplayFrameSchema{
+		Type: schemas.TerminalRecordReplayFrameTypeEnd,
+		Time: lastTime,
+	})
 	return err
 }
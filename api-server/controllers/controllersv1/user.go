@@ -117,5 +117,13 @@ func (c *userController) Create(ctx *gin.Context, schema *CreateOrganizationUser
 		return nil, errors.Wrap(err, "create cluster member")
 	}
 
+	createEvent(ctx, services.CreateEventOption{
+		Name:           string(schema.Role),
+		OrganizationId: &org.ID,
+		ResourceType:   modelschemas.ResourceTypeUser,
+		ResourceId:     user.ID,
+		OperationName:  "created",
+	}, nil)
+
 	return transformersv1.ToUserSchema(ctx, user)
 }
@@ -0,0 +1,290 @@
+package controllersv1
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type userGroupController struct {
+	organizationController
+}
+
+var UserGroupController = userGroupController{}
+
+type GetUserGroupSchema struct {
+	GetOrganizationSchema
+	UserGroupName string `path:"userGroupName"`
+}
+
+func (s *GetUserGroupSchema) GetUserGroup(ctx context.Context) (*models.UserGroup, error) {
+	org, err := s.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return services.UserGroupService.GetByName(ctx, org.ID, s.UserGroupName)
+}
+
+func (c *userGroupController) listUserIdsByNames(ctx context.Context, usernames []string) ([]uint, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	users, err := services.UserService.ListByNames(ctx, usernames)
+	if err != nil {
+		return nil, errors.Wrap(err, "list users by names")
+	}
+	userIds := make([]uint, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.ID)
+	}
+	return userIds, nil
+}
+
+type CreateUserGroupSchema struct {
+	schemas.CreateUserGroupSchema
+	GetOrganizationSchema
+}
+
+func (c *userGroupController) Create(ctx *gin.Context, schema *CreateUserGroupSchema) (*schemas.UserGroupSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	userIds, err := c.listUserIdsByNames(ctx, schema.Usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint: ineffassign, staticcheck
+	_, ctx_, df, err := services.StartTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { df(err) }()
+
+	userGroup, err := services.UserGroupService.Create(ctx_, services.CreateUserGroupOption{
+		CreatorId:      user.ID,
+		OrganizationId: org.ID,
+		Name:           schema.Name,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "create user group")
+		return nil, err
+	}
+	err = services.UserGroupService.AddUsers(ctx_, userGroup, user.ID, userIds)
+	if err != nil {
+		err = errors.Wrap(err, "add user group users")
+		return nil, err
+	}
+	createEvent(ctx_, services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeUserGroup,
+		ResourceId:     userGroup.ID,
+		OperationName:  "created",
+	}, nil)
+	return transformersv1.ToUserGroupSchema(ctx_, userGroup)
+}
+
+type UpdateUserGroupSchema struct {
+	schemas.UpdateUserGroupSchema
+	GetUserGroupSchema
+}
+
+func (c *userGroupController) Update(ctx *gin.Context, schema *UpdateUserGroupSchema) (*schemas.UserGroupSchema, error) {
+	userGroup, err := schema.GetUserGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeUserGroup,
+		ResourceId:     userGroup.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	userGroup, err = services.UserGroupService.Update(ctx, userGroup, services.UpdateUserGroupOption{
+		Name: schema.Name,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update user group")
+	}
+	return transformersv1.ToUserGroupSchema(ctx, userGroup)
+}
+
+func (c *userGroupController) Get(ctx *gin.Context, schema *GetUserGroupSchema) (*schemas.UserGroupSchema, error) {
+	userGroup, err := schema.GetUserGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, org); err != nil {
+		return nil, err
+	}
+	return transformersv1.ToUserGroupSchema(ctx, userGroup)
+}
+
+func (c *userGroupController) Delete(ctx *gin.Context, schema *GetUserGroupSchema) (*schemas.UserGroupSchema, error) {
+	userGroup, err := schema.GetUserGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	userGroupSchema, err := transformersv1.ToUserGroupSchema(ctx, userGroup)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeUserGroup,
+		ResourceId:     userGroup.ID,
+		OperationName:  "deleted",
+		ResourceName:   userGroup.Name,
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	_, err = services.UserGroupService.Delete(ctx, userGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete user group")
+	}
+	return userGroupSchema, nil
+}
+
+type UpdateUserGroupUsersSchema struct {
+	schemas.UserGroupUsersSchema
+	GetUserGroupSchema
+}
+
+func (c *userGroupController) AddUsers(ctx *gin.Context, schema *UpdateUserGroupUsersSchema) (*schemas.UserGroupSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userGroup, err := schema.GetUserGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	userIds, err := c.listUserIdsByNames(ctx, schema.Usernames)
+	if err != nil {
+		return nil, err
+	}
+	err = services.UserGroupService.AddUsers(ctx, userGroup, user.ID, userIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "add user group users")
+	}
+	for _, username := range schema.Usernames {
+		createEvent(ctx, services.CreateEventOption{
+			Name:           username,
+			OrganizationId: &org.ID,
+			ResourceType:   models.ResourceTypeUserGroup,
+			ResourceId:     userGroup.ID,
+			OperationName:  "added user",
+		}, nil)
+	}
+	return transformersv1.ToUserGroupSchema(ctx, userGroup)
+}
+
+func (c *userGroupController) RemoveUsers(ctx *gin.Context, schema *UpdateUserGroupUsersSchema) (*schemas.UserGroupSchema, error) {
+	userGroup, err := schema.GetUserGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	userIds, err := c.listUserIdsByNames(ctx, schema.Usernames)
+	if err != nil {
+		return nil, err
+	}
+	err = services.UserGroupService.RemoveUsers(ctx, userGroup, userIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "remove user group users")
+	}
+	for _, username := range schema.Usernames {
+		createEvent(ctx, services.CreateEventOption{
+			Name:           username,
+			OrganizationId: &org.ID,
+			ResourceType:   models.ResourceTypeUserGroup,
+			ResourceId:     userGroup.ID,
+			OperationName:  "removed user",
+		}, nil)
+	}
+	return transformersv1.ToUserGroupSchema(ctx, userGroup)
+}
+
+type ListUserGroupSchema struct {
+	schemasv1.ListQuerySchema
+	GetOrganizationSchema
+}
+
+func (c *userGroupController) List(ctx *gin.Context, schema *ListUserGroupSchema) (*schemas.UserGroupListSchema, error) {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, org); err != nil {
+		return nil, err
+	}
+
+	userGroups, total, err := services.UserGroupService.List(ctx, services.ListUserGroupOption{
+		BaseListOption: services.BaseListOption{
+			Start:  utils.UintPtr(schema.Start),
+			Count:  utils.UintPtr(schema.Count),
+			Search: schema.Search,
+		},
+		OrganizationId: utils.UintPtr(org.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list user groups")
+	}
+
+	userGroupSchemas, err := transformersv1.ToUserGroupSchemas(ctx, userGroups)
+	return &schemas.UserGroupListSchema{
+		BaseListSchema: schemasv1.BaseListSchema{
+			Total: total,
+			Start: schema.Start,
+			Count: schema.Count,
+		},
+		Items: userGroupSchemas,
+	}, err
+}
@@ -1,10 +1,14 @@
 package controllersv1
 
 import (
+	"context"
+
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 
+	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/services"
 )
 
 func writeWsError(conn *websocket.Conn, err error) {
@@ -22,3 +26,50 @@ func writeWsError(conn *websocket.Conn, err error) {
 		logrus.Errorf("ws write error: %q", err_.Error())
 	}
 }
+
+// createEvent records the operation of the current user in the audit log, the event fails if the operation returned an error
+func createEvent(ctx context.Context, opt services.CreateEventOption, err error) {
+	user, err_ := services.GetCurrentUser(ctx)
+	if err_ != nil {
+		logrus.Errorf("create event failed: %v", err_)
+		return
+	}
+	opt.CreatorId = user.ID
+	if user.ApiToken != nil {
+		opt.ApiTokenName = user.ApiToken.Name
+	}
+	opt.Status = modelschemas.EventStatusSuccess
+	if err != nil {
+		opt.Status = modelschemas.EventStatusFailed
+	}
+	if _, err_ = services.EventService.Create(ctx, opt); err_ != nil {
+		logrus.Errorf("create event failed: %v", err_)
+	}
+}
+
+type LabelSelectorQuerySchema struct {
+	LabelSelector string `query:"label_selector"`
+}
+
+// BindListOption binds the label_selector query parameter and the label and -label pieces of q,
+// every label piece is a comma separated list of key or key=value which matches the resources with any of them,
+// and every -label piece matches the resources with none of them. The label pieces are ANDed with the label selector.
+func (s *LabelSelectorQuerySchema) BindListOption(opt *services.BaseListByLabelsOption, q schemasv1.Q) error {
+	selector, err := services.ParseLabelSelector(s.LabelSelector)
+	if err != nil {
+		return err
+	}
+	if len(selector) > 0 {
+		opt.LabelSelector = &selector
+	}
+	queryMap := q.ToMap()
+	if v, ok := queryMap["label"]; ok {
+		labelsList := services.ParseQueryLabelsToLabelsList(v.([]string))
+		opt.LabelsList = &labelsList
+	}
+	if v, ok := queryMap["-label"]; ok {
+		labelsList := services.ParseQueryLabelsToLabelsList(v.([]string))
+		opt.LackLabelsList = &labelsList
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package controllersv1
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
+	"github.com/bentoml/yatai/common/consts"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type webhookController struct {
+	organizationController
+}
+
+var WebhookController = webhookController{}
+
+type GetWebhookSchema struct {
+	GetOrganizationSchema
+	WebhookName string `path:"webhookName"`
+}
+
+func (s *GetWebhookSchema) GetWebhook(ctx context.Context) (*models.Webhook, error) {
+	org, err := s.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return services.WebhookService.GetByName(ctx, org.ID, s.WebhookName)
+}
+
+type CreateWebhookSchema struct {
+	schemas.CreateWebhookSchema
+	GetOrganizationSchema
+}
+
+func (c *webhookController) Create(ctx *gin.Context, schema *CreateWebhookSchema) (*schemas.WebhookFullSchema, error) {
+	user, err := services.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	webhook, err := services.WebhookService.Create(ctx, services.CreateWebhookOption{
+		CreatorId:      user.ID,
+		OrganizationId: org.ID,
+		Name:           schema.Name,
+		Description:    schema.Description,
+		Url:            schema.Url,
+		Secret:         schema.Secret,
+		EventTypes:     schema.EventTypes,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create webhook")
+	}
+	createEvent(ctx, services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeWebhook,
+		ResourceId:     webhook.ID,
+		OperationName:  "created",
+	}, nil)
+	return transformersv1.ToWebhookFullSchema(ctx, webhook)
+}
+
+type UpdateWebhookSchema struct {
+	schemas.UpdateWebhookSchema
+	GetWebhookSchema
+}
+
+func (c *webhookController) Update(ctx *gin.Context, schema *UpdateWebhookSchema) (*schemas.WebhookSchema, error) {
+	webhook, err := schema.GetWebhook(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeWebhook,
+		ResourceId:     webhook.ID,
+		OperationName:  "updated",
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	webhook, err = services.WebhookService.Update(ctx, webhook, services.UpdateWebhookOption{
+		Description: schema.Description,
+		Url:         schema.Url,
+		Secret:      schema.Secret,
+		EventTypes:  schema.EventTypes,
+		Disabled:    schema.Disabled,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update webhook")
+	}
+	return transformersv1.ToWebhookSchema(ctx, webhook)
+}
+
+func (c *webhookController) Get(ctx *gin.Context, schema *GetWebhookSchema) (*schemas.WebhookSchema, error) {
+	webhook, err := schema.GetWebhook(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, org); err != nil {
+		return nil, err
+	}
+	return transformersv1.ToWebhookSchema(ctx, webhook)
+}
+
+func (c *webhookController) Delete(ctx *gin.Context, schema *GetWebhookSchema) (*schemas.WebhookSchema, error) {
+	webhook, err := schema.GetWebhook(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	webhookSchema, err := transformersv1.ToWebhookSchema(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeWebhook,
+		ResourceId:     webhook.ID,
+		OperationName:  "deleted",
+		ResourceName:   webhook.Name,
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	_, err = services.WebhookService.Delete(ctx, webhook)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete webhook")
+	}
+	return webhookSchema, nil
+}
+
+type ListWebhookSchema struct {
+	schemasv1.ListQuerySchema
+	GetOrganizationSchema
+}
+
+func (c *webhookController) List(ctx *gin.Context, schema *ListWebhookSchema) (*schemas.WebhookListSchema, error) {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, org); err != nil {
+		return nil, err
+	}
+
+	webhooks, total, err := services.WebhookService.List(ctx, services.ListWebhookOption{
+		BaseListOption: services.BaseListOption{
+			Start:  utils.UintPtr(schema.Start),
+			Count:  utils.UintPtr(schema.Count),
+			Search: schema.Search,
+		},
+		OrganizationId: utils.UintPtr(org.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list webhooks")
+	}
+
+	webhookSchemas, err := transformersv1.ToWebhookSchemas(ctx, webhooks)
+	return &schemas.WebhookListSchema{
+		BaseListSchema: schemasv1.BaseListSchema{
+			Total: total,
+			Start: schema.Start,
+			Count: schema.Count,
+		},
+		Items: webhookSchemas,
+	}, err
+}
+
+type ListWebhookDeliverySchema struct {
+	schemasv1.ListQuerySchema
+	GetWebhookSchema
+}
+
+func (c *webhookController) ListDeliveries(ctx *gin.Context, schema *ListWebhookDeliverySchema) (*schemas.WebhookDeliveryListSchema, error) {
+	webhook, err := schema.GetWebhook(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canView(ctx, org); err != nil {
+		return nil, err
+	}
+
+	deliveries, total, err := services.WebhookDeliveryService.List(ctx, services.ListWebhookDeliveryOption{
+		BaseListOption: services.BaseListOption{
+			Start: utils.UintPtr(schema.Start),
+			Count: utils.UintPtr(schema.Count),
+		},
+		WebhookId: utils.UintPtr(webhook.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list webhook deliveries")
+	}
+
+	deliverySchemas, err := transformersv1.ToWebhookDeliverySchemas(ctx, deliveries)
+	return &schemas.WebhookDeliveryListSchema{
+		BaseListSchema: schemasv1.BaseListSchema{
+			Total: total,
+			Start: schema.Start,
+			Count: schema.Count,
+		},
+		Items: deliverySchemas,
+	}, err
+}
+
+type GetWebhookDeliverySchema struct {
+	GetWebhookSchema
+	DeliveryUid string `path:"deliveryUid"`
+}
+
+func (c *webhookController) Redeliver(ctx *gin.Context, schema *GetWebhookDeliverySchema) (*schemas.WebhookDeliverySchema, error) {
+	webhook, err := schema.GetWebhook(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.canOperate(ctx, org); err != nil {
+		return nil, err
+	}
+	delivery, err := services.WebhookDeliveryService.GetByUid(ctx, schema.DeliveryUid)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookId != webhook.ID {
+		return nil, consts.ErrNotFound
+	}
+	delivery.SetAssociatedWebhookCache(webhook)
+	createEventOpt := services.CreateEventOption{
+		OrganizationId: &org.ID,
+		ResourceType:   models.ResourceTypeWebhook,
+		ResourceId:     webhook.ID,
+		OperationName:  "redelivered",
+		Name:           delivery.Uid,
+	}
+	defer func() { createEvent(ctx, createEventOpt, err) }()
+	delivery, err = services.WebhookDeliveryService.Redeliver(ctx, delivery)
+	if err != nil {
+		return nil, errors.Wrap(err, "redeliver webhook")
+	}
+	return transformersv1.ToWebhookDeliverySchema(ctx, delivery)
+}
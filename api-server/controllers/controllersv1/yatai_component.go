@@ -11,6 +11,7 @@ import (
 	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/api-server/transformers/transformersv1"
 	"github.com/bentoml/yatai/common/utils"
@@ -107,7 +108,10 @@ func (c *yataiComponentController) Register(ctx *gin.Context, schema *RegisterYa
 		manifest = schema.Manifest
 	}
 
+	// heartbeats are not audited, only the installations and the upgrades
+	operationName := ""
 	if isNotFound {
+		operationName = "registered"
 		yataiComponent, err = services.YataiComponentService.Create(ctx_, services.CreateYataiComponentOption{
 			CreatorId:      user.ID,
 			OrganizationId: cluster.OrganizationId,
@@ -126,6 +130,7 @@ func (c *yataiComponentController) Register(ctx *gin.Context, schema *RegisterYa
 			Manifest:          &manifest,
 		}
 		if yataiComponent.Version != schema.Version {
+			operationName = "upgraded"
 			opt.LatestInstalledAt = &now_
 		}
 		yataiComponent, err = services.YataiComponentService.Update(ctx_, yataiComponent, opt)
@@ -135,6 +140,32 @@ func (c *yataiComponentController) Register(ctx *gin.Context, schema *RegisterYa
 		return nil, errors.Wrap(err, "register yataiComponent")
 	}
 
+	// the heartbeat brings the stale or offline yatai component back,
+	// the status is reloaded once if the health check has changed it in the meantime
+	for i := 0; i < 2 && yataiComponent.HealthStatus != models.YataiComponentHealthStatusHealthy; i++ {
+		if i > 0 {
+			yataiComponent, err = services.YataiComponentService.Get(ctx_, yataiComponent.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		yataiComponent, err = services.YataiComponentService.SetHealthStatus(ctx_, yataiComponent, models.YataiComponentHealthStatusHealthy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if operationName != "" {
+		createEvent(ctx_, services.CreateEventOption{
+			Name:           schema.Version,
+			OrganizationId: &cluster.OrganizationId,
+			ClusterId:      &cluster.ID,
+			ResourceType:   modelschemas.ResourceTypeYataiComponent,
+			ResourceId:     yataiComponent.ID,
+			OperationName:  operationName,
+		}, nil)
+	}
+
 	yataiComponentSchema, err := transformersv1.ToYataiComponentSchema(ctx_, yataiComponent)
 	return yataiComponentSchema, err
 }
@@ -176,3 +207,41 @@ func (c *yataiComponentController) List(ctx *gin.Context, schema *GetClusterSche
 
 	return transformersv1.ToYataiComponentSchemas(ctx, yataiComponents)
 }
+
+func (c *yataiComponentController) ListAllHealth(ctx *gin.Context, schema *GetOrganizationSchema) ([]*schemas.YataiComponentHealthSchema, error) {
+	org, err := schema.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = OrganizationController.canView(ctx, org); err != nil {
+		return nil, err
+	}
+
+	yataiComponents, err := services.YataiComponentService.List(ctx, services.ListYataiComponentOption{
+		OrganizationId: &org.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transformersv1.ToYataiComponentHealthSchemas(ctx, yataiComponents)
+}
+
+func (c *yataiComponentController) ListHealth(ctx *gin.Context, schema *GetClusterSchema) ([]*schemas.YataiComponentHealthSchema, error) {
+	cluster, err := schema.GetCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ClusterController.canView(ctx, cluster); err != nil {
+		return nil, err
+	}
+
+	yataiComponents, err := services.YataiComponentService.List(ctx, services.ListYataiComponentOption{
+		ClusterId: &cluster.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transformersv1.ToYataiComponentHealthSchemas(ctx, yataiComponents)
+}
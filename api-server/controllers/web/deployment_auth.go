@@ -0,0 +1,37 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+// VerifyDeploymentAuth answers the auth requests of the ingresses of the deployments,
+// the ingresses pass the requests on 2xx and reject them with 401 and 403, other responses fail the requests with 500
+func VerifyDeploymentAuth(ctx *gin.Context) {
+	deploymentUid := ctx.Param("deploymentUid")
+	apiKeyName, err := services.DeploymentAuthService.VerifyRequest(ctx, deploymentUid, ctx.Request.Header)
+	if err != nil {
+		if utils.IsNotFound(err) {
+			ctx.String(http.StatusForbidden, "deployment not found")
+			return
+		}
+		if errors.Cause(err) == services.ErrDeploymentAuthFailed {
+			ctx.Header("WWW-Authenticate", "Bearer")
+			ctx.String(http.StatusUnauthorized, err.Error())
+			return
+		}
+		logrus.Errorf("verify the auth request of deployment %s: %s", deploymentUid, err.Error())
+		ctx.String(http.StatusInternalServerError, "")
+		return
+	}
+	if apiKeyName != "" {
+		ctx.Header(services.DeploymentAuthApiKeyHeader, apiKeyName)
+	}
+	ctx.Status(http.StatusOK)
+}
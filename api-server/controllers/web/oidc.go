@@ -0,0 +1,87 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/common/scookie"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+// getSafeRedirectUri only allows the relative paths of yatai to avoid open redirects
+func getSafeRedirectUri(redirectUri string) string {
+	if !strings.HasPrefix(redirectUri, "/") || strings.HasPrefix(redirectUri, "//") || strings.HasPrefix(redirectUri, "/\\") {
+		return "/"
+	}
+	return redirectUri
+}
+
+func abortOIDCLogin(ctx *gin.Context, code int, msg string) {
+	ctx.JSON(code, &schemasv1.MsgSchema{Message: msg})
+}
+
+// OIDCLogin redirects the browser to the authorization endpoint of the oidc provider
+func OIDCLogin(ctx *gin.Context) {
+	if !services.OIDCService.IsEnabled() {
+		abortOIDCLogin(ctx, http.StatusNotFound, "oidc login is not enabled")
+		return
+	}
+	state := utils.RandString(32)
+	nonce := utils.RandString(32)
+	err := scookie.SetOIDCAuthRequestToCookie(ctx, state, nonce, getSafeRedirectUri(ctx.Query("redirect")))
+	if err != nil {
+		abortOIDCLogin(ctx, http.StatusInternalServerError, fmt.Sprintf("save oidc auth request: %s", err.Error()))
+		return
+	}
+	authCodeURL, err := services.OIDCService.GetAuthCodeURL(ctx, state, nonce)
+	if err != nil {
+		logrus.Errorf("get oidc auth code url: %s", err.Error())
+		abortOIDCLogin(ctx, http.StatusBadGateway, "the oidc provider is unavailable")
+		return
+	}
+	ctx.Redirect(http.StatusFound, authCodeURL)
+}
+
+// OIDCCallback finishes the oidc login and sets the username to the session cookie, just like the password login
+func OIDCCallback(ctx *gin.Context) {
+	if !services.OIDCService.IsEnabled() {
+		abortOIDCLogin(ctx, http.StatusNotFound, "oidc login is not enabled")
+		return
+	}
+	state, nonce, redirectUri, err := scookie.PopOIDCAuthRequestFromCookie(ctx)
+	if err != nil {
+		abortOIDCLogin(ctx, http.StatusInternalServerError, fmt.Sprintf("get oidc auth request: %s", err.Error()))
+		return
+	}
+	if errMsg := ctx.Query("error"); errMsg != "" {
+		abortOIDCLogin(ctx, http.StatusForbidden, fmt.Sprintf("oidc login failed: %s %s", errMsg, ctx.Query("error_description")))
+		return
+	}
+	if state == "" || ctx.Query("state") != state {
+		abortOIDCLogin(ctx, http.StatusBadRequest, "invalid oidc state, please login again")
+		return
+	}
+	claims, err := services.OIDCService.Exchange(ctx, ctx.Query("code"), nonce)
+	if err != nil {
+		logrus.Errorf("oidc exchange: %s", err.Error())
+		abortOIDCLogin(ctx, http.StatusForbidden, fmt.Sprintf("oidc login failed: %s", err.Error()))
+		return
+	}
+	user, err := services.OIDCService.Login(ctx, claims)
+	if err != nil {
+		abortOIDCLogin(ctx, http.StatusForbidden, fmt.Sprintf("oidc login failed: %s", err.Error()))
+		return
+	}
+	err = scookie.SetUsernameToCookie(ctx, user.Name)
+	if err != nil {
+		abortOIDCLogin(ctx, http.StatusInternalServerError, fmt.Sprintf("set login cookie: %s", err.Error()))
+		return
+	}
+	ctx.Redirect(http.StatusFound, getSafeRedirectUri(redirectUri))
+}
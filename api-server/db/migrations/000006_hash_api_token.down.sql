@@ -0,0 +1,10 @@
+-- The plaintext tokens can not be recovered from their hashes, so every api token has to be regenerated after the downgrade
+ALTER TABLE "api_token" ADD COLUMN "token" VARCHAR(256);
+UPDATE "api_token" SET token = generate_object_id();
+ALTER TABLE "api_token" ALTER COLUMN "token" SET NOT NULL;
+ALTER TABLE "api_token" ADD CONSTRAINT "api_token_token_key" UNIQUE ("token");
+
+DROP INDEX IF EXISTS "idx_apiToken_tokenPrefix";
+ALTER TABLE "api_token" DROP COLUMN "token_prefix";
+ALTER TABLE "api_token" DROP COLUMN "token_salt";
+ALTER TABLE "api_token" DROP COLUMN "token_hash";
@@ -0,0 +1,14 @@
+ALTER TABLE "api_token" ADD COLUMN "token_prefix" VARCHAR(16);
+ALTER TABLE "api_token" ADD COLUMN "token_salt" VARCHAR(64);
+ALTER TABLE "api_token" ADD COLUMN "token_hash" VARCHAR(128);
+
+UPDATE "api_token" SET token_prefix = LEFT(token, 8), token_salt = md5(random()::text || clock_timestamp()::text);
+UPDATE "api_token" SET token_hash = encode(sha256(convert_to(token_salt || token, 'UTF8')), 'hex');
+
+ALTER TABLE "api_token" ALTER COLUMN "token_prefix" SET NOT NULL;
+ALTER TABLE "api_token" ALTER COLUMN "token_salt" SET NOT NULL;
+ALTER TABLE "api_token" ALTER COLUMN "token_hash" SET NOT NULL;
+
+ALTER TABLE "api_token" DROP COLUMN "token";
+
+CREATE INDEX "idx_apiToken_tokenPrefix" ON "api_token" ("token_prefix");
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "uk_userGroupUserRelation_userGroupId_userId";
+DROP INDEX IF EXISTS "idx_userGroupUserRelation_userId";
+DROP INDEX IF EXISTS "idx_orgMember_userGroupId";
+DROP INDEX IF EXISTS "idx_clusterMember_userGroupId";
@@ -0,0 +1,4 @@
+CREATE UNIQUE INDEX "uk_userGroupUserRelation_userGroupId_userId" ON "user_group_user_relation" ("user_group_id", "user_id");
+CREATE INDEX "idx_userGroupUserRelation_userId" ON "user_group_user_relation" ("user_id");
+CREATE INDEX "idx_orgMember_userGroupId" ON "organization_member" ("user_group_id");
+CREATE INDEX "idx_clusterMember_userGroupId" ON "cluster_member" ("user_group_id");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "webhook_delivery";
+DROP TYPE IF EXISTS "webhook_delivery_status";
+DROP TABLE IF EXISTS "webhook";
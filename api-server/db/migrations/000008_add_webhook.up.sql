@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS "webhook" (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(32) UNIQUE NOT NULL DEFAULT generate_object_id(),
+    name VARCHAR(128) NOT NULL,
+    description TEXT,
+    url TEXT NOT NULL,
+    secret VARCHAR(256) NOT NULL,
+    event_types TEXT[],
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    organization_id INTEGER NOT NULL REFERENCES "organization"("id") ON DELETE CASCADE,
+    creator_id INTEGER NOT NULL REFERENCES "user"("id") ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX "uk_webhook_organizationId_name" ON "webhook" ("organization_id", "name");
+
+CREATE TYPE "webhook_delivery_status" AS ENUM ('pending', 'success', 'failed');
+
+CREATE TABLE IF NOT EXISTS "webhook_delivery" (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(32) UNIQUE NOT NULL DEFAULT generate_object_id(),
+    webhook_id INTEGER NOT NULL REFERENCES "webhook"("id") ON DELETE CASCADE,
+    event_type VARCHAR(128) NOT NULL,
+    payload TEXT NOT NULL,
+    status webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status_code INTEGER,
+    response_body TEXT,
+    error TEXT,
+    next_retry_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX "idx_webhookDelivery_webhookId" ON "webhook_delivery" ("webhook_id");
+CREATE INDEX "idx_webhookDelivery_status_nextRetryAt" ON "webhook_delivery" ("status", "next_retry_at");
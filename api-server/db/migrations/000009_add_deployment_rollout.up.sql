@@ -0,0 +1,23 @@
+CREATE TYPE "deployment_rollout_status" AS ENUM ('running', 'succeeded', 'rolled_back', 'failed');
+
+CREATE TABLE IF NOT EXISTS "deployment_rollout" (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(32) UNIQUE NOT NULL DEFAULT generate_object_id(),
+    deployment_id INTEGER NOT NULL REFERENCES "deployment"("id") ON DELETE CASCADE,
+    deployment_revision_id INTEGER NOT NULL REFERENCES "deployment_revision"("id") ON DELETE CASCADE,
+    deployment_target_id INTEGER NOT NULL REFERENCES "deployment_target"("id") ON DELETE CASCADE,
+    status deployment_rollout_status NOT NULL DEFAULT 'running',
+    steps INTEGER[] NOT NULL,
+    step_interval_seconds INTEGER NOT NULL,
+    current_step INTEGER NOT NULL DEFAULT 0,
+    step_started_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    next_step_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    message TEXT,
+    creator_id INTEGER NOT NULL REFERENCES "user"("id") ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX "idx_deploymentRollout_deploymentId" ON "deployment_rollout" ("deployment_id");
+CREATE INDEX "idx_deploymentRollout_status_nextStepAt" ON "deployment_rollout" ("status", "next_step_at");
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS "trg_event_immutable" ON "event";
+DROP FUNCTION IF EXISTS reject_event_update();
+
+DROP INDEX IF EXISTS "idx_event_operation_name";
+DROP INDEX IF EXISTS "idx_event_api_token_name";
+DROP INDEX IF EXISTS "idx_event_creator_id";
+DROP INDEX IF EXISTS "idx_event_organization_id_created_at";
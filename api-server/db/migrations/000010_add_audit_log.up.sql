@@ -0,0 +1,16 @@
+ALTER TYPE "resource_type" ADD VALUE IF NOT EXISTS 'user_group';
+ALTER TYPE "resource_type" ADD VALUE IF NOT EXISTS 'webhook';
+
+CREATE INDEX "idx_event_organization_id_created_at" ON "event" ("organization_id", "created_at");
+CREATE INDEX "idx_event_creator_id" ON "event" ("creator_id");
+CREATE INDEX "idx_event_api_token_name" ON "event" ("api_token_name");
+CREATE INDEX "idx_event_operation_name" ON "event" ("operation_name");
+
+-- events are the audit log, so they can never be changed once written
+CREATE OR REPLACE FUNCTION reject_event_update() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'event is immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_event_immutable" BEFORE UPDATE ON "event" FOR EACH ROW EXECUTE PROCEDURE reject_event_update();
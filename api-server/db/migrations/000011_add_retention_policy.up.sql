@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "retention_policy" (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(32) UNIQUE NOT NULL DEFAULT generate_object_id(),
+    organization_id INTEGER NOT NULL REFERENCES "organization"("id") ON DELETE CASCADE,
+    resource_type resource_type NOT NULL,
+    resource_id INTEGER NOT NULL,
+    keep_last INTEGER DEFAULT NULL,
+    max_age_days INTEGER DEFAULT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    creator_id INTEGER NOT NULL REFERENCES "user"("id") ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX "uk_retentionPolicy_resourceType_resourceId" ON "retention_policy" ("resource_type", "resource_id");
+CREATE INDEX "idx_retentionPolicy_organizationId" ON "retention_policy" ("organization_id");
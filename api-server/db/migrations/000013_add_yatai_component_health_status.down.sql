@@ -0,0 +1,2 @@
+ALTER TABLE "yatai_component" DROP COLUMN IF EXISTS health_status_changed_at;
+ALTER TABLE "yatai_component" DROP COLUMN IF EXISTS health_status;
@@ -0,0 +1,2 @@
+ALTER TABLE "yatai_component" ADD COLUMN IF NOT EXISTS health_status VARCHAR(32) NOT NULL DEFAULT 'healthy';
+ALTER TABLE "yatai_component" ADD COLUMN IF NOT EXISTS health_status_changed_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "label_policy" (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(32) UNIQUE NOT NULL DEFAULT generate_object_id(),
+    organization_id INTEGER NOT NULL REFERENCES "organization"("id") ON DELETE CASCADE,
+    resource_type resource_type NOT NULL,
+    required_keys TEXT[] NOT NULL DEFAULT '{}',
+    creator_id INTEGER NOT NULL REFERENCES "user"("id") ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX "uk_labelPolicy_orgId_resourceType" ON "label_policy" ("organization_id", "resource_type");
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "idx_deployment_search";
+
+DROP INDEX IF EXISTS "idx_model_manifestModule";
+DROP INDEX IF EXISTS "idx_model_search";
+
+DROP INDEX IF EXISTS "idx_bento_manifest";
+DROP INDEX IF EXISTS "idx_bento_search";
@@ -0,0 +1,7 @@
+CREATE INDEX IF NOT EXISTS "idx_bento_search" ON "bento" USING GIN (to_tsvector('simple', coalesce(version, '') || ' ' || coalesce(description, '') || ' ' || coalesce(manifest::text, '')));
+CREATE INDEX IF NOT EXISTS "idx_bento_manifest" ON "bento" USING GIN (manifest);
+
+CREATE INDEX IF NOT EXISTS "idx_model_search" ON "model" USING GIN (to_tsvector('simple', coalesce(version, '') || ' ' || coalesce(description, '') || ' ' || coalesce(manifest::text, '')));
+CREATE INDEX IF NOT EXISTS "idx_model_manifestModule" ON "model" ((manifest->>'module'));
+
+CREATE INDEX IF NOT EXISTS "idx_deployment_search" ON "deployment" USING GIN (to_tsvector('simple', name || ' ' || coalesce(description, '')));
@@ -0,0 +1,4 @@
+ALTER TABLE "terminal_record" DROP COLUMN IF EXISTS truncated;
+ALTER TABLE "terminal_record" DROP COLUMN IF EXISTS duration;
+ALTER TABLE "terminal_record" DROP COLUMN IF EXISTS size;
+ALTER TABLE "terminal_record" DROP COLUMN IF EXISTS object_name;
@@ -0,0 +1,4 @@
+ALTER TABLE "terminal_record" ADD COLUMN IF NOT EXISTS object_name VARCHAR(512) DEFAULT NULL;
+ALTER TABLE "terminal_record" ADD COLUMN IF NOT EXISTS size BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE "terminal_record" ADD COLUMN IF NOT EXISTS duration DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE "terminal_record" ADD COLUMN IF NOT EXISTS truncated BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "deployment_scaling_schedule";
+DROP TYPE IF EXISTS "deployment_scaling_schedule_action";
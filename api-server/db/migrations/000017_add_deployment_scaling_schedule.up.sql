@@ -0,0 +1,24 @@
+CREATE TYPE "deployment_scaling_schedule_action" AS ENUM ('scale', 'scale_to_zero');
+
+CREATE TABLE IF NOT EXISTS "deployment_scaling_schedule" (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(32) UNIQUE NOT NULL DEFAULT generate_object_id(),
+    name VARCHAR(128) NOT NULL,
+    deployment_id INTEGER NOT NULL REFERENCES "deployment"("id") ON DELETE CASCADE,
+    cron VARCHAR(128) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    action deployment_scaling_schedule_action NOT NULL,
+    min_replicas INTEGER DEFAULT NULL,
+    max_replicas INTEGER DEFAULT NULL,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    last_run_message TEXT,
+    creator_id INTEGER NOT NULL REFERENCES "user"("id") ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX "uk_deploymentScalingSchedule_deploymentId_name" ON "deployment_scaling_schedule" ("deployment_id", "name");
+CREATE INDEX "idx_deploymentScalingSchedule_disabled_nextRunAt" ON "deployment_scaling_schedule" ("disabled", "next_run_at");
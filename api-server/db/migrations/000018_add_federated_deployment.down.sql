@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_deployment_federatedDeploymentId";
+ALTER TABLE "deployment" DROP COLUMN IF EXISTS ingress_host;
+ALTER TABLE "deployment" DROP COLUMN IF EXISTS federated_deployment_id;
+DROP TABLE IF EXISTS "federated_deployment";
@@ -0,0 +1,26 @@
+ALTER TYPE "resource_type" ADD VALUE IF NOT EXISTS 'federated_deployment';
+
+CREATE TABLE IF NOT EXISTS "federated_deployment" (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(32) UNIQUE NOT NULL DEFAULT generate_object_id(),
+    name VARCHAR(128) NOT NULL,
+    description TEXT,
+    kube_namespace VARCHAR(128) NOT NULL DEFAULT '',
+    targets JSONB,
+    clusters JSONB,
+    status deployment_status NOT NULL DEFAULT 'non-deployed',
+    status_syncing_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    status_updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    organization_id INTEGER NOT NULL REFERENCES "organization"("id") ON DELETE CASCADE,
+    creator_id INTEGER NOT NULL REFERENCES "user"("id") ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX "uk_federatedDeployment_organizationId_name" ON "federated_deployment" ("organization_id", "name");
+
+ALTER TABLE "deployment" ADD COLUMN IF NOT EXISTS federated_deployment_id INTEGER DEFAULT NULL REFERENCES "federated_deployment"("id") ON DELETE SET NULL;
+ALTER TABLE "deployment" ADD COLUMN IF NOT EXISTS ingress_host VARCHAR(256) NOT NULL DEFAULT '';
+
+CREATE INDEX "idx_deployment_federatedDeploymentId" ON "deployment" ("federated_deployment_id");
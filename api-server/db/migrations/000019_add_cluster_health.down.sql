@@ -0,0 +1,4 @@
+ALTER TABLE "cluster" DROP COLUMN IF EXISTS kube_version;
+ALTER TABLE "cluster" DROP COLUMN IF EXISTS health_probed_at;
+ALTER TABLE "cluster" DROP COLUMN IF EXISTS health_message;
+ALTER TABLE "cluster" DROP COLUMN IF EXISTS health_status;
@@ -0,0 +1,4 @@
+ALTER TABLE "cluster" ADD COLUMN IF NOT EXISTS health_status VARCHAR(32) NOT NULL DEFAULT 'unknown';
+ALTER TABLE "cluster" ADD COLUMN IF NOT EXISTS health_message TEXT NOT NULL DEFAULT '';
+ALTER TABLE "cluster" ADD COLUMN IF NOT EXISTS health_probed_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+ALTER TABLE "cluster" ADD COLUMN IF NOT EXISTS kube_version VARCHAR(128) NOT NULL DEFAULT '';
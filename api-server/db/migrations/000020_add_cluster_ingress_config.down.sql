@@ -0,0 +1 @@
+ALTER TABLE "cluster" DROP COLUMN IF EXISTS ingress_config;
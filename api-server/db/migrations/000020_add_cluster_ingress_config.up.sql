@@ -0,0 +1 @@
+ALTER TABLE "cluster" ADD COLUMN IF NOT EXISTS ingress_config JSONB;
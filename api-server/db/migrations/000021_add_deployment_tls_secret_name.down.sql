@@ -0,0 +1 @@
+ALTER TABLE "deployment" DROP COLUMN IF EXISTS tls_secret_name;
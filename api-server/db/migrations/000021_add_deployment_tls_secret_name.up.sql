@@ -0,0 +1 @@
+ALTER TABLE "deployment" ADD COLUMN IF NOT EXISTS tls_secret_name VARCHAR(253) NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS "api_token_usage";
+
+DROP INDEX IF EXISTS "uk_apiToken_deploymentId_name";
+DROP INDEX IF EXISTS "uk_apiToken_organizationId_userId_name";
+DELETE FROM "api_token" WHERE deployment_id IS NOT NULL;
+CREATE UNIQUE INDEX "uk_apiToken_organizationId_userId_name" ON "api_token" ("organization_id", "user_id", "name");
+
+ALTER TABLE "api_token" DROP COLUMN IF EXISTS deployment_id;
+
+ALTER TABLE "deployment" DROP COLUMN IF EXISTS auth_config;
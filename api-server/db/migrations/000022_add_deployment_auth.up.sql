@@ -0,0 +1,15 @@
+ALTER TABLE "deployment" ADD COLUMN IF NOT EXISTS auth_config JSONB;
+
+ALTER TABLE "api_token" ADD COLUMN IF NOT EXISTS deployment_id INTEGER DEFAULT NULL REFERENCES "deployment"("id") ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS "uk_apiToken_organizationId_userId_name";
+CREATE UNIQUE INDEX "uk_apiToken_organizationId_userId_name" ON "api_token" ("organization_id", "user_id", "name") WHERE deployment_id IS NULL;
+CREATE UNIQUE INDEX "uk_apiToken_deploymentId_name" ON "api_token" ("deployment_id", "name") WHERE deployment_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS "api_token_usage" (
+    api_token_id INTEGER NOT NULL REFERENCES "api_token"("id") ON DELETE CASCADE,
+    day DATE NOT NULL,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    PRIMARY KEY (api_token_id, day)
+);
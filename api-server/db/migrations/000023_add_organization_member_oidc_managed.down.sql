@@ -0,0 +1 @@
+ALTER TABLE "organization_member" DROP COLUMN IF EXISTS oidc_managed;
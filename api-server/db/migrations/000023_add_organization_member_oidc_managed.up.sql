@@ -0,0 +1 @@
+ALTER TABLE "organization_member" ADD COLUMN IF NOT EXISTS oidc_managed BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE "federated_deployment" DROP COLUMN IF EXISTS labels;
@@ -0,0 +1 @@
+ALTER TABLE "federated_deployment" ADD COLUMN IF NOT EXISTS labels JSONB;
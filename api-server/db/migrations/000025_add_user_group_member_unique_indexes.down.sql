@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "uk_orgMember_orgId_userGroupId";
+DROP INDEX IF EXISTS "uk_clusterMember_clusterId_userGroupId";
@@ -0,0 +1,4 @@
+DELETE FROM "organization_member" m USING "organization_member" newer WHERE m.user_group_id IS NOT NULL AND m.organization_id = newer.organization_id AND m.user_group_id = newer.user_group_id AND m.id < newer.id;
+DELETE FROM "cluster_member" m USING "cluster_member" newer WHERE m.user_group_id IS NOT NULL AND m.cluster_id = newer.cluster_id AND m.user_group_id = newer.user_group_id AND m.id < newer.id;
+CREATE UNIQUE INDEX "uk_orgMember_orgId_userGroupId" ON "organization_member" ("organization_id", "user_group_id") WHERE user_group_id IS NOT NULL;
+CREATE UNIQUE INDEX "uk_clusterMember_clusterId_userGroupId" ON "cluster_member" ("cluster_id", "user_group_id") WHERE user_group_id IS NOT NULL;
@@ -0,0 +1,17 @@
+DROP TRIGGER IF EXISTS "trg_event_undeletable" ON "event";
+
+CREATE OR REPLACE FUNCTION reject_event_update() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'event is immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE "event" DROP CONSTRAINT IF EXISTS "event_organization_id_fkey";
+ALTER TABLE "event" DROP CONSTRAINT IF EXISTS "event_cluster_id_fkey";
+ALTER TABLE "event" DROP CONSTRAINT IF EXISTS "event_creator_id_fkey";
+ALTER TABLE "event" ADD CONSTRAINT "event_organization_id_fkey" FOREIGN KEY (organization_id) REFERENCES "organization"("id") ON DELETE CASCADE;
+ALTER TABLE "event" ADD CONSTRAINT "event_cluster_id_fkey" FOREIGN KEY (cluster_id) REFERENCES "cluster"("id") ON DELETE CASCADE;
+ALTER TABLE "event" ADD CONSTRAINT "event_creator_id_fkey" FOREIGN KEY (creator_id) REFERENCES "user"("id") ON DELETE CASCADE;
+
+DELETE FROM "event" WHERE creator_id IS NULL;
+ALTER TABLE "event" ALTER COLUMN creator_id SET NOT NULL;
@@ -0,0 +1,26 @@
+ALTER TABLE "event" ALTER COLUMN creator_id DROP NOT NULL;
+
+ALTER TABLE "event" DROP CONSTRAINT IF EXISTS "event_organization_id_fkey";
+ALTER TABLE "event" DROP CONSTRAINT IF EXISTS "event_cluster_id_fkey";
+ALTER TABLE "event" DROP CONSTRAINT IF EXISTS "event_creator_id_fkey";
+ALTER TABLE "event" ADD CONSTRAINT "event_organization_id_fkey" FOREIGN KEY (organization_id) REFERENCES "organization"("id") ON DELETE SET NULL;
+ALTER TABLE "event" ADD CONSTRAINT "event_cluster_id_fkey" FOREIGN KEY (cluster_id) REFERENCES "cluster"("id") ON DELETE SET NULL;
+ALTER TABLE "event" ADD CONSTRAINT "event_creator_id_fkey" FOREIGN KEY (creator_id) REFERENCES "user"("id") ON DELETE SET NULL;
+
+-- events are the audit log, so they can never be changed or deleted once written,
+-- the only allowed update is the foreign keys being set to null when the organization, cluster or creator is deleted
+CREATE OR REPLACE FUNCTION reject_event_update() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND (NEW.organization_id IS NULL OR NEW.organization_id = OLD.organization_id)
+        AND (NEW.cluster_id IS NULL OR NEW.cluster_id = OLD.cluster_id)
+        AND (NEW.creator_id IS NULL OR NEW.creator_id = OLD.creator_id)
+        AND to_jsonb(NEW) - ARRAY['organization_id', 'cluster_id', 'creator_id'] = to_jsonb(OLD) - ARRAY['organization_id', 'cluster_id', 'creator_id'] THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'event is immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "trg_event_undeletable" ON "event";
+CREATE TRIGGER "trg_event_undeletable" BEFORE DELETE ON "event" FOR EACH ROW EXECUTE PROCEDURE reject_event_update();
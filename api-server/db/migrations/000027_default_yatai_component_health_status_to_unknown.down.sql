@@ -0,0 +1,2 @@
+UPDATE "yatai_component" SET health_status = 'healthy' WHERE health_status = 'unknown';
+ALTER TABLE "yatai_component" ALTER COLUMN health_status SET DEFAULT 'healthy';
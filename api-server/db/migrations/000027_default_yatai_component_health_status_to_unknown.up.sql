@@ -0,0 +1,3 @@
+ALTER TABLE "yatai_component" ALTER COLUMN health_status SET DEFAULT 'unknown';
+-- the components whose health status has never been changed by a health check or a heartbeat got the default status
+UPDATE "yatai_component" SET health_status = 'unknown' WHERE health_status_changed_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE "deployment" DROP COLUMN IF EXISTS "scaled_to_zero_by_schedule";
+ALTER TABLE "deployment_scaling_schedule" DROP COLUMN IF EXISTS "runner_names";
+ALTER TABLE "deployment_scaling_schedule" DROP COLUMN IF EXISTS "scale_api_server";
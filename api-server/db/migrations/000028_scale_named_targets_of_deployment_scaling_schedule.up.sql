@@ -0,0 +1,3 @@
+ALTER TABLE "deployment_scaling_schedule" ADD COLUMN IF NOT EXISTS "scale_api_server" BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE "deployment_scaling_schedule" ADD COLUMN IF NOT EXISTS "runner_names" TEXT[];
+ALTER TABLE "deployment" ADD COLUMN IF NOT EXISTS "scaled_to_zero_by_schedule" BOOLEAN NOT NULL DEFAULT FALSE;
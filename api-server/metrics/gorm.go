@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const gormBeginKey = "metrics:begin"
+
+// GormPlugin observes the latency of every gorm query, the operation is taken from the kind of the callback,
+// so the sql with the bound vars is never rendered for the metrics
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) before(db *gorm.DB) {
+	db.InstanceSet(gormBeginKey, time.Now())
+}
+
+func (GormPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		begin_, ok := db.InstanceGet(gormBeginKey)
+		if !ok {
+			return
+		}
+		begin, ok := begin_.(time.Time)
+		if !ok {
+			return
+		}
+		op := operation
+		if op == "" {
+			// the raw sql and the rows are classified by the leading keyword of the statement, which has no vars bound
+			op = getSQLOperation(db.Statement.SQL.String())
+		}
+		result := DBQueryResultSuccess
+		if db.Error != nil {
+			if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+				result = DBQueryResultNotFound
+			} else {
+				result = DBQueryResultError
+			}
+		}
+		ObserveDBQuery(op, result, begin)
+	}
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register("metrics:before_create", p.before); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("metrics:after_create", p.after("insert")); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("metrics:before_query", p.before); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register("metrics:after_query", p.after("select")); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("metrics:before_update", p.before); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("metrics:after_update", p.after("update")); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("metrics:before_delete", p.before); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register("metrics:after_delete", p.after("delete")); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("metrics:before_row", p.before); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register("metrics:after_row", p.after("")); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register("metrics:before_raw", p.before); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register("metrics:after_raw", p.after(""))
+}
+
+// getSQLOperation returns the lower case leading keyword of the sql such as select, insert, update and delete
+func getSQLOperation(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if idx := strings.IndexAny(sql, " \t\r\n("); idx >= 0 {
+		sql = sql[:idx]
+	}
+	operation := strings.ToLower(sql)
+	switch operation {
+	case "select", "insert", "update", "delete", "with":
+		return operation
+	case "":
+		return "unknown"
+	default:
+		return "other"
+	}
+}
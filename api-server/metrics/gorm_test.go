@@ -0,0 +1,24 @@
+package metrics
+
+import "testing"
+
+func TestGetSQLOperation(t *testing.T) {
+	cases := []struct {
+		sql      string
+		expected string
+	}{
+		{`SELECT * FROM "deployment" WHERE "id" = $1`, "select"},
+		{"  insert into event (name) values ($1)", "insert"},
+		{"UPDATE\n\"cluster\" SET \"name\" = $1", "update"},
+		{`DELETE FROM "api_token"`, "delete"},
+		{"WITH t AS (SELECT 1) SELECT * FROM t", "with"},
+		{"(SELECT 1)", "unknown"},
+		{"VACUUM", "other"},
+		{"", "unknown"},
+	}
+	for _, c := range cases {
+		if operation := getSQLOperation(c.sql); operation != c.expected {
+			t.Fatalf("the operation of %q is %q, expected %q", c.sql, operation, c.expected)
+		}
+	}
+}
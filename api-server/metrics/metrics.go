@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "yatai"
+
+type TransferDirection string
+
+const (
+	TransferDirectionUpload   TransferDirection = "upload"
+	TransferDirectionDownload TransferDirection = "download"
+)
+
+type DBQueryResult string
+
+const (
+	DBQueryResultSuccess  DBQueryResult = "success"
+	DBQueryResultNotFound DBQueryResult = "not_found"
+	DBQueryResultError    DBQueryResult = "error"
+)
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the http requests by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_errors_total",
+		Help:      "Number of the http requests which respond with a 4xx or 5xx status code.",
+	}, []string{"route", "method", "status"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Latency of the database queries by operation and result, the count of the histogram is the number of the queries.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "result"})
+
+	WebsocketConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "websocket",
+		Name:      "connections",
+		Help:      "Number of the open websocket connections by endpoint.",
+	}, []string{"endpoint"})
+
+	CronRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of the cron job runs.",
+		Buckets:   []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"job"})
+
+	CronRunFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "run_failures_total",
+		Help:      "Number of the cron job runs which encountered errors.",
+	}, []string{"job"})
+
+	TransferBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "transfer",
+		Name:      "bytes_total",
+		Help:      "Number of the bytes of the bentos and the models proxied through the api server.",
+	}, []string{"resource", "direction"})
+)
+
+var (
+	informerCacheSizesFunc   func() map[string]int
+	informerCacheSizesFuncMu sync.RWMutex
+
+	informerCacheObjectsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "informer", "cache_objects"),
+		"Number of the objects in the caches of the running kubernetes informers by resource.",
+		[]string{"resource"}, nil,
+	)
+)
+
+type informerCacheCollector struct{}
+
+func (informerCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- informerCacheObjectsDesc
+}
+
+func (informerCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	informerCacheSizesFuncMu.RLock()
+	f := informerCacheSizesFunc
+	informerCacheSizesFuncMu.RUnlock()
+	if f == nil {
+		return
+	}
+	for resource, size := range f() {
+		ch <- prometheus.MustNewConstMetric(informerCacheObjectsDesc, prometheus.GaugeValue, float64(size), resource)
+	}
+}
+
+func init() {
+	prometheus.MustRegister(informerCacheCollector{})
+}
+
+// SetInformerCacheSizesFunc sets the function which counts the cached objects of the informers when the metrics are scraped
+func SetInformerCacheSizesFunc(f func() map[string]int) {
+	informerCacheSizesFuncMu.Lock()
+	defer informerCacheSizesFuncMu.Unlock()
+	informerCacheSizesFunc = f
+}
+
+func ObserveHTTPRequest(route, method string, status int, begin time.Time) {
+	status_ := strconv.Itoa(status)
+	HTTPRequestDuration.WithLabelValues(route, method, status_).Observe(time.Since(begin).Seconds())
+	if status >= 400 {
+		HTTPRequestErrors.WithLabelValues(route, method, status_).Inc()
+	}
+}
+
+func ObserveDBQuery(operation string, result DBQueryResult, begin time.Time) {
+	DBQueryDuration.WithLabelValues(operation, string(result)).Observe(time.Since(begin).Seconds())
+}
+
+// TrackWebsocketConnection increases the connections of the endpoint, the returned function should be called when the connection is closed
+func TrackWebsocketConnection(endpoint string) func() {
+	gauge := WebsocketConnections.WithLabelValues(endpoint)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+func ObserveCronRun(job string, begin time.Time, failed bool) {
+	CronRunDuration.WithLabelValues(job).Observe(time.Since(begin).Seconds())
+	if failed {
+		CronRunFailures.WithLabelValues(job).Inc()
+	}
+}
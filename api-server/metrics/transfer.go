@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type countingReader struct {
+	reader  io.Reader
+	counter prometheus.Counter
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.counter.Add(float64(n))
+	return n, err
+}
+
+type countingWriter struct {
+	writer  io.Writer
+	counter prometheus.Counter
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.counter.Add(float64(n))
+	return n, err
+}
+
+// NewUploadReader counts the bytes read from reader as the uploaded bytes of the resource
+func NewUploadReader(resource string, reader io.Reader) io.Reader {
+	return &countingReader{
+		reader:  reader,
+		counter: TransferBytes.WithLabelValues(resource, string(TransferDirectionUpload)),
+	}
+}
+
+// NewDownloadWriter counts the bytes written to writer as the downloaded bytes of the resource
+func NewDownloadWriter(resource string, writer io.Writer) io.Writer {
+	return &countingWriter{
+		writer:  writer,
+		counter: TransferBytes.WithLabelValues(resource, string(TransferDirectionDownload)),
+	}
+}
@@ -6,15 +6,23 @@ import (
 	"github.com/bentoml/yatai-schemas/modelschemas"
 )
 
+// ApiTokenScopeInvokeDeployment is the scope of the api keys of the deployments, they can only invoke the inference endpoints of their deployments
+const ApiTokenScopeInvokeDeployment modelschemas.ApiTokenScope = "invoke_deployment"
+
 type ApiToken struct {
 	ResourceMixin
 	OrganizationAssociate
 	UserAssociate
 	Description string                       `json:"description"`
-	Token       string                       `json:"token"`
+	Token       string                       `json:"-" gorm:"-"`
+	TokenPrefix string                       `json:"token_prefix"`
+	TokenSalt   string                       `json:"-"`
+	TokenHash   string                       `json:"-"`
 	Scopes      *modelschemas.ApiTokenScopes `json:"scopes"`
 	ExpiredAt   *time.Time                   `json:"expired_at"`
 	LastUsedAt  *time.Time                   `json:"last_used_at"`
+	// the api token is an api key of the deployment if it is set
+	DeploymentId *uint `json:"deployment_id"`
 }
 
 func (a *ApiToken) GetResourceType() modelschemas.ResourceType {
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// ApiTokenUsage is the number of the requests of an api key per day
+type ApiTokenUsage struct {
+	ApiTokenId   uint       `json:"api_token_id" gorm:"primaryKey"`
+	Day          time.Time  `json:"day" gorm:"primaryKey"`
+	RequestCount uint64     `json:"request_count"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+}
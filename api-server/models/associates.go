@@ -34,6 +34,24 @@ func (a *CreatorAssociate) SetAssociatedCreatorCache(user *User) {
 	a.AssociatedCreatorCache = user
 }
 
+// NullableCreatorAssociate is the creator of the records which outlive their creators, e.g. the audit log events
+type NullableCreatorAssociate struct {
+	CreatorId              *uint `json:"creator_id"`
+	AssociatedCreatorCache *User `gorm:"foreignkey:CreatorId"`
+}
+
+func (a *NullableCreatorAssociate) GetAssociatedCreatorId() *uint {
+	return a.CreatorId
+}
+
+func (a *NullableCreatorAssociate) GetAssociatedCreatorCache() *User {
+	return a.AssociatedCreatorCache
+}
+
+func (a *NullableCreatorAssociate) SetAssociatedCreatorCache(user *User) {
+	a.AssociatedCreatorCache = user
+}
+
 type UserGroupAssociate struct {
 	UserGroupId              uint       `json:"user_group_id"`
 	AssociatedUserGroupCache *UserGroup `gorm:"foreignkey:UserGroupId"`
@@ -204,6 +222,23 @@ func (a *DeploymentRevisionAssociate) SetAssociatedDeploymentRevisionCache(deplo
 	a.AssociatedDeploymentRevisionCache = deploymentRevision
 }
 
+type DeploymentTargetAssociate struct {
+	DeploymentTargetId              uint              `json:"deployment_target_id"`
+	AssociatedDeploymentTargetCache *DeploymentTarget `gorm:"foreignkey:DeploymentTargetId"`
+}
+
+func (a *DeploymentTargetAssociate) GetAssociatedDeploymentTargetId() uint {
+	return a.DeploymentTargetId
+}
+
+func (a *DeploymentTargetAssociate) GetAssociatedDeploymentTargetCache() *DeploymentTarget {
+	return a.AssociatedDeploymentTargetCache
+}
+
+func (a *DeploymentTargetAssociate) SetAssociatedDeploymentTargetCache(deploymentTarget *DeploymentTarget) {
+	a.AssociatedDeploymentTargetCache = deploymentTarget
+}
+
 type ModelRepositoryAssociate struct {
 	ModelRepositoryId              uint             `json:"model_repository_id"`
 	AssociatedModelRepositoryCache *ModelRepository `gorm:"foreignkey:ModelRepositoryId"`
@@ -237,3 +272,20 @@ func (a *ModelAssociate) GetAssociatedModelCache() *Model {
 func (a *ModelAssociate) SetAssociatedModelCache(model *Model) {
 	a.AssociatedModelCache = model
 }
+
+type WebhookAssociate struct {
+	WebhookId              uint     `json:"webhook_id"`
+	AssociatedWebhookCache *Webhook `gorm:"foreignkey:WebhookId"`
+}
+
+func (a *WebhookAssociate) GetAssociatedWebhookId() uint {
+	return a.WebhookId
+}
+
+func (a *WebhookAssociate) GetAssociatedWebhookCache() *Webhook {
+	return a.AssociatedWebhookCache
+}
+
+func (a *WebhookAssociate) SetAssociatedWebhookCache(webhook *Webhook) {
+	a.AssociatedWebhookCache = webhook
+}
@@ -1,17 +1,106 @@
 package models
 
-import "github.com/bentoml/yatai-schemas/modelschemas"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/common/envelope"
+)
+
+type ClusterHealthStatus string
+
+const (
+	ClusterHealthStatusUnknown     ClusterHealthStatus = "unknown"
+	ClusterHealthStatusHealthy     ClusterHealthStatus = "healthy"
+	ClusterHealthStatusUnreachable ClusterHealthStatus = "unreachable"
+)
+
+type ClusterIngressProvider string
+
+const (
+	ClusterIngressProviderNginx      ClusterIngressProvider = "nginx"
+	ClusterIngressProviderTraefik    ClusterIngressProvider = "traefik"
+	ClusterIngressProviderGatewayAPI ClusterIngressProvider = "gateway-api"
+)
+
+// ClusterIngressConfig selects the ingress provider which routes the traffic of the deployments in the cluster,
+// the canary rules are translated to the resources of the provider
+type ClusterIngressConfig struct {
+	Provider ClusterIngressProvider `json:"provider"`
+	// the ingress class of the nginx and traefik ingresses, the yatai ingress class is used if it is empty
+	IngressClassName string `json:"ingress_class_name,omitempty"`
+	// the traefik entry points of the ingress routes, all the entry points are used if it is empty
+	TraefikEntryPoints []string `json:"traefik_entry_points,omitempty"`
+	// the gateway which the gateway api http routes are attached to
+	GatewayName      string `json:"gateway_name,omitempty"`
+	GatewayNamespace string `json:"gateway_namespace,omitempty"`
+	// the certificates of the deployment hostnames, the deployments are served over plain http if it is nil
+	TLS *ClusterTLSConfig `json:"tls,omitempty"`
+}
+
+type ClusterTLSIssuerKind string
+
+const (
+	ClusterTLSIssuerKindIssuer        ClusterTLSIssuerKind = "Issuer"
+	ClusterTLSIssuerKindClusterIssuer ClusterTLSIssuerKind = "ClusterIssuer"
+)
+
+// ClusterTLSConfig issues the certificates with cert-manager or serves a wildcard certificate of the default hostnames,
+// the cert-manager issuer takes precedence if both are set
+type ClusterTLSConfig struct {
+	CertManagerIssuerName string `json:"cert_manager_issuer_name,omitempty"`
+	// it is ClusterIssuer if it is empty, an Issuer must be in the namespace of the deployments
+	CertManagerIssuerKind ClusterTLSIssuerKind `json:"cert_manager_issuer_kind,omitempty"`
+	// the secret of the wildcard certificate of the default domain suffix, it is copied to the namespaces of the deployments
+	WildcardCertificateSecretName      string `json:"wildcard_certificate_secret_name,omitempty"`
+	WildcardCertificateSecretNamespace string `json:"wildcard_certificate_secret_namespace,omitempty"`
+}
+
+func (c *ClusterIngressConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(value.(string)), c)
+}
+
+func (c *ClusterIngressConfig) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
 
 type Cluster struct {
 	ResourceMixin
 	CreatorAssociate
 	OrganizationAssociate
 
-	Description string                            `json:"description"`
-	KubeConfig  string                            `json:"kube_config"`
-	Config      *modelschemas.ClusterConfigSchema `json:"config"`
+	Description    string                            `json:"description"`
+	KubeConfig     string                            `json:"kube_config"`
+	Config         *modelschemas.ClusterConfigSchema `json:"config"`
+	IngressConfig  *ClusterIngressConfig             `json:"ingress_config" type:"jsonb"`
+	HealthStatus   ClusterHealthStatus               `json:"health_status"`
+	HealthMessage  string                            `json:"health_message"`
+	HealthProbedAt *time.Time                        `json:"health_probed_at"`
+	// the git version of the kubernetes api server, it is updated by the health probes
+	KubeVersion string `json:"kube_version"`
 }
 
 func (c *Cluster) GetResourceType() modelschemas.ResourceType {
 	return modelschemas.ResourceTypeCluster
 }
+
+// AfterFind decrypts the kubeconfig, it is encrypted by the cluster service before it is written
+func (c *Cluster) AfterFind(tx *gorm.DB) error {
+	kubeConfig, err := envelope.DecryptString(tx.Statement.Context, c.KubeConfig)
+	if err != nil {
+		return errors.Wrapf(err, "decrypt the kubeconfig of cluster %s", c.Name)
+	}
+	c.KubeConfig = kubeConfig
+	return nil
+}
@@ -0,0 +1,18 @@
+package models
+
+import "github.com/bentoml/yatai-schemas/modelschemas"
+
+// ClusterUserGroupMember shares the cluster_member table with ClusterMember,
+// the rows of user group members have a NULL user_id
+type ClusterUserGroupMember struct {
+	BaseModel
+	CreatorAssociate
+	UserGroupAssociate
+	ClusterAssociate
+
+	Role modelschemas.MemberRole `json:"role"`
+}
+
+func (m *ClusterUserGroupMember) TableName() string {
+	return "cluster_member"
+}
@@ -1,11 +1,43 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"time"
 
 	"github.com/bentoml/yatai-schemas/modelschemas"
 )
 
+type DeploymentAuthMode string
+
+const (
+	DeploymentAuthModeApiKey DeploymentAuthMode = "api_key"
+	DeploymentAuthModeJWT    DeploymentAuthMode = "jwt"
+)
+
+// DeploymentAuthConfig requires the credentials of the requests at the ingress layer,
+// the ingresses send the auth requests of every request to yatai
+type DeploymentAuthConfig struct {
+	Mode DeploymentAuthMode `json:"mode"`
+	// the jwts are verified with the signing keys of the oidc discovery document of the issuer
+	JWTIssuer   string `json:"jwt_issuer,omitempty"`
+	JWTAudience string `json:"jwt_audience,omitempty"`
+}
+
+func (c *DeploymentAuthConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(value.(string)), c)
+}
+
+func (c *DeploymentAuthConfig) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
 type Deployment struct {
 	ResourceMixin
 	CreatorAssociate
@@ -17,6 +49,15 @@ type Deployment struct {
 	StatusUpdatedAt *time.Time                    `json:"status_updated_at"`
 	KubeDeployToken string                        `json:"kube_deploy_token"`
 	KubeNamespace   string                        `json:"kube_namespace"`
+	// the ingress host overrides the default hostname of the deployment
+	IngressHost string `json:"ingress_host"`
+	// the secret of the certificate of the ingress host in the namespace of the deployment, it overrides the tls config of the cluster
+	TLSSecretName         string `json:"tls_secret_name"`
+	FederatedDeploymentId *uint  `json:"federated_deployment_id"`
+	// the requests are not authenticated if it is nil
+	AuthConfig *DeploymentAuthConfig `json:"auth_config" type:"jsonb"`
+	// the scale actions of the scaling schedules only deploy a terminated deployment again if it is scaled to zero by a schedule
+	ScaledToZeroBySchedule bool `json:"scaled_to_zero_by_schedule"`
 }
 
 func (d *Deployment) GetResourceType() modelschemas.ResourceType {
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type DeploymentRolloutStatus string
+
+const (
+	DeploymentRolloutStatusRunning    DeploymentRolloutStatus = "running"
+	DeploymentRolloutStatusSucceeded  DeploymentRolloutStatus = "succeeded"
+	DeploymentRolloutStatusRolledBack DeploymentRolloutStatus = "rolled_back"
+	DeploymentRolloutStatusFailed     DeploymentRolloutStatus = "failed"
+)
+
+// DeploymentRollout shifts the traffic of a deployment from the stable target to the canary target step by step
+type DeploymentRollout struct {
+	BaseModel
+	CreatorAssociate
+	DeploymentAssociate
+	DeploymentRevisionAssociate
+	DeploymentTargetAssociate
+
+	Status              DeploymentRolloutStatus `json:"status"`
+	Steps               pq.Int64Array           `json:"steps" gorm:"type:integer[]"`
+	StepIntervalSeconds uint                    `json:"step_interval_seconds"`
+	CurrentStep         uint                    `json:"current_step"`
+	StepStartedAt       *time.Time              `json:"step_started_at"`
+	NextStepAt          *time.Time              `json:"next_step_at"`
+	Message             string                  `json:"message"`
+}
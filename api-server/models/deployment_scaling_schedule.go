@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type DeploymentScalingScheduleAction string
+
+const (
+	DeploymentScalingScheduleActionScale       DeploymentScalingScheduleAction = "scale"
+	DeploymentScalingScheduleActionScaleToZero DeploymentScalingScheduleAction = "scale_to_zero"
+)
+
+// DeploymentScalingSchedule changes the replicas of a deployment at the times matched by its cron expression
+type DeploymentScalingSchedule struct {
+	BaseModel
+	CreatorAssociate
+	DeploymentAssociate
+
+	Name           string                          `json:"name"`
+	Cron           string                          `json:"cron"`
+	Timezone       string                          `json:"timezone"`
+	Action         DeploymentScalingScheduleAction `json:"action"`
+	MinReplicas    *int32                          `json:"min_replicas"`
+	MaxReplicas    *int32                          `json:"max_replicas"`
+	Disabled       bool                            `json:"disabled"`
+	NextRunAt      *time.Time                      `json:"next_run_at"`
+	LastRunAt      *time.Time                      `json:"last_run_at"`
+	LastRunMessage string                          `json:"last_run_message"`
+	// the scale action only overrides the replicas of the api server if ScaleApiServer is true and of the runners in RunnerNames
+	ScaleApiServer bool           `json:"scale_api_server"`
+	RunnerNames    pq.StringArray `json:"runner_names" gorm:"type:text[]"`
+}
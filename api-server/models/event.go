@@ -6,7 +6,8 @@ type Event struct {
 	BaseModel
 	NullableOrganizationAssociate
 	NullableClusterAssociate
-	CreatorAssociate
+	// the organization, cluster and creator are set to null when they are deleted, the events are kept as the audit log
+	NullableCreatorAssociate
 	Name          string
 	Status        modelschemas.EventStatus
 	ResourceType  modelschemas.ResourceType
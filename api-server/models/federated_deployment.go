@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+)
+
+type FederatedDeploymentTarget struct {
+	Type            modelschemas.DeploymentTargetType         `json:"type"`
+	BentoRepository string                                    `json:"bento_repository"`
+	Bento           string                                    `json:"bento"`
+	CanaryRules     *modelschemas.DeploymentTargetCanaryRules `json:"canary_rules,omitempty"`
+	Config          *modelschemas.DeploymentTargetConfig      `json:"config,omitempty"`
+}
+
+type FederatedDeploymentTargets []*FederatedDeploymentTarget
+
+func (t *FederatedDeploymentTargets) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(value.(string)), t)
+}
+
+func (t *FederatedDeploymentTargets) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+// FederatedDeploymentLabels are the labels of every child deployment
+type FederatedDeploymentLabels modelschemas.LabelItemsSchema
+
+func (l *FederatedDeploymentLabels) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(value.(string)), l)
+}
+
+func (l *FederatedDeploymentLabels) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	return json.Marshal(l)
+}
+
+// FederatedDeploymentCluster is a member cluster of a federated deployment,
+// the non-empty fields override the targets of the federated deployment in this cluster
+type FederatedDeploymentCluster struct {
+	ClusterId     uint                                    `json:"cluster_id"`
+	KubeNamespace string                                  `json:"kube_namespace,omitempty"`
+	MinReplicas   *int32                                  `json:"min_replicas,omitempty"`
+	MaxReplicas   *int32                                  `json:"max_replicas,omitempty"`
+	Resources     *modelschemas.DeploymentTargetResources `json:"resources,omitempty"`
+	IngressHost   string                                  `json:"ingress_host,omitempty"`
+}
+
+type FederatedDeploymentClusters []*FederatedDeploymentCluster
+
+func (c *FederatedDeploymentClusters) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(value.(string)), c)
+}
+
+func (c *FederatedDeploymentClusters) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// FederatedDeployment is one logical deployment fanned out to several clusters,
+// every member cluster has a child deployment with the same name
+type FederatedDeployment struct {
+	ResourceMixin
+	OrganizationAssociate
+	CreatorAssociate
+
+	Description     string                        `json:"description"`
+	KubeNamespace   string                        `json:"kube_namespace"`
+	Targets         *FederatedDeploymentTargets   `json:"targets" type:"jsonb"`
+	Clusters        *FederatedDeploymentClusters  `json:"clusters" type:"jsonb"`
+	Labels          *FederatedDeploymentLabels    `json:"labels" type:"jsonb"`
+	Status          modelschemas.DeploymentStatus `json:"status"`
+	StatusSyncingAt *time.Time                    `json:"status_syncing_at"`
+	StatusUpdatedAt *time.Time                    `json:"status_updated_at"`
+}
+
+func (d *FederatedDeployment) GetResourceType() modelschemas.ResourceType {
+	return ResourceTypeFederatedDeployment
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"github.com/lib/pq"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+)
+
+// LabelPolicy lists the label keys which every resource of the resource type in the organization must have
+type LabelPolicy struct {
+	BaseModel
+	CreatorAssociate
+	OrganizationAssociate
+
+	ResourceType modelschemas.ResourceType `json:"resource_type"`
+	RequiredKeys pq.StringArray            `json:"required_keys" gorm:"type:text[]"`
+}
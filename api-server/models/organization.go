@@ -1,6 +1,12 @@
 package models
 
-import "github.com/bentoml/yatai-schemas/modelschemas"
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/common/envelope"
+)
 
 type Organization struct {
 	ResourceMixin
@@ -13,3 +19,36 @@ type Organization struct {
 func (o *Organization) GetResourceType() modelschemas.ResourceType {
 	return modelschemas.ResourceTypeOrganization
 }
+
+// AfterFind decrypts the secrets of the config, they are encrypted by the organization service before they are written
+func (o *Organization) AfterFind(tx *gorm.DB) error {
+	for field, secret := range OrganizationConfigSecrets(o.Config) {
+		plaintext, err := envelope.DecryptString(tx.Statement.Context, *secret)
+		if err != nil {
+			return errors.Wrapf(err, "decrypt the %s of organization %s", field, o.Name)
+		}
+		*secret = plaintext
+	}
+	return nil
+}
+
+// OrganizationConfigSecrets returns the pointers of the secret fields of the config, keyed by their json paths
+func OrganizationConfigSecrets(config *modelschemas.OrganizationConfigSchema) map[string]*string {
+	secrets := make(map[string]*string)
+	if config == nil {
+		return secrets
+	}
+	if config.S3 != nil {
+		secrets["s3.secret_key"] = &config.S3.SecretKey
+	}
+	if config.AWS != nil {
+		secrets["aws.secret_access_key"] = &config.AWS.SecretAccessKey
+		if config.AWS.ECR != nil {
+			secrets["aws.ecr.password"] = &config.AWS.ECR.Password
+		}
+	}
+	if config.DockerRegistry != nil {
+		secrets["docker_registry.password"] = &config.DockerRegistry.Password
+	}
+	return secrets
+}
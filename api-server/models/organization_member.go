@@ -13,4 +13,6 @@ type OrganizationMember struct {
 	OrganizationAssociate
 
 	Role modelschemas.MemberRole `json:"role"`
+	// OidcManaged is set when the membership is granted by the oidc group role mappings
+	OidcManaged bool `json:"oidc_managed"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import "github.com/bentoml/yatai-schemas/modelschemas"
+
+// OrganizationUserGroupMember shares the organization_member table with OrganizationMember,
+// the rows of user group members have a NULL user_id
+type OrganizationUserGroupMember struct {
+	BaseModel
+	CreatorAssociate
+	UserGroupAssociate
+	OrganizationAssociate
+
+	Role modelschemas.MemberRole `json:"role"`
+}
+
+func (m *OrganizationUserGroupMember) TableName() string {
+	return "organization_member"
+}
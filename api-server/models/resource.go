@@ -2,6 +2,13 @@ package models
 
 import "github.com/bentoml/yatai-schemas/modelschemas"
 
+// the resource types of yatai which are not defined in yatai-schemas
+const (
+	ResourceTypeUserGroup           modelschemas.ResourceType = "user_group"
+	ResourceTypeWebhook             modelschemas.ResourceType = "webhook"
+	ResourceTypeFederatedDeployment modelschemas.ResourceType = "federated_deployment"
+)
+
 type IResource interface {
 	IBaseModel
 	GetResourceType() modelschemas.ResourceType
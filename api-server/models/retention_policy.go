@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+)
+
+// RetentionPolicy decides which versions of a bento repository or a model repository can be garbage collected,
+// a version is only collected when it breaks every rule that is set.
+// The retention policy of an organization decides which of its terminal records can be collected.
+type RetentionPolicy struct {
+	BaseModel
+	CreatorAssociate
+	OrganizationAssociate
+
+	ResourceType modelschemas.ResourceType `json:"resource_type"`
+	ResourceId   uint                      `json:"resource_id"`
+
+	KeepLast   *uint      `json:"keep_last"`
+	MaxAgeDays *uint      `json:"max_age_days"`
+	LastRunAt  *time.Time `json:"last_run_at"`
+}
@@ -21,8 +21,15 @@ type TerminalRecord struct {
 	PodName       string `json:"pod_name"`
 	ContainerName string `json:"container_name"`
 
-	Meta    *modelschemas.TerminalRecordMeta `json:"meta"`
-	Content pq.StringArray                   `gorm:"type:text[]"`
+	Meta *modelschemas.TerminalRecordMeta `json:"meta"`
+	// Content holds the events while recording, it is only persisted when the organization has no object storage
+	Content pq.StringArray `gorm:"type:text[]"`
+	// ObjectName is the asciicast v2 file of the record in the object storage of the organization
+	ObjectName *string `json:"object_name"`
+	Size       int64   `json:"size"`
+	Duration   float64 `json:"duration"`
+	// Truncated is true when the recording stopped at the size limit before the session ended
+	Truncated bool `json:"truncated"`
 
 	Mu sync.Mutex `gorm:"-" json:"-"`
 }
@@ -16,6 +16,8 @@ type User struct {
 	Password        string                `json:"password"`
 	IsEmailVerified bool                  `json:"is_email_verified"`
 	Config          *UserConfig           `json:"config"`
+	OidcIssuer      *string               `json:"oidc_issuer"`
+	OidcSubject     *string               `json:"oidc_subject"`
 
 	ApiToken *ApiToken `gorm:"-" json:"-"`
 }
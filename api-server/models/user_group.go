@@ -1,7 +1,13 @@
 package models
 
+import "github.com/bentoml/yatai-schemas/modelschemas"
+
 type UserGroup struct {
 	ResourceMixin
 	OrganizationAssociate
 	CreatorAssociate
 }
+
+func (g *UserGroup) GetResourceType() modelschemas.ResourceType {
+	return ResourceTypeUserGroup
+}
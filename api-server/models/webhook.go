@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/lib/pq"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+)
+
+type Webhook struct {
+	ResourceMixin
+	OrganizationAssociate
+	CreatorAssociate
+	Description string         `json:"description"`
+	Url         string         `json:"url"`
+	Secret      string         `json:"-"`
+	EventTypes  pq.StringArray `json:"event_types" gorm:"type:text[]"`
+	Disabled    bool           `json:"disabled"`
+}
+
+func (w *Webhook) GetResourceType() modelschemas.ResourceType {
+	return ResourceTypeWebhook
+}
+
+// Subscribes returns true if the webhook should be notified of the event type, an empty filter subscribes all event types
+func (w *Webhook) Subscribes(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, eventType_ := range w.EventTypes {
+		if eventType_ == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSuccess WebhookDeliveryStatus = "success"
+	WebhookDeliveryStatusFailed  WebhookDeliveryStatus = "failed"
+)
+
+type WebhookDelivery struct {
+	BaseModel
+	WebhookAssociate
+	EventType          string                `json:"event_type"`
+	Payload            string                `json:"payload"`
+	Status             WebhookDeliveryStatus `json:"status"`
+	Attempts           uint                  `json:"attempts"`
+	ResponseStatusCode *int                  `json:"response_status_code"`
+	ResponseBody       string                `json:"response_body"`
+	Error              string                `json:"error"`
+	NextRetryAt        *time.Time            `json:"next_retry_at"`
+	DeliveredAt        *time.Time            `json:"delivered_at"`
+}
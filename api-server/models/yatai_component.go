@@ -6,18 +6,30 @@ import (
 	"github.com/bentoml/yatai-schemas/modelschemas"
 )
 
+type YataiComponentHealthStatus string
+
+const (
+	YataiComponentHealthStatusHealthy YataiComponentHealthStatus = "healthy"
+	YataiComponentHealthStatusStale   YataiComponentHealthStatus = "stale"
+	YataiComponentHealthStatusOffline YataiComponentHealthStatus = "offline"
+	// the health status of the yatai components which have never been checked
+	YataiComponentHealthStatusUnknown YataiComponentHealthStatus = "unknown"
+)
+
 type YataiComponent struct {
 	ResourceMixin
 	CreatorAssociate
 	ClusterAssociate
 	OrganizationAssociate
 
-	Version           string                                     `json:"version"`
-	KubeNamespace     string                                     `json:"kube_namespace"`
-	Description       string                                     `json:"description"`
-	Manifest          *modelschemas.YataiComponentManifestSchema `json:"manifest" type:"jsonb"`
-	LatestInstalledAt *time.Time                                 `json:"latest_installed_at"`
-	LatestHeartbeatAt *time.Time                                 `json:"latest_heartbeat_at"`
+	Version               string                                     `json:"version"`
+	KubeNamespace         string                                     `json:"kube_namespace"`
+	Description           string                                     `json:"description"`
+	Manifest              *modelschemas.YataiComponentManifestSchema `json:"manifest" type:"jsonb"`
+	LatestInstalledAt     *time.Time                                 `json:"latest_installed_at"`
+	LatestHeartbeatAt     *time.Time                                 `json:"latest_heartbeat_at"`
+	HealthStatus          YataiComponentHealthStatus                 `json:"health_status"`
+	HealthStatusChangedAt *time.Time                                 `json:"health_status_changed_at"`
 }
 
 func (d *YataiComponent) GetResourceType() modelschemas.ResourceType {
@@ -14,6 +14,7 @@ import (
 	"github.com/huandu/xstrings"
 	"github.com/loopfz/gadgeto/tonic"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/wI2L/fizz"
 	"github.com/wI2L/fizz/openapi"
 
@@ -22,9 +23,11 @@ import (
 	"github.com/bentoml/yatai/api-server/config"
 	"github.com/bentoml/yatai/api-server/controllers/controllersv1"
 	"github.com/bentoml/yatai/api-server/controllers/web"
+	"github.com/bentoml/yatai/api-server/metrics"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/api-server/services"
 	"github.com/bentoml/yatai/common/scookie"
+	"github.com/bentoml/yatai/common/tracing"
 	"github.com/bentoml/yatai/common/utils"
 	"github.com/bentoml/yatai/common/yataicontext"
 )
@@ -65,6 +68,27 @@ func injectCurrentOrganization(c *gin.Context) {
 	c.Next()
 }
 
+func observeRequest(c *gin.Context) {
+	begin := time.Now()
+	c.Next()
+	// the websocket requests last as long as the connections, they are reported by the websocket connection gauges
+	if _, exists := c.Get(WebsocketConnectContextKey); exists {
+		return
+	}
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.ObserveHTTPRequest(route, c.Request.Method, c.Writer.Status(), begin)
+}
+
+// NewMetricsRouter serves the prometheus metrics, it is listened on its own port so the metrics are never exposed with the api
+func NewMetricsRouter() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux
+}
+
 func NewRouter() (*fizz.Fizz, error) {
 	tonic.SetRenderHook(func(c *gin.Context, statusCode int, payload interface{}) {
 		if _, exists := c.Get(WebsocketConnectContextKey); exists {
@@ -99,10 +123,16 @@ func NewRouter() (*fizz.Fizz, error) {
 			MaxAge: int(time.Hour * 24 * 30),
 		})
 	}
+	engine.Use(tracing.GinMiddleware)
+	engine.Use(observeRequest)
 	engine.Use(injectCurrentOrganization)
 	engine.Use(sessions.Sessions("yatai-session-v2", store))
 
 	engine.GET("/logout", web.Logout)
+	engine.GET("/oidc/login", web.OIDCLogin)
+	engine.GET("/oidc/callback", web.OIDCCallback)
+	// the ingress controllers send the auth requests with the methods of the original requests
+	engine.Any("/api/v1/deployment_auth/:deploymentUid/verify", web.VerifyDeploymentAuth)
 
 	fizzApp := fizz.NewFromEngine(engine)
 
@@ -195,8 +225,12 @@ func NewRouter() (*fizz.Fizz, error) {
 	userRoutes(apiRootGroup)
 	organizationRoutes(apiRootGroup)
 	apiTokenRoutes(apiRootGroup)
+	userGroupRoutes(apiRootGroup)
+	webhookRoutes(apiRootGroup)
 	labelRoutes(apiRootGroup)
+	labelPolicyRoutes(apiRootGroup)
 	clusterRoutes(apiRootGroup)
+	federatedDeploymentRoutes(apiRootGroup)
 	bentoRepositoryRoutes(apiRootGroup)
 	modelRepositoryRoutes(apiRootGroup)
 	terminalRecordRoutes(apiRootGroup)
@@ -231,6 +265,11 @@ func NewRouter() (*fizz.Fizz, error) {
 		fizz.Summary("List all models"),
 	}, tonic.Handler(controllersv1.ModelController.ListAll, 200))
 
+	apiRootGroup.GET("/search", []fizz.OperationOption{
+		fizz.ID("Search bentos, models and deployments"),
+		fizz.Summary("Search bentos, models and deployments"),
+	}, tonic.Handler(controllersv1.SearchController.Search, 200))
+
 	publicApiRootGroup.POST("/setup", []fizz.OperationOption{
 		fizz.ID("Setup admin user, org, cluster for selfhosted mode"),
 		fizz.Summary("Setup admin user, org, cluster for selfhosted mode"),
@@ -282,6 +321,11 @@ func getLoginUser(ctx *gin.Context) (user *models.User, err error) {
 			err = errors.New("the api token is expired")
 			return
 		}
+		// the api keys of the deployments are only accepted by the auth requests of their ingresses
+		if apiToken.DeploymentId != nil {
+			err = errors.New("the api key of a deployment can not access the yatai api")
+			return
+		}
 		user, err = services.UserService.GetAssociatedUser(ctx, apiToken)
 		if err != nil {
 			err = errors.Wrap(err, "get user by api token")
@@ -424,6 +468,36 @@ func organizationRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("List current organization event operation names"),
 	}, tonic.Handler(controllersv1.OrganizationController.ListEventOperationNames, 200))
 
+	resourceGrp.GET("/audit_logs", []fizz.OperationOption{
+		fizz.ID("List current organization audit logs"),
+		fizz.Summary("List current organization audit logs"),
+	}, tonic.Handler(controllersv1.AuditLogController.List, 200))
+
+	resourceGrp.GET("/audit_logs/export", []fizz.OperationOption{
+		fizz.ID("Export current organization audit logs"),
+		fizz.Summary("Export current organization audit logs"),
+	}, tonic.Handler(controllersv1.AuditLogController.Export, 200))
+
+	resourceGrp.POST("/retention/run", []fizz.OperationOption{
+		fizz.ID("Run current organization retention policies"),
+		fizz.Summary("Run current organization retention policies"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.Run, 200))
+
+	resourceGrp.GET("/terminal_record_retention_policy", []fizz.OperationOption{
+		fizz.ID("Get current organization terminal record retention policy"),
+		fizz.Summary("Get current organization terminal record retention policy"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.GetTerminalRecordRetentionPolicy, 200))
+
+	resourceGrp.PUT("/terminal_record_retention_policy", []fizz.OperationOption{
+		fizz.ID("Update current organization terminal record retention policy"),
+		fizz.Summary("Update current organization terminal record retention policy"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.UpdateTerminalRecordRetentionPolicy, 200))
+
+	resourceGrp.DELETE("/terminal_record_retention_policy", []fizz.OperationOption{
+		fizz.ID("Delete current organization terminal record retention policy"),
+		fizz.Summary("Delete current organization terminal record retention policy"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.DeleteTerminalRecordRetentionPolicy, 200))
+
 	resourceGrp.PATCH("", []fizz.OperationOption{
 		fizz.ID("Update an organization"),
 		fizz.Summary("Update an organization"),
@@ -434,6 +508,17 @@ func organizationRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("List organization all yatai components"),
 	}, tonic.Handler(controllersv1.YataiComponentController.ListAll, 200))
 
+	grp.GET("/yatai_components/health", []fizz.OperationOption{
+		fizz.ID("List organization all yatai components health"),
+		fizz.Summary("List organization all yatai components health"),
+	}, tonic.Handler(controllersv1.YataiComponentController.ListAllHealth, 200))
+
+	// the clusters have no static sub routes beside /clusters/:clusterName, so the list of the health is routed here
+	grp.GET("/clusters_health", []fizz.OperationOption{
+		fizz.ID("List organization clusters health"),
+		fizz.Summary("List organization clusters health"),
+	}, tonic.Handler(controllersv1.ClusterController.ListHealth, 200))
+
 	grp.GET("/members", []fizz.OperationOption{
 		fizz.ID("List organization members"),
 		fizz.Summary("Get organization members"),
@@ -449,6 +534,21 @@ func organizationRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("Remove an organization member"),
 	}, tonic.Handler(controllersv1.OrganizationMemberController.Delete, 200))
 
+	grp.GET("/user_group_members", []fizz.OperationOption{
+		fizz.ID("List organization user group members"),
+		fizz.Summary("List organization user group members"),
+	}, tonic.Handler(controllersv1.OrganizationUserGroupMemberController.List, 200))
+
+	grp.POST("/user_group_members", []fizz.OperationOption{
+		fizz.ID("Create organization user group members"),
+		fizz.Summary("Create organization user group members"),
+	}, tonic.Handler(controllersv1.OrganizationUserGroupMemberController.Create, 200))
+
+	grp.DELETE("/user_group_members", []fizz.OperationOption{
+		fizz.ID("Remove an organization user group member"),
+		fizz.Summary("Remove an organization user group member"),
+	}, tonic.Handler(controllersv1.OrganizationUserGroupMemberController.Delete, 200))
+
 	grp.GET("/deployments", []fizz.OperationOption{
 		fizz.ID("List organization deployments"),
 		fizz.Summary("List organization deployments"),
@@ -489,6 +589,11 @@ func apiTokenRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("Delete a api token"),
 	}, tonic.Handler(controllersv1.ApiTokenController.Delete, 200))
 
+	resourceGrp.POST("/regenerate", []fizz.OperationOption{
+		fizz.ID("Regenerate a api token"),
+		fizz.Summary("Regenerate a api token"),
+	}, tonic.Handler(controllersv1.ApiTokenController.Regenerate, 200))
+
 	grp.GET("", []fizz.OperationOption{
 		fizz.ID("List api tokens"),
 		fizz.Summary("List api tokens"),
@@ -500,12 +605,159 @@ func apiTokenRoutes(grp *fizz.RouterGroup) {
 	}, tonic.Handler(controllersv1.ApiTokenController.Create, 200))
 }
 
+func userGroupRoutes(grp *fizz.RouterGroup) {
+	grp = grp.Group("/user_groups", "user groups", "user groups")
+
+	resourceGrp := grp.Group("/:userGroupName", "user group resource", "user group resource")
+
+	resourceGrp.GET("", []fizz.OperationOption{
+		fizz.ID("Get a user group"),
+		fizz.Summary("Get a user group"),
+	}, tonic.Handler(controllersv1.UserGroupController.Get, 200))
+
+	resourceGrp.PATCH("", []fizz.OperationOption{
+		fizz.ID("Update a user group"),
+		fizz.Summary("Update a user group"),
+	}, tonic.Handler(controllersv1.UserGroupController.Update, 200))
+
+	resourceGrp.DELETE("", []fizz.OperationOption{
+		fizz.ID("Delete a user group"),
+		fizz.Summary("Delete a user group"),
+	}, tonic.Handler(controllersv1.UserGroupController.Delete, 200))
+
+	resourceGrp.POST("/users", []fizz.OperationOption{
+		fizz.ID("Add users to a user group"),
+		fizz.Summary("Add users to a user group"),
+	}, tonic.Handler(controllersv1.UserGroupController.AddUsers, 200))
+
+	resourceGrp.DELETE("/users", []fizz.OperationOption{
+		fizz.ID("Remove users from a user group"),
+		fizz.Summary("Remove users from a user group"),
+	}, tonic.Handler(controllersv1.UserGroupController.RemoveUsers, 200))
+
+	grp.GET("", []fizz.OperationOption{
+		fizz.ID("List user groups"),
+		fizz.Summary("List user groups"),
+	}, tonic.Handler(controllersv1.UserGroupController.List, 200))
+
+	grp.POST("", []fizz.OperationOption{
+		fizz.ID("Create a user group"),
+		fizz.Summary("Create a user group"),
+	}, tonic.Handler(controllersv1.UserGroupController.Create, 200))
+}
+
+func webhookRoutes(grp *fizz.RouterGroup) {
+	grp = grp.Group("/webhooks", "webhooks", "webhooks")
+
+	resourceGrp := grp.Group("/:webhookName", "webhook resource", "webhook resource")
+
+	resourceGrp.GET("", []fizz.OperationOption{
+		fizz.ID("Get a webhook"),
+		fizz.Summary("Get a webhook"),
+	}, tonic.Handler(controllersv1.WebhookController.Get, 200))
+
+	resourceGrp.PATCH("", []fizz.OperationOption{
+		fizz.ID("Update a webhook"),
+		fizz.Summary("Update a webhook"),
+	}, tonic.Handler(controllersv1.WebhookController.Update, 200))
+
+	resourceGrp.DELETE("", []fizz.OperationOption{
+		fizz.ID("Delete a webhook"),
+		fizz.Summary("Delete a webhook"),
+	}, tonic.Handler(controllersv1.WebhookController.Delete, 200))
+
+	resourceGrp.GET("/deliveries", []fizz.OperationOption{
+		fizz.ID("List webhook deliveries"),
+		fizz.Summary("List webhook deliveries"),
+	}, tonic.Handler(controllersv1.WebhookController.ListDeliveries, 200))
+
+	resourceGrp.POST("/deliveries/:deliveryUid/redeliver", []fizz.OperationOption{
+		fizz.ID("Redeliver a webhook delivery"),
+		fizz.Summary("Redeliver a webhook delivery"),
+	}, tonic.Handler(controllersv1.WebhookController.Redeliver, 200))
+
+	grp.GET("", []fizz.OperationOption{
+		fizz.ID("List webhooks"),
+		fizz.Summary("List webhooks"),
+	}, tonic.Handler(controllersv1.WebhookController.List, 200))
+
+	grp.POST("", []fizz.OperationOption{
+		fizz.ID("Create a webhook"),
+		fizz.Summary("Create a webhook"),
+	}, tonic.Handler(controllersv1.WebhookController.Create, 200))
+}
+
+func federatedDeploymentRoutes(grp *fizz.RouterGroup) {
+	grp = grp.Group("/federated_deployments", "federated deployments", "federated deployments")
+
+	resourceGrp := grp.Group("/:federatedDeploymentName", "federated deployment resource", "federated deployment resource")
+
+	resourceGrp.GET("", []fizz.OperationOption{
+		fizz.ID("Get a federated deployment"),
+		fizz.Summary("Get a federated deployment"),
+	}, tonic.Handler(controllersv1.FederatedDeploymentController.Get, 200))
+
+	resourceGrp.PATCH("", []fizz.OperationOption{
+		fizz.ID("Update a federated deployment"),
+		fizz.Summary("Update a federated deployment"),
+	}, tonic.Handler(controllersv1.FederatedDeploymentController.Update, 200))
+
+	resourceGrp.DELETE("", []fizz.OperationOption{
+		fizz.ID("Delete a federated deployment"),
+		fizz.Summary("Delete a federated deployment and its terminated child deployments"),
+	}, tonic.Handler(controllersv1.FederatedDeploymentController.Delete, 200))
+
+	resourceGrp.POST("/apply", []fizz.OperationOption{
+		fizz.ID("Apply a federated deployment"),
+		fizz.Summary("Deploy a federated deployment to all of its clusters"),
+	}, tonic.Handler(controllersv1.FederatedDeploymentController.Apply, 200))
+
+	resourceGrp.POST("/terminate", []fizz.OperationOption{
+		fizz.ID("Terminate a federated deployment"),
+		fizz.Summary("Terminate all the child deployments of a federated deployment"),
+	}, tonic.Handler(controllersv1.FederatedDeploymentController.Terminate, 200))
+
+	grp.GET("", []fizz.OperationOption{
+		fizz.ID("List federated deployments"),
+		fizz.Summary("List federated deployments"),
+	}, tonic.Handler(controllersv1.FederatedDeploymentController.List, 200))
+
+	grp.POST("", []fizz.OperationOption{
+		fizz.ID("Create a federated deployment"),
+		fizz.Summary("Create a federated deployment"),
+	}, tonic.Handler(controllersv1.FederatedDeploymentController.Create, 200))
+}
+
 func labelRoutes(grp *fizz.RouterGroup) {
 	grp = grp.Group("/labels", "labels", "labels")
 	grp.GET("", []fizz.OperationOption{
 		fizz.ID("List Labels"),
 		fizz.Summary("List Labels"),
 	}, tonic.Handler(controllersv1.LabelController.List, 200))
+
+	grp.POST("/bulk", []fizz.OperationOption{
+		fizz.ID("Bulk update labels"),
+		fizz.Summary("Bulk add, remove or replace labels of resources"),
+	}, tonic.Handler(controllersv1.LabelController.BulkUpdate, 200))
+}
+
+func labelPolicyRoutes(grp *fizz.RouterGroup) {
+	grp = grp.Group("/label_policies", "label policies", "label policies")
+
+	grp.GET("", []fizz.OperationOption{
+		fizz.ID("List label policies"),
+		fizz.Summary("List label policies"),
+	}, tonic.Handler(controllersv1.LabelPolicyController.List, 200))
+
+	grp.PUT("/:resourceType", []fizz.OperationOption{
+		fizz.ID("Update a label policy"),
+		fizz.Summary("Update a label policy"),
+	}, tonic.Handler(controllersv1.LabelPolicyController.Update, 200))
+
+	grp.DELETE("/:resourceType", []fizz.OperationOption{
+		fizz.ID("Delete a label policy"),
+		fizz.Summary("Delete a label policy"),
+	}, tonic.Handler(controllersv1.LabelPolicyController.Delete, 200))
 }
 
 func clusterRoutes(grp *fizz.RouterGroup) {
@@ -523,6 +775,21 @@ func clusterRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("Update a cluster"),
 	}, tonic.Handler(controllersv1.ClusterController.Update, 200))
 
+	resourceGrp.GET("/ingress_config", []fizz.OperationOption{
+		fizz.ID("Get a cluster ingress config"),
+		fizz.Summary("Get a cluster ingress config"),
+	}, tonic.Handler(controllersv1.ClusterController.GetIngressConfig, 200))
+
+	resourceGrp.PUT("/ingress_config", []fizz.OperationOption{
+		fizz.ID("Update a cluster ingress config"),
+		fizz.Summary("Update a cluster ingress config"),
+	}, tonic.Handler(controllersv1.ClusterController.UpdateIngressConfig, 200))
+
+	resourceGrp.GET("/health", []fizz.OperationOption{
+		fizz.ID("Get a cluster health"),
+		fizz.Summary("Get a cluster health"),
+	}, tonic.Handler(controllersv1.ClusterController.GetHealth, 200))
+
 	resourceGrp.GET("/members", []fizz.OperationOption{
 		fizz.ID("List cluster members"),
 		fizz.Summary("List cluster members"),
@@ -538,6 +805,21 @@ func clusterRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("Remove a cluster member"),
 	}, tonic.Handler(controllersv1.ClusterMemberController.Delete, 200))
 
+	resourceGrp.GET("/user_group_members", []fizz.OperationOption{
+		fizz.ID("List cluster user group members"),
+		fizz.Summary("List cluster user group members"),
+	}, tonic.Handler(controllersv1.ClusterUserGroupMemberController.List, 200))
+
+	resourceGrp.POST("/user_group_members", []fizz.OperationOption{
+		fizz.ID("Create cluster user group members"),
+		fizz.Summary("Create cluster user group members"),
+	}, tonic.Handler(controllersv1.ClusterUserGroupMemberController.Create, 200))
+
+	resourceGrp.DELETE("/user_group_members", []fizz.OperationOption{
+		fizz.ID("Remove a cluster user group member"),
+		fizz.Summary("Remove a cluster user group member"),
+	}, tonic.Handler(controllersv1.ClusterUserGroupMemberController.Delete, 200))
+
 	grp.GET("", []fizz.OperationOption{
 		fizz.ID("List clusters"),
 		fizz.Summary("List clusters"),
@@ -567,6 +849,21 @@ func bentoRepositoryRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("Update a bento repository"),
 	}, tonic.Handler(controllersv1.BentoRepositoryController.Update, 200))
 
+	resourceGrp.GET("/retention_policy", []fizz.OperationOption{
+		fizz.ID("Get a bento repository retention policy"),
+		fizz.Summary("Get a bento repository retention policy"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.GetBentoRepositoryRetentionPolicy, 200))
+
+	resourceGrp.PUT("/retention_policy", []fizz.OperationOption{
+		fizz.ID("Update a bento repository retention policy"),
+		fizz.Summary("Update a bento repository retention policy"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.UpdateBentoRepositoryRetentionPolicy, 200))
+
+	resourceGrp.DELETE("/retention_policy", []fizz.OperationOption{
+		fizz.ID("Delete a bento repository retention policy"),
+		fizz.Summary("Delete a bento repository retention policy"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.DeleteBentoRepositoryRetentionPolicy, 200))
+
 	resourceGrp.GET("/deployments", []fizz.OperationOption{
 		fizz.ID("List bento repository deployments"),
 		fizz.Summary("List bento repository deployments"),
@@ -674,6 +971,11 @@ func yataiComponentRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("List yatai components"),
 	}, tonic.Handler(controllersv1.YataiComponentController.List, 200))
 
+	grp.GET("/health", []fizz.OperationOption{
+		fizz.ID("List yatai components health"),
+		fizz.Summary("List yatai components health"),
+	}, tonic.Handler(controllersv1.YataiComponentController.ListHealth, 200))
+
 	grp.POST("", []fizz.OperationOption{
 		fizz.ID("Register yatai component"),
 		fizz.Summary("Register yatai component"),
@@ -696,6 +998,21 @@ func deploymentRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("Update a deployment"),
 	}, tonic.Handler(controllersv1.DeploymentController.Update, 200))
 
+	resourceGrp.GET("/domain", []fizz.OperationOption{
+		fizz.ID("Get the domain of a deployment"),
+		fizz.Summary("Get the domain of a deployment"),
+	}, tonic.Handler(controllersv1.DeploymentController.GetDomain, 200))
+
+	resourceGrp.PUT("/domain", []fizz.OperationOption{
+		fizz.ID("Update the domain of a deployment"),
+		fizz.Summary("Update the domain of a deployment"),
+	}, tonic.Handler(controllersv1.DeploymentController.UpdateDomain, 200))
+
+	resourceGrp.POST("/dry_run", []fizz.OperationOption{
+		fizz.ID("Dry run a deployment update"),
+		fizz.Summary("Dry run a deployment update"),
+	}, tonic.Handler(controllersv1.DeploymentController.DryRunUpdate, 200))
+
 	resourceGrp.POST("/sync_status", []fizz.OperationOption{
 		fizz.ID("Sync a deployment status"),
 		fizz.Summary("Sync a deployment status"),
@@ -716,6 +1033,11 @@ func deploymentRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("List deployment terminal records"),
 	}, tonic.Handler(controllersv1.DeploymentController.ListTerminalRecords, 200))
 
+	resourceGrp.GET("/logs/download", []fizz.OperationOption{
+		fizz.ID("Download deployment pod logs"),
+		fizz.Summary("Download deployment pod logs"),
+	}, tonic.Handler(controllersv1.LogController.DownloadDeploymentPodLog, 200))
+
 	grp.GET("", []fizz.OperationOption{
 		fizz.ID("List cluster deployments"),
 		fizz.Summary("List cluster deployments"),
@@ -727,6 +1049,9 @@ func deploymentRoutes(grp *fizz.RouterGroup) {
 	}, tonic.Handler(controllersv1.DeploymentController.Create, 200))
 
 	deploymentRevisionRoutes(resourceGrp)
+	deploymentRolloutRoutes(resourceGrp)
+	deploymentScalingScheduleRoutes(resourceGrp)
+	deploymentAuthRoutes(resourceGrp)
 }
 
 func deploymentRevisionRoutes(grp *fizz.RouterGroup) {
@@ -739,12 +1064,115 @@ func deploymentRevisionRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("Get a deployment revision"),
 	}, tonic.Handler(controllersv1.DeploymentRevisionController.Get, 200))
 
+	resourceGrp.POST("/rollback", []fizz.OperationOption{
+		fizz.ID("Rollback a deployment to a deployment revision"),
+		fizz.Summary("Rollback a deployment to a deployment revision"),
+	}, tonic.Handler(controllersv1.DeploymentRevisionController.Rollback, 200))
+
 	grp.GET("", []fizz.OperationOption{
 		fizz.ID("List deployment revisions"),
 		fizz.Summary("List deployment revisions"),
 	}, tonic.Handler(controllersv1.DeploymentRevisionController.List, 200))
 }
 
+func deploymentRolloutRoutes(grp *fizz.RouterGroup) {
+	grp = grp.Group("/rollouts", "deployment rollouts", "deployment rollouts")
+
+	resourceGrp := grp.Group("/:rolloutUid", "deployment rollout resource", "deployment rollout resource")
+
+	resourceGrp.GET("", []fizz.OperationOption{
+		fizz.ID("Get a deployment rollout"),
+		fizz.Summary("Get a deployment rollout"),
+	}, tonic.Handler(controllersv1.DeploymentRolloutController.Get, 200))
+
+	resourceGrp.POST("/cancel", []fizz.OperationOption{
+		fizz.ID("Cancel a deployment rollout"),
+		fizz.Summary("Cancel a deployment rollout"),
+	}, tonic.Handler(controllersv1.DeploymentRolloutController.Cancel, 200))
+
+	grp.GET("", []fizz.OperationOption{
+		fizz.ID("List deployment rollouts"),
+		fizz.Summary("List deployment rollouts"),
+	}, tonic.Handler(controllersv1.DeploymentRolloutController.List, 200))
+
+	grp.POST("", []fizz.OperationOption{
+		fizz.ID("Create a deployment rollout"),
+		fizz.Summary("Create a deployment rollout"),
+	}, tonic.Handler(controllersv1.DeploymentRolloutController.Create, 200))
+}
+
+func deploymentScalingScheduleRoutes(grp *fizz.RouterGroup) {
+	grp = grp.Group("/scaling_schedules", "deployment scaling schedules", "deployment scaling schedules")
+
+	resourceGrp := grp.Group("/:scheduleUid", "deployment scaling schedule resource", "deployment scaling schedule resource")
+
+	resourceGrp.GET("", []fizz.OperationOption{
+		fizz.ID("Get a deployment scaling schedule"),
+		fizz.Summary("Get a deployment scaling schedule"),
+	}, tonic.Handler(controllersv1.DeploymentScalingScheduleController.Get, 200))
+
+	resourceGrp.PATCH("", []fizz.OperationOption{
+		fizz.ID("Update a deployment scaling schedule"),
+		fizz.Summary("Update a deployment scaling schedule"),
+	}, tonic.Handler(controllersv1.DeploymentScalingScheduleController.Update, 200))
+
+	resourceGrp.DELETE("", []fizz.OperationOption{
+		fizz.ID("Delete a deployment scaling schedule"),
+		fizz.Summary("Delete a deployment scaling schedule"),
+	}, tonic.Handler(controllersv1.DeploymentScalingScheduleController.Delete, 200))
+
+	grp.GET("", []fizz.OperationOption{
+		fizz.ID("List deployment scaling schedules"),
+		fizz.Summary("List deployment scaling schedules"),
+	}, tonic.Handler(controllersv1.DeploymentScalingScheduleController.List, 200))
+
+	grp.POST("", []fizz.OperationOption{
+		fizz.ID("Create a deployment scaling schedule"),
+		fizz.Summary("Create a deployment scaling schedule"),
+	}, tonic.Handler(controllersv1.DeploymentScalingScheduleController.Create, 200))
+}
+
+func deploymentAuthRoutes(grp *fizz.RouterGroup) {
+	grp.GET("/auth", []fizz.OperationOption{
+		fizz.ID("Get the auth config of a deployment"),
+		fizz.Summary("Get the auth config of a deployment"),
+	}, tonic.Handler(controllersv1.DeploymentAuthController.GetConfig, 200))
+
+	grp.PUT("/auth", []fizz.OperationOption{
+		fizz.ID("Update the auth config of a deployment"),
+		fizz.Summary("Update the auth config of a deployment"),
+	}, tonic.Handler(controllersv1.DeploymentAuthController.UpdateConfig, 200))
+
+	grp = grp.Group("/api_keys", "deployment api keys", "deployment api keys")
+
+	resourceGrp := grp.Group("/:apiTokenUid", "deployment api key resource", "deployment api key resource")
+
+	resourceGrp.DELETE("", []fizz.OperationOption{
+		fizz.ID("Delete a deployment api key"),
+		fizz.Summary("Delete a deployment api key"),
+	}, tonic.Handler(controllersv1.DeploymentAuthController.DeleteApiKey, 200))
+
+	resourceGrp.POST("/regenerate", []fizz.OperationOption{
+		fizz.ID("Regenerate a deployment api key"),
+		fizz.Summary("Regenerate a deployment api key"),
+	}, tonic.Handler(controllersv1.DeploymentAuthController.RegenerateApiKey, 200))
+
+	resourceGrp.GET("/usage", []fizz.OperationOption{
+		fizz.ID("List the usage of a deployment api key"),
+		fizz.Summary("List the usage of a deployment api key"),
+	}, tonic.Handler(controllersv1.DeploymentAuthController.ListApiKeyUsage, 200))
+
+	grp.GET("", []fizz.OperationOption{
+		fizz.ID("List deployment api keys"),
+		fizz.Summary("List deployment api keys"),
+	}, tonic.Handler(controllersv1.DeploymentAuthController.ListApiKeys, 200))
+
+	grp.POST("", []fizz.OperationOption{
+		fizz.ID("Create a deployment api key"),
+		fizz.Summary("Create a deployment api key"),
+	}, tonic.Handler(controllersv1.DeploymentAuthController.CreateApiKey, 200))
+}
+
 func terminalRecordRoutes(grp *fizz.RouterGroup) {
 	grp = grp.Group("/terminal_records", "terminal records", "terminal records")
 
@@ -759,6 +1187,11 @@ func terminalRecordRoutes(grp *fizz.RouterGroup) {
 		fizz.ID("Download a terminal record"),
 		fizz.Summary("Download a terminal record"),
 	}, tonic.Handler(controllersv1.TerminalRecordController.Download, 200))
+
+	resourceGrp.GET("/replay", []fizz.OperationOption{
+		fizz.ID("Replay a terminal record"),
+		fizz.Summary("Replay a terminal record"),
+	}, tonic.Handler(controllersv1.TerminalRecordController.Replay, 200))
 }
 
 func modelRepositoryRoutes(grp *fizz.RouterGroup) {
@@ -776,6 +1209,21 @@ func modelRepositoryRoutes(grp *fizz.RouterGroup) {
 		fizz.Summary("Update a model repository"),
 	}, tonic.Handler(controllersv1.ModelRepositoryController.Update, 200))
 
+	resourceGrp.GET("/retention_policy", []fizz.OperationOption{
+		fizz.ID("Get a model repository retention policy"),
+		fizz.Summary("Get a model repository retention policy"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.GetModelRepositoryRetentionPolicy, 200))
+
+	resourceGrp.PUT("/retention_policy", []fizz.OperationOption{
+		fizz.ID("Update a model repository retention policy"),
+		fizz.Summary("Update a model repository retention policy"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.UpdateModelRepositoryRetentionPolicy, 200))
+
+	resourceGrp.DELETE("/retention_policy", []fizz.OperationOption{
+		fizz.ID("Delete a model repository retention policy"),
+		fizz.Summary("Delete a model repository retention policy"),
+	}, tonic.Handler(controllersv1.RetentionPolicyController.DeleteModelRepositoryRetentionPolicy, 200))
+
 	grp.GET("", []fizz.OperationOption{
 		fizz.ID("List model repositories"),
 		fizz.Summary("List model repositories"),
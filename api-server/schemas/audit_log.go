@@ -0,0 +1,46 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+// AuditLogSchema is a flattened event, it is also the record format of the audit log export
+type AuditLogSchema struct {
+	Uid           string                    `json:"uid"`
+	CreatedAt     time.Time                 `json:"created_at"`
+	Actor         string                    `json:"actor"`
+	ActorUid      string                    `json:"actor_uid"`
+	ApiTokenName  string                    `json:"api_token_name"`
+	Cluster       string                    `json:"cluster"`
+	ResourceType  modelschemas.ResourceType `json:"resource_type"`
+	ResourceName  string                    `json:"resource_name"`
+	OperationName string                    `json:"operation_name"`
+	Detail        string                    `json:"detail"`
+	Status        modelschemas.EventStatus  `json:"status"`
+}
+
+type AuditLogListSchema struct {
+	schemasv1.BaseListSchema
+	Items []*AuditLogSchema `json:"items"`
+}
+
+// AuditLogFilterSchema filters the audit logs, the time range is in RFC3339 format
+type AuditLogFilterSchema struct {
+	Actor        *string                    `query:"actor"`
+	ApiTokenName *string                    `query:"api_token_name"`
+	Operation    *string                    `query:"operation"`
+	ResourceType *modelschemas.ResourceType `query:"resource_type"`
+	Status       *modelschemas.EventStatus  `query:"status"`
+	StartedAt    *string                    `query:"started_at"`
+	EndedAt      *string                    `query:"ended_at"`
+}
+
+type AuditLogExportFormat string
+
+const (
+	AuditLogExportFormatNDJSON AuditLogExportFormat = "ndjson"
+	AuditLogExportFormatCSV    AuditLogExportFormat = "csv"
+)
@@ -0,0 +1,15 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type ClusterHealthSchema struct {
+	*schemasv1.ClusterSchema
+	HealthStatus   string     `json:"health_status" enum:"unknown,healthy,unreachable"`
+	HealthMessage  string     `json:"health_message"`
+	HealthProbedAt *time.Time `json:"health_probed_at"`
+	KubeVersion    string     `json:"kube_version"`
+}
@@ -0,0 +1,18 @@
+package schemas
+
+type ClusterIngressConfigSchema struct {
+	Provider           string   `json:"provider" enum:"nginx,traefik,gateway-api"`
+	IngressClassName   string   `json:"ingress_class_name"`
+	TraefikEntryPoints []string `json:"traefik_entry_points"`
+	GatewayName        string   `json:"gateway_name"`
+	GatewayNamespace   string   `json:"gateway_namespace"`
+	// the deployments are served over plain http if it is null
+	TLS *ClusterTLSConfigSchema `json:"tls"`
+}
+
+type ClusterTLSConfigSchema struct {
+	CertManagerIssuerName              string `json:"cert_manager_issuer_name"`
+	CertManagerIssuerKind              string `json:"cert_manager_issuer_kind" enum:"Issuer,ClusterIssuer,"`
+	WildcardCertificateSecretName      string `json:"wildcard_certificate_secret_name"`
+	WildcardCertificateSecretNamespace string `json:"wildcard_certificate_secret_namespace"`
+}
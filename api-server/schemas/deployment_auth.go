@@ -0,0 +1,24 @@
+package schemas
+
+import (
+	"time"
+)
+
+type DeploymentAuthConfigSchema struct {
+	// the requests are not authenticated if it is empty
+	Mode        string `json:"mode" enum:"api_key,jwt,"`
+	JWTIssuer   string `json:"jwt_issuer"`
+	JWTAudience string `json:"jwt_audience"`
+}
+
+type CreateDeploymentApiKeySchema struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	ExpiredAt   *time.Time `json:"expired_at"`
+}
+
+type DeploymentApiKeyUsageSchema struct {
+	Day          string     `json:"day"`
+	RequestCount uint64     `json:"request_count"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+}
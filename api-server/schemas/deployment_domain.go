@@ -0,0 +1,14 @@
+package schemas
+
+type DeploymentDomainSchema struct {
+	// the default hostname is used if it is empty
+	IngressHost string `json:"ingress_host"`
+	// the secret of the certificate of the ingress host in the namespace of the deployment, the tls config of the cluster is used if it is empty
+	TLSSecretName string   `json:"tls_secret_name"`
+	URLs          []string `json:"urls"`
+}
+
+type UpdateDeploymentDomainSchema struct {
+	IngressHost   *string `json:"ingress_host"`
+	TLSSecretName *string `json:"tls_secret_name"`
+}
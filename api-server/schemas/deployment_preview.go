@@ -0,0 +1,24 @@
+package schemas
+
+type KubeObjectFieldDiffSchema struct {
+	Path      string      `json:"path"`
+	Operation string      `json:"operation"`
+	Old       interface{} `json:"old,omitempty"`
+	New       interface{} `json:"new,omitempty"`
+}
+
+type KubeObjectPreviewSchema struct {
+	ApiVersion           string                       `json:"api_version"`
+	Kind                 string                       `json:"kind"`
+	Namespace            string                       `json:"namespace"`
+	Name                 string                       `json:"name"`
+	Object               map[string]interface{}       `json:"object"`
+	ActiveRevisionChange string                       `json:"active_revision_change"`
+	ActiveRevisionDiffs  []*KubeObjectFieldDiffSchema `json:"active_revision_diffs"`
+	LiveChange           string                       `json:"live_change"`
+	LiveDiffs            []*KubeObjectFieldDiffSchema `json:"live_diffs"`
+}
+
+type DeploymentPreviewSchema struct {
+	Objects []*KubeObjectPreviewSchema `json:"objects"`
+}
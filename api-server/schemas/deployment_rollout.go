@@ -0,0 +1,32 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type DeploymentRolloutSchema struct {
+	schemasv1.BaseSchema
+	Creator               *schemasv1.UserSchema `json:"creator"`
+	DeploymentRevisionUid string                `json:"deployment_revision_uid"`
+	DeploymentTargetUid   string                `json:"deployment_target_uid"`
+	Status                string                `json:"status"`
+	Steps                 []uint                `json:"steps"`
+	StepIntervalSeconds   uint                  `json:"step_interval_seconds"`
+	CurrentStep           uint                  `json:"current_step"`
+	CurrentWeight         uint                  `json:"current_weight"`
+	StepStartedAt         *time.Time            `json:"step_started_at"`
+	NextStepAt            *time.Time            `json:"next_step_at"`
+	Message               string                `json:"message"`
+}
+
+type DeploymentRolloutListSchema struct {
+	schemasv1.BaseListSchema
+	Items []*DeploymentRolloutSchema `json:"items"`
+}
+
+type CreateDeploymentRolloutSchema struct {
+	Steps               []uint `json:"steps"`
+	StepIntervalSeconds uint   `json:"step_interval_seconds"`
+}
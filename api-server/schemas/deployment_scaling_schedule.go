@@ -0,0 +1,55 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type DeploymentScalingScheduleSchema struct {
+	schemasv1.BaseSchema
+	Creator        *schemasv1.UserSchema `json:"creator"`
+	Name           string                `json:"name"`
+	Cron           string                `json:"cron"`
+	Timezone       string                `json:"timezone"`
+	Action         string                `json:"action"`
+	MinReplicas    *int32                `json:"min_replicas"`
+	MaxReplicas    *int32                `json:"max_replicas"`
+	Disabled       bool                  `json:"disabled"`
+	NextRunAt      *time.Time            `json:"next_run_at"`
+	LastRunAt      *time.Time            `json:"last_run_at"`
+	LastRunMessage string                `json:"last_run_message"`
+	ScaleApiServer bool                  `json:"scale_api_server"`
+	RunnerNames    []string              `json:"runner_names"`
+}
+
+type DeploymentScalingScheduleListSchema struct {
+	schemasv1.BaseListSchema
+	Items []*DeploymentScalingScheduleSchema `json:"items"`
+}
+
+type CreateDeploymentScalingScheduleSchema struct {
+	Name string `json:"name"`
+	// the standard 5 fields cron expression, such as "0 20 * * 1-5"
+	Cron     string `json:"cron"`
+	Timezone string `json:"timezone"`
+	// scale or scale_to_zero
+	Action      string `json:"action"`
+	MinReplicas *int32 `json:"min_replicas"`
+	MaxReplicas *int32 `json:"max_replicas"`
+	Disabled    bool   `json:"disabled"`
+	// the scale action overrides the replicas of the api server unless it is false, and of the runners named in runner_names
+	ScaleApiServer *bool    `json:"scale_api_server"`
+	RunnerNames    []string `json:"runner_names"`
+}
+
+type UpdateDeploymentScalingScheduleSchema struct {
+	Cron           *string   `json:"cron"`
+	Timezone       *string   `json:"timezone"`
+	Action         *string   `json:"action"`
+	MinReplicas    *int32    `json:"min_replicas"`
+	MaxReplicas    *int32    `json:"max_replicas"`
+	Disabled       *bool     `json:"disabled"`
+	ScaleApiServer *bool     `json:"scale_api_server"`
+	RunnerNames    *[]string `json:"runner_names"`
+}
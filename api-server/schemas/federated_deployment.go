@@ -0,0 +1,72 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type FederatedDeploymentClusterSchema struct {
+	Cluster       *schemasv1.ClusterSchema                `json:"cluster"`
+	KubeNamespace string                                  `json:"kube_namespace"`
+	MinReplicas   *int32                                  `json:"min_replicas"`
+	MaxReplicas   *int32                                  `json:"max_replicas"`
+	Resources     *modelschemas.DeploymentTargetResources `json:"resources"`
+	IngressHost   string                                  `json:"ingress_host"`
+}
+
+type FederatedDeploymentSchema struct {
+	schemasv1.BaseSchema
+	Creator         *schemasv1.UserSchema                     `json:"creator"`
+	Name            string                                    `json:"name"`
+	Description     string                                    `json:"description"`
+	KubeNamespace   string                                    `json:"kube_namespace"`
+	Status          modelschemas.DeploymentStatus             `json:"status"`
+	StatusSyncingAt *time.Time                                `json:"status_syncing_at"`
+	StatusUpdatedAt *time.Time                                `json:"status_updated_at"`
+	Targets         []*schemasv1.CreateDeploymentTargetSchema `json:"targets"`
+	Clusters        []*FederatedDeploymentClusterSchema       `json:"clusters"`
+	Labels          modelschemas.LabelItemsSchema             `json:"labels"`
+}
+
+type FederatedDeploymentFullSchema struct {
+	FederatedDeploymentSchema
+	Deployments []*schemasv1.DeploymentSchema `json:"deployments"`
+}
+
+type FederatedDeploymentListSchema struct {
+	schemasv1.BaseListSchema
+	Items []*FederatedDeploymentSchema `json:"items"`
+}
+
+type CreateFederatedDeploymentClusterSchema struct {
+	ClusterName string `json:"cluster_name"`
+	// the kube namespace of the federated deployment is used if it is empty
+	KubeNamespace string `json:"kube_namespace"`
+	// the replicas and resources override the ones of every target in this cluster
+	MinReplicas *int32                                  `json:"min_replicas"`
+	MaxReplicas *int32                                  `json:"max_replicas"`
+	Resources   *modelschemas.DeploymentTargetResources `json:"resources"`
+	// the default hostname of the cluster is used if it is empty
+	IngressHost string `json:"ingress_host"`
+}
+
+type CreateFederatedDeploymentSchema struct {
+	Name          string                                    `json:"name"`
+	Description   string                                    `json:"description"`
+	KubeNamespace string                                    `json:"kube_namespace"`
+	Targets       []*schemasv1.CreateDeploymentTargetSchema `json:"targets"`
+	Clusters      []*CreateFederatedDeploymentClusterSchema `json:"clusters"`
+	// the labels are set on the child deployment of every cluster
+	Labels      *modelschemas.LabelItemsSchema `json:"labels"`
+	DoNotDeploy bool                           `json:"do_not_deploy"`
+}
+
+type UpdateFederatedDeploymentSchema struct {
+	Description *string                                    `json:"description"`
+	Targets     *[]*schemasv1.CreateDeploymentTargetSchema `json:"targets"`
+	Clusters    *[]*CreateFederatedDeploymentClusterSchema `json:"clusters"`
+	Labels      *modelschemas.LabelItemsSchema             `json:"labels"`
+	DoNotDeploy bool                                       `json:"do_not_deploy"`
+}
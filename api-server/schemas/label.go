@@ -0,0 +1,31 @@
+package schemas
+
+import (
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type BulkUpdateLabelsSchema struct {
+	ResourceType modelschemas.ResourceType `json:"resource_type"`
+	// the resources are selected either by resource_uids or by label_selector
+	ResourceUids  *[]string                     `json:"resource_uids"`
+	LabelSelector *string                       `json:"label_selector"`
+	Operation     string                        `json:"operation" enum:"add,remove,replace"`
+	Labels        modelschemas.LabelItemsSchema `json:"labels"`
+}
+
+type BulkUpdateLabelsResultSchema struct {
+	ResourceType modelschemas.ResourceType `json:"resource_type"`
+	ResourceUids []string                  `json:"resource_uids"`
+}
+
+type LabelPolicySchema struct {
+	schemasv1.BaseSchema
+	ResourceType modelschemas.ResourceType `json:"resource_type"`
+	RequiredKeys []string                  `json:"required_keys"`
+	Creator      *schemasv1.UserSchema     `json:"creator"`
+}
+
+type UpdateLabelPolicySchema struct {
+	RequiredKeys []string `json:"required_keys"`
+}
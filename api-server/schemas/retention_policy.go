@@ -0,0 +1,35 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type RetentionPolicySchema struct {
+	schemasv1.BaseSchema
+	ResourceType modelschemas.ResourceType `json:"resource_type"`
+	KeepLast     *uint                     `json:"keep_last"`
+	MaxAgeDays   *uint                     `json:"max_age_days"`
+	LastRunAt    *time.Time                `json:"last_run_at"`
+	Creator      *schemasv1.UserSchema     `json:"creator"`
+}
+
+type UpdateRetentionPolicySchema struct {
+	KeepLast   *uint `json:"keep_last"`
+	MaxAgeDays *uint `json:"max_age_days"`
+}
+
+type RetentionRunItemSchema struct {
+	ResourceType   modelschemas.ResourceType `json:"resource_type"`
+	RepositoryName string                    `json:"repository_name,omitempty"`
+	Version        string                    `json:"version,omitempty"`
+	Uid            string                    `json:"uid,omitempty"`
+	CreatedAt      time.Time                 `json:"created_at"`
+}
+
+type RetentionRunReportSchema struct {
+	DryRun bool                      `json:"dry_run"`
+	Items  []*RetentionRunItemSchema `json:"items"`
+}
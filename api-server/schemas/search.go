@@ -0,0 +1,18 @@
+package schemas
+
+import (
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type SearchResultSchema struct {
+	Type       string                               `json:"type"`
+	Rank       float64                              `json:"rank"`
+	Bento      *schemasv1.BentoWithRepositorySchema `json:"bento,omitempty"`
+	Model      *schemasv1.ModelWithRepositorySchema `json:"model,omitempty"`
+	Deployment *schemasv1.DeploymentSchema          `json:"deployment,omitempty"`
+}
+
+type SearchResultListSchema struct {
+	schemasv1.BaseListSchema
+	Items []*SearchResultSchema `json:"items"`
+}
@@ -0,0 +1,19 @@
+package schemas
+
+type TerminalRecordReplayFrameType string
+
+const (
+	TerminalRecordReplayFrameTypeHeader TerminalRecordReplayFrameType = "header"
+	TerminalRecordReplayFrameTypeEvent  TerminalRecordReplayFrameType = "event"
+	TerminalRecordReplayFrameTypeEnd    TerminalRecordReplayFrameType = "end"
+)
+
+// TerminalRecordReplayFrameSchema is the payload of a replay websocket message,
+// the header frame carries the asciicast v2 header and every event frame carries an event of the record
+type TerminalRecordReplayFrameSchema struct {
+	Type      TerminalRecordReplayFrameType `json:"type"`
+	Header    map[string]interface{}        `json:"header,omitempty"`
+	Time      float64                       `json:"time"`
+	EventType string                        `json:"event_type,omitempty"`
+	Data      string                        `json:"data,omitempty"`
+}
@@ -0,0 +1,57 @@
+package schemas
+
+import (
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type UserGroupSchema struct {
+	schemasv1.BaseSchema
+	Name         string                        `json:"name"`
+	Creator      *schemasv1.UserSchema         `json:"creator"`
+	Organization *schemasv1.OrganizationSchema `json:"organization"`
+	Users        []*schemasv1.UserSchema       `json:"users"`
+}
+
+type UserGroupListSchema struct {
+	schemasv1.BaseListSchema
+	Items []*UserGroupSchema `json:"items"`
+}
+
+type CreateUserGroupSchema struct {
+	Name      string   `json:"name"`
+	Usernames []string `json:"usernames"`
+}
+
+type UpdateUserGroupSchema struct {
+	Name *string `json:"name"`
+}
+
+type UserGroupUsersSchema struct {
+	Usernames []string `json:"usernames"`
+}
+
+type OrganizationUserGroupMemberSchema struct {
+	schemasv1.BaseSchema
+	Role         modelschemas.MemberRole      `json:"role"`
+	Creator      *schemasv1.UserSchema        `json:"creator"`
+	UserGroup    UserGroupSchema              `json:"user_group"`
+	Organization schemasv1.OrganizationSchema `json:"organization"`
+}
+
+type ClusterUserGroupMemberSchema struct {
+	schemasv1.BaseSchema
+	Role      modelschemas.MemberRole `json:"role"`
+	Creator   *schemasv1.UserSchema   `json:"creator"`
+	UserGroup UserGroupSchema         `json:"user_group"`
+	Cluster   schemasv1.ClusterSchema `json:"cluster"`
+}
+
+type CreateUserGroupMembersSchema struct {
+	UserGroupNames []string                `json:"user_group_names"`
+	Role           modelschemas.MemberRole `json:"role" enum:"guest,developer,admin"`
+}
+
+type DeleteUserGroupMemberSchema struct {
+	UserGroupName string `json:"user_group_name"`
+}
@@ -0,0 +1,62 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type WebhookSchema struct {
+	schemasv1.BaseSchema
+	Name         string                        `json:"name"`
+	Description  string                        `json:"description"`
+	Url          string                        `json:"url"`
+	EventTypes   []string                      `json:"event_types"`
+	Disabled     bool                          `json:"disabled"`
+	Creator      *schemasv1.UserSchema         `json:"creator"`
+	Organization *schemasv1.OrganizationSchema `json:"organization"`
+}
+
+type WebhookFullSchema struct {
+	WebhookSchema
+	Secret string `json:"secret"`
+}
+
+type WebhookListSchema struct {
+	schemasv1.BaseListSchema
+	Items []*WebhookSchema `json:"items"`
+}
+
+type CreateWebhookSchema struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Url         string   `json:"url"`
+	Secret      string   `json:"secret"`
+	EventTypes  []string `json:"event_types"`
+}
+
+type UpdateWebhookSchema struct {
+	Description *string   `json:"description"`
+	Url         *string   `json:"url"`
+	Secret      *string   `json:"secret"`
+	EventTypes  *[]string `json:"event_types"`
+	Disabled    *bool     `json:"disabled"`
+}
+
+type WebhookDeliverySchema struct {
+	schemasv1.BaseSchema
+	EventType          string     `json:"event_type"`
+	Payload            string     `json:"payload"`
+	Status             string     `json:"status"`
+	Attempts           uint       `json:"attempts"`
+	ResponseStatusCode *int       `json:"response_status_code"`
+	ResponseBody       string     `json:"response_body"`
+	Error              string     `json:"error"`
+	NextRetryAt        *time.Time `json:"next_retry_at"`
+	DeliveredAt        *time.Time `json:"delivered_at"`
+}
+
+type WebhookDeliveryListSchema struct {
+	schemasv1.BaseListSchema
+	Items []*WebhookDeliverySchema `json:"items"`
+}
@@ -0,0 +1,13 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+)
+
+type YataiComponentHealthSchema struct {
+	*schemasv1.YataiComponentSchema
+	HealthStatus          string     `json:"health_status"`
+	HealthStatusChangedAt *time.Time `json:"health_status_changed_at"`
+}
@@ -2,11 +2,14 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/rs/xid"
 	"gorm.io/gorm"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -26,6 +29,38 @@ func (*apiTokenService) getBaseDB(ctx context.Context) *gorm.DB {
 	return mustGetSession(ctx).Model(&models.ApiToken{})
 }
 
+const apiTokenPrefixLength = 8
+
+func randomHexString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashApiToken(salt, token string) string {
+	sum := sha256.Sum256([]byte(salt + token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateApiToken generates a new plaintext token together with the prefix, salt and hash that are persisted for it
+func generateApiToken() (token, prefix, salt, hash string, err error) {
+	token, err = randomHexString(24)
+	if err != nil {
+		err = errors.Wrap(err, "generate api token")
+		return
+	}
+	salt, err = randomHexString(16)
+	if err != nil {
+		err = errors.Wrap(err, "generate api token salt")
+		return
+	}
+	prefix = token[:apiTokenPrefixLength]
+	hash = hashApiToken(salt, token)
+	return
+}
+
 type CreateApiTokenOption struct {
 	UserId         uint
 	OrganizationId uint
@@ -33,6 +68,8 @@ type CreateApiTokenOption struct {
 	Description    string
 	Scopes         *modelschemas.ApiTokenScopes
 	ExpiredAt      *time.Time
+	// DeploymentId is set for the api keys of the deployments
+	DeploymentId *uint
 }
 
 type UpdateApiTokenOption struct {
@@ -48,6 +85,8 @@ type ListApiTokenOption struct {
 	OrganizationId *uint
 	Ids            *[]uint
 	Order          *string
+	// the api keys of the deployments are only listed by their deployments
+	DeploymentId *uint
 }
 
 func (s *apiTokenService) Create(ctx context.Context, opt CreateApiTokenOption) (*models.ApiToken, error) {
@@ -56,8 +95,10 @@ func (s *apiTokenService) Create(ctx context.Context, opt CreateApiTokenOption)
 		return nil, errors.New(strings.Join(errs, ";"))
 	}
 
-	guid := xid.New()
-	token := guid.String()
+	token, tokenPrefix, tokenSalt, tokenHash, err := generateApiToken()
+	if err != nil {
+		return nil, err
+	}
 
 	apiToken := models.ApiToken{
 		ResourceMixin: models.ResourceMixin{
@@ -70,17 +111,42 @@ func (s *apiTokenService) Create(ctx context.Context, opt CreateApiTokenOption)
 		OrganizationAssociate: models.OrganizationAssociate{
 			OrganizationId: opt.OrganizationId,
 		},
-		Token:     token,
-		Scopes:    opt.Scopes,
-		ExpiredAt: opt.ExpiredAt,
+		TokenPrefix:  tokenPrefix,
+		TokenSalt:    tokenSalt,
+		TokenHash:    tokenHash,
+		Scopes:       opt.Scopes,
+		ExpiredAt:    opt.ExpiredAt,
+		DeploymentId: opt.DeploymentId,
 	}
-	err := mustGetSession(ctx).Create(&apiToken).Error
+	err = mustGetSession(ctx).Create(&apiToken).Error
 	if err != nil {
 		return nil, err
 	}
+	// The plaintext token is only available on the returned object, it is never persisted
+	apiToken.Token = token
 	return &apiToken, err
 }
 
+func (s *apiTokenService) Regenerate(ctx context.Context, c *models.ApiToken) (*models.ApiToken, error) {
+	token, tokenPrefix, tokenSalt, tokenHash, err := generateApiToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.getBaseDB(ctx).Where("id = ?", c.ID).Updates(map[string]interface{}{
+		"token_prefix": tokenPrefix,
+		"token_salt":   tokenSalt,
+		"token_hash":   tokenHash,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	c.TokenPrefix = tokenPrefix
+	c.TokenSalt = tokenSalt
+	c.TokenHash = tokenHash
+	c.Token = token
+	return c, nil
+}
+
 func (s *apiTokenService) Update(ctx context.Context, c *models.ApiToken, opt UpdateApiTokenOption) (*models.ApiToken, error) {
 	var err error
 	updaters := make(map[string]interface{})
@@ -245,15 +311,34 @@ func (s *apiTokenService) GetByToken(ctx context.Context, token string) (*models
 		}
 		return apiToken, nil
 	}
-	var apiToken models.ApiToken
-	err := getBaseQuery(ctx, s).Where("token = ?", token).First(&apiToken).Error
+	return s.GetByPlaintextToken(ctx, token)
+}
+
+// GetByPlaintextToken looks up the api token by the hash of the token, the component tokens of the clusters are not accepted
+func (s *apiTokenService) GetByPlaintextToken(ctx context.Context, token string) (*models.ApiToken, error) {
+	if len(token) < apiTokenPrefixLength {
+		return nil, consts.ErrNotFound
+	}
+	apiTokens := make([]*models.ApiToken, 0)
+	err := getBaseQuery(ctx, s).Where("token_prefix = ?", token[:apiTokenPrefixLength]).Find(&apiTokens).Error
 	if err != nil {
 		return nil, err
 	}
-	if apiToken.ID == 0 {
+	apiToken := matchApiToken(apiTokens, token)
+	if apiToken == nil {
 		return nil, consts.ErrNotFound
 	}
-	return &apiToken, nil
+	return apiToken, nil
+}
+
+// matchApiToken returns the api token whose hash matches the plaintext token
+func matchApiToken(apiTokens []*models.ApiToken, token string) *models.ApiToken {
+	for _, apiToken := range apiTokens {
+		if subtle.ConstantTimeCompare([]byte(hashApiToken(apiToken.TokenSalt, token)), []byte(apiToken.TokenHash)) == 1 {
+			return apiToken
+		}
+	}
+	return nil
 }
 
 func (s *apiTokenService) GetByName(ctx context.Context, organizationId, userId uint, name string) (*models.ApiToken, error) {
@@ -277,6 +362,11 @@ func (s *apiTokenService) List(ctx context.Context, opt ListApiTokenOption) ([]*
 	if opt.OrganizationId != nil {
 		query = query.Where("organization_id = ?", *opt.OrganizationId)
 	}
+	if opt.DeploymentId != nil {
+		query = query.Where("deployment_id = ?", *opt.DeploymentId)
+	} else if opt.Ids == nil {
+		query = query.Where("deployment_id IS NULL")
+	}
 	if opt.Ids != nil {
 		if len(*opt.Ids) == 0 {
 			return apiTokens, 0, nil
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+func TestGenerateApiToken(t *testing.T) {
+	token, prefix, salt, hash, err := generateApiToken()
+	if err != nil {
+		t.Fatalf("generate api token: %v", err)
+	}
+	if len(token) != 48 || len(salt) != 32 || len(hash) != 64 {
+		t.Fatalf("the token, salt and hash have %d, %d and %d characters", len(token), len(salt), len(hash))
+	}
+	if prefix != token[:apiTokenPrefixLength] {
+		t.Fatalf("the prefix %q is not the prefix of the token %q", prefix, token)
+	}
+	if strings.Contains(hash, token) || hash != hashApiToken(salt, token) {
+		t.Fatalf("the hash %q is not the salted hash of the token", hash)
+	}
+
+	anotherToken, _, anotherSalt, _, err := generateApiToken()
+	if err != nil {
+		t.Fatalf("generate api token: %v", err)
+	}
+	if anotherToken == token || anotherSalt == salt {
+		t.Fatal("the same token or salt is generated twice")
+	}
+}
+
+func TestHashApiToken(t *testing.T) {
+	hash := hashApiToken("salt-1", "token")
+	cases := []struct {
+		name  string
+		salt  string
+		token string
+		equal bool
+	}{
+		{"same salt and token", "salt-1", "token", true},
+		{"another salt", "salt-2", "token", false},
+		{"another token", "salt-1", "token2", false},
+	}
+	for _, c := range cases {
+		if equal := hashApiToken(c.salt, c.token) == hash; equal != c.equal {
+			t.Fatalf("%s: the hashes are equal: %v, expected %v", c.name, equal, c.equal)
+		}
+	}
+}
+
+func TestMatchApiToken(t *testing.T) {
+	newApiToken := func(id uint, salt, token string) *models.ApiToken {
+		apiToken := &models.ApiToken{
+			TokenPrefix: token[:apiTokenPrefixLength],
+			TokenSalt:   salt,
+			TokenHash:   hashApiToken(salt, token),
+		}
+		apiToken.ID = id
+		return apiToken
+	}
+	// the tokens share the prefix, so both of them are loaded by the prefix lookup
+	apiTokens := []*models.ApiToken{
+		newApiToken(1, "salt-1", "0123456789abcdef"),
+		newApiToken(2, "salt-2", "0123456788888888"),
+	}
+	cases := []struct {
+		name       string
+		token      string
+		expectedId uint
+	}{
+		{"first token", "0123456789abcdef", 1},
+		{"second token", "0123456788888888", 2},
+		{"same prefix", "01234567ffffffff", 0},
+		{"truncated token", "0123456789abcde", 0},
+		{"hash of the token", apiTokens[0].TokenHash, 0},
+	}
+	for _, c := range cases {
+		apiToken := matchApiToken(apiTokens, c.token)
+		var id uint
+		if apiToken != nil {
+			id = apiToken.ID
+		}
+		if id != c.expectedId {
+			t.Fatalf("%s: the token matches the api token %d, expected %d", c.name, id, c.expectedId)
+		}
+	}
+}
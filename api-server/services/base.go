@@ -62,22 +62,20 @@ func (opt BaseListOption) BindQueryWithKeywords(query *gorm.DB, tableName string
 	return query
 }
 
+// BaseListByLabelsOption filters the resources by labels, every labels list of LabelsList is satisfied by the resources
+// which have any of its labels, and every labels list of LackLabelsList by the resources which have none of its labels.
+// A label with an empty value matches the key only.
 type BaseListByLabelsOption struct {
 	LabelsList     *[][]modelschemas.LabelItemSchema
 	LackLabelsList *[][]modelschemas.LabelItemSchema
+	LabelSelector  *LabelSelector
 }
 
 func (opt BaseListByLabelsOption) BindQueryWithLabels(query *gorm.DB, resourceType modelschemas.ResourceType) *gorm.DB {
-	if opt.LabelsList == nil && opt.LackLabelsList == nil {
-		return query
-	}
 	quotedResourceType := query.Statement.Quote(string(resourceType))
-	idx := 0
-	if opt.LabelsList != nil {
-		for _, labels := range *opt.LabelsList {
-			alias := fmt.Sprintf("label_%d", idx)
-			alias = query.Statement.Quote(alias)
-			idx++
+	bindLabelsList := func(prefix string, labelsList [][]modelschemas.LabelItemSchema, lack bool) {
+		for idx, labels := range labelsList {
+			alias := query.Statement.Quote(fmt.Sprintf("%s_%d", prefix, idx))
 			orSqlPieces := make([]string, 0, len(labels))
 			orSqlArgs := make([]interface{}, 0, len(labels))
 			for _, label := range labels {
@@ -89,9 +87,24 @@ func (opt BaseListByLabelsOption) BindQueryWithLabels(query *gorm.DB, resourceTy
 					orSqlArgs = append(orSqlArgs, label.Key)
 				}
 			}
-			query = query.Joins(fmt.Sprintf("JOIN label %s ON %s.resource_type = ? AND %s.resource_id = %s.id AND (%s)", alias, alias, alias, quotedResourceType, strings.Join(orSqlPieces, " OR ")), append([]interface{}{resourceType}, orSqlArgs...)...)
+			on := fmt.Sprintf("%s.resource_type = ? AND %s.resource_id = %s.id AND (%s)", alias, alias, quotedResourceType, strings.Join(orSqlPieces, " OR "))
+			args := append([]interface{}{resourceType}, orSqlArgs...)
+			if lack {
+				query = query.Joins(fmt.Sprintf("LEFT JOIN label %s ON %s", alias, on), args...).Where(fmt.Sprintf("%s.id IS NULL", alias))
+			} else {
+				query = query.Joins(fmt.Sprintf("JOIN label %s ON %s", alias, on), args...)
+			}
 		}
 	}
+	if opt.LabelsList != nil {
+		bindLabelsList("label", *opt.LabelsList, false)
+	}
+	if opt.LackLabelsList != nil {
+		bindLabelsList("lack_label", *opt.LackLabelsList, true)
+	}
+	if opt.LabelSelector != nil {
+		query = LabelService.BindQueryWithLabelSelector(query, resourceType, *opt.LabelSelector)
+	}
 	return query
 }
 
@@ -20,6 +20,7 @@ import (
 
 	commonconsts "github.com/bentoml/yatai-common/consts"
 	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/metrics"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/common/consts"
 	"github.com/bentoml/yatai/common/utils"
@@ -126,6 +127,11 @@ func (s *bentoService) Create(ctx context.Context, opt CreateBentoOption) (bento
 		return
 	}
 
+	err = LabelPolicyService.Validate(ctx, org.ID, modelschemas.ResourceTypeBento, opt.Labels)
+	if err != nil {
+		return
+	}
+
 	err = LabelService.CreateOrUpdateLabelsFromLabelItemsSchema(ctx, opt.Labels, user.ID, org.ID, bento)
 
 	return
@@ -344,7 +350,7 @@ func (s *bentoService) Upload(ctx context.Context, bento *models.Bento, reader i
 	}
 
 	logrus.Debugf("uploading to s3: %s/%s", bucketName, objectName)
-	_, err = minioClient.PutObject(ctx, bucketName, objectName, reader, objectSize, minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	_, err = minioClient.PutObject(ctx, bucketName, objectName, metrics.NewUploadReader("bento", reader), objectSize, minio.PutObjectOptions{ContentType: "application/octet-stream"})
 	if err != nil {
 		err = errors.Wrap(err, "put object")
 		return
@@ -439,7 +445,7 @@ func (s *bentoService) Download(ctx context.Context, bento *models.Bento, writer
 		return
 	}
 
-	_, err = io.Copy(writer, obj)
+	_, err = io.Copy(metrics.NewDownloadWriter("bento", writer), obj)
 	if err != nil {
 		err = errors.Wrap(err, "copy object")
 	}
@@ -490,6 +496,22 @@ func (s *bentoService) GetTag(ctx context.Context, bento *models.Bento) (modelsc
 
 func (s *bentoService) Update(ctx context.Context, bento *models.Bento, opt UpdateBentoOption) (*models.Bento, error) {
 	var err error
+	// the labels are validated against the label policy before anything is written
+	var org *models.Organization
+	if opt.Labels != nil {
+		bentoRepository, err := BentoRepositoryService.GetAssociatedBentoRepository(ctx, bento)
+		if err != nil {
+			return nil, err
+		}
+		org, err = OrganizationService.GetAssociatedOrganization(ctx, bentoRepository)
+		if err != nil {
+			return nil, err
+		}
+		err = LabelPolicyService.Validate(ctx, org.ID, modelschemas.ResourceTypeBento, *opt.Labels)
+		if err != nil {
+			return nil, err
+		}
+	}
 	updaters := make(map[string]interface{})
 	if opt.ImageBuildStatus != nil {
 		updaters["image_build_status"] = *opt.ImageBuildStatus
@@ -571,14 +593,6 @@ func (s *bentoService) Update(ctx context.Context, bento *models.Bento, opt Upda
 	}
 
 	if opt.Labels != nil {
-		bentoRepository, err := BentoRepositoryService.GetAssociatedBentoRepository(ctx, bento)
-		if err != nil {
-			return nil, err
-		}
-		org, err := OrganizationService.GetAssociatedOrganization(ctx, bentoRepository)
-		if err != nil {
-			return nil, err
-		}
 		user, err := GetCurrentUser(ctx)
 		if err != nil {
 			return nil, err
@@ -596,6 +610,58 @@ func (s *bentoService) Update(ctx context.Context, bento *models.Bento, opt Upda
 	return bento, nil
 }
 
+// Delete deletes the bento with its labels and removes its object from s3,
+// the db changes are rolled back if the s3 object cannot be removed
+func (s *bentoService) Delete(ctx context.Context, bento *models.Bento) (err error) {
+	bucketName, err := s.GetS3BucketName(ctx, bento)
+	if err != nil {
+		return
+	}
+	objectName, err := s.getS3ObjectName(ctx, bento)
+	if err != nil {
+		return
+	}
+	bentoRepository, err := BentoRepositoryService.GetAssociatedBentoRepository(ctx, bento)
+	if err != nil {
+		return
+	}
+	org, err := OrganizationService.GetAssociatedOrganization(ctx, bentoRepository)
+	if err != nil {
+		return
+	}
+	s3Config, err := OrganizationService.GetS3Config(ctx, org)
+	if err != nil {
+		return
+	}
+	minioClient, err := s3Config.GetMinioClient()
+	if err != nil {
+		err = errors.Wrap(err, "create s3 client")
+		return
+	}
+
+	// nolint: ineffassign,staticcheck
+	db, ctx, df, err := startTransaction(ctx)
+	if err != nil {
+		return
+	}
+	defer func() { df(err) }()
+
+	err = LabelService.DeleteByResource(ctx, bento)
+	if err != nil {
+		err = errors.Wrap(err, "delete labels")
+		return
+	}
+	err = db.Unscoped().Delete(bento).Error
+	if err != nil {
+		return
+	}
+	err = minioClient.RemoveObject(ctx, bucketName, objectName, minio.RemoveObjectOptions{})
+	if err != nil {
+		err = errors.Wrap(err, "remove object")
+	}
+	return
+}
+
 func (s *bentoService) GetImageBuilderKubeName(ctx context.Context, bento *models.Bento) (string, error) {
 	bentoRepository, err := BentoRepositoryService.GetAssociatedBentoRepository(ctx, bento)
 	if err != nil {
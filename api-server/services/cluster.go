@@ -8,10 +8,12 @@ import (
 
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
+	"go.uber.org/multierr"
 	"gorm.io/gorm"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -24,7 +26,9 @@ import (
 	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/common/consts"
+	"github.com/bentoml/yatai/common/envelope"
 	"github.com/bentoml/yatai/common/helmchart"
+	"github.com/bentoml/yatai/common/tracing"
 	"github.com/bentoml/yatai/common/utils"
 )
 
@@ -53,9 +57,10 @@ type CreateClusterOption struct {
 }
 
 type UpdateClusterOption struct {
-	Description *string
-	Config      **modelschemas.ClusterConfigSchema
-	KubeConfig  *string
+	Description   *string
+	Config        **modelschemas.ClusterConfigSchema
+	KubeConfig    *string
+	IngressConfig **models.ClusterIngressConfig
 }
 
 type ListClusterOption struct {
@@ -81,13 +86,18 @@ func (s *clusterService) Create(ctx context.Context, opt CreateClusterOption) (*
 	}
 
 	defer func() { df(err) }()
+	kubeConfig, err := envelope.EncryptString(ctx, opt.KubeConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypt kubeconfig")
+	}
 	cluster := models.Cluster{
 		ResourceMixin: models.ResourceMixin{
 			Name: opt.Name,
 		},
-		Description: opt.Description,
-		KubeConfig:  opt.KubeConfig,
-		Config:      opt.Config,
+		Description:  opt.Description,
+		KubeConfig:   kubeConfig,
+		Config:       opt.Config,
+		HealthStatus: models.ClusterHealthStatusUnknown,
 		CreatorAssociate: models.CreatorAssociate{
 			CreatorId: opt.CreatorId,
 		},
@@ -99,6 +109,7 @@ func (s *clusterService) Create(ctx context.Context, opt CreateClusterOption) (*
 	if err != nil {
 		return nil, err
 	}
+	cluster.KubeConfig = opt.KubeConfig
 
 	return &cluster, err
 }
@@ -122,11 +133,39 @@ func (s *clusterService) Update(ctx context.Context, c *models.Cluster, opt Upda
 			}
 		}()
 	}
-	if opt.KubeConfig != nil {
-		updaters["kube_config"] = *opt.KubeConfig
+	if opt.KubeConfig != nil && *opt.KubeConfig != SecretMask {
+		var kubeConfig string
+		kubeConfig, err = envelope.EncryptString(ctx, *opt.KubeConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "encrypt kubeconfig")
+		}
+		updaters["kube_config"] = kubeConfig
+		updaters["health_status"] = models.ClusterHealthStatusUnknown
 		defer func() {
 			if err == nil {
 				c.KubeConfig = *opt.KubeConfig
+				c.HealthStatus = models.ClusterHealthStatusUnknown
+				invalidateKubeClient(c.ID)
+			}
+		}()
+	}
+
+	if opt.IngressConfig != nil {
+		if err = validateClusterIngressConfig(*opt.IngressConfig); err != nil {
+			return nil, err
+		}
+		if err = s.validateIngressProviderDeploymentAuth(ctx, c, *opt.IngressConfig); err != nil {
+			return nil, err
+		}
+		if getIngressProvider(*opt.IngressConfig) != s.GetIngressProvider(c) {
+			if err = s.moveIngressProvider(ctx, c, *opt.IngressConfig); err != nil {
+				return nil, err
+			}
+		}
+		updaters["ingress_config"] = *opt.IngressConfig
+		defer func() {
+			if err == nil {
+				c.IngressConfig = *opt.IngressConfig
 			}
 		}()
 	}
@@ -143,6 +182,107 @@ func (s *clusterService) Update(ctx context.Context, c *models.Cluster, opt Upda
 	return c, err
 }
 
+func validateClusterIngressConfig(ingressConfig *models.ClusterIngressConfig) error {
+	if ingressConfig == nil {
+		return nil
+	}
+	switch ingressConfig.Provider {
+	case models.ClusterIngressProviderNginx, models.ClusterIngressProviderTraefik:
+	case models.ClusterIngressProviderGatewayAPI:
+		if ingressConfig.GatewayName == "" {
+			return errors.New("the gateway name is required by the gateway-api ingress provider")
+		}
+	default:
+		return errors.Errorf("unknown ingress provider %q", ingressConfig.Provider)
+	}
+	if tls := ingressConfig.TLS; tls != nil {
+		switch tls.CertManagerIssuerKind {
+		case "", models.ClusterTLSIssuerKindIssuer, models.ClusterTLSIssuerKindClusterIssuer:
+		default:
+			return errors.Errorf("unknown cert-manager issuer kind %q", tls.CertManagerIssuerKind)
+		}
+		if tls.CertManagerIssuerName == "" && tls.WildcardCertificateSecretName == "" {
+			return errors.New("either the cert-manager issuer or the wildcard certificate secret is required by the tls config")
+		}
+		if tls.WildcardCertificateSecretName != "" && tls.WildcardCertificateSecretNamespace == "" {
+			return errors.New("the namespace of the wildcard certificate secret is required")
+		}
+	}
+	return nil
+}
+
+func getIngressProvider(ingressConfig *models.ClusterIngressConfig) models.ClusterIngressProvider {
+	if ingressConfig == nil || ingressConfig.Provider == "" {
+		return models.ClusterIngressProviderNginx
+	}
+	return ingressConfig.Provider
+}
+
+// GetIngressProvider returns the ingress provider of the cluster, the clusters use nginx by default
+func (s *clusterService) GetIngressProvider(c *models.Cluster) models.ClusterIngressProvider {
+	return getIngressProvider(c.IngressConfig)
+}
+
+// listActiveDeployments returns the deployments of the cluster which are routed, the terminating deployments are not listed
+func (s *clusterService) listActiveDeployments(ctx context.Context, c *models.Cluster) ([]*models.Deployment, error) {
+	deployments, _, err := DeploymentService.List(ctx, ListDeploymentOption{
+		ClusterId: utils.UintPtr(c.ID),
+		Statuses: &[]modelschemas.DeploymentStatus{
+			modelschemas.DeploymentStatusUnknown,
+			modelschemas.DeploymentStatusRunning,
+			modelschemas.DeploymentStatusUnhealthy,
+			modelschemas.DeploymentStatusFailed,
+			modelschemas.DeploymentStatusDeploying,
+			modelschemas.DeploymentStatusImageBuilding,
+			modelschemas.DeploymentStatusImageBuildFailed,
+			modelschemas.DeploymentStatusImageBuildSucceeded,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "list active deployments of cluster %s", c.Name)
+	}
+	return deployments, nil
+}
+
+// moveIngressProvider routes the active deployments of the cluster with the objects of the new ingress provider and then deletes the objects of the old provider.
+// It runs before the new ingress config is saved, so a failed move keeps the old provider and it is retried by updating the ingress config again
+func (s *clusterService) moveIngressProvider(ctx context.Context, c *models.Cluster, ingressConfig *models.ClusterIngressConfig) error {
+	newCluster := *c
+	newCluster.IngressConfig = ingressConfig
+	deployments, err := s.listActiveDeployments(ctx, c)
+	if err != nil {
+		return err
+	}
+	var errs error
+	for _, deployment := range deployments {
+		err = KubeIngressService.MoveDeploymentKubeIngresses(ctx, deployment, c, &newCluster)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "move the routes of deployment %s", deployment.Name))
+		}
+	}
+	if errs != nil {
+		return errors.Wrapf(errs, "the ingress provider of cluster %s is not changed from %s to %s", c.Name, s.GetIngressProvider(c), getIngressProvider(ingressConfig))
+	}
+	return nil
+}
+
+// validateIngressProviderDeploymentAuth refuses to switch to the gateway-api ingress provider while any deployment of the cluster requires authentication,
+// the http routes can not authenticate the requests and the deployments would be exposed without it once they are deployed again
+func (s *clusterService) validateIngressProviderDeploymentAuth(ctx context.Context, c *models.Cluster, ingressConfig *models.ClusterIngressConfig) error {
+	if getIngressProvider(ingressConfig) != models.ClusterIngressProviderGatewayAPI || s.GetIngressProvider(c) == models.ClusterIngressProviderGatewayAPI {
+		return nil
+	}
+	var deployment models.Deployment
+	err := DeploymentService.getBaseDB(ctx).Where("cluster_id = ?", c.ID).Where("auth_config is not null").Order("id ASC").Limit(1).Find(&deployment).Error
+	if err != nil {
+		return errors.Wrap(err, "find the deployments which require authentication")
+	}
+	if deployment.ID != 0 {
+		return errors.Errorf("cluster %s can not switch to the gateway-api ingress provider, it does not support the authentication of deployment %s, remove its auth config first", c.Name, deployment.Name)
+	}
+	return nil
+}
+
 func (s *clusterService) Get(ctx context.Context, id uint) (*models.Cluster, error) {
 	var cluster models.Cluster
 	err := getBaseQuery(ctx, s).Where("id = ?", id).First(&cluster).Error
@@ -310,7 +450,32 @@ func (s *clusterService) GetDefault(ctx context.Context, orgId uint) (defaultClu
 	return
 }
 
-func (s *clusterService) GetKubeCliSet(ctx context.Context, c *models.Cluster) (clientSet *kubernetes.Clientset, restConfig *rest.Config, err error) {
+// GetKubeCliSet returns the cached client of the cluster, it fails fast if the latest health probe of the cluster failed.
+// The returned rest config is a copy, the callers can modify it
+func (s *clusterService) GetKubeCliSet(ctx context.Context, c *models.Cluster) (*kubernetes.Clientset, *rest.Config, error) {
+	client, err := s.getKubeClient(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if client.unreachableErr != nil {
+		return nil, nil, errors.Wrapf(ErrClusterUnreachable, "cluster %s: %s", c.Name, client.unreachableErr.Error())
+	}
+	return client.clientSet, rest.CopyConfig(client.restConfig), nil
+}
+
+// GetDynamicClient returns the cached dynamic client of the cluster, it fails fast like GetKubeCliSet
+func (s *clusterService) GetDynamicClient(ctx context.Context, c *models.Cluster) (dynamic.Interface, error) {
+	client, err := s.getKubeClient(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if client.unreachableErr != nil {
+		return nil, errors.Wrapf(ErrClusterUnreachable, "cluster %s: %s", c.Name, client.unreachableErr.Error())
+	}
+	return client.dynamicClient, nil
+}
+
+func (s *clusterService) newKubeCliSet(ctx context.Context, c *models.Cluster) (clientSet *kubernetes.Clientset, restConfig *rest.Config, err error) {
 	if c.KubeConfig == "" {
 		restConfig, err = rest.InClusterConfig()
 		if err != nil {
@@ -351,6 +516,7 @@ func (s *clusterService) GetKubeCliSet(ctx context.Context, c *models.Cluster) (
 
 	restConfig.QPS = defaultQPS
 	restConfig.Burst = defaultBurst
+	restConfig.Wrap(tracing.WrapTransport)
 
 	clientSet, err = kubernetes.NewForConfig(restConfig)
 	if err != nil {
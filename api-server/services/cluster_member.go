@@ -100,6 +100,8 @@ func (s *clusterMemberService) GetBy(ctx context.Context, userId, clusterId uint
 func (s *clusterMemberService) List(ctx context.Context, opt ListClusterMemberOption) ([]*models.ClusterMember, error) {
 	members := make([]*models.ClusterMember, 0)
 	query := getBaseQuery(ctx, s)
+	// the members with a NULL user_id are user group members
+	query = query.Where("user_id IS NOT NULL")
 	if opt.ClusterId != nil {
 		query = query.Where("cluster_id = ?", *opt.ClusterId)
 	}
@@ -130,8 +132,9 @@ func (s *clusterMemberService) GetOrganization(ctx context.Context, resourceId u
 }
 
 func (s *clusterMemberService) CheckRoles(ctx context.Context, userId, resourceId uint, roles []modelschemas.MemberRole) (bool, error) {
+	// the user gets the roles either directly or through the user groups the user belongs to
 	q := s.getBaseDB(ctx).
-		Where("user_id = ?", userId).
+		Where("(user_id = ? OR user_group_id in (?))", userId, UserGroupService.getUserGroupIdsQuery(ctx, userId)).
 		Where("cluster_id = ?", resourceId).
 		Where("role in (?)", roles)
 	var total int64
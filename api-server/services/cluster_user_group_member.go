@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type clusterUserGroupMemberService struct{}
+
+var ClusterUserGroupMemberService = clusterUserGroupMemberService{}
+
+func (s *clusterUserGroupMemberService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.ClusterUserGroupMember{}).Where("cluster_member.user_group_id IS NOT NULL")
+}
+
+type CreateClusterUserGroupMemberOption struct {
+	CreatorId   uint
+	UserGroupId uint
+	ClusterId   uint
+	Role        modelschemas.MemberRole
+}
+
+type UpdateClusterUserGroupMemberOption struct {
+	Role modelschemas.MemberRole
+}
+
+type ListClusterUserGroupMemberOption struct {
+	UserGroupId *uint
+	ClusterId   *uint
+	Roles       *[]modelschemas.MemberRole
+}
+
+func (s *clusterUserGroupMemberService) Create(ctx context.Context, operatorId uint, opt CreateClusterUserGroupMemberOption) (*models.ClusterUserGroupMember, error) {
+	oldMember, err := s.GetBy(ctx, opt.UserGroupId, opt.ClusterId)
+	if err != nil && !utils.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		return s.Update(ctx, oldMember, operatorId, UpdateClusterUserGroupMemberOption{Role: opt.Role})
+	}
+
+	// nolint: ineffassign,staticcheck
+	db, ctx, df, err := startTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { df(err) }()
+	member := &models.ClusterUserGroupMember{
+		CreatorAssociate: models.CreatorAssociate{
+			CreatorId: opt.CreatorId,
+		},
+		UserGroupAssociate: models.UserGroupAssociate{
+			UserGroupId: opt.UserGroupId,
+		},
+		ClusterAssociate: models.ClusterAssociate{
+			ClusterId: opt.ClusterId,
+		},
+		Role: opt.Role,
+	}
+	err = db.Create(member).Error
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *clusterUserGroupMemberService) GetBy(ctx context.Context, userGroupId, clusterId uint) (*models.ClusterUserGroupMember, error) {
+	var member models.ClusterUserGroupMember
+	err := getBaseQuery(ctx, s).Where("cluster_id = ?", clusterId).Where("user_group_id = ?", userGroupId).First(&member).Error
+	return &member, err
+}
+
+func (s *clusterUserGroupMemberService) List(ctx context.Context, opt ListClusterUserGroupMemberOption) ([]*models.ClusterUserGroupMember, error) {
+	members := make([]*models.ClusterUserGroupMember, 0)
+	query := getBaseQuery(ctx, s)
+	if opt.ClusterId != nil {
+		query = query.Where("cluster_id = ?", *opt.ClusterId)
+	}
+	if opt.UserGroupId != nil {
+		query = query.Where("user_group_id = ?", *opt.UserGroupId)
+	}
+	if opt.Roles != nil {
+		query = query.Where("role in (?)", *opt.Roles)
+	}
+	err := query.Order("id DESC").Find(&members).Error
+	return members, err
+}
+
+func (s *clusterUserGroupMemberService) Update(ctx context.Context, m *models.ClusterUserGroupMember, operatorId uint, opt UpdateClusterUserGroupMemberOption) (*models.ClusterUserGroupMember, error) {
+	err := s.getBaseDB(ctx).Where("id = ?", m.ID).Updates(map[string]interface{}{
+		"role": opt.Role,
+	}).Error
+	if err == nil {
+		m.Role = opt.Role
+	}
+	return m, err
+}
+
+func (s *clusterUserGroupMemberService) Delete(ctx context.Context, m *models.ClusterUserGroupMember, operatorId uint) (*models.ClusterUserGroupMember, error) {
+	err := mustGetSession(ctx).Unscoped().Delete(m).Error
+	return m, err
+}
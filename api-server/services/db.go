@@ -16,13 +16,13 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"github.com/uber/jaeger-client-go"
 	postgres "go.elastic.co/apm/module/apmgormv2/driver/postgres"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
 
 	"github.com/bentoml/yatai/api-server/config"
+	"github.com/bentoml/yatai/api-server/metrics"
 	"github.com/bentoml/yatai/common/command"
 	"github.com/bentoml/yatai/common/tracing"
 	"github.com/bentoml/yatai/common/utils"
@@ -45,11 +45,8 @@ func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
 
 func (l *GormLogger) getLogger(ctx context.Context) gormlogger.Interface {
 	prefix := "\r\n"
-	span := tracing.GetSpan(ctx)
-	if span != nil {
-		if sc, ok := span.Context().(jaeger.SpanContext); ok {
-			prefix = fmt.Sprintf("%straceID=%s ", prefix, sc.TraceID().String())
-		}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		prefix = fmt.Sprintf("%straceID=%s ", prefix, traceID)
 	}
 	return gormlogger.New(log.New(os.Stdout, prefix, log.LstdFlags), gormlogger.Config{
 		SlowThreshold: 200 * time.Millisecond,
@@ -110,6 +107,14 @@ func openDB() (*gorm.DB, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "open db")
 	}
+	err = db.Use(tracing.GormPlugin{})
+	if err != nil {
+		return nil, errors.Wrap(err, "use gorm tracing plugin")
+	}
+	err = db.Use(metrics.GormPlugin{})
+	if err != nil {
+		return nil, errors.Wrap(err, "use gorm metrics plugin")
+	}
 	return db, nil
 }
 
@@ -32,6 +32,7 @@ import (
 
 	servingv1alpha2 "github.com/bentoml/yatai-deployment/generated/serving/clientset/versioned/typed/serving/v1alpha2"
 	servingv1alpha3 "github.com/bentoml/yatai-deployment/generated/serving/clientset/versioned/typed/serving/v1alpha3"
+	"github.com/bentoml/yatai/common/tracing"
 )
 
 type deploymentService struct{}
@@ -49,12 +50,18 @@ type CreateDeploymentOption struct {
 	Description   string
 	Labels        modelschemas.LabelItemsSchema
 	KubeNamespace string
+	// IngressHost is empty to use the default hostname
+	IngressHost           string
+	FederatedDeploymentId *uint
 }
 
 type UpdateDeploymentOption struct {
-	Description *string
-	Labels      *modelschemas.LabelItemsSchema
-	Status      *modelschemas.DeploymentStatus
+	Description   *string
+	Labels        *modelschemas.LabelItemsSchema
+	Status        *modelschemas.DeploymentStatus
+	IngressHost   *string
+	TLSSecretName *string
+	AuthConfig    **models.DeploymentAuthConfig
 }
 
 type UpdateDeploymentStatusOption struct {
@@ -72,6 +79,7 @@ type ListDeploymentOption struct {
 	LastUpdaterId   *uint
 	OrganizationId  *uint
 	ClusterIds      *[]uint
+	KubeNamespace   *string
 	CreatorIds      *[]uint
 	LastUpdaterIds  *[]uint
 	OrganizationIds *[]uint
@@ -79,6 +87,8 @@ type ListDeploymentOption struct {
 	BentoIds        *[]uint
 	Statuses        *[]modelschemas.DeploymentStatus
 	Order           *string
+
+	FederatedDeploymentId *uint
 }
 
 func (*deploymentService) Create(ctx context.Context, opt CreateDeploymentOption) (*models.Deployment, error) {
@@ -92,6 +102,13 @@ func (*deploymentService) Create(ctx context.Context, opt CreateDeploymentOption
 		return nil, errors.New(strings.Join(errs, ";"))
 	}
 
+	if opt.IngressHost != "" {
+		errs = validation.IsDNS1123Subdomain(opt.IngressHost)
+		if len(errs) > 0 {
+			return nil, errors.New(strings.Join(errs, ";"))
+		}
+	}
+
 	guid := xid.New()
 
 	deployment := models.Deployment{
@@ -104,10 +121,12 @@ func (*deploymentService) Create(ctx context.Context, opt CreateDeploymentOption
 		ClusterAssociate: models.ClusterAssociate{
 			ClusterId: opt.ClusterId,
 		},
-		Description:     opt.Description,
-		Status:          modelschemas.DeploymentStatusNonDeployed,
-		KubeDeployToken: guid.String(),
-		KubeNamespace:   opt.KubeNamespace,
+		Description:           opt.Description,
+		Status:                modelschemas.DeploymentStatusNonDeployed,
+		KubeDeployToken:       guid.String(),
+		KubeNamespace:         opt.KubeNamespace,
+		IngressHost:           opt.IngressHost,
+		FederatedDeploymentId: opt.FederatedDeploymentId,
 	}
 	err := mustGetSession(ctx).Create(&deployment).Error
 	if err != nil {
@@ -121,6 +140,10 @@ func (*deploymentService) Create(ctx context.Context, opt CreateDeploymentOption
 	if err != nil {
 		return nil, err
 	}
+	err = LabelPolicyService.Validate(ctx, org.ID, modelschemas.ResourceTypeDeployment, opt.Labels)
+	if err != nil {
+		return nil, err
+	}
 	err = LabelService.CreateOrUpdateLabelsFromLabelItemsSchema(ctx, opt.Labels, opt.CreatorId, org.ID, &deployment)
 	return &deployment, err
 }
@@ -146,23 +169,90 @@ func (s *deploymentService) Update(ctx context.Context, b *models.Deployment, op
 		}()
 	}
 
-	if len(updaters) == 0 {
-		return b, nil
+	if opt.IngressHost != nil {
+		if *opt.IngressHost != "" {
+			errs := validation.IsDNS1123Subdomain(*opt.IngressHost)
+			if len(errs) > 0 {
+				return nil, errors.New(strings.Join(errs, ";"))
+			}
+		}
+		updaters["ingress_host"] = *opt.IngressHost
+		defer func() {
+			if err == nil {
+				b.IngressHost = *opt.IngressHost
+			}
+		}()
 	}
 
-	err = s.getBaseDB(ctx).Where("id = ?", b.ID).Updates(updaters).Error
-	if err != nil {
-		return nil, err
+	if opt.TLSSecretName != nil {
+		if *opt.TLSSecretName != "" {
+			errs := validation.IsDNS1123Subdomain(*opt.TLSSecretName)
+			if len(errs) > 0 {
+				return nil, errors.New(strings.Join(errs, ";"))
+			}
+			cluster, err := ClusterService.GetAssociatedCluster(ctx, b)
+			if err != nil {
+				return nil, errors.Wrap(err, "get associated cluster")
+			}
+			if ClusterService.GetIngressProvider(cluster) == models.ClusterIngressProviderGatewayAPI {
+				return nil, errors.New("the certificates of the gateway-api ingress provider are served by the listeners of the gateway")
+			}
+		}
+		updaters["tls_secret_name"] = *opt.TLSSecretName
+		defer func() {
+			if err == nil {
+				b.TLSSecretName = *opt.TLSSecretName
+			}
+		}()
 	}
+
+	if opt.AuthConfig != nil {
+		var cluster *models.Cluster
+		cluster, err = ClusterService.GetAssociatedCluster(ctx, b)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated cluster")
+		}
+		err = validateDeploymentAuthConfig(cluster, *opt.AuthConfig)
+		if err != nil {
+			return nil, err
+		}
+		updaters["auth_config"] = *opt.AuthConfig
+		defer func() {
+			if err == nil {
+				b.AuthConfig = *opt.AuthConfig
+			}
+		}()
+	}
+
+	// the labels are validated against the label policy before anything is written
+	var org *models.Organization
 	if opt.Labels != nil {
-		cluster, err := ClusterService.GetAssociatedCluster(ctx, b)
+		var cluster *models.Cluster
+		cluster, err = ClusterService.GetAssociatedCluster(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		org, err = OrganizationService.GetAssociatedOrganization(ctx, cluster)
 		if err != nil {
 			return nil, err
 		}
-		org, err := OrganizationService.GetAssociatedOrganization(ctx, cluster)
+		err = LabelPolicyService.Validate(ctx, org.ID, modelschemas.ResourceTypeDeployment, *opt.Labels)
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	if len(updaters) == 0 && opt.Labels == nil {
+		return b, nil
+	}
+
+	if len(updaters) > 0 {
+		err = s.getBaseDB(ctx).Where("id = ?", b.ID).Updates(updaters).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opt.Labels != nil {
 		user, err := GetCurrentUser(ctx)
 		if err != nil {
 			return nil, err
@@ -222,6 +312,8 @@ func (s *deploymentService) ListByUids(ctx context.Context, uids []string) ([]*m
 }
 
 func (s *deploymentService) List(ctx context.Context, opt ListDeploymentOption) ([]*models.Deployment, uint, error) {
+	ctx, span := tracing.StartSpan(ctx, "DeploymentService.List")
+	defer span.End()
 	query := getBaseQuery(ctx, s)
 	if opt.OrganizationId != nil {
 		query = query.Joins("LEFT JOIN cluster ON cluster.id = deployment.cluster_id")
@@ -250,6 +342,9 @@ func (s *deploymentService) List(ctx context.Context, opt ListDeploymentOption)
 	if opt.ClusterIds != nil {
 		query = query.Where("deployment.cluster_id IN (?)", *opt.ClusterIds)
 	}
+	if opt.KubeNamespace != nil {
+		query = query.Where("deployment.kube_namespace = ?", *opt.KubeNamespace)
+	}
 	if opt.CreatorId != nil {
 		query = query.Where("deployment.creator_id = ?", *opt.CreatorId)
 	}
@@ -259,6 +354,9 @@ func (s *deploymentService) List(ctx context.Context, opt ListDeploymentOption)
 	if opt.Statuses != nil {
 		query = query.Where("deployment.status IN (?)", *opt.Statuses)
 	}
+	if opt.FederatedDeploymentId != nil {
+		query = query.Where("deployment.federated_deployment_id = ?", *opt.FederatedDeploymentId)
+	}
 	query = opt.BindQueryWithKeywords(query, "deployment")
 	query = opt.BindQueryWithLabels(query, modelschemas.ResourceTypeDeployment)
 	query = query.Select("deployment_revision.*, deployment.*")
@@ -284,7 +382,7 @@ func (s *deploymentService) List(ctx context.Context, opt ListDeploymentOption)
 func (s *deploymentService) ListUnsynced(ctx context.Context) ([]*models.Deployment, error) {
 	q := getBaseQuery(ctx, s)
 	now := time.Now()
-	t := now.Add(-time.Minute)
+	t := now.Add(-DeploymentStatusReconcileInterval)
 	q = q.Where("status_syncing_at is null or status_syncing_at < ? or status_updated_at is null or status_updated_at < ?", t, t)
 	envs := make([]*models.Deployment, 0)
 	err := q.Order("id DESC").Find(&envs).Error
@@ -464,6 +562,7 @@ func (s *deploymentService) GetKubeBentoDeploymentV1alpha3Cli(ctx context.Contex
 }
 
 func (s *deploymentService) SyncStatus(ctx context.Context, d *models.Deployment) (modelschemas.DeploymentStatus, error) {
+	oldStatus := d.Status
 	now := time.Now()
 	nowPtr := &now
 	_, err := s.UpdateStatus(ctx, d, UpdateDeploymentStatusOption{
@@ -478,16 +577,53 @@ func (s *deploymentService) SyncStatus(ctx context.Context, d *models.Deployment
 	}
 	now = time.Now()
 	nowPtr = &now
-	_, err = s.UpdateStatus(ctx, d, UpdateDeploymentStatusOption{
-		Status:    &currentStatus,
-		UpdatedAt: &nowPtr,
-	})
+	changedFrom, changed, err := s.compareAndUpdateStatus(ctx, d, oldStatus, currentStatus, nowPtr)
 	if err != nil {
 		return currentStatus, err
 	}
+	if changed {
+		WebhookService.DispatchDeploymentStatusChange(ctx, d, changedFrom)
+	}
 	return currentStatus, nil
 }
 
+// deploymentStatusUpdateMaxAttempts bounds the retries of compareAndUpdateStatus when the status is changed concurrently
+const deploymentStatusUpdateMaxAttempts = 3
+
+// compareAndUpdateStatus updates the status only if it is still oldStatus in the db, and reports whether this call changed it and from which status,
+// so that only one of the concurrent status syncs of the informers and the cron dispatches the status change
+func (s *deploymentService) compareAndUpdateStatus(ctx context.Context, d *models.Deployment, oldStatus, status modelschemas.DeploymentStatus, updatedAt *time.Time) (changedFrom modelschemas.DeploymentStatus, changed bool, err error) {
+	for i := 0; i < deploymentStatusUpdateMaxAttempts; i++ {
+		if oldStatus == status {
+			d.Status = status
+			_, err = s.UpdateStatus(ctx, d, UpdateDeploymentStatusOption{
+				UpdatedAt: &updatedAt,
+			})
+			return oldStatus, false, err
+		}
+		db := s.getBaseDB(ctx).Where("id = ?", d.ID).Where("status = ?", oldStatus).Updates(map[string]interface{}{
+			"status":            status,
+			"status_updated_at": updatedAt,
+		})
+		if db.Error != nil {
+			return oldStatus, false, db.Error
+		}
+		if db.RowsAffected == 1 {
+			d.Status = status
+			d.StatusUpdatedAt = updatedAt
+			return oldStatus, true, nil
+		}
+		// the status is changed by another sync, compare with the status in the db again
+		var current models.Deployment
+		err = s.getBaseDB(ctx).Select("status").Where("id = ?", d.ID).First(&current).Error
+		if err != nil {
+			return oldStatus, false, err
+		}
+		oldStatus = current.Status
+	}
+	return oldStatus, false, errors.Errorf("the status of deployment %s is changed concurrently", d.Name)
+}
+
 func (s *deploymentService) getStatusFromK8s(ctx context.Context, d *models.Deployment) (modelschemas.DeploymentStatus, error) {
 	defaultStatus := modelschemas.DeploymentStatusUnknown
 
@@ -653,12 +789,22 @@ func (s *deploymentService) Delete(ctx context.Context, deployment *models.Deplo
 }
 
 func (s *deploymentService) Terminate(ctx context.Context, deployment *models.Deployment) (*models.Deployment, error) {
+	return s.terminate(ctx, deployment, false)
+}
+
+// terminate records whether the deployment is scaled to zero by a scaling schedule, the scale actions only deploy such terminated deployments again
+func (s *deploymentService) terminate(ctx context.Context, deployment *models.Deployment, scaledToZeroBySchedule bool) (*models.Deployment, error) {
 	deployment, err := s.UpdateStatus(ctx, deployment, UpdateDeploymentStatusOption{
 		Status: modelschemas.DeploymentStatusTerminating.Ptr(),
 	})
 	if err != nil {
 		return nil, err
 	}
+	err = s.getBaseDB(ctx).Where("id = ?", deployment.ID).Update("scaled_to_zero_by_schedule", scaledToZeroBySchedule).Error
+	if err != nil {
+		return nil, err
+	}
+	deployment.ScaledToZeroBySchedule = scaledToZeroBySchedule
 	deploymentRevisions, _, err := DeploymentRevisionService.List(ctx, ListDeploymentRevisionOption{
 		BaseListOption: BaseListOption{
 			Start: utils.UintPtr(0),
@@ -684,6 +830,14 @@ func (s *deploymentService) GetKubeName(deployment *models.Deployment) string {
 	return fmt.Sprintf("yatai-%s", deployment.Name)
 }
 
+// GenerateIngressHost returns the ingress host of the deployment if it is set, otherwise the default hostname
+func (s *deploymentService) GenerateIngressHost(ctx context.Context, deployment *models.Deployment) (string, error) {
+	if deployment.IngressHost != "" {
+		return deployment.IngressHost, nil
+	}
+	return s.GenerateDefaultHostname(ctx, deployment)
+}
+
 func (s *deploymentService) GenerateDefaultHostname(ctx context.Context, deployment *models.Deployment) (string, error) {
 	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
 	if err != nil {
@@ -716,6 +870,36 @@ func (s *deploymentService) GetURLs(ctx context.Context, deployment *models.Depl
 	if len(deploymentRevisions) == 0 {
 		return []string{}, nil
 	}
+	// the traefik and the gateway api routes of the stable targets which are managed by yatai are not ingresses,
+	// their host is the ingress host of the deployment
+	stableType := modelschemas.DeploymentTargetTypeStable
+	stableTargets, _, err := DeploymentTargetService.List(ctx, ListDeploymentTargetOption{
+		DeploymentRevisionId: utils.UintPtr(deploymentRevisions[0].ID),
+		Type:                 &stableType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(stableTargets) > 0 {
+		managesKubeIngresses, err := DeploymentTargetService.ManagesKubeIngresses(ctx, stableTargets[0])
+		if err != nil {
+			return nil, err
+		}
+		if managesKubeIngresses {
+			host, err := s.GenerateIngressHost(ctx, deployment)
+			if err != nil {
+				return nil, err
+			}
+			tlsSecretName, err := KubeIngressService.GetIngressTLSSecretName(ctx, deployment)
+			if err != nil {
+				return nil, err
+			}
+			if tlsSecretName != "" {
+				return []string{fmt.Sprintf("https://%s", host)}, nil
+			}
+			return []string{fmt.Sprintf("http://%s", host)}, nil
+		}
+	}
 	urls := make([]string, 0)
 	kubeName := deployment.Name
 	ingCli, err := s.GetKubeIngressesCli(ctx, deployment)
@@ -730,7 +914,17 @@ func (s *deploymentService) GetURLs(ctx context.Context, deployment *models.Depl
 	if ingIsNotFound {
 		return []string{}, nil
 	}
+	tlsHosts := make(map[string]struct{})
+	for _, tls := range ing.Spec.TLS {
+		for _, host := range tls.Hosts {
+			tlsHosts[host] = struct{}{}
+		}
+	}
 	for _, rule := range ing.Spec.Rules {
+		if _, ok := tlsHosts[rule.Host]; ok {
+			urls = append(urls, fmt.Sprintf("https://%s", rule.Host))
+			continue
+		}
 		urls = append(urls, fmt.Sprintf("http://%s", rule.Host))
 	}
 	return urls, nil
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/bentoml/yatai/api-server/config"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+// ErrDeploymentAuthFailed is the cause of the errors of the requests without valid credentials, the ingresses reject them with 401
+var ErrDeploymentAuthFailed = errors.New("deployment authentication failed")
+
+// DeploymentAuthApiKeyHeader carries the name of the verified api key to the deployment,
+// the ingresses replace the header of the client with the one of the auth response
+const DeploymentAuthApiKeyHeader = "X-Yatai-Api-Key"
+
+const (
+	// deploymentAuthCacheTTL is how long a verified credential is accepted without verifying it again, by yatai and by the nginx auth cache,
+	// so a revoked api key is still accepted for at most this long
+	deploymentAuthCacheTTL  = 10 * time.Second
+	deploymentAuthCacheSize = 10000
+)
+
+type deploymentAuthCacheEntry struct {
+	// it is 0 for the deployments without api keys
+	apiTokenId   uint
+	apiTokenName string
+	expiresAt    time.Time
+}
+
+type apiTokenUsageKey struct {
+	apiTokenId uint
+	day        string
+}
+
+type apiTokenUsageDelta struct {
+	requestCount uint64
+	lastUsedAt   time.Time
+}
+
+type deploymentAuthService struct {
+	// the usage is buffered in memory and flushed to the database by the cron, the auth requests are on the path of every inference request
+	usageMu sync.Mutex
+	usage   map[apiTokenUsageKey]*apiTokenUsageDelta
+
+	// the verified credentials by the hash of the deployment uid and the authorization header
+	verifiedMu sync.Mutex
+	verified   map[string]*deploymentAuthCacheEntry
+
+	jwks jwksCache
+}
+
+var DeploymentAuthService = deploymentAuthService{}
+
+func validateDeploymentAuthConfig(cluster *models.Cluster, authConfig *models.DeploymentAuthConfig) error {
+	if authConfig == nil {
+		return nil
+	}
+	switch authConfig.Mode {
+	case models.DeploymentAuthModeApiKey:
+	case models.DeploymentAuthModeJWT:
+		issuerURL, err := url.Parse(authConfig.JWTIssuer)
+		if err != nil || issuerURL.Scheme != "https" || issuerURL.Host == "" {
+			return errors.Errorf("the jwt issuer %q is not an https url", authConfig.JWTIssuer)
+		}
+		if authConfig.JWTAudience == "" {
+			return errors.New("the jwt audience is required")
+		}
+	default:
+		return errors.Errorf("unknown deployment auth mode %q", authConfig.Mode)
+	}
+	if config.YataiConfig.Server.ExternalURL == "" {
+		return errors.New("the deployment authentication requires server.external_url in the yatai config")
+	}
+	if ClusterService.GetIngressProvider(cluster) == models.ClusterIngressProviderGatewayAPI {
+		return errors.New("the gateway-api ingress provider does not support the deployment authentication")
+	}
+	return nil
+}
+
+// GetAuthURL returns the url which the ingresses send the auth requests of the deployment to
+func (s *deploymentAuthService) GetAuthURL(deployment *models.Deployment) string {
+	return fmt.Sprintf("%s/api/v1/deployment_auth/%s/verify", strings.TrimSuffix(config.YataiConfig.Server.ExternalURL, "/"), deployment.Uid)
+}
+
+func getBearerToken(header http.Header) string {
+	authorization := header.Get("Authorization")
+	if len(authorization) < len("Bearer ") || !strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len("Bearer "):])
+}
+
+func getDeploymentAuthCacheKey(deploymentUid, authorization string) string {
+	sum := sha256.Sum256([]byte(deploymentUid + "\x00" + authorization))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *deploymentAuthService) getVerified(key string, now time.Time) (*deploymentAuthCacheEntry, bool) {
+	s.verifiedMu.Lock()
+	defer s.verifiedMu.Unlock()
+	entry, ok := s.verified[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (s *deploymentAuthService) addVerified(key string, entry *deploymentAuthCacheEntry, now time.Time) {
+	s.verifiedMu.Lock()
+	defer s.verifiedMu.Unlock()
+	if s.verified == nil {
+		s.verified = make(map[string]*deploymentAuthCacheEntry)
+	}
+	if len(s.verified) >= deploymentAuthCacheSize {
+		for key_, entry_ := range s.verified {
+			if !now.Before(entry_.expiresAt) {
+				delete(s.verified, key_)
+			}
+		}
+		// the entries are short-lived, the cache is dropped instead of evicting the live entries one by one
+		if len(s.verified) >= deploymentAuthCacheSize {
+			s.verified = make(map[string]*deploymentAuthCacheEntry)
+		}
+	}
+	s.verified[key] = entry
+}
+
+// VerifyRequest verifies the auth request of the ingress of the deployment and returns the name of the api key in the api key mode,
+// the verified credentials are cached for deploymentAuthCacheTTL so that the inference requests do not query the database every time
+func (s *deploymentAuthService) VerifyRequest(ctx context.Context, deploymentUid string, header http.Header) (string, error) {
+	now := time.Now()
+	cacheKey := getDeploymentAuthCacheKey(deploymentUid, header.Get("Authorization"))
+	if entry, ok := s.getVerified(cacheKey, now); ok {
+		if entry.apiTokenId != 0 {
+			s.recordUsage(entry.apiTokenId)
+		}
+		return entry.apiTokenName, nil
+	}
+	deployment, err := DeploymentService.GetByUid(ctx, deploymentUid)
+	if err != nil {
+		return "", errors.Wrapf(err, "get deployment %s", deploymentUid)
+	}
+	apiToken, err := s.Verify(ctx, deployment, header)
+	if err != nil {
+		return "", err
+	}
+	entry := &deploymentAuthCacheEntry{
+		expiresAt: now.Add(deploymentAuthCacheTTL),
+	}
+	if apiToken != nil {
+		entry.apiTokenId = apiToken.ID
+		entry.apiTokenName = apiToken.Name
+		if apiToken.ExpiredAt != nil && apiToken.ExpiredAt.Before(entry.expiresAt) {
+			entry.expiresAt = *apiToken.ExpiredAt
+		}
+	}
+	s.addVerified(cacheKey, entry, now)
+	return entry.apiTokenName, nil
+}
+
+// Verify checks the bearer token of the request headers which are forwarded by the ingress,
+// it returns the api key of the request in the api key mode and nil in the jwt mode
+func (s *deploymentAuthService) Verify(ctx context.Context, deployment *models.Deployment, header http.Header) (*models.ApiToken, error) {
+	if deployment.AuthConfig == nil {
+		return nil, nil
+	}
+	token := getBearerToken(header)
+	if token == "" {
+		return nil, errors.Wrap(ErrDeploymentAuthFailed, "no bearer token")
+	}
+	switch deployment.AuthConfig.Mode {
+	case models.DeploymentAuthModeApiKey:
+		return s.verifyApiKey(ctx, deployment, token)
+	case models.DeploymentAuthModeJWT:
+		return nil, s.verifyJWT(ctx, deployment.AuthConfig, token)
+	default:
+		return nil, errors.Errorf("unknown deployment auth mode %q", deployment.AuthConfig.Mode)
+	}
+}
+
+func (s *deploymentAuthService) verifyApiKey(ctx context.Context, deployment *models.Deployment, token string) (*models.ApiToken, error) {
+	apiToken, err := ApiTokenService.GetByPlaintextToken(ctx, token)
+	if utils.IsNotFound(err) {
+		return nil, errors.Wrap(ErrDeploymentAuthFailed, "invalid api key")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "get api key")
+	}
+	if err = checkApiKey(deployment, apiToken); err != nil {
+		return nil, err
+	}
+	s.recordUsage(apiToken.ID)
+	return apiToken, nil
+}
+
+// checkApiKey checks that the api key belongs to the deployment and is allowed to invoke it
+func checkApiKey(deployment *models.Deployment, apiToken *models.ApiToken) error {
+	if apiToken.DeploymentId == nil || *apiToken.DeploymentId != deployment.ID || apiToken.Scopes == nil || !apiToken.Scopes.Contains(models.ApiTokenScopeInvokeDeployment) {
+		return errors.Wrap(ErrDeploymentAuthFailed, "the api key can not invoke this deployment")
+	}
+	if apiToken.IsExpired() {
+		return errors.Wrap(ErrDeploymentAuthFailed, "the api key is expired")
+	}
+	return nil
+}
+
+func (s *deploymentAuthService) verifyJWT(ctx context.Context, authConfig *models.DeploymentAuthConfig, rawJWT string) error {
+	issuer := strings.TrimSuffix(authConfig.JWTIssuer, "/")
+	claims, err := verifyRS256JWT(ctx, rawJWT, func(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+		return s.getJWTKey(ctx, issuer, kid)
+	})
+	if err != nil {
+		return errors.Wrapf(ErrDeploymentAuthFailed, "verify jwt: %s", err.Error())
+	}
+	if strings.TrimSuffix(claims.GetString("iss"), "/") != issuer {
+		return errors.Wrapf(ErrDeploymentAuthFailed, "unexpected jwt issuer %s", claims.GetString("iss"))
+	}
+	audienceMatched := false
+	for _, aud := range claims.GetStrings("aud") {
+		if aud == authConfig.JWTAudience {
+			audienceMatched = true
+			break
+		}
+	}
+	if !audienceMatched {
+		return errors.Wrap(ErrDeploymentAuthFailed, "the jwt is not issued for this deployment")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.Wrap(ErrDeploymentAuthFailed, "no exp in the jwt")
+	}
+	if time.Now().Add(-oidcClockSkew).After(time.Unix(int64(exp), 0)) {
+		return errors.Wrap(ErrDeploymentAuthFailed, "the jwt is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && time.Now().Add(oidcClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return errors.Wrap(ErrDeploymentAuthFailed, "the jwt is not valid yet")
+	}
+	return nil
+}
+
+// getJWTKey returns the signing key of the issuer by kid, the keys are cached like the keys of the oidc login
+func (s *deploymentAuthService) getJWTKey(ctx context.Context, issuer, kid string) (*rsa.PublicKey, error) {
+	return s.jwks.getKey(ctx, issuer, kid)
+}
+
+func (s *deploymentAuthService) recordUsage(apiTokenId uint) {
+	now := time.Now().UTC()
+	key := apiTokenUsageKey{
+		apiTokenId: apiTokenId,
+		day:        now.Format("2006-01-02"),
+	}
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	if s.usage == nil {
+		s.usage = make(map[apiTokenUsageKey]*apiTokenUsageDelta)
+	}
+	delta, ok := s.usage[key]
+	if !ok {
+		delta = &apiTokenUsageDelta{}
+		s.usage[key] = delta
+	}
+	delta.requestCount++
+	delta.lastUsedAt = now
+}
+
+// FlushUsage adds the buffered usage to the database, the usage which fails to be flushed is kept for the next flush
+func (s *deploymentAuthService) FlushUsage(ctx context.Context) error {
+	s.usageMu.Lock()
+	usage := s.usage
+	s.usage = nil
+	s.usageMu.Unlock()
+
+	for key, delta := range usage {
+		err := s.flushUsage(ctx, key, delta)
+		if err != nil {
+			s.restoreUsage(usage)
+			return errors.Wrapf(err, "flush the usage of api key %d", key.apiTokenId)
+		}
+		delete(usage, key)
+	}
+	return nil
+}
+
+// flushUsage saves the usage of the api key in one transaction, so the usage which is restored after a failure is never saved twice
+func (s *deploymentAuthService) flushUsage(ctx context.Context, key apiTokenUsageKey, delta *apiTokenUsageDelta) (err error) {
+	db, _, df, err := startTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { df(err) }()
+	err = db.Exec(`insert into api_token_usage (api_token_id, day, request_count, last_used_at) values (?, ?, ?, ?)
+on conflict (api_token_id, day) do update set request_count = api_token_usage.request_count + excluded.request_count, last_used_at = greatest(api_token_usage.last_used_at, excluded.last_used_at)`,
+		key.apiTokenId, key.day, delta.requestCount, delta.lastUsedAt).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&models.ApiToken{}).Where("id = ?", key.apiTokenId).Update("last_used_at", delta.lastUsedAt).Error
+}
+
+func (s *deploymentAuthService) restoreUsage(usage map[apiTokenUsageKey]*apiTokenUsageDelta) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	if s.usage == nil {
+		s.usage = make(map[apiTokenUsageKey]*apiTokenUsageDelta)
+	}
+	for key, delta := range usage {
+		current, ok := s.usage[key]
+		if !ok {
+			s.usage[key] = delta
+			continue
+		}
+		current.requestCount += delta.requestCount
+		if delta.lastUsedAt.After(current.lastUsedAt) {
+			current.lastUsedAt = delta.lastUsedAt
+		}
+	}
+	logrus.Warnf("kept the usage of %d api keys for the next flush", len(usage))
+}
+
+// ListUsage returns the daily usage of the api key since the day, the days without requests are left out
+func (s *deploymentAuthService) ListUsage(ctx context.Context, apiToken *models.ApiToken, since time.Time) ([]*models.ApiTokenUsage, error) {
+	usage := make([]*models.ApiTokenUsage, 0)
+	err := mustGetSession(ctx).Model(&models.ApiTokenUsage{}).Where("api_token_id = ?", apiToken.ID).Where("day >= ?", since.UTC().Format("2006-01-02")).Order("day ASC").Find(&usage).Error
+	return usage, err
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/config"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+func TestGetBearerToken(t *testing.T) {
+	cases := []struct {
+		authorization string
+		expected      string
+	}{
+		{"Bearer abc", "abc"},
+		{"bearer  abc ", "abc"},
+		{"Basic abc", ""},
+		{"Bearer", ""},
+		{"", ""},
+	}
+	for _, c := range cases {
+		header := http.Header{}
+		header.Set("Authorization", c.authorization)
+		if token := getBearerToken(header); token != c.expected {
+			t.Fatalf("the bearer token of %q is %q, expected %q", c.authorization, token, c.expected)
+		}
+	}
+}
+
+func TestCheckApiKey(t *testing.T) {
+	deployment := &models.Deployment{}
+	deployment.ID = 1
+	invokeScopes := &modelschemas.ApiTokenScopes{models.ApiTokenScopeInvokeDeployment}
+	otherScopes := &modelschemas.ApiTokenScopes{modelschemas.ApiTokenScopeApi}
+	cases := []struct {
+		name         string
+		deploymentId *uint
+		scopes       *modelschemas.ApiTokenScopes
+		expiredAt    *time.Time
+		wantErr      string
+	}{
+		{"valid", utils.UintPtr(1), invokeScopes, nil, ""},
+		{"not expired yet", utils.UintPtr(1), invokeScopes, utils.TimePtr(time.Now().Add(time.Hour)), ""},
+		{"expired", utils.UintPtr(1), invokeScopes, utils.TimePtr(time.Now().Add(-time.Hour)), "expired"},
+		{"another deployment", utils.UintPtr(2), invokeScopes, nil, "can not invoke this deployment"},
+		{"not an api key of a deployment", nil, invokeScopes, nil, "can not invoke this deployment"},
+		{"no invoke scope", utils.UintPtr(1), otherScopes, nil, "can not invoke this deployment"},
+		{"no scopes", utils.UintPtr(1), nil, nil, "can not invoke this deployment"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkApiKey(deployment, &models.ApiToken{
+				DeploymentId: c.deploymentId,
+				Scopes:       c.scopes,
+				ExpiredAt:    c.expiredAt,
+			})
+			if c.wantErr == "" {
+				if err != nil {
+					t.Fatalf("the api key is rejected: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Fatalf("the error is %v, expected it to contain %q", err, c.wantErr)
+			}
+			if errors.Cause(err) != ErrDeploymentAuthFailed {
+				t.Fatalf("the error %v is not caused by ErrDeploymentAuthFailed", err)
+			}
+		})
+	}
+}
+
+func TestValidateDeploymentAuthConfig(t *testing.T) {
+	oldExternalURL := config.YataiConfig.Server.ExternalURL
+	config.YataiConfig.Server.ExternalURL = "https://yatai.example.com"
+	t.Cleanup(func() {
+		config.YataiConfig.Server.ExternalURL = oldExternalURL
+	})
+	nginxCluster := &models.Cluster{}
+	gatewayAPICluster := &models.Cluster{
+		IngressConfig: &models.ClusterIngressConfig{
+			Provider:    models.ClusterIngressProviderGatewayAPI,
+			GatewayName: "yatai-gateway",
+		},
+	}
+	cases := []struct {
+		name       string
+		cluster    *models.Cluster
+		authConfig *models.DeploymentAuthConfig
+		wantErr    bool
+	}{
+		{"no auth", gatewayAPICluster, nil, false},
+		{"api key", nginxCluster, &models.DeploymentAuthConfig{Mode: models.DeploymentAuthModeApiKey}, false},
+		{"jwt", nginxCluster, &models.DeploymentAuthConfig{Mode: models.DeploymentAuthModeJWT, JWTIssuer: "https://idp.example.com", JWTAudience: "iris"}, false},
+		{"jwt over http", nginxCluster, &models.DeploymentAuthConfig{Mode: models.DeploymentAuthModeJWT, JWTIssuer: "http://idp.example.com", JWTAudience: "iris"}, true},
+		{"jwt without audience", nginxCluster, &models.DeploymentAuthConfig{Mode: models.DeploymentAuthModeJWT, JWTIssuer: "https://idp.example.com"}, true},
+		{"unknown mode", nginxCluster, &models.DeploymentAuthConfig{Mode: "basic"}, true},
+		{"gateway api", gatewayAPICluster, &models.DeploymentAuthConfig{Mode: models.DeploymentAuthModeApiKey}, true},
+	}
+	for _, c := range cases {
+		err := validateDeploymentAuthConfig(c.cluster, c.authConfig)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%s: the error is %v, expected an error: %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestVerifyJWT(t *testing.T) {
+	idp := newTestIdP(t)
+	authConfig := &models.DeploymentAuthConfig{
+		Mode:        models.DeploymentAuthModeJWT,
+		JWTIssuer:   idp.server.URL + "/",
+		JWTAudience: "iris",
+	}
+
+	header := map[string]interface{}{"alg": "RS256", "kid": testOIDCKid}
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := map[string]interface{}{
+			"iss": idp.server.URL,
+			"aud": "iris",
+			"sub": "client-1",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		if value == nil {
+			delete(claims, name)
+		} else if name != "" {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	cases := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"valid", signTestJWT(t, idp.key, header, withClaim("", nil)), ""},
+		{"one of the audiences", signTestJWT(t, idp.key, header, withClaim("aud", []string{"other", "iris"})), ""},
+		{"wrong audience", signTestJWT(t, idp.key, header, withClaim("aud", "other")), "not issued for this deployment"},
+		{"wrong issuer", signTestJWT(t, idp.key, header, withClaim("iss", "https://evil.example.com")), "unexpected jwt issuer"},
+		{"expired", signTestJWT(t, idp.key, header, withClaim("exp", time.Now().Add(-time.Hour).Unix())), "expired"},
+		{"no exp", signTestJWT(t, idp.key, header, withClaim("exp", nil)), "no exp"},
+		{"not valid yet", signTestJWT(t, idp.key, header, withClaim("nbf", time.Now().Add(time.Hour).Unix())), "not valid yet"},
+		{"unknown kid", signTestJWT(t, idp.key, map[string]interface{}{"alg": "RS256", "kid": "rotated"}, withClaim("", nil)), "unknown signing key"},
+		{"alg none", signTestJWT(t, idp.key, map[string]interface{}{"alg": "none", "kid": testOIDCKid}, withClaim("", nil)), "verify jwt"},
+		{"malformed", "not-a-jwt", "verify jwt"},
+	}
+	s := &deploymentAuthService{}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := s.verifyJWT(context.Background(), authConfig, c.token)
+			if c.wantErr == "" {
+				if err != nil {
+					t.Fatalf("the jwt is rejected: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Fatalf("the error is %v, expected it to contain %q", err, c.wantErr)
+			}
+			if errors.Cause(err) != ErrDeploymentAuthFailed {
+				t.Fatalf("the error %v is not caused by ErrDeploymentAuthFailed", err)
+			}
+		})
+	}
+	if n := atomic.LoadInt32(&idp.jwksRequests); n != 1 {
+		t.Fatalf("the jwks is requested %d times, expected it to be cached after the first request", n)
+	}
+}
+
+func TestGetJWTKeyFetchesOnceConcurrently(t *testing.T) {
+	idp := newTestIdP(t)
+	s := &deploymentAuthService{}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.getJWTKey(context.Background(), idp.server.URL, testOIDCKid)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("get jwt key: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&idp.jwksRequests); n != 1 {
+		t.Fatalf("the jwks is requested %d times by the concurrent requests, expected 1", n)
+	}
+}
+
+func TestGetJWTKeyBacksOffAfterFailures(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	s := &deploymentAuthService{}
+	_, err := s.getJWTKey(context.Background(), server.URL, testOIDCKid)
+	if err == nil {
+		t.Fatal("the key of the failing issuer is returned")
+	}
+	_, err = s.getJWTKey(context.Background(), server.URL, testOIDCKid)
+	if err == nil || !strings.Contains(err.Error(), "backed off") {
+		t.Fatalf("the refresh is not backed off, the error is %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("the failing issuer is requested %d times, expected 1", n)
+	}
+
+	// the issuer is requested again once the backoff has elapsed
+	s.jwks.mu.Lock()
+	s.jwks.issuers[server.URL].failedAt = time.Now().Add(-jwtRefreshMaxBackoff)
+	s.jwks.mu.Unlock()
+	_, _ = s.getJWTKey(context.Background(), server.URL, testOIDCKid)
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("the failing issuer is requested %d times after the backoff, expected 2", n)
+	}
+	s.jwks.mu.Lock()
+	failures := s.jwks.issuers[server.URL].failures
+	s.jwks.mu.Unlock()
+	if failures != 2 {
+		t.Fatalf("%d failures are recorded, expected 2", failures)
+	}
+}
+
+func TestDeploymentAuthVerifiedCache(t *testing.T) {
+	if getDeploymentAuthCacheKey("uid-1", "Bearer a") == getDeploymentAuthCacheKey("uid-2", "Bearer a") {
+		t.Fatal("the credential of a deployment is accepted for another deployment")
+	}
+	if getDeploymentAuthCacheKey("uid-1", "Bearer a") == getDeploymentAuthCacheKey("uid-1", "Bearer b") {
+		t.Fatal("the cache keys of different credentials are the same")
+	}
+
+	now := time.Now()
+	s := &deploymentAuthService{}
+	s.addVerified("key", &deploymentAuthCacheEntry{apiTokenId: 1, apiTokenName: "partner", expiresAt: now.Add(deploymentAuthCacheTTL)}, now)
+	if entry, ok := s.getVerified("key", now.Add(deploymentAuthCacheTTL/2)); !ok || entry.apiTokenName != "partner" {
+		t.Fatalf("the verified credential is not cached: %v", ok)
+	}
+	if _, ok := s.getVerified("key", now.Add(deploymentAuthCacheTTL)); ok {
+		t.Fatal("the expired credential is accepted")
+	}
+
+	// the expired entries are pruned when the cache is full
+	s = &deploymentAuthService{}
+	for i := 0; i < deploymentAuthCacheSize; i++ {
+		s.addVerified(fmt.Sprintf("expired-%d", i), &deploymentAuthCacheEntry{expiresAt: now}, now)
+	}
+	s.addVerified("live", &deploymentAuthCacheEntry{expiresAt: now.Add(time.Second)}, now.Add(time.Millisecond))
+	if len(s.verified) != 1 {
+		t.Fatalf("the cache has %d entries after the expired entries are pruned, expected 1", len(s.verified))
+	}
+	// the cache is dropped when it is full of live entries
+	for i := 0; len(s.verified) < deploymentAuthCacheSize; i++ {
+		s.addVerified(fmt.Sprintf("live-%d", i), &deploymentAuthCacheEntry{expiresAt: now.Add(time.Second)}, now)
+	}
+	s.addVerified("new", &deploymentAuthCacheEntry{expiresAt: now.Add(time.Second)}, now)
+	if _, ok := s.getVerified("new", now); !ok || len(s.verified) != 1 {
+		t.Fatalf("the full cache has %d entries after adding one, expected 1", len(s.verified))
+	}
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type KubeObjectChangeType string
+
+const (
+	KubeObjectChangeTypeCreate    KubeObjectChangeType = "create"
+	KubeObjectChangeTypeUpdate    KubeObjectChangeType = "update"
+	KubeObjectChangeTypeDelete    KubeObjectChangeType = "delete"
+	KubeObjectChangeTypeUnchanged KubeObjectChangeType = "unchanged"
+)
+
+type KubeObjectFieldDiffOperation string
+
+const (
+	KubeObjectFieldDiffOperationAdd     KubeObjectFieldDiffOperation = "add"
+	KubeObjectFieldDiffOperationRemove  KubeObjectFieldDiffOperation = "remove"
+	KubeObjectFieldDiffOperationReplace KubeObjectFieldDiffOperation = "replace"
+)
+
+type KubeObjectFieldDiff struct {
+	Path      string
+	Operation KubeObjectFieldDiffOperation
+	Old       interface{}
+	New       interface{}
+}
+
+// KubeObjectPreview is a kubernetes object which an update of the deployment would apply,
+// compared with the object of the active revision and with the live object in the cluster.
+// The live comparison is only filled by DiffLive.
+type KubeObjectPreview struct {
+	ApiVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	// Object is nil when the object only exists in the active revision
+	Object map[string]interface{}
+
+	ActiveRevisionChange KubeObjectChangeType
+	ActiveRevisionDiffs  []*KubeObjectFieldDiff
+	LiveChange           KubeObjectChangeType
+	LiveDiffs            []*KubeObjectFieldDiff
+
+	// source is the rendered object which Object is converted from, it is nil when Object is nil
+	source metav1.Object
+}
+
+type renderedKubeObject struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+	source     metav1.Object
+	object     map[string]interface{}
+}
+
+func (o *renderedKubeObject) key() string {
+	return fmt.Sprintf("%s/%s/%s", o.kind, o.namespace, o.name)
+}
+
+type deploymentPreviewService struct{}
+
+var DeploymentPreviewService = deploymentPreviewService{}
+
+func toKubeObjectMap(obj interface{}) (map[string]interface{}, error) {
+	content, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]interface{})
+	err = json.Unmarshal(content, &res)
+	return res, err
+}
+
+func newRenderedKubeObject(apiVersion, kind string, obj metav1.Object) (*renderedKubeObject, error) {
+	object, err := toKubeObjectMap(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "convert %s %s", kind, obj.GetName())
+	}
+	return &renderedKubeObject{
+		apiVersion: apiVersion,
+		kind:       kind,
+		namespace:  obj.GetNamespace(),
+		name:       obj.GetName(),
+		source:     obj,
+		object:     object,
+	}, nil
+}
+
+// render renders the kubernetes objects of the deployment target, nothing is fetched from the cluster
+func (s *deploymentPreviewService) render(ctx context.Context, deploymentTarget *models.DeploymentTarget, deployOption *models.DeployOption) ([]*renderedKubeObject, error) {
+	res := make([]*renderedKubeObject, 0)
+
+	kubeBentoDeployment, err := KubeBentoDeploymentService.Render(ctx, deploymentTarget)
+	if err != nil {
+		return nil, errors.Wrap(err, "render kube bento deployment")
+	}
+	apiVersion := kubeBentoDeployment.(metav1.Type).GetAPIVersion()
+	obj, err := newRenderedKubeObject(apiVersion, "BentoDeployment", kubeBentoDeployment)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, obj)
+
+	kubeService, err := KubeServiceService.DeploymentTargetToKubeService(ctx, deploymentTarget, deployOption)
+	if err != nil {
+		return nil, errors.Wrap(err, "render kube service")
+	}
+	obj, err = newRenderedKubeObject("v1", "Service", kubeService)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, obj)
+
+	kubeHPA, err := KubeHPAService.DeploymentTargetToKubeHPA(ctx, deploymentTarget, deployOption)
+	if err != nil {
+		return nil, errors.Wrap(err, "render kube hpa")
+	}
+	if kubeHPA != nil {
+		obj, err = newRenderedKubeObject("autoscaling/v2beta2", "HorizontalPodAutoscaler", kubeHPA)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, obj)
+	}
+
+	// only the ingresses managed by yatai are rendered, the others are managed by the bento deployment CR
+	managesKubeIngresses, err := DeploymentTargetService.ManagesKubeIngresses(ctx, deploymentTarget)
+	if err != nil {
+		return nil, err
+	}
+	if managesKubeIngresses {
+		kubeIngressObjects, err := KubeIngressService.ToKubeObjects(ctx, deploymentTarget, deployOption)
+		if err != nil {
+			return nil, errors.Wrap(err, "render kube ingresses")
+		}
+		for _, kubeIngressObject := range kubeIngressObjects {
+			obj, err = newRenderedKubeObject(kubeIngressObject.GetAPIVersion(), kubeIngressObject.GetKind(), kubeIngressObject)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, obj)
+		}
+	}
+
+	return res, nil
+}
+
+// getComparableKubeObject keeps the fields which are rendered by yatai,
+// the status and the metadata maintained by kubernetes are not comparable
+func getComparableKubeObject(object map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{})
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		comparableMetadata := make(map[string]interface{})
+		for _, key := range []string{"labels", "annotations"} {
+			if v, ok := metadata[key]; ok {
+				comparableMetadata[key] = v
+			}
+		}
+		res["metadata"] = comparableMetadata
+	}
+	if spec, ok := object["spec"]; ok {
+		res["spec"] = spec
+	}
+	return res
+}
+
+func joinKubeObjectFieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return fmt.Sprintf("%s.%s", path, key)
+}
+
+// diffKubeObjectFields appends the differences between old and new to diffs,
+// the fields which only exist in old are ignored if ignoreRemoved is true, which is used to skip the defaults filled by kubernetes
+func diffKubeObjectFields(path string, old, new interface{}, ignoreRemoved bool, diffs *[]*KubeObjectFieldDiff) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for key := range oldMap {
+			keys = append(keys, key)
+		}
+		for key := range newMap {
+			if _, ok := oldMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			oldValue, inOld := oldMap[key]
+			newValue, inNew := newMap[key]
+			fieldPath := joinKubeObjectFieldPath(path, key)
+			switch {
+			case inOld && !inNew:
+				if !ignoreRemoved {
+					*diffs = append(*diffs, &KubeObjectFieldDiff{
+						Path:      fieldPath,
+						Operation: KubeObjectFieldDiffOperationRemove,
+						Old:       oldValue,
+					})
+				}
+			case !inOld && inNew:
+				*diffs = append(*diffs, &KubeObjectFieldDiff{
+					Path:      fieldPath,
+					Operation: KubeObjectFieldDiffOperationAdd,
+					New:       newValue,
+				})
+			default:
+				diffKubeObjectFields(fieldPath, oldValue, newValue, ignoreRemoved, diffs)
+			}
+		}
+		return
+	}
+	oldSlice, oldIsSlice := old.([]interface{})
+	newSlice, newIsSlice := new.([]interface{})
+	if oldIsSlice && newIsSlice && len(oldSlice) == len(newSlice) {
+		for idx := range oldSlice {
+			diffKubeObjectFields(fmt.Sprintf("%s[%d]", path, idx), oldSlice[idx], newSlice[idx], ignoreRemoved, diffs)
+		}
+		return
+	}
+	if !reflect.DeepEqual(old, new) {
+		*diffs = append(*diffs, &KubeObjectFieldDiff{
+			Path:      path,
+			Operation: KubeObjectFieldDiffOperationReplace,
+			Old:       old,
+			New:       new,
+		})
+	}
+}
+
+func diffKubeObjects(old, new map[string]interface{}, ignoreRemoved bool) (KubeObjectChangeType, []*KubeObjectFieldDiff) {
+	diffs := make([]*KubeObjectFieldDiff, 0)
+	if old == nil {
+		return KubeObjectChangeTypeCreate, diffs
+	}
+	diffKubeObjectFields("", getComparableKubeObject(old), getComparableKubeObject(new), ignoreRemoved, &diffs)
+	if len(diffs) == 0 {
+		return KubeObjectChangeTypeUnchanged, diffs
+	}
+	return KubeObjectChangeTypeUpdate, diffs
+}
+
+// Preview renders the kubernetes objects of the deployment targets of the deployment revision which is not deployed yet,
+// and compares them with the objects of the active revisions of the deployment.
+// It only reads the database, so it can run in the transaction which creates the revision; call DiffLive after the transaction.
+func (s *deploymentPreviewService) Preview(ctx context.Context, deploymentRevision *models.DeploymentRevision, deploymentTargets []*models.DeploymentTarget) ([]*KubeObjectPreview, error) {
+	deployOption, err := DeploymentRevisionService.GetDeployOption(ctx, deploymentRevision, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "get deploy option")
+	}
+
+	objects := make([]*renderedKubeObject, 0)
+	for _, deploymentTarget := range deploymentTargets {
+		objects_, err := s.render(ctx, deploymentTarget, deployOption)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, objects_...)
+	}
+
+	status := modelschemas.DeploymentRevisionStatusActive
+	activeDeploymentRevisions, _, err := DeploymentRevisionService.List(ctx, ListDeploymentRevisionOption{
+		DeploymentId: utils.UintPtr(deploymentRevision.DeploymentId),
+		Status:       &status,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list active deployment revisions")
+	}
+	activeObjects := make(map[string]*renderedKubeObject)
+	activeObjectKeys := make([]string, 0)
+	for _, activeDeploymentRevision := range activeDeploymentRevisions {
+		if activeDeploymentRevision.ID == deploymentRevision.ID {
+			continue
+		}
+		activeDeploymentTargets, _, err := DeploymentTargetService.List(ctx, ListDeploymentTargetOption{
+			DeploymentRevisionId: utils.UintPtr(activeDeploymentRevision.ID),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "list active deployment targets")
+		}
+		for _, activeDeploymentTarget := range activeDeploymentTargets {
+			objects_, err := s.render(ctx, activeDeploymentTarget, deployOption)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range objects_ {
+				if _, ok := activeObjects[obj.key()]; !ok {
+					activeObjectKeys = append(activeObjectKeys, obj.key())
+				}
+				activeObjects[obj.key()] = obj
+			}
+		}
+	}
+
+	res := make([]*KubeObjectPreview, 0, len(objects))
+	rendered := make(map[string]struct{}, len(objects))
+	for _, obj := range objects {
+		rendered[obj.key()] = struct{}{}
+		preview := &KubeObjectPreview{
+			ApiVersion: obj.apiVersion,
+			Kind:       obj.kind,
+			Namespace:  obj.namespace,
+			Name:       obj.name,
+			Object:     obj.object,
+			source:     obj.source,
+		}
+		var activeObject map[string]interface{}
+		if activeObj, ok := activeObjects[obj.key()]; ok {
+			activeObject = activeObj.object
+		}
+		preview.ActiveRevisionChange, preview.ActiveRevisionDiffs = diffKubeObjects(activeObject, obj.object, false)
+		res = append(res, preview)
+	}
+	// the objects of the active revision which the update no longer renders
+	for _, key := range activeObjectKeys {
+		if _, ok := rendered[key]; ok {
+			continue
+		}
+		obj := activeObjects[key]
+		res = append(res, &KubeObjectPreview{
+			ApiVersion:           obj.apiVersion,
+			Kind:                 obj.kind,
+			Namespace:            obj.namespace,
+			Name:                 obj.name,
+			ActiveRevisionChange: KubeObjectChangeTypeDelete,
+			ActiveRevisionDiffs:  make([]*KubeObjectFieldDiff, 0),
+		})
+	}
+	return res, nil
+}
+
+// getLiveKubeObject returns nil if the object of the preview does not exist in the cluster,
+// the rendered bento deployment CR is merged with the live CR just like Deploy does
+func (s *deploymentPreviewService) getLiveKubeObject(ctx context.Context, deployment *models.Deployment, preview *KubeObjectPreview) (map[string]interface{}, error) {
+	if preview.Kind == "BentoDeployment" && preview.source != nil {
+		live, err := KubeBentoDeploymentService.MergeLive(ctx, deployment, preview.source)
+		if err != nil || live == nil {
+			return nil, err
+		}
+		preview.Object, err = toKubeObjectMap(preview.source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "convert %s %s", preview.Kind, preview.Name)
+		}
+		return toKubeObjectMap(live)
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(preview.ApiVersion)
+	obj.SetKind(preview.Kind)
+	obj.SetNamespace(preview.Namespace)
+	obj.SetName(preview.Name)
+	live, err := KubeIngressService.GetLiveKubeObject(ctx, deployment, obj)
+	if err != nil || live == nil {
+		return nil, err
+	}
+	return live.Object, nil
+}
+
+// DiffLive compares the previews with the live objects in the cluster, the fields filled by kubernetes in the live objects are not reported.
+// It talks to the cluster, so it must not be called in a database transaction.
+func (s *deploymentPreviewService) DiffLive(ctx context.Context, deployment *models.Deployment, previews []*KubeObjectPreview) error {
+	for _, preview := range previews {
+		live, err := s.getLiveKubeObject(ctx, deployment, preview)
+		if err != nil {
+			return errors.Wrapf(err, "get live kube %s %s", preview.Kind, preview.Name)
+		}
+		if preview.Object != nil {
+			preview.LiveChange, preview.LiveDiffs = diffKubeObjects(live, preview.Object, true)
+			continue
+		}
+		// the object is removed by the update, it is only deleted from the cluster if it still exists
+		preview.LiveDiffs = make([]*KubeObjectFieldDiff, 0)
+		if live == nil {
+			preview.LiveChange = KubeObjectChangeTypeUnchanged
+		} else {
+			preview.LiveChange = KubeObjectChangeTypeDelete
+		}
+	}
+	return nil
+}
@@ -148,7 +148,7 @@ func (s *deploymentRevisionService) GenerateIngressHost(ctx context.Context, dep
 	if err != nil {
 		return "", err
 	}
-	return DeploymentService.GenerateDefaultHostname(ctx, deployment)
+	return DeploymentService.GenerateIngressHost(ctx, deployment)
 }
 
 func (s *deploymentRevisionService) GetKubeOwnerReferenceName(ctx context.Context, deploymentRevision *models.DeploymentRevision) (string, error) {
@@ -350,6 +350,8 @@ func (s *deploymentRevisionService) Deploy(ctx context.Context, deploymentRevisi
 		return
 	}
 
+	go DeploymentStatusWatcherService.Watch(cluster, kubeNs)
+
 	deployOption, err := s.GetDeployOption(ctx, deploymentRevision, force)
 	if err != nil {
 		return
@@ -375,6 +377,96 @@ func (s *deploymentRevisionService) Deploy(ctx context.Context, deploymentRevisi
 	return nil
 }
 
+// Redeploy applies the targets of the deployment revision again without creating a new revision,
+// e.g. to route the changed ingress host of the deployment
+func (s *deploymentRevisionService) Redeploy(ctx context.Context, deploymentRevision *models.DeploymentRevision) error {
+	deployOption, err := s.GetDeployOption(ctx, deploymentRevision, false)
+	if err != nil {
+		return err
+	}
+	deploymentTargets, _, err := DeploymentTargetService.List(ctx, ListDeploymentTargetOption{
+		DeploymentRevisionId: utils.UintPtr(deploymentRevision.ID),
+	})
+	if err != nil {
+		return errors.Wrap(err, "list deployment targets")
+	}
+	for _, deploymentTarget := range deploymentTargets {
+		_, err = DeploymentTargetService.Deploy(ctx, deploymentTarget, deployOption)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback clones the targets of an inactive deployment revision into a new revision and deploys it
+func (s *deploymentRevisionService) Rollback(ctx context.Context, deploymentRevision *models.DeploymentRevision, creatorId uint) (*models.DeploymentRevision, error) {
+	oldDeploymentTargets, _, err := DeploymentTargetService.List(ctx, ListDeploymentTargetOption{
+		DeploymentRevisionId: utils.UintPtr(deploymentRevision.ID),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list deployment targets")
+	}
+	if err = validateRollbackDeploymentRevision(deploymentRevision, oldDeploymentTargets); err != nil {
+		return nil, err
+	}
+
+	newDeploymentRevision, err := s.Create(ctx, CreateDeploymentRevisionOption{
+		CreatorId:    creatorId,
+		DeploymentId: deploymentRevision.DeploymentId,
+		Status:       modelschemas.DeploymentRevisionStatusActive,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create deployment revision")
+	}
+
+	deploymentTargets := make([]*models.DeploymentTarget, 0, len(oldDeploymentTargets))
+	for _, oldDeploymentTarget := range oldDeploymentTargets {
+		deploymentTarget, err := DeploymentTargetService.Create(ctx, CreateDeploymentTargetOption{
+			CreatorId:            creatorId,
+			DeploymentId:         oldDeploymentTarget.DeploymentId,
+			DeploymentRevisionId: newDeploymentRevision.ID,
+			BentoId:              oldDeploymentTarget.BentoId,
+			Type:                 oldDeploymentTarget.Type,
+			CanaryRules:          oldDeploymentTarget.CanaryRules,
+			Config:               copyDeploymentTargetConfig(oldDeploymentTarget.Config),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "create deployment target")
+		}
+		deploymentTargets = append(deploymentTargets, deploymentTarget)
+	}
+
+	err = s.Deploy(ctx, newDeploymentRevision, deploymentTargets, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "deploy deployment revision")
+	}
+
+	return newDeploymentRevision, nil
+}
+
+// validateRollbackDeploymentRevision checks that the deployment revision could be rolled back to
+func validateRollbackDeploymentRevision(deploymentRevision *models.DeploymentRevision, deploymentTargets []*models.DeploymentTarget) error {
+	if deploymentRevision.Status == modelschemas.DeploymentRevisionStatusActive {
+		return errors.Errorf("deployment revision %s is already active", deploymentRevision.Uid)
+	}
+	if len(deploymentTargets) == 0 {
+		return errors.Errorf("deployment revision %s has no deployment targets", deploymentRevision.Uid)
+	}
+	return nil
+}
+
+// copyDeploymentTargetConfig copies the config of an old deployment target without its kube resource, which belongs to the old revision
+func copyDeploymentTargetConfig(config *modelschemas.DeploymentTargetConfig) *modelschemas.DeploymentTargetConfig {
+	if config == nil {
+		return nil
+	}
+	config_ := *config
+	config_.KubeResourceUid = ""
+	config_.KubeResourceVersion = ""
+	return &config_
+}
+
 func (s *deploymentRevisionService) GetKubeCliSet(ctx context.Context, deploymentRevision *models.DeploymentRevision) (kubeCli *kubernetes.Clientset, restConfig *rest.Config, err error) {
 	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentRevision)
 	if err != nil {
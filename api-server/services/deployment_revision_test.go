@@ -0,0 +1,55 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+func TestValidateRollbackDeploymentRevision(t *testing.T) {
+	deploymentTargets := []*models.DeploymentTarget{{}}
+	cases := []struct {
+		name              string
+		status            modelschemas.DeploymentRevisionStatus
+		deploymentTargets []*models.DeploymentTarget
+		wantErr           bool
+	}{
+		{"inactive revision", modelschemas.DeploymentRevisionStatusInactive, deploymentTargets, false},
+		{"active revision", modelschemas.DeploymentRevisionStatusActive, deploymentTargets, true},
+		{"revision without targets", modelschemas.DeploymentRevisionStatusInactive, nil, true},
+	}
+	for _, c := range cases {
+		deploymentRevision := &models.DeploymentRevision{Status: c.status}
+		deploymentRevision.Uid = "rev-1"
+		err := validateRollbackDeploymentRevision(deploymentRevision, c.deploymentTargets)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%s: the error is %v, expected an error: %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestCopyDeploymentTargetConfig(t *testing.T) {
+	if config := copyDeploymentTargetConfig(nil); config != nil {
+		t.Fatalf("the config of a target without config is %+v", config)
+	}
+
+	oldConfig := &modelschemas.DeploymentTargetConfig{
+		KubeResourceUid:     "uid-1",
+		KubeResourceVersion: "42",
+		HPAConf: &modelschemas.DeploymentTargetHPAConf{
+			MinReplicas: func() *int32 { v := int32(2); return &v }(),
+		},
+	}
+	config := copyDeploymentTargetConfig(oldConfig)
+	if config.KubeResourceUid != "" || config.KubeResourceVersion != "" {
+		t.Fatalf("the kube resource of the old revision is kept: %q %q", config.KubeResourceUid, config.KubeResourceVersion)
+	}
+	if !reflect.DeepEqual(config.HPAConf, oldConfig.HPAConf) {
+		t.Fatalf("the hpa conf is %+v, expected %+v", config.HPAConf, oldConfig.HPAConf)
+	}
+	if oldConfig.KubeResourceUid != "uid-1" || oldConfig.KubeResourceVersion != "42" {
+		t.Fatal("the config of the old deployment target is modified")
+	}
+}
@@ -0,0 +1,655 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	commonconsts "github.com/bentoml/yatai-common/consts"
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/consts"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+const (
+	DefaultDeploymentRolloutStepIntervalSeconds = 60
+
+	// a step is failed if the canary pods are still not ready after this many step intervals
+	deploymentRolloutStepTimeoutFactor = 10
+
+	// a claimed rollout is not advanced by anyone else until the claim expires, it matches the timeout of the advance cron run
+	deploymentRolloutClaimTimeout = 5 * time.Minute
+)
+
+var DefaultDeploymentRolloutSteps = []uint{10, 25, 50, 100}
+
+type deploymentRolloutService struct{}
+
+var DeploymentRolloutService = deploymentRolloutService{}
+
+func (s *deploymentRolloutService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.DeploymentRollout{})
+}
+
+type CreateDeploymentRolloutOption struct {
+	CreatorId           uint
+	DeploymentId        uint
+	Steps               []uint
+	StepIntervalSeconds uint
+}
+
+type ListDeploymentRolloutOption struct {
+	BaseListOption
+	DeploymentId *uint
+	Statuses     *[]models.DeploymentRolloutStatus
+}
+
+func validateDeploymentRolloutSteps(steps []uint) ([]uint, error) {
+	if len(steps) == 0 {
+		return DefaultDeploymentRolloutSteps, nil
+	}
+	var last uint
+	for _, step := range steps {
+		if step == 0 || step > 100 {
+			return nil, errors.Errorf("rollout step weight should be between 1 and 100: %d", step)
+		}
+		if step <= last {
+			return nil, errors.New("rollout step weights should be increasing")
+		}
+		last = step
+	}
+	if last != 100 {
+		steps = append(steps, 100)
+	}
+	return steps, nil
+}
+
+// Create starts a progressive rollout from the stable target to the canary target of the active revision
+func (s *deploymentRolloutService) Create(ctx context.Context, opt CreateDeploymentRolloutOption) (*models.DeploymentRollout, error) {
+	steps, err := validateDeploymentRolloutSteps(opt.Steps)
+	if err != nil {
+		return nil, err
+	}
+	stepIntervalSeconds := opt.StepIntervalSeconds
+	if stepIntervalSeconds == 0 {
+		stepIntervalSeconds = DefaultDeploymentRolloutStepIntervalSeconds
+	}
+
+	_, total, err := s.List(ctx, ListDeploymentRolloutOption{
+		DeploymentId: utils.UintPtr(opt.DeploymentId),
+		Statuses:     &[]models.DeploymentRolloutStatus{models.DeploymentRolloutStatusRunning},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list running rollouts")
+	}
+	if total > 0 {
+		return nil, errors.New("there is already a running rollout of this deployment")
+	}
+
+	activeStatus := modelschemas.DeploymentRevisionStatusActive
+	deploymentRevisions, _, err := DeploymentRevisionService.List(ctx, ListDeploymentRevisionOption{
+		DeploymentId: utils.UintPtr(opt.DeploymentId),
+		Status:       &activeStatus,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list active deployment revisions")
+	}
+	if len(deploymentRevisions) == 0 {
+		return nil, errors.New("the deployment has no active revision")
+	}
+	deploymentRevision := deploymentRevisions[0]
+	canaryType := modelschemas.DeploymentTargetTypeCanary
+	canaryTargets, _, err := DeploymentTargetService.List(ctx, ListDeploymentTargetOption{
+		DeploymentRevisionId: utils.UintPtr(deploymentRevision.ID),
+		Type:                 &canaryType,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list canary deployment targets")
+	}
+	if len(canaryTargets) != 1 {
+		return nil, errors.Errorf("the active revision should have exactly one canary target to roll out, but got %d", len(canaryTargets))
+	}
+	canaryTarget := canaryTargets[0]
+
+	stepInts := make([]int64, 0, len(steps))
+	for _, step := range steps {
+		stepInts = append(stepInts, int64(step))
+	}
+	now := time.Now()
+	nextStepAt := now.Add(time.Duration(stepIntervalSeconds) * time.Second)
+	rollout := models.DeploymentRollout{
+		CreatorAssociate: models.CreatorAssociate{
+			CreatorId: opt.CreatorId,
+		},
+		DeploymentAssociate: models.DeploymentAssociate{
+			DeploymentId: opt.DeploymentId,
+		},
+		DeploymentRevisionAssociate: models.DeploymentRevisionAssociate{
+			DeploymentRevisionId: deploymentRevision.ID,
+		},
+		DeploymentTargetAssociate: models.DeploymentTargetAssociate{
+			DeploymentTargetId: canaryTarget.ID,
+		},
+		Status:              models.DeploymentRolloutStatusRunning,
+		Steps:               stepInts,
+		StepIntervalSeconds: stepIntervalSeconds,
+		StepStartedAt:       &now,
+		NextStepAt:          &nextStepAt,
+	}
+	err = mustGetSession(ctx).Create(&rollout).Error
+	if err != nil {
+		return nil, err
+	}
+	rollout.SetAssociatedDeploymentRevisionCache(deploymentRevision)
+	rollout.SetAssociatedDeploymentTargetCache(canaryTarget)
+
+	err = s.setCanaryWeight(ctx, &rollout, steps[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "set canary weight")
+	}
+	s.createEvent(ctx, &rollout, modelschemas.EventStatusSuccess, fmt.Sprintf("rollout step 1/%d: canary weight %d%%", len(steps), steps[0]))
+	return &rollout, nil
+}
+
+func (s *deploymentRolloutService) Get(ctx context.Context, id uint) (*models.DeploymentRollout, error) {
+	var rollout models.DeploymentRollout
+	err := getBaseQuery(ctx, s).Where("id = ?", id).First(&rollout).Error
+	if err != nil {
+		return nil, err
+	}
+	if rollout.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &rollout, nil
+}
+
+func (s *deploymentRolloutService) GetByUid(ctx context.Context, uid string) (*models.DeploymentRollout, error) {
+	var rollout models.DeploymentRollout
+	err := getBaseQuery(ctx, s).Where("uid = ?", uid).First(&rollout).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "get deployment rollout %s", uid)
+	}
+	if rollout.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &rollout, nil
+}
+
+func (s *deploymentRolloutService) List(ctx context.Context, opt ListDeploymentRolloutOption) ([]*models.DeploymentRollout, uint, error) {
+	query := getBaseQuery(ctx, s)
+	if opt.DeploymentId != nil {
+		query = query.Where("deployment_rollout.deployment_id = ?", *opt.DeploymentId)
+	}
+	if opt.Statuses != nil {
+		query = query.Where("deployment_rollout.status in (?)", *opt.Statuses)
+	}
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	query = opt.BindQueryWithLimit(query)
+	rollouts := make([]*models.DeploymentRollout, 0)
+	err = query.Order("deployment_rollout.id DESC").Find(&rollouts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return rollouts, uint(total), err
+}
+
+// ListDue lists the running rollouts whose current step should be checked
+func (s *deploymentRolloutService) ListDue(ctx context.Context) ([]*models.DeploymentRollout, error) {
+	rollouts := make([]*models.DeploymentRollout, 0)
+	err := getBaseQuery(ctx, s).Where("status = ?", models.DeploymentRolloutStatusRunning).Where("next_step_at <= ?", time.Now()).Order("id ASC").Find(&rollouts).Error
+	return rollouts, err
+}
+
+func (s *deploymentRolloutService) update(ctx context.Context, rollout *models.DeploymentRollout, updaters map[string]interface{}) error {
+	return s.getBaseDB(ctx).Where("id = ?", rollout.ID).Updates(updaters).Error
+}
+
+func (s *deploymentRolloutService) createEvent(ctx context.Context, rollout *models.DeploymentRollout, status modelschemas.EventStatus, operationName string) {
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, rollout)
+	if err != nil {
+		logrus.Errorf("get deployment rollout %s associated deployment: %s", rollout.Uid, err.Error())
+		return
+	}
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		logrus.Errorf("get deployment %s associated cluster: %s", deployment.Name, err.Error())
+		return
+	}
+	_, err = EventService.Create(ctx, CreateEventOption{
+		CreatorId:      rollout.CreatorId,
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		Status:         status,
+		OperationName:  operationName,
+	})
+	if err != nil {
+		logrus.Errorf("create event failed: %v", err)
+	}
+}
+
+// setCanaryWeight replaces the weight rule of the canary target and applies it to the canary ingress
+func (s *deploymentRolloutService) setCanaryWeight(ctx context.Context, rollout *models.DeploymentRollout, weight uint) error {
+	canaryTarget, err := DeploymentTargetService.GetAssociatedDeploymentTarget(ctx, rollout)
+	if err != nil {
+		return errors.Wrap(err, "get associated deployment target")
+	}
+	deploymentRevision, err := DeploymentRevisionService.GetAssociatedDeploymentRevision(ctx, rollout)
+	if err != nil {
+		return errors.Wrap(err, "get associated deployment revision")
+	}
+
+	canaryRules := make(modelschemas.DeploymentTargetCanaryRules, 0)
+	if canaryTarget.CanaryRules != nil {
+		for _, rule := range *canaryTarget.CanaryRules {
+			if rule.Type == modelschemas.DeploymentTargetCanaryRuleTypeWeight {
+				continue
+			}
+			canaryRules = append(canaryRules, rule)
+		}
+	}
+	weight_ := weight
+	canaryRules = append(canaryRules, &modelschemas.DeploymentTargetCanaryRule{
+		Type:   modelschemas.DeploymentTargetCanaryRuleTypeWeight,
+		Weight: &weight_,
+	})
+	canaryRulesPtr := &canaryRules
+	canaryTarget, err = DeploymentTargetService.Update(ctx, canaryTarget, UpdateDeploymentTargetOption{
+		CanaryRules: &canaryRulesPtr,
+	})
+	if err != nil {
+		return errors.Wrap(err, "update canary rules")
+	}
+
+	deployOption, err := DeploymentRevisionService.GetDeployOption(ctx, deploymentRevision, false)
+	if err != nil {
+		return errors.Wrap(err, "get deploy option")
+	}
+	return KubeIngressService.DeployDeploymentTargetAsKubeIngresses(ctx, canaryTarget, deployOption)
+}
+
+// checkCanary returns whether the canary pods are healthy, a non-empty reason means the gate is failed
+func (s *deploymentRolloutService) checkCanary(ctx context.Context, rollout *models.DeploymentRollout) (ready bool, reason string, err error) {
+	canaryTarget, err := DeploymentTargetService.GetAssociatedDeploymentTarget(ctx, rollout)
+	if err != nil {
+		err = errors.Wrap(err, "get associated deployment target")
+		return
+	}
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, rollout)
+	if err != nil {
+		err = errors.Wrap(err, "get associated deployment")
+		return
+	}
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		err = errors.Wrap(err, "get associated cluster")
+		return
+	}
+	namespace := DeploymentService.GetKubeNamespace(deployment)
+	kubeName, err := KubeBentoDeploymentService.GetKubeName(ctx, canaryTarget)
+	if err != nil {
+		return
+	}
+
+	_, podLister, err := GetPodInformer(ctx, cluster, namespace)
+	if err != nil {
+		return
+	}
+	selector, err := labels.Parse(fmt.Sprintf("%s = %s", commonconsts.KubeLabelYataiBentoDeployment, kubeName))
+	if err != nil {
+		return
+	}
+	pods_, err := podLister.List(selector)
+	if err != nil {
+		return
+	}
+	if len(pods_) == 0 {
+		return
+	}
+	pods := make([]apiv1.Pod, 0, len(pods_))
+	podUids := make(map[types.UID]struct{}, len(pods_))
+	for _, p := range pods_ {
+		pods = append(pods, *p)
+		podUids[p.UID] = struct{}{}
+	}
+
+	events, err := KubeEventService.ListAllKubeEvents(ctx, cluster, namespace, func(event *apiv1.Event) bool {
+		_, ok := podUids[event.InvolvedObject.UID]
+		return ok
+	})
+	if err != nil {
+		return
+	}
+
+	ready, reason = getCanaryHealth(rollout.StepStartedAt, pods, events)
+	return
+}
+
+// getCanaryHealth is the health gate of the current step, the canary is ready if all its pods are running,
+// and the gate is failed with a reason if a pod has failed or has raised a warning event since the step started
+func getCanaryHealth(stepStartedAt *time.Time, pods []apiv1.Pod, events []apiv1.Event) (ready bool, reason string) {
+	if len(pods) == 0 {
+		return
+	}
+
+	// warning events raised before the current step started are not taken into account
+	for _, event := range KubeEventService.FilterWarningKubeEvents(events) {
+		if stepStartedAt != nil && event.LastTimestamp.Time.Before(*stepStartedAt) {
+			continue
+		}
+		reason = fmt.Sprintf("pod %s has warning event %s: %s", event.InvolvedObject.Name, event.Reason, event.Message)
+		return
+	}
+
+	ready = true
+	warningsMapping := KubeEventService.GetKubePodsWarningEventsMapping(events, pods)
+	for _, pod := range pods {
+		status := KubePodService.GetKubePodStatus(pod, warningsMapping[pod.UID])
+		switch status.Status {
+		case modelschemas.KubePodActualStatusFailed, modelschemas.KubePodActualStatusUnknown:
+			ready = false
+			reason = fmt.Sprintf("pod %s is %s", pod.Name, status.Status)
+			return
+		case modelschemas.KubePodActualStatusRunning, modelschemas.KubePodActualStatusSucceeded:
+		default:
+			ready = false
+		}
+	}
+	return
+}
+
+// isDeploymentRolloutStepTimedOut reports whether the canary has not become ready within the step timeout
+func isDeploymentRolloutStepTimedOut(rollout *models.DeploymentRollout, now time.Time) bool {
+	stepInterval := time.Duration(rollout.StepIntervalSeconds) * time.Second
+	return rollout.StepStartedAt != nil && now.Sub(*rollout.StepStartedAt) > stepInterval*deploymentRolloutStepTimeoutFactor
+}
+
+// claim pushes the next step time of the running rollout forward, it only succeeds if nobody else has touched the rollout since it was loaded,
+// so overlapping cron runs, replicas and cancellations never drive the same rollout concurrently
+func (s *deploymentRolloutService) claim(ctx context.Context, rollout *models.DeploymentRollout) (bool, error) {
+	leaseUntil := time.Now().Add(deploymentRolloutClaimTimeout)
+	query := s.getBaseDB(ctx).Where("id = ?", rollout.ID).Where("status = ?", models.DeploymentRolloutStatusRunning)
+	if rollout.NextStepAt == nil {
+		query = query.Where("next_step_at is null")
+	} else {
+		query = query.Where("next_step_at = ?", *rollout.NextStepAt)
+	}
+	res := query.Updates(map[string]interface{}{
+		"next_step_at": leaseUntil,
+	})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected != 1 {
+		return false, nil
+	}
+	rollout.NextStepAt = &leaseUntil
+	return true, nil
+}
+
+// isRevisionActive reports whether the revision of the rollout is still the active revision of the deployment
+func (s *deploymentRolloutService) isRevisionActive(ctx context.Context, rollout *models.DeploymentRollout) (bool, error) {
+	activeStatus := modelschemas.DeploymentRevisionStatusActive
+	deploymentRevisions, _, err := DeploymentRevisionService.List(ctx, ListDeploymentRevisionOption{
+		DeploymentId: utils.UintPtr(rollout.DeploymentId),
+		Status:       &activeStatus,
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "list active deployment revisions")
+	}
+	for _, deploymentRevision := range deploymentRevisions {
+		if deploymentRevision.ID == rollout.DeploymentRevisionId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// abortIfSuperseded finishes the rollout without touching the kube resources if the deployment has been redeployed since the rollout started,
+// the new revision owns the resources of the deployment now
+func (s *deploymentRolloutService) abortIfSuperseded(ctx context.Context, rollout *models.DeploymentRollout) (bool, *models.DeploymentRollout, error) {
+	active, err := s.isRevisionActive(ctx, rollout)
+	if err != nil {
+		return false, nil, err
+	}
+	if active {
+		return false, rollout, nil
+	}
+	logrus.Infof("aborting deployment rollout %s: the deployment revision is no longer active", rollout.Uid)
+	s.createEvent(ctx, rollout, modelschemas.EventStatusFailed, "rollout abort")
+	rollout, err = s.finish(ctx, rollout, models.DeploymentRolloutStatusFailed, "the deployment was redeployed during the rollout")
+	return true, rollout, err
+}
+
+// Advance checks the health of the canary and moves the rollout to the next step, promotes it or rolls it back
+func (s *deploymentRolloutService) Advance(ctx context.Context, rollout *models.DeploymentRollout) (*models.DeploymentRollout, error) {
+	if rollout.Status != models.DeploymentRolloutStatusRunning {
+		return rollout, nil
+	}
+
+	dueAt := rollout.NextStepAt
+	claimed, err := s.claim(ctx, rollout)
+	if err != nil {
+		return nil, errors.Wrap(err, "claim deployment rollout")
+	}
+	if !claimed {
+		return rollout, nil
+	}
+
+	aborted, rollout, err := s.abortIfSuperseded(ctx, rollout)
+	if err != nil || aborted {
+		return rollout, err
+	}
+
+	ready, reason, err := s.checkCanary(ctx, rollout)
+	if err != nil {
+		return nil, errors.Wrap(err, "check canary")
+	}
+	if reason != "" {
+		return s.rollback(ctx, rollout, reason)
+	}
+
+	now := time.Now()
+	stepInterval := time.Duration(rollout.StepIntervalSeconds) * time.Second
+	if !ready {
+		if isDeploymentRolloutStepTimedOut(rollout, now) {
+			return s.rollback(ctx, rollout, "timed out waiting for the canary pods to be ready")
+		}
+		// release the claim, the rollout is checked again in the next cron run
+		err = s.update(ctx, rollout, map[string]interface{}{
+			"next_step_at": dueAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		rollout.NextStepAt = dueAt
+		return rollout, nil
+	}
+
+	nextStep := rollout.CurrentStep + 1
+	if int(nextStep) >= len(rollout.Steps) {
+		return s.promote(ctx, rollout)
+	}
+
+	weight := uint(rollout.Steps[nextStep])
+	err = s.setCanaryWeight(ctx, rollout, weight)
+	if err != nil {
+		return nil, errors.Wrap(err, "set canary weight")
+	}
+	nextStepAt := now.Add(stepInterval)
+	err = s.update(ctx, rollout, map[string]interface{}{
+		"current_step":    nextStep,
+		"step_started_at": now,
+		"next_step_at":    nextStepAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rollout.CurrentStep = nextStep
+	rollout.StepStartedAt = &now
+	rollout.NextStepAt = &nextStepAt
+	s.createEvent(ctx, rollout, modelschemas.EventStatusSuccess, fmt.Sprintf("rollout step %d/%d: canary weight %d%%", nextStep+1, len(rollout.Steps), weight))
+	return rollout, nil
+}
+
+// Cancel stops the running rollout and rolls the traffic back to the stable target
+func (s *deploymentRolloutService) Cancel(ctx context.Context, rollout *models.DeploymentRollout, reason string) (*models.DeploymentRollout, error) {
+	if rollout.Status != models.DeploymentRolloutStatusRunning {
+		return nil, errors.Errorf("deployment rollout %s is not running", rollout.Uid)
+	}
+	claimed, err := s.claim(ctx, rollout)
+	if err != nil {
+		return nil, errors.Wrap(err, "claim deployment rollout")
+	}
+	if !claimed {
+		return nil, errors.Errorf("deployment rollout %s is being advanced, please try again later", rollout.Uid)
+	}
+	aborted, rollout, err := s.abortIfSuperseded(ctx, rollout)
+	if err != nil || aborted {
+		return rollout, err
+	}
+	return s.rollback(ctx, rollout, reason)
+}
+
+// deployStable deploys a new revision which only contains the given target as the stable target
+func (s *deploymentRolloutService) deployStable(ctx context.Context, rollout *models.DeploymentRollout, deploymentTarget *models.DeploymentTarget) (err error) {
+	// nolint: ineffassign,staticcheck
+	_, ctx, df, err := startTransaction(ctx)
+	if err != nil {
+		return
+	}
+	defer func() { df(err) }()
+
+	newDeploymentRevision, err := DeploymentRevisionService.Create(ctx, CreateDeploymentRevisionOption{
+		CreatorId:    rollout.CreatorId,
+		DeploymentId: rollout.DeploymentId,
+		Status:       modelschemas.DeploymentRevisionStatusActive,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "create deployment revision")
+		return
+	}
+	newDeploymentTarget, err := DeploymentTargetService.Create(ctx, CreateDeploymentTargetOption{
+		CreatorId:            rollout.CreatorId,
+		DeploymentId:         rollout.DeploymentId,
+		DeploymentRevisionId: newDeploymentRevision.ID,
+		BentoId:              deploymentTarget.BentoId,
+		Type:                 modelschemas.DeploymentTargetTypeStable,
+		Config:               copyDeploymentTargetConfig(deploymentTarget.Config),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "create deployment target")
+		return
+	}
+	err = DeploymentRevisionService.Deploy(ctx, newDeploymentRevision, []*models.DeploymentTarget{newDeploymentTarget}, false)
+	if err != nil {
+		err = errors.Wrap(err, "deploy deployment revision")
+		return
+	}
+	return
+}
+
+// cleanupCanary removes the canary ingress and the canary bento deployment
+func (s *deploymentRolloutService) cleanupCanary(ctx context.Context, rollout *models.DeploymentRollout) error {
+	canaryTarget, err := DeploymentTargetService.GetAssociatedDeploymentTarget(ctx, rollout)
+	if err != nil {
+		return errors.Wrap(err, "get associated deployment target")
+	}
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, rollout)
+	if err != nil {
+		return errors.Wrap(err, "get associated deployment")
+	}
+	deploymentRevision, err := DeploymentRevisionService.GetAssociatedDeploymentRevision(ctx, rollout)
+	if err != nil {
+		return errors.Wrap(err, "get associated deployment revision")
+	}
+	deployOption, err := DeploymentRevisionService.GetDeployOption(ctx, deploymentRevision, false)
+	if err != nil {
+		return errors.Wrap(err, "get deploy option")
+	}
+	err = KubeIngressService.DeleteDeploymentTargetKubeIngresses(ctx, canaryTarget, deployOption)
+	if err != nil {
+		return errors.Wrapf(err, "delete the kube ingresses of deployment %s canary target", deployment.Name)
+	}
+	return KubeBentoDeploymentService.Delete(ctx, canaryTarget)
+}
+
+func (s *deploymentRolloutService) finish(ctx context.Context, rollout *models.DeploymentRollout, status models.DeploymentRolloutStatus, message string) (*models.DeploymentRollout, error) {
+	err := s.update(ctx, rollout, map[string]interface{}{
+		"status":       status,
+		"message":      message,
+		"next_step_at": nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rollout.Status = status
+	rollout.Message = message
+	rollout.NextStepAt = nil
+	return rollout, nil
+}
+
+func (s *deploymentRolloutService) promote(ctx context.Context, rollout *models.DeploymentRollout) (*models.DeploymentRollout, error) {
+	canaryTarget, err := DeploymentTargetService.GetAssociatedDeploymentTarget(ctx, rollout)
+	if err != nil {
+		return nil, errors.Wrap(err, "get associated deployment target")
+	}
+	err = s.deployStable(ctx, rollout, canaryTarget)
+	if err != nil {
+		s.createEvent(ctx, rollout, modelschemas.EventStatusFailed, "rollout promote canary")
+		return s.finish(ctx, rollout, models.DeploymentRolloutStatusFailed, err.Error())
+	}
+	err = s.cleanupCanary(ctx, rollout)
+	if err != nil {
+		logrus.Errorf("cleanup canary of deployment rollout %s: %s", rollout.Uid, err.Error())
+	}
+	s.createEvent(ctx, rollout, modelschemas.EventStatusSuccess, "rollout promote canary")
+	return s.finish(ctx, rollout, models.DeploymentRolloutStatusSucceeded, "")
+}
+
+func (s *deploymentRolloutService) rollback(ctx context.Context, rollout *models.DeploymentRollout, reason string) (*models.DeploymentRollout, error) {
+	logrus.Infof("rolling back deployment rollout %s: %s", rollout.Uid, reason)
+
+	// stop sending traffic to the canary before redeploying the stable target
+	err := s.setCanaryWeight(ctx, rollout, 0)
+	if err != nil {
+		logrus.Errorf("reset canary weight of deployment rollout %s: %s", rollout.Uid, err.Error())
+	}
+
+	stableType := modelschemas.DeploymentTargetTypeStable
+	stableTargets, _, err := DeploymentTargetService.List(ctx, ListDeploymentTargetOption{
+		DeploymentRevisionId: utils.UintPtr(rollout.DeploymentRevisionId),
+		Type:                 &stableType,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list stable deployment targets")
+	}
+	if len(stableTargets) == 0 {
+		s.createEvent(ctx, rollout, modelschemas.EventStatusFailed, "rollout roll back")
+		return s.finish(ctx, rollout, models.DeploymentRolloutStatusFailed, fmt.Sprintf("%s; no stable target to roll back to", reason))
+	}
+	err = s.deployStable(ctx, rollout, stableTargets[0])
+	if err != nil {
+		s.createEvent(ctx, rollout, modelschemas.EventStatusFailed, "rollout roll back")
+		return s.finish(ctx, rollout, models.DeploymentRolloutStatusFailed, fmt.Sprintf("%s; %s", reason, err.Error()))
+	}
+	err = s.cleanupCanary(ctx, rollout)
+	if err != nil {
+		logrus.Errorf("cleanup canary of deployment rollout %s: %s", rollout.Uid, err.Error())
+	}
+	s.createEvent(ctx, rollout, modelschemas.EventStatusFailed, "rollout roll back")
+	return s.finish(ctx, rollout, models.DeploymentRolloutStatusRolledBack, reason)
+}
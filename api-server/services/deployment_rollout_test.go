@@ -0,0 +1,134 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+func TestValidateDeploymentRolloutSteps(t *testing.T) {
+	cases := []struct {
+		steps    []uint
+		expected []uint
+		wantErr  bool
+	}{
+		{steps: nil, expected: DefaultDeploymentRolloutSteps},
+		{steps: []uint{20, 50, 100}, expected: []uint{20, 50, 100}},
+		{steps: []uint{20, 50}, expected: []uint{20, 50, 100}},
+		{steps: []uint{100}, expected: []uint{100}},
+		{steps: []uint{0, 50}, wantErr: true},
+		{steps: []uint{50, 101}, wantErr: true},
+		{steps: []uint{50, 20}, wantErr: true},
+		{steps: []uint{50, 50}, wantErr: true},
+	}
+	for _, c := range cases {
+		steps, err := validateDeploymentRolloutSteps(c.steps)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%v: the error is %v, expected an error: %v", c.steps, err, c.wantErr)
+		}
+		if !c.wantErr && !reflect.DeepEqual(steps, c.expected) {
+			t.Fatalf("%v: the steps are %v, expected %v", c.steps, steps, c.expected)
+		}
+	}
+}
+
+func TestIsDeploymentRolloutStepTimedOut(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name          string
+		stepStartedAt *time.Time
+		expected      bool
+	}{
+		{"step not started", nil, false},
+		{"within the step timeout", func() *time.Time { t := now.Add(-9 * time.Minute); return &t }(), false},
+		{"after the step timeout", func() *time.Time { t := now.Add(-11 * time.Minute); return &t }(), true},
+	}
+	for _, c := range cases {
+		rollout := &models.DeploymentRollout{
+			StepIntervalSeconds: 60,
+			StepStartedAt:       c.stepStartedAt,
+		}
+		if timedOut := isDeploymentRolloutStepTimedOut(rollout, now); timedOut != c.expected {
+			t.Fatalf("%s: the step is timed out: %v, expected %v", c.name, timedOut, c.expected)
+		}
+	}
+}
+
+func newTestCanaryPod(name string, phase apiv1.PodPhase, ready bool) apiv1.Pod {
+	readyStatus := apiv1.ConditionFalse
+	if ready {
+		readyStatus = apiv1.ConditionTrue
+	}
+	return apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			UID:  types.UID(name),
+		},
+		Status: apiv1.PodStatus{
+			Phase: phase,
+			Conditions: []apiv1.PodCondition{
+				{Type: apiv1.PodInitialized, Status: apiv1.ConditionTrue},
+				{Type: apiv1.PodReady, Status: readyStatus},
+			},
+		},
+	}
+}
+
+func newTestCanaryWarningEvent(podName string, at time.Time) apiv1.Event {
+	return apiv1.Event{
+		InvolvedObject: apiv1.ObjectReference{
+			Name: podName,
+			UID:  types.UID(podName),
+		},
+		Type:          apiv1.EventTypeWarning,
+		Reason:        "Unhealthy",
+		Message:       "Readiness probe failed",
+		LastTimestamp: metav1.NewTime(at),
+	}
+}
+
+func TestGetCanaryHealth(t *testing.T) {
+	stepStartedAt := time.Now().Add(-time.Minute)
+	running := newTestCanaryPod("canary-1", apiv1.PodRunning, true)
+	starting := newTestCanaryPod("canary-2", apiv1.PodRunning, false)
+	failed := newTestCanaryPod("canary-3", apiv1.PodFailed, false)
+	cases := []struct {
+		name          string
+		pods          []apiv1.Pod
+		events        []apiv1.Event
+		expectedReady bool
+		failed        bool
+	}{
+		{name: "no pods"},
+		{name: "all pods running", pods: []apiv1.Pod{running}, expectedReady: true},
+		{name: "a pod is starting", pods: []apiv1.Pod{running, starting}},
+		{name: "a pod failed", pods: []apiv1.Pod{running, failed}, failed: true},
+		{
+			name:   "warning event in the current step",
+			pods:   []apiv1.Pod{running},
+			events: []apiv1.Event{newTestCanaryWarningEvent("canary-1", stepStartedAt.Add(time.Second))},
+			failed: true,
+		},
+		{
+			name:          "warning event of a previous step",
+			pods:          []apiv1.Pod{running},
+			events:        []apiv1.Event{newTestCanaryWarningEvent("canary-1", stepStartedAt.Add(-time.Second))},
+			expectedReady: true,
+		},
+	}
+	for _, c := range cases {
+		ready, reason := getCanaryHealth(&stepStartedAt, c.pods, c.events)
+		if ready != c.expectedReady {
+			t.Fatalf("%s: the canary is ready: %v, expected %v", c.name, ready, c.expectedReady)
+		}
+		if (reason != "") != c.failed {
+			t.Fatalf("%s: the gate is failed with %q, expected failed: %v", c.name, reason, c.failed)
+		}
+	}
+}
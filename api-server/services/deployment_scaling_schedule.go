@@ -0,0 +1,528 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tianweidut/cron"
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/consts"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type deploymentScalingScheduleService struct{}
+
+var DeploymentScalingScheduleService = deploymentScalingScheduleService{}
+
+func (s *deploymentScalingScheduleService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.DeploymentScalingSchedule{})
+}
+
+type CreateDeploymentScalingScheduleOption struct {
+	CreatorId    uint
+	DeploymentId uint
+	Name         string
+	Cron         string
+	Timezone     string
+	Action       models.DeploymentScalingScheduleAction
+	MinReplicas  *int32
+	MaxReplicas  *int32
+	// the api server and the runners whose replicas are overridden by the scale action
+	ScaleApiServer bool
+	RunnerNames    []string
+	Disabled       bool
+}
+
+type UpdateDeploymentScalingScheduleOption struct {
+	Cron           *string
+	Timezone       *string
+	Action         *models.DeploymentScalingScheduleAction
+	MinReplicas    **int32
+	MaxReplicas    **int32
+	ScaleApiServer *bool
+	RunnerNames    *[]string
+	Disabled       *bool
+}
+
+type ListDeploymentScalingScheduleOption struct {
+	BaseListOption
+	DeploymentId *uint
+}
+
+// getNextRunAt returns the first time after now which matches the standard 5 fields cron expression in the timezone
+func getNextRunAt(cronExpr, timezone string, now time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(cronExpr)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "parse cron expression %q", cronExpr)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "load timezone %q", timezone)
+	}
+	next := schedule.Next(now.In(location))
+	if next.IsZero() {
+		return time.Time{}, errors.Errorf("cron expression %q never matches", cronExpr)
+	}
+	return next, nil
+}
+
+func validateDeploymentScalingSchedule(schedule *models.DeploymentScalingSchedule) error {
+	if strings.TrimSpace(schedule.Name) == "" {
+		return errors.New("name should not be empty")
+	}
+	switch schedule.Action {
+	case models.DeploymentScalingScheduleActionScale:
+		// the horizontal pod autoscaler does not accept zero replicas, use the scale_to_zero action instead
+		if schedule.MinReplicas == nil || *schedule.MinReplicas < 1 {
+			return errors.New("min_replicas should be at least 1 for the scale action")
+		}
+		if schedule.MaxReplicas == nil || *schedule.MaxReplicas < *schedule.MinReplicas {
+			return errors.New("max_replicas should not be less than min_replicas for the scale action")
+		}
+		if !schedule.ScaleApiServer && len(schedule.RunnerNames) == 0 {
+			return errors.New("the scale action should scale the api server or at least one runner")
+		}
+		runnerNames := make(map[string]struct{}, len(schedule.RunnerNames))
+		for _, runnerName := range schedule.RunnerNames {
+			if strings.TrimSpace(runnerName) == "" {
+				return errors.New("runner name should not be empty")
+			}
+			if _, ok := runnerNames[runnerName]; ok {
+				return errors.Errorf("duplicate runner name %q", runnerName)
+			}
+			runnerNames[runnerName] = struct{}{}
+		}
+	case models.DeploymentScalingScheduleActionScaleToZero:
+		if schedule.MinReplicas != nil || schedule.MaxReplicas != nil {
+			return errors.New("min_replicas and max_replicas should be empty for the scale_to_zero action")
+		}
+	default:
+		return errors.Errorf("unknown scaling schedule action %q", schedule.Action)
+	}
+	return nil
+}
+
+func (s *deploymentScalingScheduleService) Create(ctx context.Context, opt CreateDeploymentScalingScheduleOption) (*models.DeploymentScalingSchedule, error) {
+	timezone := opt.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	schedule := models.DeploymentScalingSchedule{
+		CreatorAssociate: models.CreatorAssociate{
+			CreatorId: opt.CreatorId,
+		},
+		DeploymentAssociate: models.DeploymentAssociate{
+			DeploymentId: opt.DeploymentId,
+		},
+		Name:           opt.Name,
+		Cron:           opt.Cron,
+		Timezone:       timezone,
+		Action:         opt.Action,
+		MinReplicas:    opt.MinReplicas,
+		MaxReplicas:    opt.MaxReplicas,
+		ScaleApiServer: opt.ScaleApiServer,
+		RunnerNames:    pq.StringArray(opt.RunnerNames),
+		Disabled:       opt.Disabled,
+	}
+	err := validateDeploymentScalingSchedule(&schedule)
+	if err != nil {
+		return nil, err
+	}
+	nextRunAt, err := getNextRunAt(schedule.Cron, schedule.Timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = &nextRunAt
+	err = mustGetSession(ctx).Create(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (s *deploymentScalingScheduleService) Update(ctx context.Context, schedule *models.DeploymentScalingSchedule, opt UpdateDeploymentScalingScheduleOption) (*models.DeploymentScalingSchedule, error) {
+	// the fields depend on each other, so the whole schedule is validated after applying the changes
+	schedule_ := *schedule
+	if opt.Cron != nil {
+		schedule_.Cron = *opt.Cron
+	}
+	if opt.Timezone != nil {
+		schedule_.Timezone = *opt.Timezone
+	}
+	if opt.Action != nil {
+		schedule_.Action = *opt.Action
+	}
+	if opt.MinReplicas != nil {
+		schedule_.MinReplicas = *opt.MinReplicas
+	}
+	if opt.MaxReplicas != nil {
+		schedule_.MaxReplicas = *opt.MaxReplicas
+	}
+	if opt.ScaleApiServer != nil {
+		schedule_.ScaleApiServer = *opt.ScaleApiServer
+	}
+	if opt.RunnerNames != nil {
+		schedule_.RunnerNames = pq.StringArray(*opt.RunnerNames)
+	}
+	if opt.Disabled != nil {
+		schedule_.Disabled = *opt.Disabled
+	}
+	err := validateDeploymentScalingSchedule(&schedule_)
+	if err != nil {
+		return nil, err
+	}
+	nextRunAt, err := getNextRunAt(schedule_.Cron, schedule_.Timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	schedule_.NextRunAt = &nextRunAt
+	err = s.getBaseDB(ctx).Where("id = ?", schedule.ID).Updates(map[string]interface{}{
+		"cron":             schedule_.Cron,
+		"timezone":         schedule_.Timezone,
+		"action":           schedule_.Action,
+		"min_replicas":     schedule_.MinReplicas,
+		"max_replicas":     schedule_.MaxReplicas,
+		"scale_api_server": schedule_.ScaleApiServer,
+		"runner_names":     schedule_.RunnerNames,
+		"disabled":         schedule_.Disabled,
+		"next_run_at":      schedule_.NextRunAt,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule_, nil
+}
+
+func (s *deploymentScalingScheduleService) Delete(ctx context.Context, schedule *models.DeploymentScalingSchedule) (*models.DeploymentScalingSchedule, error) {
+	err := s.getBaseDB(ctx).Unscoped().Delete(schedule).Error
+	return schedule, err
+}
+
+func (s *deploymentScalingScheduleService) GetByUid(ctx context.Context, uid string) (*models.DeploymentScalingSchedule, error) {
+	var schedule models.DeploymentScalingSchedule
+	err := getBaseQuery(ctx, s).Where("uid = ?", uid).First(&schedule).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "get deployment scaling schedule %s", uid)
+	}
+	if schedule.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &schedule, nil
+}
+
+func (s *deploymentScalingScheduleService) List(ctx context.Context, opt ListDeploymentScalingScheduleOption) ([]*models.DeploymentScalingSchedule, uint, error) {
+	query := getBaseQuery(ctx, s)
+	if opt.DeploymentId != nil {
+		query = query.Where("deployment_scaling_schedule.deployment_id = ?", *opt.DeploymentId)
+	}
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	query = opt.BindQueryWithLimit(query)
+	schedules := make([]*models.DeploymentScalingSchedule, 0)
+	err = query.Order("deployment_scaling_schedule.id DESC").Find(&schedules).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return schedules, uint(total), err
+}
+
+// ListDue lists the enabled schedules which should be run
+func (s *deploymentScalingScheduleService) ListDue(ctx context.Context) ([]*models.DeploymentScalingSchedule, error) {
+	schedules := make([]*models.DeploymentScalingSchedule, 0)
+	err := getBaseQuery(ctx, s).Where("disabled = ?", false).Where("next_run_at <= ?", time.Now()).Order("next_run_at ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+func (s *deploymentScalingScheduleService) createEvent(ctx context.Context, schedule *models.DeploymentScalingSchedule, deployment *models.Deployment, status modelschemas.EventStatus, operationName string) {
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		logrus.Errorf("get deployment %s associated cluster: %s", deployment.Name, err.Error())
+		return
+	}
+	_, err = EventService.Create(ctx, CreateEventOption{
+		CreatorId:      schedule.CreatorId,
+		OrganizationId: &cluster.OrganizationId,
+		ClusterId:      &cluster.ID,
+		ResourceType:   modelschemas.ResourceTypeDeployment,
+		ResourceId:     deployment.ID,
+		Status:         status,
+		OperationName:  operationName,
+	})
+	if err != nil {
+		logrus.Errorf("create event failed: %v", err)
+	}
+}
+
+// isHPAConfScaled reports whether the hpa config already has the replicas of the schedule
+func isHPAConfScaled(hpaConf *modelschemas.DeploymentTargetHPAConf, schedule *models.DeploymentScalingSchedule) bool {
+	return hpaConf != nil && hpaConf.MinReplicas != nil && hpaConf.MaxReplicas != nil && *hpaConf.MinReplicas == *schedule.MinReplicas && *hpaConf.MaxReplicas == *schedule.MaxReplicas
+}
+
+// getScaledHPAConf returns a copy of the hpa config with the replicas of the schedule
+func getScaledHPAConf(hpaConf *modelschemas.DeploymentTargetHPAConf, schedule *models.DeploymentScalingSchedule) *modelschemas.DeploymentTargetHPAConf {
+	res := hpaConf.DeepCopy()
+	if res == nil {
+		res = &modelschemas.DeploymentTargetHPAConf{}
+	}
+	minReplicas := *schedule.MinReplicas
+	maxReplicas := *schedule.MaxReplicas
+	res.MinReplicas = &minReplicas
+	res.MaxReplicas = &maxReplicas
+	return res
+}
+
+// isDeploymentTargetScaled reports whether the api server and the runners named by the schedule already have the replicas of the schedule
+func isDeploymentTargetScaled(deploymentTarget *models.DeploymentTarget, schedule *models.DeploymentScalingSchedule) bool {
+	if deploymentTarget.Config == nil {
+		return false
+	}
+	if schedule.ScaleApiServer && !isHPAConfScaled(deploymentTarget.Config.HPAConf, schedule) {
+		return false
+	}
+	for _, runnerName := range schedule.RunnerNames {
+		runner, ok := deploymentTarget.Config.Runners[runnerName]
+		if !ok || !isHPAConfScaled(runner.HPAConf, schedule) {
+			return false
+		}
+	}
+	return true
+}
+
+// getScaledDeploymentTargetConfig returns a copy of the config which only overrides the replicas of the api server and the runners named by the schedule
+func getScaledDeploymentTargetConfig(config *modelschemas.DeploymentTargetConfig, schedule *models.DeploymentScalingSchedule) *modelschemas.DeploymentTargetConfig {
+	res := &modelschemas.DeploymentTargetConfig{}
+	if config != nil {
+		config_ := *config
+		res = &config_
+	}
+	res.KubeResourceUid = ""
+	res.KubeResourceVersion = ""
+	if schedule.ScaleApiServer {
+		res.HPAConf = getScaledHPAConf(res.HPAConf, schedule)
+	}
+	if len(schedule.RunnerNames) > 0 {
+		// the runners map is shared with the old config, so it is copied before scaling
+		runners := make(map[string]modelschemas.DeploymentTargetRunnerConfig, len(res.Runners)+len(schedule.RunnerNames))
+		for name, runner := range res.Runners {
+			runners[name] = runner
+		}
+		for _, runnerName := range schedule.RunnerNames {
+			runner := runners[runnerName]
+			runner.HPAConf = getScaledHPAConf(runner.HPAConf, schedule)
+			runners[runnerName] = runner
+		}
+		res.Runners = runners
+	}
+	return res
+}
+
+// scale deploys a new revision which clones the targets of the latest revision with the replicas of the schedule for the api server and the named runners,
+// the horizontal pod autoscalers are owned by the bento deployments, so patching them directly would be reverted by yatai-deployment.
+// The revision is created and deployed in a transaction just like the update of the deployment.
+func (s *deploymentScalingScheduleService) scale(ctx context.Context, schedule *models.DeploymentScalingSchedule, deployment *models.Deployment) (changed bool, err error) {
+	_, runningRollouts, err := DeploymentRolloutService.List(ctx, ListDeploymentRolloutOption{
+		DeploymentId: utils.UintPtr(deployment.ID),
+		Statuses:     &[]models.DeploymentRolloutStatus{models.DeploymentRolloutStatusRunning},
+	})
+	if err != nil {
+		err = errors.Wrap(err, "list running rollouts")
+		return
+	}
+	if runningRollouts > 0 {
+		err = errors.New("the deployment has a running rollout")
+		return
+	}
+
+	deploymentRevisions, _, err := DeploymentRevisionService.List(ctx, ListDeploymentRevisionOption{
+		BaseListOption: BaseListOption{
+			Start: utils.UintPtr(0),
+			Count: utils.UintPtr(1),
+		},
+		DeploymentId: utils.UintPtr(deployment.ID),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "list deployment revisions")
+		return
+	}
+	if len(deploymentRevisions) == 0 {
+		err = errors.New("the deployment has no revision")
+		return
+	}
+	deploymentRevision := deploymentRevisions[0]
+	oldDeploymentTargets, _, err := DeploymentTargetService.List(ctx, ListDeploymentTargetOption{
+		DeploymentRevisionId: utils.UintPtr(deploymentRevision.ID),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "list deployment targets")
+		return
+	}
+	if len(oldDeploymentTargets) == 0 {
+		err = errors.Errorf("deployment revision %s has no deployment targets", deploymentRevision.Uid)
+		return
+	}
+
+	if !isDeploymentTerminated(deployment) && deploymentRevision.Status == modelschemas.DeploymentRevisionStatusActive {
+		unchanged := true
+		for _, deploymentTarget := range oldDeploymentTargets {
+			if !isDeploymentTargetScaled(deploymentTarget, schedule) {
+				unchanged = false
+				break
+			}
+		}
+		if unchanged {
+			return
+		}
+	}
+
+	// nolint: ineffassign,staticcheck
+	_, ctx, df, err := startTransaction(ctx)
+	if err != nil {
+		return
+	}
+	defer func() { df(err) }()
+
+	newDeploymentRevision, err := DeploymentRevisionService.Create(ctx, CreateDeploymentRevisionOption{
+		CreatorId:    schedule.CreatorId,
+		DeploymentId: deployment.ID,
+		Status:       modelschemas.DeploymentRevisionStatusActive,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "create deployment revision")
+		return
+	}
+
+	deploymentTargets := make([]*models.DeploymentTarget, 0, len(oldDeploymentTargets))
+	for _, oldDeploymentTarget := range oldDeploymentTargets {
+		var deploymentTarget *models.DeploymentTarget
+		deploymentTarget, err = DeploymentTargetService.Create(ctx, CreateDeploymentTargetOption{
+			CreatorId:            schedule.CreatorId,
+			DeploymentId:         oldDeploymentTarget.DeploymentId,
+			DeploymentRevisionId: newDeploymentRevision.ID,
+			BentoId:              oldDeploymentTarget.BentoId,
+			Type:                 oldDeploymentTarget.Type,
+			CanaryRules:          oldDeploymentTarget.CanaryRules,
+			Config:               getScaledDeploymentTargetConfig(oldDeploymentTarget.Config, schedule),
+		})
+		if err != nil {
+			err = errors.Wrap(err, "create deployment target")
+			return
+		}
+		deploymentTargets = append(deploymentTargets, deploymentTarget)
+	}
+
+	err = DeploymentRevisionService.Deploy(ctx, newDeploymentRevision, deploymentTargets, false)
+	if err != nil {
+		err = errors.Wrap(err, "deploy deployment revision")
+		return
+	}
+	changed = true
+	return
+}
+
+// scaleToZero terminates the deployment, the next scale action deploys it again
+func (s *deploymentScalingScheduleService) scaleToZero(ctx context.Context, deployment *models.Deployment) (changed bool, err error) {
+	if isDeploymentTerminated(deployment) {
+		return
+	}
+	_, err = DeploymentService.terminate(ctx, deployment, true)
+	if err != nil {
+		err = errors.Wrap(err, "terminate deployment")
+		return
+	}
+	changed = true
+	return
+}
+
+// claim moves the due schedule to its next matched time, it only succeeds if nobody else has claimed the same run,
+// so overlapping cron runs and replicas never apply the schedule twice
+func (s *deploymentScalingScheduleService) claim(ctx context.Context, schedule *models.DeploymentScalingSchedule, nextRunAt time.Time) (bool, error) {
+	query := s.getBaseDB(ctx).Where("id = ?", schedule.ID).Where("disabled = ?", false)
+	if schedule.NextRunAt == nil {
+		query = query.Where("next_run_at is null")
+	} else {
+		query = query.Where("next_run_at = ?", *schedule.NextRunAt)
+	}
+	res := query.Updates(map[string]interface{}{
+		"next_run_at": nextRunAt,
+	})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Run applies the schedule to its deployment and moves it to the next matched time, every change is recorded as an event of the deployment
+func (s *deploymentScalingScheduleService) Run(ctx context.Context, schedule *models.DeploymentScalingSchedule) (*models.DeploymentScalingSchedule, error) {
+	now := time.Now()
+	nextRunAt, err := getNextRunAt(schedule.Cron, schedule.Timezone, now)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := s.claim(ctx, schedule, nextRunAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "claim deployment scaling schedule")
+	}
+	if !claimed {
+		return schedule, nil
+	}
+	schedule.NextRunAt = &nextRunAt
+
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, schedule)
+	if err != nil {
+		return nil, errors.Wrap(err, "get associated deployment")
+	}
+
+	var changed bool
+	var operationName string
+	var runErr error
+	message := "the deployment is already scaled as scheduled"
+	switch {
+	case schedule.Action == models.DeploymentScalingScheduleActionScaleToZero:
+		operationName = fmt.Sprintf("scaling schedule %s scaled to zero", schedule.Name)
+		changed, runErr = s.scaleToZero(ctx, deployment)
+	case isDeploymentTerminated(deployment) && !deployment.ScaledToZeroBySchedule:
+		// the deployments terminated by the users are left terminated
+		message = "the deployment is terminated, only the deployments scaled to zero by the schedules are scaled again"
+	default:
+		operationName = fmt.Sprintf("scaling schedule %s scaled to %d-%d replicas", schedule.Name, *schedule.MinReplicas, *schedule.MaxReplicas)
+		changed, runErr = s.scale(ctx, schedule, deployment)
+	}
+
+	switch {
+	case runErr != nil:
+		message = runErr.Error()
+		s.createEvent(ctx, schedule, deployment, modelschemas.EventStatusFailed, operationName)
+	case changed:
+		message = ""
+		s.createEvent(ctx, schedule, deployment, modelschemas.EventStatusSuccess, operationName)
+	}
+
+	err = s.getBaseDB(ctx).Where("id = ?", schedule.ID).Updates(map[string]interface{}{
+		"last_run_at":      now,
+		"last_run_message": message,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	schedule.LastRunAt = &now
+	schedule.LastRunMessage = message
+	if runErr != nil {
+		return nil, runErr
+	}
+	return schedule, nil
+}
+
+func isDeploymentTerminated(deployment *models.Deployment) bool {
+	return deployment.Status == modelschemas.DeploymentStatusTerminated || deployment.Status == modelschemas.DeploymentStatusTerminating
+}
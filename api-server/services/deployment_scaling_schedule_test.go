@@ -0,0 +1,117 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+func TestGetNextRunAt(t *testing.T) {
+	now := time.Date(2022, 8, 5, 19, 30, 0, 0, time.UTC) // a friday
+	cases := []struct {
+		name     string
+		cron     string
+		timezone string
+		expected time.Time
+		wantErr  bool
+	}{
+		{"later today", "0 20 * * 1-5", "UTC", time.Date(2022, 8, 5, 20, 0, 0, 0, time.UTC), false},
+		{"skips the weekend", "0 8 * * 1-5", "UTC", time.Date(2022, 8, 8, 8, 0, 0, 0, time.UTC), false},
+		{"in the timezone", "0 20 * * *", "Asia/Shanghai", time.Date(2022, 8, 6, 12, 0, 0, 0, time.UTC), false},
+		{"the current minute is not matched again", "30 19 * * *", "UTC", time.Date(2022, 8, 6, 19, 30, 0, 0, time.UTC), false},
+		{"invalid cron expression", "0 25 * * *", "UTC", time.Time{}, true},
+		{"six fields", "0 0 20 * * *", "UTC", time.Time{}, true},
+		{"unknown timezone", "0 20 * * *", "Mars/Olympus", time.Time{}, true},
+	}
+	for _, c := range cases {
+		nextRunAt, err := getNextRunAt(c.cron, c.timezone, now)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%s: the error is %v, expected an error: %v", c.name, err, c.wantErr)
+		}
+		if !nextRunAt.Equal(c.expected) {
+			t.Fatalf("%s: the next run is at %s, expected %s", c.name, nextRunAt, c.expected)
+		}
+	}
+}
+
+func TestValidateDeploymentScalingSchedule(t *testing.T) {
+	cases := []struct {
+		name     string
+		schedule models.DeploymentScalingSchedule
+		wantErr  bool
+	}{
+		{"scale the api server", models.DeploymentScalingSchedule{Name: "day", Action: models.DeploymentScalingScheduleActionScale, MinReplicas: utils.Int32Ptr(1), MaxReplicas: utils.Int32Ptr(3), ScaleApiServer: true}, false},
+		{"scale a runner", models.DeploymentScalingSchedule{Name: "day", Action: models.DeploymentScalingScheduleActionScale, MinReplicas: utils.Int32Ptr(2), MaxReplicas: utils.Int32Ptr(2), RunnerNames: []string{"gpu"}}, false},
+		{"scale to zero", models.DeploymentScalingSchedule{Name: "night", Action: models.DeploymentScalingScheduleActionScaleToZero, ScaleApiServer: true}, false},
+		{"empty name", models.DeploymentScalingSchedule{Name: " ", Action: models.DeploymentScalingScheduleActionScaleToZero}, true},
+		{"zero min replicas", models.DeploymentScalingSchedule{Name: "day", Action: models.DeploymentScalingScheduleActionScale, MinReplicas: utils.Int32Ptr(0), MaxReplicas: utils.Int32Ptr(3), ScaleApiServer: true}, true},
+		{"max less than min", models.DeploymentScalingSchedule{Name: "day", Action: models.DeploymentScalingScheduleActionScale, MinReplicas: utils.Int32Ptr(3), MaxReplicas: utils.Int32Ptr(2), ScaleApiServer: true}, true},
+		{"no targets", models.DeploymentScalingSchedule{Name: "day", Action: models.DeploymentScalingScheduleActionScale, MinReplicas: utils.Int32Ptr(1), MaxReplicas: utils.Int32Ptr(3)}, true},
+		{"empty runner name", models.DeploymentScalingSchedule{Name: "day", Action: models.DeploymentScalingScheduleActionScale, MinReplicas: utils.Int32Ptr(1), MaxReplicas: utils.Int32Ptr(3), RunnerNames: []string{""}}, true},
+		{"duplicate runner names", models.DeploymentScalingSchedule{Name: "day", Action: models.DeploymentScalingScheduleActionScale, MinReplicas: utils.Int32Ptr(1), MaxReplicas: utils.Int32Ptr(3), RunnerNames: []string{"gpu", "gpu"}}, true},
+		{"replicas of scale to zero", models.DeploymentScalingSchedule{Name: "night", Action: models.DeploymentScalingScheduleActionScaleToZero, MinReplicas: utils.Int32Ptr(1)}, true},
+		{"unknown action", models.DeploymentScalingSchedule{Name: "day", Action: "pause"}, true},
+	}
+	for _, c := range cases {
+		c := c
+		if err := validateDeploymentScalingSchedule(&c.schedule); (err != nil) != c.wantErr {
+			t.Fatalf("%s: the error is %v, expected an error: %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestGetScaledDeploymentTargetConfig(t *testing.T) {
+	newHPAConf := func(minReplicas, maxReplicas int32) *modelschemas.DeploymentTargetHPAConf {
+		return &modelschemas.DeploymentTargetHPAConf{MinReplicas: utils.Int32Ptr(minReplicas), MaxReplicas: utils.Int32Ptr(maxReplicas)}
+	}
+	config := &modelschemas.DeploymentTargetConfig{
+		KubeResourceUid: "uid",
+		HPAConf:         newHPAConf(1, 2),
+		Runners: map[string]modelschemas.DeploymentTargetRunnerConfig{
+			"cpu": {HPAConf: newHPAConf(1, 1)},
+			"gpu": {HPAConf: newHPAConf(1, 4)},
+		},
+	}
+	cases := []struct {
+		name            string
+		scaleApiServer  bool
+		runnerNames     []string
+		expectedApi     *modelschemas.DeploymentTargetHPAConf
+		expectedRunners map[string]*modelschemas.DeploymentTargetHPAConf
+	}{
+		{"the api server only", true, nil, newHPAConf(5, 5), map[string]*modelschemas.DeploymentTargetHPAConf{"cpu": newHPAConf(1, 1), "gpu": newHPAConf(1, 4)}},
+		{"a runner only", false, []string{"gpu"}, newHPAConf(1, 2), map[string]*modelschemas.DeploymentTargetHPAConf{"cpu": newHPAConf(1, 1), "gpu": newHPAConf(5, 5)}},
+		{"a runner without config", false, []string{"tokenizer"}, newHPAConf(1, 2), map[string]*modelschemas.DeploymentTargetHPAConf{"cpu": newHPAConf(1, 1), "gpu": newHPAConf(1, 4), "tokenizer": newHPAConf(5, 5)}},
+	}
+	for _, c := range cases {
+		schedule := &models.DeploymentScalingSchedule{MinReplicas: utils.Int32Ptr(5), MaxReplicas: utils.Int32Ptr(5), ScaleApiServer: c.scaleApiServer, RunnerNames: c.runnerNames}
+		scaled := getScaledDeploymentTargetConfig(config, schedule)
+		if scaled.KubeResourceUid != "" {
+			t.Fatalf("%s: the kube resource uid is copied", c.name)
+		}
+		if !reflect.DeepEqual(scaled.HPAConf, c.expectedApi) {
+			t.Fatalf("%s: the hpa config of the api server is %+v, expected %+v", c.name, scaled.HPAConf, c.expectedApi)
+		}
+		runners := make(map[string]*modelschemas.DeploymentTargetHPAConf, len(scaled.Runners))
+		for name, runner := range scaled.Runners {
+			runners[name] = runner.HPAConf
+		}
+		if !reflect.DeepEqual(runners, c.expectedRunners) {
+			t.Fatalf("%s: the hpa configs of the runners are %+v, expected %+v", c.name, runners, c.expectedRunners)
+		}
+		if !isDeploymentTargetScaled(&models.DeploymentTarget{Config: scaled}, schedule) {
+			t.Fatalf("%s: the scaled config is not reported as scaled", c.name)
+		}
+		if isDeploymentTargetScaled(&models.DeploymentTarget{Config: config}, schedule) {
+			t.Fatalf("%s: the original config is reported as scaled", c.name)
+		}
+	}
+	// the original config is not changed
+	if *config.HPAConf.MinReplicas != 1 || *config.Runners["gpu"].HPAConf.MinReplicas != 1 || len(config.Runners) != 2 {
+		t.Fatalf("the original config is changed: %+v", config)
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+
+	commonconsts "github.com/bentoml/yatai-common/consts"
+
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+// DeploymentStatusReconcileInterval is the interval of the backstop cron which re-syncs
+// the deployments whose status has not been refreshed by the informer event handlers
+const DeploymentStatusReconcileInterval = 10 * time.Minute
+
+// deploymentStatusSyncDebounce collapses the bursts of pod events of a rollout into one status sync
+const deploymentStatusSyncDebounce = 2 * time.Second
+
+// deploymentStatusSyncTimeout bounds a debounced status sync
+const deploymentStatusSyncTimeout = time.Minute
+
+type deploymentStatusWatcherService struct {
+	mu sync.Mutex
+	// ctx is the context of the watcher, it is set by the first Start and never cancelled
+	ctx      context.Context
+	watching map[string]struct{}
+	pending  map[string]*time.Timer
+}
+
+var DeploymentStatusWatcherService = deploymentStatusWatcherService{
+	watching: make(map[string]struct{}),
+	pending:  make(map[string]*time.Timer),
+}
+
+var deploymentStatusWatcherLogger = logrus.WithField("service", "deployment status watcher")
+
+// Start watches the pods and deployments of every kube namespace which contains yatai deployments,
+// it can be called repeatedly to pick up the namespaces which failed to be watched before
+func (s *deploymentStatusWatcherService) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.ctx == nil {
+		s.ctx = ctx
+	}
+	ctx = s.ctx
+	s.mu.Unlock()
+
+	deployments, _, err := DeploymentService.List(ctx, ListDeploymentOption{})
+	if err != nil {
+		return errors.Wrap(err, "list deployments")
+	}
+	// a cluster which could not be watched is logged and picked up again by the next Start,
+	// it should not keep the other clusters from being watched
+	for _, deployment := range deployments {
+		cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+		if err != nil {
+			deploymentStatusWatcherLogger.Errorf("get the cluster of deployment %s: %s", deployment.Name, err.Error())
+			continue
+		}
+		s.Watch(cluster, DeploymentService.GetKubeNamespace(deployment))
+	}
+	return nil
+}
+
+// Watch registers the informer event handlers for the kube namespace of the cluster if they are not registered yet
+func (s *deploymentStatusWatcherService) Watch(cluster *models.Cluster, namespace string) {
+	key := fmt.Sprintf("%d:%s", cluster.ID, namespace)
+
+	s.mu.Lock()
+	ctx := s.ctx
+	if ctx == nil {
+		s.mu.Unlock()
+		return
+	}
+	if _, ok := s.watching[key]; ok {
+		s.mu.Unlock()
+		return
+	}
+	s.watching[key] = struct{}{}
+	s.mu.Unlock()
+
+	err := s.watch(ctx, cluster, namespace)
+	if err != nil {
+		deploymentStatusWatcherLogger.Errorf("watch kube namespace %s of cluster %s: %s", namespace, cluster.Name, err.Error())
+		s.mu.Lock()
+		delete(s.watching, key)
+		s.mu.Unlock()
+	}
+}
+
+// unwatchCluster forgets the watched namespaces of the cluster when its informers are stopped,
+// so that they are watched again with the new informers by the next Start
+func (s *deploymentStatusWatcherService) unwatchCluster(clusterId uint) {
+	prefix := fmt.Sprintf("%d:", clusterId)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.watching {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.watching, key)
+		}
+	}
+}
+
+// watch adds the event handlers to the shared informers of the namespace for as long as the watcher runs
+func (s *deploymentStatusWatcherService) watch(ctx context.Context, cluster *models.Cluster, namespace string) error {
+	podInformer, _, err := GetPodInformer(ctx, cluster, namespace)
+	if err != nil {
+		return errors.Wrap(err, "get pod informer")
+	}
+	deploymentInformer, _, err := GetDeploymentInformer(ctx, cluster, namespace)
+	if err != nil {
+		return errors.Wrap(err, "get deployment informer")
+	}
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			s.handle(cluster.ID, namespace, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			s.handle(cluster.ID, namespace, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			s.handle(cluster.ID, namespace, obj)
+		},
+	}
+	AddInformerEventHandler(ctx, podInformer.Informer(), handler)
+	AddInformerEventHandler(ctx, deploymentInformer.Informer(), handler)
+	return nil
+}
+
+func (s *deploymentStatusWatcherService) handle(clusterId uint, namespace string, obj interface{}) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	labels := accessor.GetLabels()
+	if deploymentName, ok := labels[commonconsts.KubeLabelYataiBentoDeployment]; ok {
+		s.enqueue(clusterId, namespace, deploymentName)
+		return
+	}
+	// image builder pods are labeled with the bento instead of the deployment,
+	// so every deployment in the namespace should be re-synced
+	if labels[commonconsts.KubeLabelIsBentoImageBuilder] == "true" {
+		s.enqueue(clusterId, namespace, "")
+	}
+}
+
+// enqueue schedules a status sync of the deployment, an empty deployment name means all the deployments of the namespace
+func (s *deploymentStatusWatcherService) enqueue(clusterId uint, namespace, deploymentName string) {
+	key := fmt.Sprintf("%d:%s:%s", clusterId, namespace, deploymentName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if timer, ok := s.pending[key]; ok {
+		timer.Reset(deploymentStatusSyncDebounce)
+		return
+	}
+	s.pending[key] = time.AfterFunc(deploymentStatusSyncDebounce, func() {
+		s.mu.Lock()
+		delete(s.pending, key)
+		s.mu.Unlock()
+
+		err := s.sync(clusterId, namespace, deploymentName)
+		if err != nil {
+			deploymentStatusWatcherLogger.Errorf("sync deployment status %s: %s", key, err.Error())
+		}
+	})
+}
+
+// sync runs with a timeout, so that a hanging cluster could not hold the debounced syncs forever
+func (s *deploymentStatusWatcherService) sync(clusterId uint, namespace, deploymentName string) error {
+	s.mu.Lock()
+	ctx := s.ctx
+	s.mu.Unlock()
+	ctx, cancel := context.WithTimeout(ctx, deploymentStatusSyncTimeout)
+	defer cancel()
+
+	var deployments []*models.Deployment
+	if deploymentName != "" {
+		deployment, err := DeploymentService.GetByName(ctx, clusterId, namespace, deploymentName)
+		if err != nil {
+			return err
+		}
+		deployments = []*models.Deployment{deployment}
+	} else {
+		var err error
+		deployments, _, err = DeploymentService.List(ctx, ListDeploymentOption{
+			ClusterId:     &clusterId,
+			KubeNamespace: &namespace,
+		})
+		if err != nil {
+			return errors.Wrap(err, "list deployments")
+		}
+	}
+	for _, deployment := range deployments {
+		_, err := DeploymentService.SyncStatus(ctx, deployment)
+		if err != nil {
+			return errors.Wrapf(err, "sync deployment %s status", deployment.Name)
+		}
+	}
+	return nil
+}
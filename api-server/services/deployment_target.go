@@ -35,7 +35,8 @@ type CreateDeploymentTargetOption struct {
 }
 
 type UpdateDeploymentTargetOption struct {
-	Config **modelschemas.DeploymentTargetConfig
+	CanaryRules **modelschemas.DeploymentTargetCanaryRules
+	Config      **modelschemas.DeploymentTargetConfig
 }
 
 type ListDeploymentTargetOption struct {
@@ -151,6 +152,16 @@ func (s *deploymentTargetService) List(ctx context.Context, opt ListDeploymentTa
 	return deploymentTargets, uint(total), err
 }
 
+// ListReferencedBentoIds returns the ids of the given bentos which are referenced by any deployment target of any revision
+func (s *deploymentTargetService) ListReferencedBentoIds(ctx context.Context, bentoIds []uint) ([]uint, error) {
+	res := make([]uint, 0)
+	if len(bentoIds) == 0 {
+		return res, nil
+	}
+	err := s.getBaseDB(ctx).Where("bento_id in (?)", bentoIds).Distinct("bento_id").Pluck("bento_id", &res).Error
+	return res, err
+}
+
 func (s *deploymentTargetService) GetKubeName(ctx context.Context, deploymentTarget *models.DeploymentTarget) (string, error) {
 	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
 	if err != nil {
@@ -168,7 +179,7 @@ func (s *deploymentTargetService) GenerateIngressHost(ctx context.Context, deplo
 	if err != nil {
 		return "", err
 	}
-	return DeploymentService.GenerateDefaultHostname(ctx, deployment)
+	return DeploymentService.GenerateIngressHost(ctx, deployment)
 }
 
 func (s *deploymentTargetService) GetKubeLabels(ctx context.Context, deploymentTarget *models.DeploymentTarget) (map[string]string, error) {
@@ -200,6 +211,15 @@ func (s *deploymentTargetService) Update(ctx context.Context, b *models.Deployme
 	var err error
 	updaters := make(map[string]interface{})
 
+	if opt.CanaryRules != nil {
+		updaters["canary_rules"] = *opt.CanaryRules
+		defer func() {
+			if err == nil {
+				b.CanaryRules = *opt.CanaryRules
+			}
+		}()
+	}
+
 	if opt.Config != nil {
 		updaters["config"] = *opt.Config
 		defer func() {
@@ -248,9 +268,49 @@ func (s *deploymentTargetService) Deploy(ctx context.Context, deploymentTarget *
 		return
 	}
 
+	managesKubeIngresses, err := s.ManagesKubeIngresses(ctx, deploymentTarget)
+	if err != nil {
+		return
+	}
+	if managesKubeIngresses {
+		err = KubeIngressService.DeployDeploymentTargetAsKubeIngresses(ctx, deploymentTarget, deployOption)
+		if err != nil {
+			err = errors.Wrap(err, "failed to deploy kube ingresses")
+			return
+		}
+	}
+
 	return
 }
 
+// ManagesKubeIngresses returns true if the traffic of the deployment target is routed by the ingresses of KubeIngressService instead of the operator:
+// the canary targets, the deployments with a custom ingress host because the operator only uses the default hostname,
+// the clusters of the gateway api ingress provider because the operator only creates ingresses,
+// and the deployments with tls or authentication because the ingresses of the operator have no tls sections or auth annotations
+func (s *deploymentTargetService) ManagesKubeIngresses(ctx context.Context, deploymentTarget *models.DeploymentTarget) (bool, error) {
+	if deploymentTarget.Type == modelschemas.DeploymentTargetTypeCanary {
+		return true, nil
+	}
+	if deploymentTarget.Config == nil || deploymentTarget.Config.EnableIngress == nil || !*deploymentTarget.Config.EnableIngress {
+		return false, nil
+	}
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
+	if err != nil {
+		return false, errors.Wrap(err, "get associated deployment")
+	}
+	if deployment.IngressHost != "" || deployment.AuthConfig != nil {
+		return true, nil
+	}
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return false, errors.Wrap(err, "get associated cluster")
+	}
+	if ClusterService.GetIngressProvider(cluster) == models.ClusterIngressProviderGatewayAPI {
+		return true, nil
+	}
+	return getIngressTLS(cluster, deployment) != nil, nil
+}
+
 func (s *deploymentTargetService) GetKubeCliSet(ctx context.Context, deploymentTarget *models.DeploymentTarget) (kubeCli *kubernetes.Clientset, restConfig *rest.Config, err error) {
 	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
 	if err != nil {
@@ -262,3 +322,19 @@ func (s *deploymentTargetService) GetKubeCliSet(ctx context.Context, deploymentT
 	}
 	return ClusterService.GetKubeCliSet(ctx, cluster)
 }
+
+type IDeploymentTargetAssociate interface {
+	GetAssociatedDeploymentTargetId() uint
+	GetAssociatedDeploymentTargetCache() *models.DeploymentTarget
+	SetAssociatedDeploymentTargetCache(deploymentTarget *models.DeploymentTarget)
+}
+
+func (s *deploymentTargetService) GetAssociatedDeploymentTarget(ctx context.Context, associate IDeploymentTargetAssociate) (*models.DeploymentTarget, error) {
+	cache := associate.GetAssociatedDeploymentTargetCache()
+	if cache != nil {
+		return cache, nil
+	}
+	deploymentTarget, err := s.Get(ctx, associate.GetAssociatedDeploymentTargetId())
+	associate.SetAssociatedDeploymentTargetCache(deploymentTarget)
+	return deploymentTarget, err
+}
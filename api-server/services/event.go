@@ -28,6 +28,8 @@ type CreateEventOption struct {
 	Status         modelschemas.EventStatus
 	OrganizationId *uint
 	ClusterId      *uint
+	// ResourceName skips the lookup of the resource, it should be set when the resource has been deleted
+	ResourceName string
 }
 
 type ListEventOption struct {
@@ -42,7 +44,10 @@ type ListEventOption struct {
 	StartedAt      *time.Time
 	EndedAt        *time.Time
 	OperationNames *[]string
+	ApiTokenName   *string
 	Status         *modelschemas.EventStatus
+	// BeforeId lists the events older than the given event, it is used to page through the events stably
+	BeforeId *uint
 }
 
 func (s *eventService) Create(ctx context.Context, opt CreateEventOption) (event *models.Event, err error) {
@@ -52,9 +57,14 @@ func (s *eventService) Create(ctx context.Context, opt CreateEventOption) (event
 		return
 	}
 	defer func() { df(err) }()
-	resource, err := ResourceService.Get(ctx, opt.ResourceType, opt.ResourceId)
-	if err != nil {
-		return nil, err
+	resourceName := opt.ResourceName
+	if resourceName == "" {
+		var resource models.IResource
+		resource, err = ResourceService.Get(ctx, opt.ResourceType, opt.ResourceId)
+		if err != nil {
+			return nil, err
+		}
+		resourceName = resource.GetName()
 	}
 	event = &models.Event{
 		BaseModel: models.BaseModel{
@@ -63,8 +73,8 @@ func (s *eventService) Create(ctx context.Context, opt CreateEventOption) (event
 				UpdatedAt: time.Now(),
 			},
 		},
-		CreatorAssociate: models.CreatorAssociate{
-			CreatorId: opt.CreatorId,
+		NullableCreatorAssociate: models.NullableCreatorAssociate{
+			CreatorId: &opt.CreatorId,
 		},
 		NullableOrganizationAssociate: models.NullableOrganizationAssociate{
 			OrganizationId: opt.OrganizationId,
@@ -73,7 +83,7 @@ func (s *eventService) Create(ctx context.Context, opt CreateEventOption) (event
 			ClusterId: opt.ClusterId,
 		},
 		Info: &modelschemas.EventInfo{
-			ResourceName: resource.GetName(),
+			ResourceName: resourceName,
 		},
 		Name:          opt.Name,
 		Status:        opt.Status,
@@ -128,6 +138,12 @@ func (s *eventService) List(ctx context.Context, opt ListEventOption) (events []
 	if opt.OperationNames != nil {
 		query = query.Where("operation_name in (?)", *opt.OperationNames)
 	}
+	if opt.ApiTokenName != nil {
+		query = query.Where("api_token_name = ?", *opt.ApiTokenName)
+	}
+	if opt.BeforeId != nil {
+		query = query.Where("id < ?", *opt.BeforeId)
+	}
 	var total_ int64
 	err = query.Count(&total_).Error
 	if err != nil {
@@ -0,0 +1,708 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/consts"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type federatedDeploymentService struct{}
+
+var FederatedDeploymentService = federatedDeploymentService{}
+
+func (s *federatedDeploymentService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.FederatedDeployment{})
+}
+
+type CreateFederatedDeploymentOption struct {
+	CreatorId      uint
+	OrganizationId uint
+	Name           string
+	Description    string
+	KubeNamespace  string
+	Targets        models.FederatedDeploymentTargets
+	Clusters       models.FederatedDeploymentClusters
+	Labels         *modelschemas.LabelItemsSchema
+}
+
+type UpdateFederatedDeploymentOption struct {
+	Description *string
+	Targets     *models.FederatedDeploymentTargets
+	Clusters    *models.FederatedDeploymentClusters
+	Labels      *modelschemas.LabelItemsSchema
+}
+
+type UpdateFederatedDeploymentStatusOption struct {
+	Status    *modelschemas.DeploymentStatus
+	SyncingAt **time.Time
+	UpdatedAt **time.Time
+}
+
+type ListFederatedDeploymentOption struct {
+	BaseListOption
+	OrganizationId *uint
+	Ids            *[]uint
+	Statuses       *[]modelschemas.DeploymentStatus
+}
+
+func (s *federatedDeploymentService) validateTargets(targets models.FederatedDeploymentTargets) error {
+	if len(targets) == 0 {
+		return errors.New("federated deployment should have at least one target")
+	}
+	for _, target := range targets {
+		if target.BentoRepository == "" || target.Bento == "" {
+			return errors.New("the bento of the federated deployment target is empty")
+		}
+	}
+	return nil
+}
+
+func (s *federatedDeploymentService) validateClusters(ctx context.Context, organizationId uint, clusters models.FederatedDeploymentClusters) error {
+	if len(clusters) == 0 {
+		return errors.New("federated deployment should have at least one cluster")
+	}
+	seen := make(map[uint]struct{}, len(clusters))
+	for _, c := range clusters {
+		if _, ok := seen[c.ClusterId]; ok {
+			return errors.Errorf("cluster %d is duplicated in the federated deployment", c.ClusterId)
+		}
+		seen[c.ClusterId] = struct{}{}
+		cluster, err := ClusterService.Get(ctx, c.ClusterId)
+		if err != nil {
+			return errors.Wrapf(err, "get cluster %d", c.ClusterId)
+		}
+		if cluster.OrganizationId != organizationId {
+			return errors.Errorf("cluster %s does not belong to the organization of the federated deployment", cluster.Name)
+		}
+		if c.KubeNamespace != "" {
+			errs := validation.IsDNS1035Label(c.KubeNamespace)
+			if len(errs) > 0 {
+				return errors.New(strings.Join(errs, ";"))
+			}
+		}
+		if c.IngressHost != "" {
+			errs := validation.IsDNS1123Subdomain(c.IngressHost)
+			if len(errs) > 0 {
+				return errors.New(strings.Join(errs, ";"))
+			}
+		}
+		if c.MinReplicas != nil && *c.MinReplicas < 0 {
+			return errors.Errorf("min replicas of cluster %s should not be negative", cluster.Name)
+		}
+		if c.MinReplicas != nil && c.MaxReplicas != nil && *c.MaxReplicas < *c.MinReplicas {
+			return errors.Errorf("max replicas of cluster %s should not be less than min replicas", cluster.Name)
+		}
+	}
+	return nil
+}
+
+func (s *federatedDeploymentService) Create(ctx context.Context, opt CreateFederatedDeploymentOption) (*models.FederatedDeployment, error) {
+	errs := validation.IsDNS1035Label(opt.Name)
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, ";"))
+	}
+	if opt.KubeNamespace != "" {
+		errs = validation.IsDNS1035Label(opt.KubeNamespace)
+		if len(errs) > 0 {
+			return nil, errors.New(strings.Join(errs, ";"))
+		}
+	}
+	if err := s.validateTargets(opt.Targets); err != nil {
+		return nil, err
+	}
+	if err := s.validateClusters(ctx, opt.OrganizationId, opt.Clusters); err != nil {
+		return nil, err
+	}
+	var labels *models.FederatedDeploymentLabels
+	if opt.Labels != nil {
+		// the labels are validated against the label policy of the child deployments
+		if err := LabelPolicyService.Validate(ctx, opt.OrganizationId, modelschemas.ResourceTypeDeployment, *opt.Labels); err != nil {
+			return nil, err
+		}
+		labels_ := models.FederatedDeploymentLabels(*opt.Labels)
+		labels = &labels_
+	}
+
+	federatedDeployment := models.FederatedDeployment{
+		ResourceMixin: models.ResourceMixin{
+			Name: opt.Name,
+		},
+		OrganizationAssociate: models.OrganizationAssociate{
+			OrganizationId: opt.OrganizationId,
+		},
+		CreatorAssociate: models.CreatorAssociate{
+			CreatorId: opt.CreatorId,
+		},
+		Description:   opt.Description,
+		KubeNamespace: opt.KubeNamespace,
+		Targets:       &opt.Targets,
+		Clusters:      &opt.Clusters,
+		Labels:        labels,
+		Status:        modelschemas.DeploymentStatusNonDeployed,
+	}
+	err := mustGetSession(ctx).Create(&federatedDeployment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &federatedDeployment, nil
+}
+
+func (s *federatedDeploymentService) Update(ctx context.Context, d *models.FederatedDeployment, opt UpdateFederatedDeploymentOption) (*models.FederatedDeployment, error) {
+	var err error
+	updaters := make(map[string]interface{})
+	if opt.Description != nil {
+		updaters["description"] = *opt.Description
+		defer func() {
+			if err == nil {
+				d.Description = *opt.Description
+			}
+		}()
+	}
+	if opt.Targets != nil {
+		if err = s.validateTargets(*opt.Targets); err != nil {
+			return nil, err
+		}
+		updaters["targets"] = opt.Targets
+		defer func() {
+			if err == nil {
+				d.Targets = opt.Targets
+			}
+		}()
+	}
+	if opt.Clusters != nil {
+		if err = s.validateClusters(ctx, d.OrganizationId, *opt.Clusters); err != nil {
+			return nil, err
+		}
+		updaters["clusters"] = opt.Clusters
+		defer func() {
+			if err == nil {
+				d.Clusters = opt.Clusters
+			}
+		}()
+	}
+	if opt.Labels != nil {
+		if err = LabelPolicyService.Validate(ctx, d.OrganizationId, modelschemas.ResourceTypeDeployment, *opt.Labels); err != nil {
+			return nil, err
+		}
+		labels := models.FederatedDeploymentLabels(*opt.Labels)
+		updaters["labels"] = &labels
+		defer func() {
+			if err == nil {
+				d.Labels = &labels
+			}
+		}()
+	}
+
+	if len(updaters) == 0 {
+		return d, nil
+	}
+
+	err = s.getBaseDB(ctx).Where("id = ?", d.ID).Updates(updaters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return d, err
+}
+
+func (s *federatedDeploymentService) UpdateStatus(ctx context.Context, d *models.FederatedDeployment, opt UpdateFederatedDeploymentStatusOption) (*models.FederatedDeployment, error) {
+	updater := map[string]interface{}{}
+	if opt.Status != nil {
+		d.Status = *opt.Status
+		updater["status"] = *opt.Status
+	}
+	if opt.SyncingAt != nil {
+		d.StatusSyncingAt = *opt.SyncingAt
+		updater["status_syncing_at"] = *opt.SyncingAt
+	}
+	if opt.UpdatedAt != nil {
+		d.StatusUpdatedAt = *opt.UpdatedAt
+		updater["status_updated_at"] = *opt.UpdatedAt
+	}
+	err := s.getBaseDB(ctx).Where("id = ?", d.ID).Updates(updater).Error
+	return d, err
+}
+
+func (s *federatedDeploymentService) Get(ctx context.Context, id uint) (*models.FederatedDeployment, error) {
+	var federatedDeployment models.FederatedDeployment
+	err := getBaseQuery(ctx, s).Where("id = ?", id).First(&federatedDeployment).Error
+	if err != nil {
+		return nil, err
+	}
+	if federatedDeployment.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &federatedDeployment, nil
+}
+
+func (s *federatedDeploymentService) GetByUid(ctx context.Context, uid string) (*models.FederatedDeployment, error) {
+	var federatedDeployment models.FederatedDeployment
+	err := getBaseQuery(ctx, s).Where("uid = ?", uid).First(&federatedDeployment).Error
+	if err != nil {
+		return nil, err
+	}
+	if federatedDeployment.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &federatedDeployment, nil
+}
+
+func (s *federatedDeploymentService) GetByName(ctx context.Context, organizationId uint, name string) (*models.FederatedDeployment, error) {
+	var federatedDeployment models.FederatedDeployment
+	err := getBaseQuery(ctx, s).Where("organization_id = ?", organizationId).Where("name = ?", name).First(&federatedDeployment).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "get federated deployment %s", name)
+	}
+	if federatedDeployment.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &federatedDeployment, nil
+}
+
+func (s *federatedDeploymentService) List(ctx context.Context, opt ListFederatedDeploymentOption) ([]*models.FederatedDeployment, uint, error) {
+	query := getBaseQuery(ctx, s)
+	if opt.OrganizationId != nil {
+		query = query.Where("federated_deployment.organization_id = ?", *opt.OrganizationId)
+	}
+	if opt.Ids != nil {
+		query = query.Where("federated_deployment.id in (?)", *opt.Ids)
+	}
+	if opt.Statuses != nil {
+		query = query.Where("federated_deployment.status in (?)", *opt.Statuses)
+	}
+	query = opt.BindQueryWithKeywords(query, "federated_deployment")
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	query = opt.BindQueryWithLimit(query)
+	federatedDeployments := make([]*models.FederatedDeployment, 0)
+	err = query.Order("federated_deployment.id DESC").Find(&federatedDeployments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return federatedDeployments, uint(total), err
+}
+
+// ListChildren returns the child deployments of the federated deployment, including the ones of the clusters which have been removed from it
+func (s *federatedDeploymentService) ListChildren(ctx context.Context, d *models.FederatedDeployment) ([]*models.Deployment, error) {
+	deployments, _, err := DeploymentService.List(ctx, ListDeploymentOption{
+		FederatedDeploymentId: utils.UintPtr(d.ID),
+	})
+	return deployments, err
+}
+
+func (s *federatedDeploymentService) getClusters(d *models.FederatedDeployment) models.FederatedDeploymentClusters {
+	if d.Clusters == nil {
+		return models.FederatedDeploymentClusters{}
+	}
+	return *d.Clusters
+}
+
+func (s *federatedDeploymentService) getMemberCluster(d *models.FederatedDeployment, clusterId uint) *models.FederatedDeploymentCluster {
+	for _, c := range s.getClusters(d) {
+		if c.ClusterId == clusterId {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *federatedDeploymentService) isMember(d *models.FederatedDeployment, clusterId uint) bool {
+	return s.getMemberCluster(d, clusterId) != nil
+}
+
+// getChildKubeNamespace returns the namespace of the child deployment in the cluster,
+// the namespace of the cluster overrides the one of the federated deployment, which overrides the default namespace of the cluster
+func getChildKubeNamespace(d *models.FederatedDeployment, c *models.FederatedDeploymentCluster, defaultKubeNamespace string) string {
+	if c.KubeNamespace != "" {
+		return c.KubeNamespace
+	}
+	if d.KubeNamespace != "" {
+		return d.KubeNamespace
+	}
+	return defaultKubeNamespace
+}
+
+// isCurrentChild reports whether the child deployment is in the namespace of a member cluster,
+// the children left in the previous namespace after the namespace is changed are not current
+func (s *federatedDeploymentService) isCurrentChild(ctx context.Context, d *models.FederatedDeployment, child *models.Deployment) (bool, error) {
+	c := s.getMemberCluster(d, child.ClusterId)
+	if c == nil {
+		return false, nil
+	}
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, child)
+	if err != nil {
+		return false, errors.Wrapf(err, "get cluster %d", child.ClusterId)
+	}
+	return child.KubeNamespace == getChildKubeNamespace(d, c, ClusterService.GetDeploymentKubeNamespace(cluster)), nil
+}
+
+func (s *federatedDeploymentService) getBentosMapping(ctx context.Context, d *models.FederatedDeployment) (map[*models.FederatedDeploymentTarget]*models.Bento, error) {
+	bentosMapping := make(map[*models.FederatedDeploymentTarget]*models.Bento)
+	if d.Targets == nil {
+		return bentosMapping, nil
+	}
+	for _, target := range *d.Targets {
+		bentoRepository, err := BentoRepositoryService.GetByName(ctx, d.OrganizationId, target.BentoRepository)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get bento repository %s", target.BentoRepository)
+		}
+		bento, err := BentoService.GetByVersion(ctx, bentoRepository.ID, target.Bento)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get bento %s:%s", target.BentoRepository, target.Bento)
+		}
+		bentosMapping[target] = bento
+	}
+	return bentosMapping, nil
+}
+
+// getTargetConfig merges the overrides of the cluster into the config of the target
+func (s *federatedDeploymentService) getTargetConfig(target *models.FederatedDeploymentTarget, c *models.FederatedDeploymentCluster) *modelschemas.DeploymentTargetConfig {
+	config := target.Config.DeepCopy()
+	if config == nil {
+		config = &modelschemas.DeploymentTargetConfig{}
+	}
+	config.KubeResourceUid = ""
+	config.KubeResourceVersion = ""
+	if c.Resources != nil {
+		config.Resources = c.Resources.DeepCopy()
+	}
+	if c.MinReplicas != nil || c.MaxReplicas != nil {
+		hpaConf := config.HPAConf.DeepCopy()
+		if hpaConf == nil {
+			hpaConf = &modelschemas.DeploymentTargetHPAConf{}
+		}
+		if c.MinReplicas != nil {
+			minReplicas := *c.MinReplicas
+			hpaConf.MinReplicas = &minReplicas
+		}
+		if c.MaxReplicas != nil {
+			maxReplicas := *c.MaxReplicas
+			hpaConf.MaxReplicas = &maxReplicas
+		}
+		config.HPAConf = hpaConf
+	}
+	return config
+}
+
+func (s *federatedDeploymentService) getLabels(d *models.FederatedDeployment) modelschemas.LabelItemsSchema {
+	if d.Labels == nil {
+		return modelschemas.LabelItemsSchema{}
+	}
+	return modelschemas.LabelItemsSchema(*d.Labels)
+}
+
+// getOrCreateChild returns the child deployment in the namespace of the cluster, a new child is created after the namespace is changed
+func (s *federatedDeploymentService) getOrCreateChild(ctx context.Context, d *models.FederatedDeployment, c *models.FederatedDeploymentCluster, children []*models.Deployment, creatorId uint) (*models.Deployment, error) {
+	cluster, err := ClusterService.Get(ctx, c.ClusterId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get cluster %d", c.ClusterId)
+	}
+	kubeNamespace := getChildKubeNamespace(d, c, ClusterService.GetDeploymentKubeNamespace(cluster))
+
+	for _, child := range children {
+		if child.ClusterId != c.ClusterId || child.KubeNamespace != kubeNamespace {
+			continue
+		}
+		opt := UpdateDeploymentOption{}
+		if child.IngressHost != c.IngressHost {
+			opt.IngressHost = &c.IngressHost
+		}
+		if d.Labels != nil {
+			labels := s.getLabels(d)
+			opt.Labels = &labels
+		}
+		return DeploymentService.Update(ctx, child, opt)
+	}
+
+	_, err = DeploymentService.GetByName(ctx, cluster.ID, kubeNamespace, d.Name)
+	if err == nil {
+		return nil, errors.Errorf("deployment %s already exists in the namespace %s of cluster %s", d.Name, kubeNamespace, cluster.Name)
+	}
+	if !utils.IsNotFound(err) {
+		return nil, err
+	}
+
+	return DeploymentService.Create(ctx, CreateDeploymentOption{
+		CreatorId:             creatorId,
+		ClusterId:             cluster.ID,
+		Name:                  d.Name,
+		Description:           d.Description,
+		Labels:                s.getLabels(d),
+		KubeNamespace:         kubeNamespace,
+		IngressHost:           c.IngressHost,
+		FederatedDeploymentId: &d.ID,
+	})
+}
+
+func (s *federatedDeploymentService) deployChild(ctx context.Context, d *models.FederatedDeployment, c *models.FederatedDeploymentCluster, child *models.Deployment, bentosMapping map[*models.FederatedDeploymentTarget]*models.Bento, creatorId uint) error {
+	deploymentRevision, err := DeploymentRevisionService.Create(ctx, CreateDeploymentRevisionOption{
+		CreatorId:    creatorId,
+		DeploymentId: child.ID,
+		Status:       modelschemas.DeploymentRevisionStatusActive,
+	})
+	if err != nil {
+		return errors.Wrap(err, "create deployment revision")
+	}
+
+	deploymentTargets := make([]*models.DeploymentTarget, 0, len(bentosMapping))
+	for _, target := range *d.Targets {
+		deploymentTarget, err := DeploymentTargetService.Create(ctx, CreateDeploymentTargetOption{
+			CreatorId:            creatorId,
+			DeploymentId:         child.ID,
+			DeploymentRevisionId: deploymentRevision.ID,
+			BentoId:              bentosMapping[target].ID,
+			Type:                 target.Type,
+			CanaryRules:          target.CanaryRules,
+			Config:               s.getTargetConfig(target, c),
+		})
+		if err != nil {
+			return errors.Wrap(err, "create deployment target")
+		}
+		deploymentTargets = append(deploymentTargets, deploymentTarget)
+	}
+
+	return DeploymentRevisionService.Deploy(ctx, deploymentRevision, deploymentTargets, false)
+}
+
+// applyToCluster creates or updates the child deployment of the cluster and deploys it,
+// the rows of the child deployment are rolled back if it fails to be deployed
+func (s *federatedDeploymentService) applyToCluster(ctx context.Context, d *models.FederatedDeployment, c *models.FederatedDeploymentCluster, children []*models.Deployment, bentosMapping map[*models.FederatedDeploymentTarget]*models.Bento, creatorId uint) (err error) {
+	// nolint: ineffassign,staticcheck
+	_, ctx, df, err := startTransaction(ctx)
+	if err != nil {
+		return
+	}
+	defer func() { df(err) }()
+
+	child, err := s.getOrCreateChild(ctx, d, c, children, creatorId)
+	if err != nil {
+		err = errors.Wrap(err, "get or create the child deployment")
+		return
+	}
+	err = s.deployChild(ctx, d, c, child, bentosMapping, creatorId)
+	if err != nil {
+		err = errors.Wrap(err, "deploy the child deployment")
+	}
+	return
+}
+
+func (s *federatedDeploymentService) getClusterName(ctx context.Context, clusterId uint) string {
+	cluster, err := ClusterService.Get(ctx, clusterId)
+	if err != nil {
+		return fmt.Sprintf("%d", clusterId)
+	}
+	return cluster.Name
+}
+
+// getFanOutError reports the clusters in which the operation has succeeded along with the errors of the other clusters,
+// nothing is rolled back in the succeeded clusters
+func getFanOutError(operation string, succeededClusterNames []string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if len(succeededClusterNames) == 0 {
+		return errors.Wrapf(err, "%s failed in every cluster", operation)
+	}
+	return errors.Wrapf(err, "%s only succeeded in the clusters %s", operation, strings.Join(succeededClusterNames, ", "))
+}
+
+// Apply deploys the targets of the federated deployment to the child deployment of every member cluster,
+// the child deployments of the clusters which have been removed from the federated deployment are terminated.
+// A failed cluster does not stop the others, the returned error tells which clusters have succeeded
+func (s *federatedDeploymentService) Apply(ctx context.Context, d *models.FederatedDeployment, creatorId uint) (*models.FederatedDeployment, error) {
+	bentosMapping, err := s.getBentosMapping(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := s.ListChildren(ctx, d)
+	if err != nil {
+		return nil, errors.Wrap(err, "list child deployments")
+	}
+
+	var errs error
+	succeededClusterNames := make([]string, 0)
+	failedClusterIds := make(map[uint]struct{})
+	for _, c := range s.getClusters(d) {
+		clusterName := s.getClusterName(ctx, c.ClusterId)
+		err = s.applyToCluster(ctx, d, c, children, bentosMapping, creatorId)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "apply to cluster %s", clusterName))
+			failedClusterIds[c.ClusterId] = struct{}{}
+			continue
+		}
+		succeededClusterNames = append(succeededClusterNames, clusterName)
+	}
+
+	for _, child := range children {
+		if child.Status == modelschemas.DeploymentStatusTerminated || child.Status == modelschemas.DeploymentStatusTerminating {
+			continue
+		}
+		// the child in the previous namespace keeps serving until the child in the new namespace is deployed
+		if _, ok := failedClusterIds[child.ClusterId]; ok {
+			continue
+		}
+		clusterName := s.getClusterName(ctx, child.ClusterId)
+		current, err := s.isCurrentChild(ctx, d, child)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		if current {
+			continue
+		}
+		_, err = DeploymentService.Terminate(ctx, child)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "terminate the child deployment of the removed cluster or namespace %s/%s", clusterName, child.KubeNamespace))
+			continue
+		}
+		succeededClusterNames = append(succeededClusterNames, clusterName)
+	}
+
+	_, err = s.SyncStatus(ctx, d)
+	if err != nil {
+		errs = multierr.Append(errs, err)
+	}
+	return d, getFanOutError("apply", succeededClusterNames, errs)
+}
+
+func (s *federatedDeploymentService) Terminate(ctx context.Context, d *models.FederatedDeployment) (*models.FederatedDeployment, error) {
+	children, err := s.ListChildren(ctx, d)
+	if err != nil {
+		return nil, errors.Wrap(err, "list child deployments")
+	}
+	var errs error
+	succeededClusterNames := make([]string, 0)
+	for _, child := range children {
+		if child.Status == modelschemas.DeploymentStatusTerminated || child.Status == modelschemas.DeploymentStatusTerminating {
+			continue
+		}
+		clusterName := s.getClusterName(ctx, child.ClusterId)
+		_, err = DeploymentService.Terminate(ctx, child)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "terminate the child deployment of cluster %s", clusterName))
+			continue
+		}
+		succeededClusterNames = append(succeededClusterNames, clusterName)
+	}
+	_, err = s.SyncStatus(ctx, d)
+	if err != nil {
+		errs = multierr.Append(errs, err)
+	}
+	return d, getFanOutError("terminate", succeededClusterNames, errs)
+}
+
+// Delete deletes the federated deployment and its child deployments, all of them should be terminated.
+// The federated deployment is kept if any child deployment fails to be deleted so that the deletion can be retried
+func (s *federatedDeploymentService) Delete(ctx context.Context, d *models.FederatedDeployment) (*models.FederatedDeployment, error) {
+	children, err := s.ListChildren(ctx, d)
+	if err != nil {
+		return nil, errors.Wrap(err, "list child deployments")
+	}
+	var errs error
+	succeededClusterNames := make([]string, 0)
+	for _, child := range children {
+		clusterName := s.getClusterName(ctx, child.ClusterId)
+		_, err = DeploymentService.Delete(ctx, child)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "delete the child deployment of cluster %s", clusterName))
+			continue
+		}
+		succeededClusterNames = append(succeededClusterNames, clusterName)
+	}
+	if errs != nil {
+		return nil, getFanOutError("delete", succeededClusterNames, errs)
+	}
+	return d, s.getBaseDB(ctx).Unscoped().Delete(d).Error
+}
+
+// SyncStatus syncs the status of the child deployment of every member cluster and aggregates them,
+// the children whose status fails to be synced, such as those in an unreachable cluster, are counted as unhealthy
+func (s *federatedDeploymentService) SyncStatus(ctx context.Context, d *models.FederatedDeployment) (modelschemas.DeploymentStatus, error) {
+	now := time.Now()
+	nowPtr := &now
+	_, err := s.UpdateStatus(ctx, d, UpdateFederatedDeploymentStatusOption{
+		SyncingAt: &nowPtr,
+	})
+	if err != nil {
+		return d.Status, err
+	}
+
+	children, err := s.ListChildren(ctx, d)
+	if err != nil {
+		return d.Status, errors.Wrap(err, "list child deployments")
+	}
+
+	var errs error
+	statuses := make([]modelschemas.DeploymentStatus, 0, len(children))
+	for _, child := range children {
+		current, err := s.isCurrentChild(ctx, d, child)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			statuses = append(statuses, modelschemas.DeploymentStatusUnhealthy)
+			continue
+		}
+		if !current {
+			continue
+		}
+		status, err := DeploymentService.SyncStatus(ctx, child)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "sync the status of the child deployment of cluster %s", s.getClusterName(ctx, child.ClusterId)))
+			status = modelschemas.DeploymentStatusUnhealthy
+		}
+		statuses = append(statuses, status)
+	}
+
+	currentStatus := aggregateDeploymentStatuses(statuses)
+	now = time.Now()
+	nowPtr = &now
+	_, err = s.UpdateStatus(ctx, d, UpdateFederatedDeploymentStatusOption{
+		Status:    &currentStatus,
+		UpdatedAt: &nowPtr,
+	})
+	if err != nil {
+		errs = multierr.Append(errs, err)
+	}
+	return currentStatus, errs
+}
+
+// aggregateDeploymentStatuses returns the status shared by all the deployments,
+// otherwise the most significant status among them, a partially running federated deployment is unhealthy
+func aggregateDeploymentStatuses(statuses []modelschemas.DeploymentStatus) modelschemas.DeploymentStatus {
+	if len(statuses) == 0 {
+		return modelschemas.DeploymentStatusNonDeployed
+	}
+	counts := make(map[modelschemas.DeploymentStatus]int, len(statuses))
+	for _, status := range statuses {
+		counts[status]++
+	}
+	if len(counts) == 1 {
+		return statuses[0]
+	}
+	switch {
+	case counts[modelschemas.DeploymentStatusFailed] > 0 || counts[modelschemas.DeploymentStatusImageBuildFailed] > 0:
+		return modelschemas.DeploymentStatusFailed
+	case counts[modelschemas.DeploymentStatusDeploying] > 0 || counts[modelschemas.DeploymentStatusImageBuilding] > 0 || counts[modelschemas.DeploymentStatusImageBuildSucceeded] > 0:
+		return modelschemas.DeploymentStatusDeploying
+	case counts[modelschemas.DeploymentStatusTerminating] > 0:
+		return modelschemas.DeploymentStatusTerminating
+	case counts[modelschemas.DeploymentStatusUnknown] > 0:
+		return modelschemas.DeploymentStatusUnknown
+	default:
+		return modelschemas.DeploymentStatusUnhealthy
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+func TestAggregateDeploymentStatuses(t *testing.T) {
+	cases := []struct {
+		name     string
+		statuses []modelschemas.DeploymentStatus
+		expected modelschemas.DeploymentStatus
+	}{
+		{"no children", nil, modelschemas.DeploymentStatusNonDeployed},
+		{"all running", []modelschemas.DeploymentStatus{modelschemas.DeploymentStatusRunning, modelschemas.DeploymentStatusRunning}, modelschemas.DeploymentStatusRunning},
+		{"all terminated", []modelschemas.DeploymentStatus{modelschemas.DeploymentStatusTerminated, modelschemas.DeploymentStatusTerminated}, modelschemas.DeploymentStatusTerminated},
+		{"one failed", []modelschemas.DeploymentStatus{modelschemas.DeploymentStatusRunning, modelschemas.DeploymentStatusDeploying, modelschemas.DeploymentStatusFailed}, modelschemas.DeploymentStatusFailed},
+		{"image build failed", []modelschemas.DeploymentStatus{modelschemas.DeploymentStatusRunning, modelschemas.DeploymentStatusImageBuildFailed}, modelschemas.DeploymentStatusFailed},
+		{"one deploying", []modelschemas.DeploymentStatus{modelschemas.DeploymentStatusRunning, modelschemas.DeploymentStatusImageBuilding}, modelschemas.DeploymentStatusDeploying},
+		{"one terminating", []modelschemas.DeploymentStatus{modelschemas.DeploymentStatusTerminated, modelschemas.DeploymentStatusTerminating}, modelschemas.DeploymentStatusTerminating},
+		{"one unknown", []modelschemas.DeploymentStatus{modelschemas.DeploymentStatusRunning, modelschemas.DeploymentStatusUnknown}, modelschemas.DeploymentStatusUnknown},
+		{"partially running", []modelschemas.DeploymentStatus{modelschemas.DeploymentStatusRunning, modelschemas.DeploymentStatusTerminated}, modelschemas.DeploymentStatusUnhealthy},
+		{"an unreachable cluster", []modelschemas.DeploymentStatus{modelschemas.DeploymentStatusRunning, modelschemas.DeploymentStatusUnhealthy}, modelschemas.DeploymentStatusUnhealthy},
+	}
+	for _, c := range cases {
+		if status := aggregateDeploymentStatuses(c.statuses); status != c.expected {
+			t.Fatalf("%s: the aggregated status is %s, expected %s", c.name, status, c.expected)
+		}
+	}
+}
+
+func TestFederatedDeploymentGetTargetConfig(t *testing.T) {
+	resources := &modelschemas.DeploymentTargetResources{
+		Requests: &modelschemas.DeploymentTargetResourceItem{CPU: "500m", Memory: "1Gi"},
+	}
+	clusterResources := &modelschemas.DeploymentTargetResources{
+		Requests: &modelschemas.DeploymentTargetResourceItem{CPU: "2", GPU: "1"},
+	}
+	target := &models.FederatedDeploymentTarget{
+		Config: &modelschemas.DeploymentTargetConfig{
+			KubeResourceUid:     "uid",
+			KubeResourceVersion: "1",
+			Resources:           resources,
+			HPAConf:             &modelschemas.DeploymentTargetHPAConf{MinReplicas: utils.Int32Ptr(1), MaxReplicas: utils.Int32Ptr(3)},
+		},
+	}
+	cases := []struct {
+		name              string
+		target            *models.FederatedDeploymentTarget
+		cluster           *models.FederatedDeploymentCluster
+		expectedResources *modelschemas.DeploymentTargetResources
+		expectedHPAConf   *modelschemas.DeploymentTargetHPAConf
+	}{
+		{"no overrides", target, &models.FederatedDeploymentCluster{}, resources, &modelschemas.DeploymentTargetHPAConf{MinReplicas: utils.Int32Ptr(1), MaxReplicas: utils.Int32Ptr(3)}},
+		{"resources", target, &models.FederatedDeploymentCluster{Resources: clusterResources}, clusterResources, &modelschemas.DeploymentTargetHPAConf{MinReplicas: utils.Int32Ptr(1), MaxReplicas: utils.Int32Ptr(3)}},
+		{"min replicas", target, &models.FederatedDeploymentCluster{MinReplicas: utils.Int32Ptr(2)}, resources, &modelschemas.DeploymentTargetHPAConf{MinReplicas: utils.Int32Ptr(2), MaxReplicas: utils.Int32Ptr(3)}},
+		{"both replicas", target, &models.FederatedDeploymentCluster{MinReplicas: utils.Int32Ptr(4), MaxReplicas: utils.Int32Ptr(8)}, resources, &modelschemas.DeploymentTargetHPAConf{MinReplicas: utils.Int32Ptr(4), MaxReplicas: utils.Int32Ptr(8)}},
+		{"no target config", &models.FederatedDeploymentTarget{}, &models.FederatedDeploymentCluster{MaxReplicas: utils.Int32Ptr(2)}, nil, &modelschemas.DeploymentTargetHPAConf{MaxReplicas: utils.Int32Ptr(2)}},
+	}
+	for _, c := range cases {
+		config := FederatedDeploymentService.getTargetConfig(c.target, c.cluster)
+		if config.KubeResourceUid != "" || config.KubeResourceVersion != "" {
+			t.Fatalf("%s: the kube resource of the target is copied", c.name)
+		}
+		if !reflect.DeepEqual(config.Resources, c.expectedResources) {
+			t.Fatalf("%s: the resources are %+v, expected %+v", c.name, config.Resources, c.expectedResources)
+		}
+		if !reflect.DeepEqual(config.HPAConf, c.expectedHPAConf) {
+			t.Fatalf("%s: the hpa config is %+v, expected %+v", c.name, config.HPAConf, c.expectedHPAConf)
+		}
+	}
+	// the config of the target is shared by the clusters, so it is never changed
+	if *target.Config.HPAConf.MinReplicas != 1 || target.Config.KubeResourceUid != "uid" {
+		t.Fatalf("the config of the target is changed: %+v", target.Config)
+	}
+}
+
+func TestGetChildKubeNamespace(t *testing.T) {
+	cases := []struct {
+		name                    string
+		deploymentKubeNamespace string
+		clusterKubeNamespace    string
+		expected                string
+	}{
+		{"the default namespace of the cluster", "", "", "yatai"},
+		{"the namespace of the federated deployment", "prod", "", "prod"},
+		{"the namespace of the cluster", "prod", "prod-eu", "prod-eu"},
+	}
+	for _, c := range cases {
+		d := &models.FederatedDeployment{KubeNamespace: c.deploymentKubeNamespace}
+		cluster := &models.FederatedDeploymentCluster{KubeNamespace: c.clusterKubeNamespace}
+		if kubeNamespace := getChildKubeNamespace(d, cluster, "yatai"); kubeNamespace != c.expected {
+			t.Fatalf("%s: the namespace is %s, expected %s", c.name, kubeNamespace, c.expected)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// the refreshes of the jwks of an issuer are backed off exponentially after the failures
+const (
+	jwtRefreshMinBackoff = time.Second
+	jwtRefreshMaxBackoff = time.Minute
+)
+
+// jwtIssuerKeys are the cached discovery document and signing keys of an issuer, the fields are guarded by the lock of the cache
+type jwtIssuerKeys struct {
+	metadata    *oidcProviderMetadata
+	keys        map[string]*rsa.PublicKey
+	refreshedAt time.Time
+	// it is closed when the refresh in flight finishes, the other requests of the issuer wait for it instead of fetching the jwks again
+	refreshing chan struct{}
+	failures   int
+	failedAt   time.Time
+	lastErr    error
+}
+
+func (k *jwtIssuerKeys) backoff() time.Duration {
+	if k.failures == 0 {
+		return 0
+	}
+	backoff := jwtRefreshMaxBackoff
+	if k.failures <= 6 {
+		backoff = jwtRefreshMinBackoff << uint(k.failures-1)
+	}
+	if backoff > jwtRefreshMaxBackoff {
+		backoff = jwtRefreshMaxBackoff
+	}
+	return backoff
+}
+
+// jwksCache caches the discovery documents and the signing keys of the issuers, it is shared by the oidc login and the jwt auth of the deployments.
+// The lock is not held while an issuer is fetched, only one request of an issuer fetches it at a time and the others wait for it
+type jwksCache struct {
+	mu      sync.Mutex
+	issuers map[string]*jwtIssuerKeys
+}
+
+func fetchJWTKeys(ctx context.Context, issuer string) (*oidcProviderMetadata, map[string]*rsa.PublicKey, error) {
+	var metadata oidcProviderMetadata
+	err := getOIDCJSON(ctx, issuer+"/.well-known/openid-configuration", &metadata)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "get the discovery document of issuer %s", issuer)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != issuer {
+		return nil, nil, errors.Errorf("oidc issuer mismatch, expected %s, got %s", issuer, metadata.Issuer)
+	}
+	var jwks struct {
+		Keys []oidcJSONWebKey `json:"keys"`
+	}
+	err = getOIDCJSON(ctx, metadata.JwksURI, &jwks)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "get the jwks of issuer %s", issuer)
+	}
+	keys, err := parseOIDCJSONWebKeys(jwks.Keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &metadata, keys, nil
+}
+
+// load returns the cached discovery document and signing keys of the issuer, they are fetched again when stale reports that the cached ones are stale
+func (c *jwksCache) load(ctx context.Context, issuer string, stale func(issuerKeys *jwtIssuerKeys) bool) (*oidcProviderMetadata, map[string]*rsa.PublicKey, error) {
+	for {
+		c.mu.Lock()
+		if c.issuers == nil {
+			c.issuers = make(map[string]*jwtIssuerKeys)
+		}
+		issuerKeys, ok := c.issuers[issuer]
+		if !ok {
+			issuerKeys = &jwtIssuerKeys{}
+			c.issuers[issuer] = issuerKeys
+		}
+		if issuerKeys.metadata != nil && !stale(issuerKeys) {
+			metadata, keys := issuerKeys.metadata, issuerKeys.keys
+			c.mu.Unlock()
+			return metadata, keys, nil
+		}
+		if issuerKeys.failures > 0 && time.Since(issuerKeys.failedAt) < issuerKeys.backoff() {
+			lastErr := issuerKeys.lastErr
+			c.mu.Unlock()
+			return nil, nil, errors.Wrapf(lastErr, "the refresh of the jwks of issuer %s is backed off", issuer)
+		}
+		if refreshing := issuerKeys.refreshing; refreshing != nil {
+			c.mu.Unlock()
+			select {
+			case <-refreshing:
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+		refreshing := make(chan struct{})
+		issuerKeys.refreshing = refreshing
+		c.mu.Unlock()
+
+		metadata, keys, err := fetchJWTKeys(ctx, issuer)
+
+		c.mu.Lock()
+		issuerKeys.refreshing = nil
+		close(refreshing)
+		switch {
+		case err == nil:
+			issuerKeys.metadata = metadata
+			issuerKeys.keys = keys
+			issuerKeys.refreshedAt = time.Now()
+			issuerKeys.failures = 0
+			issuerKeys.lastErr = nil
+		case ctx.Err() == nil:
+			// the canceled requests are not the failures of the issuer
+			issuerKeys.failures++
+			issuerKeys.failedAt = time.Now()
+			issuerKeys.lastErr = err
+		}
+		c.mu.Unlock()
+		if err != nil {
+			return nil, nil, err
+		}
+		return metadata, keys, nil
+	}
+}
+
+func (c *jwksCache) getMetadata(ctx context.Context, issuer string) (*oidcProviderMetadata, error) {
+	metadata, _, err := c.load(ctx, issuer, func(issuerKeys *jwtIssuerKeys) bool {
+		return time.Since(issuerKeys.refreshedAt) >= oidcDiscoveryCacheTTL
+	})
+	return metadata, err
+}
+
+// getKey returns the signing key of the issuer by kid, the keys are fetched again when the kid is unknown to follow the key rotation of the issuer
+func (c *jwksCache) getKey(ctx context.Context, issuer, kid string) (*rsa.PublicKey, error) {
+	_, keys, err := c.load(ctx, issuer, func(issuerKeys *jwtIssuerKeys) bool {
+		if _, ok := issuerKeys.keys[kid]; ok {
+			return time.Since(issuerKeys.refreshedAt) >= oidcDiscoveryCacheTTL
+		}
+		return time.Since(issuerKeys.refreshedAt) >= oidcMinRefreshInterval
+	})
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keys[kid]
+	if !ok {
+		return nil, errors.Errorf("unknown signing key %s of issuer %s", kid, issuer)
+	}
+	return key, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJWTIssuerKeysBackoff(t *testing.T) {
+	cases := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{100, time.Minute},
+	}
+	for _, c := range cases {
+		k := &jwtIssuerKeys{failures: c.failures}
+		if backoff := k.backoff(); backoff != c.expected {
+			t.Fatalf("the backoff of %d failures is %s, expected %s", c.failures, backoff, c.expected)
+		}
+	}
+}
+
+func TestJWKSCacheGetMetadataFetchesOnceConcurrently(t *testing.T) {
+	idp := newTestIdP(t)
+	c := &jwksCache{}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.getMetadata(context.Background(), idp.server.URL)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("get metadata: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&idp.jwksRequests); n != 1 {
+		t.Fatalf("the jwks is requested %d times by the concurrent requests, expected 1", n)
+	}
+	// the keys are fetched with the discovery document
+	if _, err := c.getKey(context.Background(), idp.server.URL, testOIDCKid); err != nil {
+		t.Fatalf("get key: %v", err)
+	}
+	if n := atomic.LoadInt32(&idp.jwksRequests); n != 1 {
+		t.Fatalf("the jwks is requested %d times, expected 1", n)
+	}
+}
+
+func TestJWKSCacheDoesNotBlockOnSlowIssuer(t *testing.T) {
+	idp := newTestIdP(t)
+	unblock := make(chan struct{})
+	slowIssuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(slowIssuer.Close)
+	t.Cleanup(func() { close(unblock) })
+
+	c := &jwksCache{}
+	go func() {
+		_, _ = c.getMetadata(context.Background(), slowIssuer.URL)
+	}()
+	// wait for the slow refresh to be in flight
+	for {
+		c.mu.Lock()
+		issuerKeys := c.issuers[slowIssuer.URL]
+		inFlight := issuerKeys != nil && issuerKeys.refreshing != nil
+		c.mu.Unlock()
+		if inFlight {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.getKey(context.Background(), idp.server.URL, testOIDCKid)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("get key: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the requests of an issuer are blocked by the refresh of another issuer")
+	}
+
+	// the requests of the slow issuer give up when their context is done instead of waiting for the refresh in flight
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.getMetadata(ctx, slowIssuer.URL); err != context.DeadlineExceeded {
+		t.Fatalf("the error is %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
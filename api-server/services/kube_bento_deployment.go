@@ -19,6 +19,19 @@ type kubeBentoDeploymentService struct{}
 
 var KubeBentoDeploymentService = kubeBentoDeploymentService{}
 
+// GetKubeName returns the name of the bento deployment CR of the deployment target,
+// canary targets run side by side with the stable target, so they are deployed as separate CRs
+func (s *kubeBentoDeploymentService) GetKubeName(ctx context.Context, deploymentTarget *models.DeploymentTarget) (string, error) {
+	if deploymentTarget.Type == modelschemas.DeploymentTargetTypeCanary {
+		return DeploymentTargetService.GetKubeName(ctx, deploymentTarget)
+	}
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get associated deployment")
+	}
+	return deployment.Name, nil
+}
+
 func (s *kubeBentoDeploymentService) transformToBentoDeploymentV1alpha2(ctx context.Context, deploymentTarget *models.DeploymentTarget) (kubeBentoDeployment *servingv1alpha2.BentoDeployment, err error) {
 	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
 	if err != nil {
@@ -75,13 +88,22 @@ func (s *kubeBentoDeploymentService) transformToBentoDeploymentV1alpha2(ctx cont
 
 	ingress := servingv1alpha2.BentoDeploymentIngressSpec{}
 
-	if deploymentTarget.Config != nil && deploymentTarget.Config.EnableIngress != nil && *deploymentTarget.Config.EnableIngress {
+	managesKubeIngresses, err := DeploymentTargetService.ManagesKubeIngresses(ctx, deploymentTarget)
+	if err != nil {
+		return
+	}
+	if !managesKubeIngresses && deploymentTarget.Config != nil && deploymentTarget.Config.EnableIngress != nil && *deploymentTarget.Config.EnableIngress {
 		ingress.Enabled = true
 	}
 
+	kubeName, err := s.GetKubeName(ctx, deploymentTarget)
+	if err != nil {
+		return
+	}
+
 	kubeBentoDeployment = &servingv1alpha2.BentoDeployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      deployment.Name,
+			Name:      kubeName,
 			Namespace: DeploymentService.GetKubeNamespace(deployment),
 		},
 		Spec: servingv1alpha2.BentoDeploymentSpec{
@@ -111,8 +133,13 @@ func (s *kubeBentoDeploymentService) DeployV1alpha2(ctx context.Context, deploym
 	}
 
 	if deploymentTarget.Config != nil && deploymentTarget.Config.KubeResourceVersion != "" {
+		var kubeName string
+		kubeName, err = s.GetKubeName(ctx, deploymentTarget)
+		if err != nil {
+			return
+		}
 		var oldKubeBentoDeployment *servingv1alpha2.BentoDeployment
-		oldKubeBentoDeployment, err = cli.Get(ctx, deployment.Name, metav1.GetOptions{})
+		oldKubeBentoDeployment, err = cli.Get(ctx, kubeName, metav1.GetOptions{})
 		isNotFound := apierrors.IsNotFound(err)
 		if err != nil && !isNotFound {
 			err = errors.Wrap(err, "failed to get kube bento deployment")
@@ -160,31 +187,7 @@ func (s *kubeBentoDeploymentService) DeployV1alpha2(ctx context.Context, deploym
 			return
 		}
 	} else {
-		kubeBentoDeployment.SetResourceVersion(oldKubeBentoDeployment.GetResourceVersion())
-		if kubeBentoDeployment.Annotations == nil {
-			kubeBentoDeployment.Annotations = make(map[string]string)
-		}
-		for k, v := range oldKubeBentoDeployment.Annotations {
-			if _, ok := kubeBentoDeployment.Annotations[k]; !ok {
-				kubeBentoDeployment.Annotations[k] = v
-			}
-		}
-		if kubeBentoDeployment.Labels == nil {
-			kubeBentoDeployment.Labels = make(map[string]string)
-		}
-		for k, v := range oldKubeBentoDeployment.Labels {
-			if _, ok := kubeBentoDeployment.Labels[k]; !ok {
-				kubeBentoDeployment.Labels[k] = v
-			}
-		}
-		kubeBentoDeployment.Spec.Autoscaling = oldKubeBentoDeployment.Spec.Autoscaling
-		for idx, runner := range kubeBentoDeployment.Spec.Runners {
-			for _, oldRunner := range oldKubeBentoDeployment.Spec.Runners {
-				if runner.Name == oldRunner.Name {
-					kubeBentoDeployment.Spec.Runners[idx].Autoscaling = oldRunner.Autoscaling
-				}
-			}
-		}
+		mergeOldBentoDeploymentV1alpha2(kubeBentoDeployment, oldKubeBentoDeployment)
 		kubeBentoDeployment, err = cli.Update(ctx, kubeBentoDeployment, metav1.UpdateOptions{})
 		if err != nil {
 			err = errors.Wrapf(err, "failed to update kube bento deployment %s", kubeBentoDeployment.Name)
@@ -208,8 +211,13 @@ func (s *kubeBentoDeploymentService) DeployV1alpha3(ctx context.Context, deploym
 	}
 
 	if deploymentTarget.Config != nil && deploymentTarget.Config.KubeResourceVersion != "" {
+		var kubeName string
+		kubeName, err = s.GetKubeName(ctx, deploymentTarget)
+		if err != nil {
+			return
+		}
 		var oldKubeBentoDeployment *servingv1alpha3.BentoDeployment
-		oldKubeBentoDeployment, err = cli.Get(ctx, deployment.Name, metav1.GetOptions{})
+		oldKubeBentoDeployment, err = cli.Get(ctx, kubeName, metav1.GetOptions{})
 		isNotFound := apierrors.IsNotFound(err)
 		if err != nil && !isNotFound {
 			err = errors.Wrap(err, "failed to get kube bento deployment")
@@ -264,47 +272,176 @@ func (s *kubeBentoDeploymentService) DeployV1alpha3(ctx context.Context, deploym
 			return
 		}
 	} else {
-		kubeBentoDeployment.SetResourceVersion(oldKubeBentoDeployment.GetResourceVersion())
-		if kubeBentoDeployment.Annotations == nil {
-			kubeBentoDeployment.Annotations = make(map[string]string)
-		}
-		for k, v := range oldKubeBentoDeployment.Annotations {
-			if _, ok := kubeBentoDeployment.Annotations[k]; !ok {
-				kubeBentoDeployment.Annotations[k] = v
-			}
+		mergeOldBentoDeploymentV1alpha3(kubeBentoDeployment, oldKubeBentoDeployment)
+		kubeBentoDeployment, err = cli.Update(ctx, kubeBentoDeployment, metav1.UpdateOptions{})
+		if err != nil {
+			err = errors.Wrapf(err, "failed to update kube bento deployment %s", kubeBentoDeployment.Name)
+			return
 		}
-		if kubeBentoDeployment.Labels == nil {
-			kubeBentoDeployment.Labels = make(map[string]string)
+	}
+	return
+}
+
+func mergeStringMap(m map[string]string, old map[string]string) map[string]string {
+	if m == nil {
+		m = make(map[string]string)
+	}
+	for k, v := range old {
+		if _, ok := m[k]; !ok {
+			m[k] = v
 		}
-		for k, v := range oldKubeBentoDeployment.Labels {
-			if _, ok := kubeBentoDeployment.Labels[k]; !ok {
-				kubeBentoDeployment.Labels[k] = v
+	}
+	return m
+}
+
+// mergeOldBentoDeploymentV1alpha2 keeps the fields of the live CR which are not managed by yatai
+func mergeOldBentoDeploymentV1alpha2(kubeBentoDeployment, oldKubeBentoDeployment *servingv1alpha2.BentoDeployment) {
+	kubeBentoDeployment.SetResourceVersion(oldKubeBentoDeployment.GetResourceVersion())
+	kubeBentoDeployment.Annotations = mergeStringMap(kubeBentoDeployment.Annotations, oldKubeBentoDeployment.Annotations)
+	kubeBentoDeployment.Labels = mergeStringMap(kubeBentoDeployment.Labels, oldKubeBentoDeployment.Labels)
+	kubeBentoDeployment.Spec.Autoscaling = oldKubeBentoDeployment.Spec.Autoscaling
+	for idx, runner := range kubeBentoDeployment.Spec.Runners {
+		for _, oldRunner := range oldKubeBentoDeployment.Spec.Runners {
+			if runner.Name == oldRunner.Name {
+				kubeBentoDeployment.Spec.Runners[idx].Autoscaling = oldRunner.Autoscaling
 			}
 		}
-		kubeBentoDeployment.Spec.Annotations = oldKubeBentoDeployment.Spec.Annotations
-		kubeBentoDeployment.Spec.Labels = oldKubeBentoDeployment.Spec.Labels
-		kubeBentoDeployment.Spec.ExtraPodMetadata = oldKubeBentoDeployment.Spec.ExtraPodMetadata
-		kubeBentoDeployment.Spec.ExtraPodSpec = oldKubeBentoDeployment.Spec.ExtraPodSpec
-		kubeBentoDeployment.Spec.Ingress.Annotations = oldKubeBentoDeployment.Spec.Ingress.Annotations
-		kubeBentoDeployment.Spec.Ingress.Labels = oldKubeBentoDeployment.Spec.Ingress.Labels
-		kubeBentoDeployment.Spec.Ingress.TLS = oldKubeBentoDeployment.Spec.Ingress.TLS
-		kubeBentoDeployment.Spec.Autoscaling = oldKubeBentoDeployment.Spec.Autoscaling
-		for idx, runner := range kubeBentoDeployment.Spec.Runners {
-			for _, oldRunner := range oldKubeBentoDeployment.Spec.Runners {
-				if runner.Name == oldRunner.Name {
-					kubeBentoDeployment.Spec.Runners[idx].Annotations = oldRunner.Annotations
-					kubeBentoDeployment.Spec.Runners[idx].Labels = oldRunner.Labels
-					kubeBentoDeployment.Spec.Runners[idx].ExtraPodMetadata = oldRunner.ExtraPodMetadata
-					kubeBentoDeployment.Spec.Runners[idx].ExtraPodSpec = oldRunner.ExtraPodSpec
-					kubeBentoDeployment.Spec.Runners[idx].Autoscaling = oldRunner.Autoscaling
-				}
+	}
+}
+
+// mergeOldBentoDeploymentV1alpha3 keeps the fields of the live CR which are not managed by yatai
+func mergeOldBentoDeploymentV1alpha3(kubeBentoDeployment, oldKubeBentoDeployment *servingv1alpha3.BentoDeployment) {
+	kubeBentoDeployment.SetResourceVersion(oldKubeBentoDeployment.GetResourceVersion())
+	kubeBentoDeployment.Annotations = mergeStringMap(kubeBentoDeployment.Annotations, oldKubeBentoDeployment.Annotations)
+	kubeBentoDeployment.Labels = mergeStringMap(kubeBentoDeployment.Labels, oldKubeBentoDeployment.Labels)
+	kubeBentoDeployment.Spec.Annotations = oldKubeBentoDeployment.Spec.Annotations
+	kubeBentoDeployment.Spec.Labels = oldKubeBentoDeployment.Spec.Labels
+	kubeBentoDeployment.Spec.ExtraPodMetadata = oldKubeBentoDeployment.Spec.ExtraPodMetadata
+	kubeBentoDeployment.Spec.ExtraPodSpec = oldKubeBentoDeployment.Spec.ExtraPodSpec
+	kubeBentoDeployment.Spec.Ingress.Annotations = oldKubeBentoDeployment.Spec.Ingress.Annotations
+	kubeBentoDeployment.Spec.Ingress.Labels = oldKubeBentoDeployment.Spec.Ingress.Labels
+	kubeBentoDeployment.Spec.Ingress.TLS = oldKubeBentoDeployment.Spec.Ingress.TLS
+	kubeBentoDeployment.Spec.Autoscaling = oldKubeBentoDeployment.Spec.Autoscaling
+	for idx, runner := range kubeBentoDeployment.Spec.Runners {
+		for _, oldRunner := range oldKubeBentoDeployment.Spec.Runners {
+			if runner.Name == oldRunner.Name {
+				kubeBentoDeployment.Spec.Runners[idx].Annotations = oldRunner.Annotations
+				kubeBentoDeployment.Spec.Runners[idx].Labels = oldRunner.Labels
+				kubeBentoDeployment.Spec.Runners[idx].ExtraPodMetadata = oldRunner.ExtraPodMetadata
+				kubeBentoDeployment.Spec.Runners[idx].ExtraPodSpec = oldRunner.ExtraPodSpec
+				kubeBentoDeployment.Spec.Runners[idx].Autoscaling = oldRunner.Autoscaling
 			}
 		}
-		kubeBentoDeployment, err = cli.Update(ctx, kubeBentoDeployment, metav1.UpdateOptions{})
+	}
+}
+
+// Render returns the bento deployment CR which Deploy would apply for the deployment target without applying it,
+// it is not merged with the live CR, see MergeLive
+func (s *kubeBentoDeploymentService) Render(ctx context.Context, deploymentTarget *models.DeploymentTarget) (metav1.Object, error) {
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get associated deployment")
+	}
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "get associated cluster")
+	}
+	yataiDeploymentComp, err := YataiComponentService.GetByName(ctx, cluster.ID, string(modelschemas.YataiComponentNameDeployment))
+	if err != nil {
+		return nil, errors.Wrap(err, "get yatai deployment component")
+	}
+	kubeBentoDeploymentV1alpha2, err := s.transformToBentoDeploymentV1alpha2(ctx, deploymentTarget)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to transform to kube bento deployment")
+	}
+	if yataiDeploymentComp.Manifest == nil || yataiDeploymentComp.Manifest.LatestCRDVersion != "v1alpha3" {
+		kubeBentoDeploymentV1alpha2.TypeMeta = metav1.TypeMeta{
+			APIVersion: servingv1alpha2.GroupVersion.String(),
+			Kind:       "BentoDeployment",
+		}
+		return kubeBentoDeploymentV1alpha2, nil
+	}
+	kubeBentoDeployment := &servingv1alpha3.BentoDeployment{}
+	err = kubeBentoDeploymentV1alpha2.ConvertTo(kubeBentoDeployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert kube bento deployment v1alpha2 to v1alpha3")
+	}
+	kubeBentoDeployment.TypeMeta = metav1.TypeMeta{
+		APIVersion: servingv1alpha3.GroupVersion.String(),
+		Kind:       "BentoDeployment",
+	}
+	return kubeBentoDeployment, nil
+}
+
+// MergeLive merges the rendered CR with the live CR in the cluster just like Deploy does and returns the live CR,
+// nil is returned if the CR does not exist in the cluster
+func (s *kubeBentoDeploymentService) MergeLive(ctx context.Context, deployment *models.Deployment, kubeBentoDeployment metav1.Object) (metav1.Object, error) {
+	switch kubeBentoDeployment := kubeBentoDeployment.(type) {
+	case *servingv1alpha2.BentoDeployment:
+		cli, err := DeploymentService.GetKubeBentoDeploymentV1alpha2Cli(ctx, deployment)
 		if err != nil {
-			err = errors.Wrapf(err, "failed to update kube bento deployment %s", kubeBentoDeployment.Name)
-			return
+			return nil, errors.Wrap(err, "failed to get kube bento deployment cli")
+		}
+		oldKubeBentoDeployment, err := cli.Get(ctx, kubeBentoDeployment.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get kube bento deployment")
 		}
+		mergeOldBentoDeploymentV1alpha2(kubeBentoDeployment, oldKubeBentoDeployment)
+		return oldKubeBentoDeployment, nil
+	case *servingv1alpha3.BentoDeployment:
+		cli, err := DeploymentService.GetKubeBentoDeploymentV1alpha3Cli(ctx, deployment)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get kube bento deployment cli")
+		}
+		oldKubeBentoDeployment, err := cli.Get(ctx, kubeBentoDeployment.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get kube bento deployment")
+		}
+		mergeOldBentoDeploymentV1alpha3(kubeBentoDeployment, oldKubeBentoDeployment)
+		return oldKubeBentoDeployment, nil
 	}
-	return
+	return nil, errors.Errorf("unsupported kube bento deployment %T", kubeBentoDeployment)
+}
+
+func (s *kubeBentoDeploymentService) Delete(ctx context.Context, deploymentTarget *models.DeploymentTarget) error {
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
+	if err != nil {
+		return errors.Wrap(err, "failed to get associated deployment")
+	}
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return errors.Wrap(err, "get associated cluster")
+	}
+	kubeName, err := s.GetKubeName(ctx, deploymentTarget)
+	if err != nil {
+		return err
+	}
+	yataiDeploymentComp, err := YataiComponentService.GetByName(ctx, cluster.ID, string(modelschemas.YataiComponentNameDeployment))
+	if err != nil {
+		return errors.Wrap(err, "get yatai deployment component")
+	}
+	var deleteErr error
+	if yataiDeploymentComp.Manifest != nil && yataiDeploymentComp.Manifest.LatestCRDVersion == "v1alpha3" {
+		cli, err := DeploymentService.GetKubeBentoDeploymentV1alpha3Cli(ctx, deployment)
+		if err != nil {
+			return errors.Wrap(err, "failed to get kube bento deployment cli")
+		}
+		deleteErr = cli.Delete(ctx, kubeName, metav1.DeleteOptions{})
+	} else {
+		cli, err := DeploymentService.GetKubeBentoDeploymentV1alpha2Cli(ctx, deployment)
+		if err != nil {
+			return errors.Wrap(err, "failed to get kube bento deployment cli")
+		}
+		deleteErr = cli.Delete(ctx, kubeName, metav1.DeleteOptions{})
+	}
+	if deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+		return errors.Wrapf(deleteErr, "failed to delete kube bento deployment %s", kubeName)
+	}
+	return nil
 }
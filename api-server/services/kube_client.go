@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/sync/errsgroup"
+)
+
+var ErrClusterUnreachable = errors.New("cluster is unreachable")
+
+var kubeClientProbeTimeout = 10 * time.Second
+
+type kubeClient struct {
+	// the kubeconfig which the client is built from, the client is rebuilt if the kubeconfig of the cluster is changed
+	kubeConfig string
+	clientSet  *kubernetes.Clientset
+	restConfig *rest.Config
+	// the dynamic client of the custom resources which have no typed clients, e.g. the traefik and gateway api routes
+	dynamicClient dynamic.Interface
+	// the error of the latest failed probe, the requests fail fast until a probe succeeds
+	unreachableErr error
+}
+
+// kubeClientCache are the kubernetes clients of the clusters, they are shared by all the requests
+var (
+	kubeClientCache   = make(map[uint]*kubeClient)
+	kubeClientCacheMu sync.RWMutex
+)
+
+func getCachedKubeClient(c *models.Cluster) (kubeClient, bool) {
+	kubeClientCacheMu.RLock()
+	defer kubeClientCacheMu.RUnlock()
+	client, ok := kubeClientCache[c.ID]
+	if !ok || client.kubeConfig != c.KubeConfig {
+		return kubeClient{}, false
+	}
+	return *client, true
+}
+
+func cacheKubeClient(c *models.Cluster, client *kubeClient) {
+	kubeClientCacheMu.Lock()
+	defer kubeClientCacheMu.Unlock()
+	kubeClientCache[c.ID] = client
+}
+
+func invalidateKubeClient(clusterId uint) {
+	kubeClientCacheMu.Lock()
+	delete(kubeClientCache, clusterId)
+	kubeClientCacheMu.Unlock()
+	stopSharedInformerFactories(clusterId)
+}
+
+func setKubeClientUnreachableErr(c *models.Cluster, unreachableErr error) {
+	kubeClientCacheMu.Lock()
+	defer kubeClientCacheMu.Unlock()
+	client, ok := kubeClientCache[c.ID]
+	if !ok || client.kubeConfig != c.KubeConfig {
+		return
+	}
+	client.unreachableErr = unreachableErr
+}
+
+func (s *clusterService) getKubeClient(ctx context.Context, c *models.Cluster) (kubeClient, error) {
+	if client, ok := getCachedKubeClient(c); ok {
+		return client, nil
+	}
+	clientSet, restConfig, err := s.newKubeCliSet(ctx, c)
+	if err != nil {
+		return kubeClient{}, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return kubeClient{}, errors.Wrap(err, "new dynamic client for k8s config")
+	}
+	client := &kubeClient{
+		kubeConfig:    c.KubeConfig,
+		clientSet:     clientSet,
+		restConfig:    restConfig,
+		dynamicClient: dynamicClient,
+	}
+	cacheKubeClient(c, client)
+	return *client, nil
+}
+
+// ProbeHealth requests the version of the kubernetes api server of the cluster and records the health status of the cluster
+func (s *clusterService) ProbeHealth(ctx context.Context, c *models.Cluster) (*models.Cluster, error) {
+	var info version.Info
+	client, probeErr := s.getKubeClient(ctx, c)
+	if probeErr == nil {
+		ctx_, cancel := context.WithTimeout(ctx, kubeClientProbeTimeout)
+		var body []byte
+		body, probeErr = client.clientSet.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx_).Raw()
+		cancel()
+		if probeErr == nil {
+			probeErr = errors.Wrap(json.Unmarshal(body, &info), "unmarshal kubernetes version")
+		}
+		setKubeClientUnreachableErr(c, probeErr)
+	}
+
+	now := time.Now()
+	healthStatus := models.ClusterHealthStatusHealthy
+	healthMessage := ""
+	kubeVersion := c.KubeVersion
+	if probeErr != nil {
+		healthStatus = models.ClusterHealthStatusUnreachable
+		healthMessage = probeErr.Error()
+	} else {
+		kubeVersion = info.GitVersion
+	}
+	err := s.getBaseDB(ctx).Where("id = ?", c.ID).Updates(map[string]interface{}{
+		"health_status":    healthStatus,
+		"health_message":   healthMessage,
+		"health_probed_at": now,
+		"kube_version":     kubeVersion,
+	}).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "update the health status of cluster %s", c.Name)
+	}
+	c.HealthStatus = healthStatus
+	c.HealthMessage = healthMessage
+	c.HealthProbedAt = &now
+	c.KubeVersion = kubeVersion
+	return c, nil
+}
+
+// ProbeAllHealth probes all the clusters concurrently, an unreachable cluster is not an error
+func (s *clusterService) ProbeAllHealth(ctx context.Context) error {
+	clusters, _, err := s.List(ctx, ListClusterOption{})
+	if err != nil {
+		return errors.Wrap(err, "list clusters")
+	}
+	var eg errsgroup.Group
+	eg.SetPoolSize(100)
+	for _, cluster := range clusters {
+		cluster := cluster
+		eg.Go(func() error {
+			_, err := s.ProbeHealth(ctx, cluster)
+			return err
+		})
+	}
+	return eg.Wait()
+}
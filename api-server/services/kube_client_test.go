@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+func newTestKubeClientCluster(t *testing.T, kubeConfig string) *models.Cluster {
+	c := &models.Cluster{KubeConfig: kubeConfig}
+	c.ID = 1 << 30
+	c.Name = "test"
+	t.Cleanup(func() {
+		invalidateKubeClient(c.ID)
+	})
+	return c
+}
+
+func TestKubeClientCacheInvalidation(t *testing.T) {
+	c := newTestKubeClientCluster(t, "kubeconfig-1")
+	cacheKubeClient(c, &kubeClient{kubeConfig: c.KubeConfig, restConfig: &rest.Config{Host: "https://cluster-1"}})
+
+	client, ok := getCachedKubeClient(c)
+	if !ok || client.restConfig.Host != "https://cluster-1" {
+		t.Fatalf("the cached client is not returned: %v", ok)
+	}
+
+	// the client of the old kubeconfig is not used after the kubeconfig is changed
+	changed := *c
+	changed.KubeConfig = "kubeconfig-2"
+	if _, ok = getCachedKubeClient(&changed); ok {
+		t.Fatal("the client of the old kubeconfig is returned")
+	}
+	setKubeClientUnreachableErr(&changed, errors.New("connection refused"))
+	if client, _ = getCachedKubeClient(c); client.unreachableErr != nil {
+		t.Fatalf("the probe of the new kubeconfig marks the client of the old kubeconfig unreachable: %v", client.unreachableErr)
+	}
+
+	invalidateKubeClient(c.ID)
+	if _, ok = getCachedKubeClient(c); ok {
+		t.Fatal("the client is returned after it is invalidated")
+	}
+}
+
+func TestKubeClientUnreachableErr(t *testing.T) {
+	c := newTestKubeClientCluster(t, "kubeconfig")
+	cacheKubeClient(c, &kubeClient{kubeConfig: c.KubeConfig, restConfig: &rest.Config{Host: "https://cluster"}})
+
+	// a failed probe makes the requests fail fast
+	setKubeClientUnreachableErr(c, errors.New("connection refused"))
+	if _, _, err := ClusterService.GetKubeCliSet(context.Background(), c); !errors.Is(err, ErrClusterUnreachable) {
+		t.Fatalf("the error is %v, expected %v", err, ErrClusterUnreachable)
+	}
+	if _, err := ClusterService.GetDynamicClient(context.Background(), c); !errors.Is(err, ErrClusterUnreachable) {
+		t.Fatalf("the error of the dynamic client is %v, expected %v", err, ErrClusterUnreachable)
+	}
+
+	// a successful probe clears the error
+	setKubeClientUnreachableErr(c, nil)
+	_, restConfig, err := ClusterService.GetKubeCliSet(context.Background(), c)
+	if err != nil {
+		t.Fatalf("the error is not cleared after a successful probe: %v", err)
+	}
+	if restConfig.Host != "https://cluster" {
+		t.Fatalf("the host is %s, expected https://cluster", restConfig.Host)
+	}
+	if _, err = ClusterService.GetDynamicClient(context.Background(), c); err != nil {
+		t.Fatalf("the error of the dynamic client is not cleared after a successful probe: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -16,6 +17,7 @@ import (
 	listerNetworkingV1 "k8s.io/client-go/listers/networking/v1"
 	"k8s.io/client-go/tools/cache"
 
+	"github.com/bentoml/yatai/api-server/metrics"
 	"github.com/bentoml/yatai/api-server/models"
 )
 
@@ -24,26 +26,38 @@ type CacheKey string
 var (
 	informerSyncTimeout = 30 * time.Second
 
-	informerFactoryCache   = make(map[CacheKey]informers.SharedInformerFactory)
+	informerFactoryCache   = make(map[CacheKey]*sharedInformerFactory)
 	informerFactoryCacheRW = lock.NewCASMutex()
 )
 
+// sharedInformerFactory is a cached informer factory of a cluster, its informers are shared by all the requests
+// and run until the factory is stopped, which happens when the kube client of the cluster is invalidated
+type sharedInformerFactory struct {
+	informers.SharedInformerFactory
+	clusterId uint
+	// the kubeconfig which the factory is built from, the factory is rebuilt if the kubeconfig of the cluster is changed
+	kubeConfig string
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+}
+
+func (f *sharedInformerFactory) stop() {
+	f.stopOnce.Do(func() {
+		close(f.stopCh)
+	})
+}
+
 type getSharedInformerFactoryOption struct {
 	cluster   *models.Cluster
 	namespace *string
 }
 
-func getSharedInformerFactory(ctx context.Context, option *getSharedInformerFactoryOption) (informers.SharedInformerFactory, error) {
-	org, err := OrganizationService.GetAssociatedOrganization(ctx, option.cluster)
-	if err != nil {
-		err = errors.Wrap(err, "failed to get associated organization")
-		return nil, err
-	}
+func getSharedInformerFactory(ctx context.Context, option *getSharedInformerFactoryOption) (*sharedInformerFactory, error) {
 	var cacheKey CacheKey
 	if option.namespace != nil {
-		cacheKey = CacheKey(fmt.Sprintf("%s:%s:%s", org.Name, option.cluster.Name, *option.namespace))
+		cacheKey = CacheKey(fmt.Sprintf("%d:%s", option.cluster.ID, *option.namespace))
 	} else {
-		cacheKey = CacheKey(fmt.Sprintf("%s:%s", org.Name, option.cluster.Name))
+		cacheKey = CacheKey(fmt.Sprintf("%d", option.cluster.ID))
 	}
 
 	if locked := informerFactoryCacheRW.TryLockWithContext(ctx); !locked {
@@ -51,26 +65,85 @@ func getSharedInformerFactory(ctx context.Context, option *getSharedInformerFact
 	}
 	defer informerFactoryCacheRW.Unlock()
 
-	var factory informers.SharedInformerFactory
-	var ok bool
-	if factory, ok = informerFactoryCache[cacheKey]; !ok {
-		clientset, _, err := ClusterService.GetKubeCliSet(ctx, option.cluster)
-		if err != nil {
-			err = errors.Wrap(err, "failed to get kubernetes client set")
-			return nil, err
-		}
-		informerOptions := make([]informers.SharedInformerOption, 0)
-		if option.namespace != nil {
-			informerOptions = append(informerOptions, informers.WithNamespace(*option.namespace))
+	if factory, ok := informerFactoryCache[cacheKey]; ok {
+		if factory.kubeConfig == option.cluster.KubeConfig {
+			return factory, nil
 		}
-		factory = informers.NewSharedInformerFactoryWithOptions(clientset, 0, informerOptions...)
+		factory.stop()
+		delete(informerFactoryCache, cacheKey)
 	}
 
+	clientset, _, err := ClusterService.GetKubeCliSet(ctx, option.cluster)
+	if err != nil {
+		err = errors.Wrap(err, "failed to get kubernetes client set")
+		return nil, err
+	}
+	informerOptions := make([]informers.SharedInformerOption, 0)
+	if option.namespace != nil {
+		informerOptions = append(informerOptions, informers.WithNamespace(*option.namespace))
+	}
+	factory := &sharedInformerFactory{
+		SharedInformerFactory: informers.NewSharedInformerFactoryWithOptions(clientset, 0, informerOptions...),
+		clusterId:             option.cluster.ID,
+		kubeConfig:            option.cluster.KubeConfig,
+		stopCh:                make(chan struct{}),
+	}
+	informerFactoryCache[cacheKey] = factory
 	return factory, nil
 }
 
-func startAndSyncInformer(ctx context.Context, informer cache.SharedIndexInformer) (err error) {
-	go informer.Run(ctx.Done())
+// stopSharedInformerFactories stops the informers of the cluster, they are started again with the new kube client by the next request
+func stopSharedInformerFactories(clusterId uint) {
+	informerFactoryCacheRW.Lock()
+	defer informerFactoryCacheRW.Unlock()
+	for cacheKey, factory := range informerFactoryCache {
+		if factory.clusterId != clusterId {
+			continue
+		}
+		factory.stop()
+		delete(informerFactoryCache, cacheKey)
+	}
+	DeploymentStatusWatcherService.unwatchCluster(clusterId)
+}
+
+// runningInformers are the informers which are running with their resources, they are used to report the informer cache sizes
+var (
+	runningInformers   = make(map[cache.SharedIndexInformer]string)
+	runningInformersMu sync.Mutex
+)
+
+func init() {
+	metrics.SetInformerCacheSizesFunc(getInformerCacheSizes)
+}
+
+func getInformerCacheSizes() map[string]int {
+	runningInformersMu.Lock()
+	defer runningInformersMu.Unlock()
+	res := make(map[string]int)
+	for informer, resource := range runningInformers {
+		res[resource] += len(informer.GetStore().ListKeys())
+	}
+	return res
+}
+
+// startAndSyncInformer starts the informer with the factory if it is not running yet and waits for its cache to be synced
+func startAndSyncInformer(ctx context.Context, factory *sharedInformerFactory, resource string, informer cache.SharedIndexInformer) (err error) {
+	factory.Start(factory.stopCh)
+
+	runningInformersMu.Lock()
+	if _, ok := runningInformers[informer]; !ok {
+		runningInformers[informer] = resource
+		go func() {
+			<-factory.stopCh
+			runningInformersMu.Lock()
+			delete(runningInformers, informer)
+			runningInformersMu.Unlock()
+			informerEventHandlersMu.Lock()
+			delete(informerEventHandlers, informer)
+			informerEventHandlersMu.Unlock()
+		}()
+	}
+	runningInformersMu.Unlock()
 
 	ctx_, cancel := context.WithTimeout(ctx, informerSyncTimeout)
 	defer cancel()
@@ -83,6 +156,74 @@ func startAndSyncInformer(ctx context.Context, informer cache.SharedIndexInforme
 	return nil
 }
 
+// informerEventDispatcher dispatches the events of a shared informer to the handlers of the requests,
+// the informers of client-go v0.25 could not remove their handlers, so the handlers are removed from the dispatcher instead
+type informerEventDispatcher struct {
+	mu       sync.RWMutex
+	nextId   int
+	handlers map[int]cache.ResourceEventHandler
+}
+
+func (d *informerEventDispatcher) getHandlers() []cache.ResourceEventHandler {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	res := make([]cache.ResourceEventHandler, 0, len(d.handlers))
+	for _, handler := range d.handlers {
+		res = append(res, handler)
+	}
+	return res
+}
+
+func (d *informerEventDispatcher) OnAdd(obj interface{}) {
+	for _, handler := range d.getHandlers() {
+		handler.OnAdd(obj)
+	}
+}
+
+func (d *informerEventDispatcher) OnUpdate(oldObj, newObj interface{}) {
+	for _, handler := range d.getHandlers() {
+		handler.OnUpdate(oldObj, newObj)
+	}
+}
+
+func (d *informerEventDispatcher) OnDelete(obj interface{}) {
+	for _, handler := range d.getHandlers() {
+		handler.OnDelete(obj)
+	}
+}
+
+var (
+	informerEventHandlers   = make(map[cache.SharedIndexInformer]*informerEventDispatcher)
+	informerEventHandlersMu sync.Mutex
+)
+
+// AddInformerEventHandler adds the event handler to the shared informer until the context is done
+func AddInformerEventHandler(ctx context.Context, informer cache.SharedIndexInformer, handler cache.ResourceEventHandler) {
+	informerEventHandlersMu.Lock()
+	dispatcher, ok := informerEventHandlers[informer]
+	if !ok {
+		dispatcher = &informerEventDispatcher{
+			handlers: make(map[int]cache.ResourceEventHandler),
+		}
+		informerEventHandlers[informer] = dispatcher
+		informer.AddEventHandler(dispatcher)
+	}
+	informerEventHandlersMu.Unlock()
+
+	dispatcher.mu.Lock()
+	id := dispatcher.nextId
+	dispatcher.nextId++
+	dispatcher.handlers[id] = handler
+	dispatcher.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		dispatcher.mu.Lock()
+		delete(dispatcher.handlers, id)
+		dispatcher.mu.Unlock()
+	}()
+}
+
 func GetPodInformer(ctx context.Context, cluster *models.Cluster, namespace string) (informerCoreV1.PodInformer, listerCoreV1.PodNamespaceLister, error) {
 	factory, err := getSharedInformerFactory(ctx, &getSharedInformerFactoryOption{
 		cluster:   cluster,
@@ -92,7 +233,7 @@ func GetPodInformer(ctx context.Context, cluster *models.Cluster, namespace stri
 		return nil, nil, err
 	}
 	podInformer := factory.Core().V1().Pods()
-	err = startAndSyncInformer(ctx, podInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "pods", podInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -108,7 +249,7 @@ func GetDeploymentInformer(ctx context.Context, kubeCluster *models.Cluster, nam
 		return nil, nil, err
 	}
 	deploymentInformer := factory.Apps().V1().Deployments()
-	err = startAndSyncInformer(ctx, deploymentInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "deployments", deploymentInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -124,7 +265,7 @@ func GetStatefulSetInformer(ctx context.Context, kubeCluster *models.Cluster, na
 		return nil, nil, err
 	}
 	statefulSetInformer := factory.Apps().V1().StatefulSets()
-	err = startAndSyncInformer(ctx, statefulSetInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "statefulsets", statefulSetInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -140,7 +281,7 @@ func GetIngressInformer(ctx context.Context, kubeCluster *models.Cluster, namesp
 		return nil, nil, err
 	}
 	ingressInformer := factory.Networking().V1().Ingresses()
-	err = startAndSyncInformer(ctx, ingressInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "ingresses", ingressInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -156,7 +297,7 @@ func GetDaemonSetInformer(ctx context.Context, kubeCluster *models.Cluster, name
 		return nil, nil, err
 	}
 	daemonSetInformer := factory.Apps().V1().DaemonSets()
-	err = startAndSyncInformer(ctx, daemonSetInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "daemonsets", daemonSetInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -172,7 +313,7 @@ func GetEventInformer(ctx context.Context, cluster *models.Cluster, namespace st
 		return nil, nil, err
 	}
 	eventInformer := factory.Core().V1().Events()
-	err = startAndSyncInformer(ctx, eventInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "events", eventInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -188,7 +329,7 @@ func GetNodeEventInformer(ctx context.Context, kubeCluster *models.Cluster) (inf
 		return nil, nil, err
 	}
 	eventInformer := factory.Core().V1().Events()
-	err = startAndSyncInformer(ctx, eventInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "events", eventInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -204,7 +345,7 @@ func GetSecretInformer(ctx context.Context, kubeCluster *models.Cluster, namespa
 		return nil, nil, err
 	}
 	secretInformer := factory.Core().V1().Secrets()
-	err = startAndSyncInformer(ctx, secretInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "secrets", secretInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -220,7 +361,7 @@ func GetConfigMapInformer(ctx context.Context, kubeCluster *models.Cluster, name
 		return nil, nil, err
 	}
 	configMapInformer := factory.Core().V1().ConfigMaps()
-	err = startAndSyncInformer(ctx, configMapInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "configmaps", configMapInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -236,7 +377,7 @@ func GetNodeInformer(ctx context.Context, kubeCluster *models.Cluster) (informer
 		return nil, nil, err
 	}
 	nodeInformer := factory.Core().V1().Nodes()
-	err = startAndSyncInformer(ctx, nodeInformer.Informer())
+	err = startAndSyncInformer(ctx, factory, "nodes", nodeInformer.Informer())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2,16 +2,15 @@ package services
 
 import (
 	"context"
-	"fmt"
-	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	v1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
-	commonconsts "github.com/bentoml/yatai-common/consts"
 	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/common/consts"
@@ -22,109 +21,238 @@ type kubeIngressService struct{}
 
 var KubeIngressService = kubeIngressService{}
 
-func (s *kubeIngressService) ToKubeIngresses(ctx context.Context, deploymentTarget *models.DeploymentTarget, deployOption *models.DeployOption) (ingresses []*v1.Ingress, err error) {
-	kubeName, err := DeploymentTargetService.GetKubeName(ctx, deploymentTarget)
+// ingressCanary are the canary rules of a canary target, they have the semantics of the nginx canary annotations:
+// the header and the cookie take precedence over the weight, their value "always" routes to the canary target and "never" routes to the stable target
+type ingressCanary struct {
+	Weight      *uint
+	Header      *string
+	HeaderValue *string
+	Cookie      *string
+}
+
+// ingressRoute is the provider independent routing of a deployment target
+type ingressRoute struct {
+	Name            string
+	Namespace       string
+	Host            string
+	Labels          map[string]string
+	Annotations     map[string]string
+	OwnerReferences []metav1.OwnerReference
+	ResponseHeaders map[string]string
+	ServiceName     string
+	ServicePort     int32
+	// the service of the stable target, the canary targets fall back to it
+	StableServiceName string
+	// the certificate secret of the host, the host is served over plain http if it is empty
+	TLSSecretName string
+	// the url which authenticates the requests, the requests are not authenticated if it is empty
+	AuthURL string
+	// it is nil for the stable targets
+	Canary *ingressCanary
+	// the routes of the canary targets, they are only set for the providers which route all the targets with the objects of the stable target
+	Canaries []*ingressRoute
+}
+
+type ingressProvider interface {
+	// toKubeObjects translates the route to the kube objects of the provider
+	toKubeObjects(cluster *models.Cluster, route *ingressRoute) ([]*unstructured.Unstructured, error)
+	// kubeResources are the resources of the kube objects, they are deleted by the route name
+	kubeResources() []schema.GroupVersionResource
+	// routesAllTargets is true if the objects of the stable target route the traffic of the canary targets too
+	routesAllTargets() bool
+	// terminatesTLS is true if the objects refer to the certificate secrets of the routes,
+	// otherwise the tls is terminated by the shared listeners which are configured by the cluster admins
+	terminatesTLS() bool
+}
+
+var ingressProviders = map[models.ClusterIngressProvider]ingressProvider{
+	models.ClusterIngressProviderNginx:      nginxIngressProvider{},
+	models.ClusterIngressProviderTraefik:    traefikIngressProvider{},
+	models.ClusterIngressProviderGatewayAPI: gatewayAPIIngressProvider{},
+}
+
+func (s *kubeIngressService) getIngressProvider(ctx context.Context, deployment *models.Deployment) (*models.Cluster, ingressProvider, error) {
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
 	if err != nil {
-		return
+		return nil, nil, errors.Wrap(err, "get associated cluster")
+	}
+	providerName := ClusterService.GetIngressProvider(cluster)
+	provider, ok := ingressProviders[providerName]
+	if !ok {
+		return nil, nil, errors.Errorf("unknown ingress provider %q of cluster %s", providerName, cluster.Name)
+	}
+	return cluster, provider, nil
+}
+
+func (s *kubeIngressService) toIngressRoute(ctx context.Context, deploymentTarget *models.DeploymentTarget, deployOption *models.DeployOption) (*ingressRoute, error) {
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
+	if err != nil {
+		return nil, errors.Wrap(err, "get deployment")
+	}
+
+	// the ingress routes to the service which is created by the operator for the bento deployment
+	kubeName, err := KubeBentoDeploymentService.GetKubeName(ctx, deploymentTarget)
+	if err != nil {
+		return nil, err
 	}
 
 	bento, err := BentoService.GetAssociatedBento(ctx, deploymentTarget)
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	internalHost, err := DeploymentTargetService.GenerateIngressHost(ctx, deploymentTarget)
+	host, err := DeploymentTargetService.GenerateIngressHost(ctx, deploymentTarget)
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	annotations, err := DeploymentTargetService.GetKubeAnnotations(ctx, deploymentTarget)
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	labels, err := DeploymentTargetService.GetKubeLabels(ctx, deploymentTarget)
+	if err != nil {
+		return nil, err
 	}
 
 	tag, err := BentoService.GetTag(ctx, bento)
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "get associated cluster")
+	}
+
+	route := &ingressRoute{
+		Name:            kubeName,
+		Namespace:       DeploymentService.GetKubeNamespace(deployment),
+		Host:            host,
+		Labels:          labels,
+		Annotations:     annotations,
+		OwnerReferences: deployOption.OwnerReferences,
+		ResponseHeaders: map[string]string{
+			"X-Powered-By":  "Yatai",
+			"X-Yatai-Bento": string(tag),
+		},
+		ServiceName: kubeName,
+		ServicePort: consts.BentoServicePort,
+		// the operator names the service of the stable target after the deployment
+		StableServiceName: deployment.Name,
+	}
+
+	if tls := getIngressTLS(cluster, deployment); tls != nil {
+		route.TLSSecretName = tls.SecretName
+	}
+
+	if deployment.AuthConfig != nil {
+		route.AuthURL = DeploymentAuthService.GetAuthURL(deployment)
 	}
 
-	annotations["nginx.ingress.kubernetes.io/configuration-snippet"] = fmt.Sprintf(`
-more_set_headers "X-Powered-By: Yatai";
-more_set_headers "X-Yatai-Bento: %s";
-`, string(tag))
 	if deploymentTarget.Type == modelschemas.DeploymentTargetTypeCanary && deploymentTarget.CanaryRules != nil {
-		// nolint: goconst
-		annotations["nginx.ingress.kubernetes.io/canary"] = "true"
+		canary := &ingressCanary{}
 		for _, rule := range *deploymentTarget.CanaryRules {
 			// nolint: gocritic
 			if rule.Type == modelschemas.DeploymentTargetCanaryRuleTypeWeight && rule.Weight != nil {
-				annotations["nginx.ingress.kubernetes.io/canary-weight"] = strconv.Itoa(int(*rule.Weight))
+				canary.Weight = rule.Weight
 			} else if rule.Type == modelschemas.DeploymentTargetCanaryRuleTypeHeader && rule.Header != nil {
-				annotations["nginx.ingress.kubernetes.io/canary-by-header"] = *rule.Header
-				if rule.HeaderValue != nil {
-					annotations["nginx.ingress.kubernetes.io/canary-by-header-value"] = *rule.HeaderValue
-				}
+				canary.Header = rule.Header
+				canary.HeaderValue = rule.HeaderValue
 			} else if rule.Type == modelschemas.DeploymentTargetCanaryRuleTypeCookie && rule.Cookie != nil {
-				annotations["nginx.ingress.kubernetes.io/canary-by-cookie"] = *rule.Cookie
+				canary.Cookie = rule.Cookie
 			}
 		}
+		route.Canary = canary
 	}
 
-	annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "false"
+	return route, nil
+}
 
-	labels, err := DeploymentTargetService.GetKubeLabels(ctx, deploymentTarget)
+// toStableIngressRoute returns the route of the stable target in the same deployment revision with the routes of the canary targets,
+// the canary target of excludedTargetId is left out
+func (s *kubeIngressService) toStableIngressRoute(ctx context.Context, deploymentTarget *models.DeploymentTarget, deployOption *models.DeployOption, excludedTargetId *uint) (*ingressRoute, error) {
+	deploymentTargets, _, err := DeploymentTargetService.List(ctx, ListDeploymentTargetOption{
+		DeploymentRevisionId: utils.UintPtr(deploymentTarget.DeploymentRevisionId),
+	})
 	if err != nil {
-		return
+		return nil, errors.Wrap(err, "list deployment targets")
 	}
+	var stableRoute *ingressRoute
+	canaryRoutes := make([]*ingressRoute, 0)
+	for _, deploymentTarget_ := range deploymentTargets {
+		if excludedTargetId != nil && deploymentTarget_.ID == *excludedTargetId {
+			continue
+		}
+		route, err := s.toIngressRoute(ctx, deploymentTarget_, deployOption)
+		if err != nil {
+			return nil, err
+		}
+		if deploymentTarget_.Type == modelschemas.DeploymentTargetTypeCanary {
+			canaryRoutes = append(canaryRoutes, route)
+		} else {
+			stableRoute = route
+		}
+	}
+	if stableRoute == nil {
+		return nil, errors.Errorf("deployment revision %d has no stable deployment target", deploymentTarget.DeploymentRevisionId)
+	}
+	stableRoute.Canaries = canaryRoutes
+	return stableRoute, nil
+}
 
-	pathType := v1.PathTypeImplementationSpecific
-
+// ToKubeObjects renders the ingress objects of the deployment target with the ingress provider of its cluster
+func (s *kubeIngressService) ToKubeObjects(ctx context.Context, deploymentTarget *models.DeploymentTarget, deployOption *models.DeployOption) ([]*unstructured.Unstructured, error) {
 	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
 	if err != nil {
 		return nil, errors.Wrap(err, "get deployment")
 	}
-
-	kubeNs := DeploymentService.GetKubeNamespace(deployment)
-
-	interIng := &v1.Ingress{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            kubeName,
-			Namespace:       kubeNs,
-			Labels:          labels,
-			Annotations:     annotations,
-			OwnerReferences: deployOption.OwnerReferences,
-		},
-		Spec: v1.IngressSpec{
-			IngressClassName: utils.StringPtr(commonconsts.KubeIngressClassName),
-			Rules: []v1.IngressRule{
-				{
-					Host: internalHost,
-					IngressRuleValue: v1.IngressRuleValue{
-						HTTP: &v1.HTTPIngressRuleValue{
-							Paths: []v1.HTTPIngressPath{
-								{
-									Path:     "/",
-									PathType: &pathType,
-									Backend: v1.IngressBackend{
-										Service: &v1.IngressServiceBackend{
-											Name: kubeName,
-											Port: v1.ServiceBackendPort{
-												Number: consts.BentoServicePort,
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-		},
+	cluster, provider, err := s.getIngressProvider(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	var route *ingressRoute
+	if provider.routesAllTargets() {
+		route, err = s.toStableIngressRoute(ctx, deploymentTarget, deployOption, nil)
+	} else {
+		route, err = s.toIngressRoute(ctx, deploymentTarget, deployOption)
 	}
+	if err != nil {
+		return nil, err
+	}
+	kubeObjects, err := provider.toKubeObjects(cluster, route)
+	if err != nil {
+		return nil, err
+	}
+	// the canary targets share the certificate of the stable target, it is issued before the routes refer to it
+	tls := getIngressTLS(cluster, deployment)
+	if tls != nil && tls.IssuerName != "" && provider.terminatesTLS() && route.Canary == nil {
+		kubeObjects = append([]*unstructured.Unstructured{toCertificate(route, tls)}, kubeObjects...)
+	}
+	return kubeObjects, nil
+}
 
-	ings := []*v1.Ingress{interIng}
+func getKubeObjectResource(obj *unstructured.Unstructured) schema.GroupVersionResource {
+	resource, _ := meta.UnsafeGuessKindToResource(obj.GroupVersionKind())
+	return resource
+}
 
-	return ings, nil
+// GetLiveKubeObject returns nil if the object does not exist
+func (s *kubeIngressService) GetLiveKubeObject(ctx context.Context, deployment *models.Deployment, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "get associated cluster")
+	}
+	dynamicCli, err := ClusterService.GetDynamicClient(ctx, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "get dynamic client")
+	}
+	liveObj, err := dynamicCli.Resource(getKubeObjectResource(obj)).Namespace(obj.GetNamespace()).Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return liveObj, err
 }
 
 func (s *kubeIngressService) DeployDeploymentTargetAsKubeIngresses(ctx context.Context, deploymentTarget *models.DeploymentTarget, deployOption *models.DeployOption) error {
@@ -133,33 +261,193 @@ func (s *kubeIngressService) DeployDeploymentTargetAsKubeIngresses(ctx context.C
 		return errors.Wrap(err, "get deployment")
 	}
 
-	ingressesCli, err := DeploymentService.GetKubeIngressesCli(ctx, deployment)
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
 	if err != nil {
-		return errors.Wrap(err, "get kube ingresses cli")
+		return errors.Wrap(err, "get associated cluster")
 	}
 
-	kubeIngresses, err := s.ToKubeIngresses(ctx, deploymentTarget, deployOption)
+	dynamicCli, err := ClusterService.GetDynamicClient(ctx, cluster)
+	if err != nil {
+		return errors.Wrap(err, "get dynamic client")
+	}
+
+	_, provider, err := s.getIngressProvider(ctx, deployment)
 	if err != nil {
 		return err
 	}
-	for _, kubeIng := range kubeIngresses {
-		logrus.Infof("get k8s ingress %s ...", kubeIng.Name)
-		_, err = ingressesCli.Get(ctx, kubeIng.Name, metav1.GetOptions{})
+
+	tls := getIngressTLS(cluster, deployment)
+	if tls != nil && tls.WildcardSecretName != "" && provider.terminatesTLS() {
+		err = s.syncWildcardCertificateSecret(ctx, cluster, tls, DeploymentService.GetKubeNamespace(deployment))
+		if err != nil {
+			return errors.Wrap(err, "sync wildcard certificate secret")
+		}
+	}
+
+	kubeObjects, err := s.ToKubeObjects(ctx, deploymentTarget, deployOption)
+	if err != nil {
+		return err
+	}
+	for _, kubeObject := range kubeObjects {
+		kind := kubeObject.GetKind()
+		cli := dynamicCli.Resource(getKubeObjectResource(kubeObject)).Namespace(kubeObject.GetNamespace())
+		logrus.Infof("get k8s %s %s ...", kind, kubeObject.GetName())
+		oldKubeObject, err := cli.Get(ctx, kubeObject.GetName(), metav1.GetOptions{})
 		notFound := apierrors.IsNotFound(err)
 		if !notFound && err != nil {
-			return errors.Wrapf(err, "get k8s ingress %s", kubeIng.Name)
+			return errors.Wrapf(err, "get k8s %s %s", kind, kubeObject.GetName())
 		}
 		if notFound {
-			logrus.Infof("create k8s ingress %s ...", kubeIng.Name)
-			_, err = ingressesCli.Create(ctx, kubeIng, metav1.CreateOptions{})
+			logrus.Infof("create k8s %s %s ...", kind, kubeObject.GetName())
+			_, err = cli.Create(ctx, kubeObject, metav1.CreateOptions{})
 			if err != nil {
-				return errors.Wrapf(err, "create k8s ingress %s", kubeIng.Name)
+				return errors.Wrapf(err, "create k8s %s %s", kind, kubeObject.GetName())
 			}
 		} else {
-			logrus.Infof("update k8s ingress %s ...", kubeIng.Name)
-			_, err = ingressesCli.Update(ctx, kubeIng, metav1.UpdateOptions{})
+			logrus.Infof("update k8s %s %s ...", kind, kubeObject.GetName())
+			kubeObject.SetResourceVersion(oldKubeObject.GetResourceVersion())
+			_, err = cli.Update(ctx, kubeObject, metav1.UpdateOptions{})
+			if err != nil {
+				return errors.Wrapf(err, "update k8s %s %s", kind, kubeObject.GetName())
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteDeploymentTargetKubeIngresses removes the routing of the deployment target,
+// the objects of the stable target are rendered again without the deployment target if they route all the targets
+func (s *kubeIngressService) DeleteDeploymentTargetKubeIngresses(ctx context.Context, deploymentTarget *models.DeploymentTarget, deployOption *models.DeployOption) error {
+	deployment, err := DeploymentService.GetAssociatedDeployment(ctx, deploymentTarget)
+	if err != nil {
+		return errors.Wrap(err, "get deployment")
+	}
+
+	cluster, provider, err := s.getIngressProvider(ctx, deployment)
+	if err != nil {
+		return err
+	}
+
+	dynamicCli, err := ClusterService.GetDynamicClient(ctx, cluster)
+	if err != nil {
+		return errors.Wrap(err, "get dynamic client")
+	}
+
+	if provider.routesAllTargets() && deploymentTarget.Type == modelschemas.DeploymentTargetTypeCanary {
+		route, err := s.toStableIngressRoute(ctx, deploymentTarget, deployOption, &deploymentTarget.ID)
+		if err != nil {
+			return err
+		}
+		kubeObjects, err := provider.toKubeObjects(cluster, route)
+		if err != nil {
+			return err
+		}
+		for _, kubeObject := range kubeObjects {
+			cli := dynamicCli.Resource(getKubeObjectResource(kubeObject)).Namespace(kubeObject.GetNamespace())
+			oldKubeObject, err := cli.Get(ctx, kubeObject.GetName(), metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			}
 			if err != nil {
-				return errors.Wrapf(err, "update k8s ingress %s", kubeIng.Name)
+				return errors.Wrapf(err, "get k8s %s %s", kubeObject.GetKind(), kubeObject.GetName())
+			}
+			kubeObject.SetResourceVersion(oldKubeObject.GetResourceVersion())
+			_, err = cli.Update(ctx, kubeObject, metav1.UpdateOptions{})
+			if err != nil {
+				return errors.Wrapf(err, "update k8s %s %s", kubeObject.GetKind(), kubeObject.GetName())
+			}
+		}
+		return nil
+	}
+
+	kubeName, err := KubeBentoDeploymentService.GetKubeName(ctx, deploymentTarget)
+	if err != nil {
+		return err
+	}
+	kubeNs := DeploymentService.GetKubeNamespace(deployment)
+	for _, resource := range provider.kubeResources() {
+		err = dynamicCli.Resource(resource).Namespace(kubeNs).Delete(ctx, kubeName, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete k8s %s %s", resource.Resource, kubeName)
+		}
+	}
+	return nil
+}
+
+// MoveDeploymentKubeIngresses deploys the active targets of the deployment again with the ingress provider of the new cluster config,
+// then it deletes the objects of the old provider, the targets are deployed again because the operator routes the targets which are not managed by yatai
+func (s *kubeIngressService) MoveDeploymentKubeIngresses(ctx context.Context, deployment *models.Deployment, oldCluster, newCluster *models.Cluster) error {
+	oldProviderName := ClusterService.GetIngressProvider(oldCluster)
+	oldProvider, ok := ingressProviders[oldProviderName]
+	if !ok {
+		return errors.Errorf("unknown ingress provider %q of cluster %s", oldProviderName, oldCluster.Name)
+	}
+	status := modelschemas.DeploymentRevisionStatusActive
+	deploymentRevisions, _, err := DeploymentRevisionService.List(ctx, ListDeploymentRevisionOption{
+		BaseListOption: BaseListOption{
+			Start: utils.UintPtr(0),
+			Count: utils.UintPtr(1),
+		},
+		DeploymentId: utils.UintPtr(deployment.ID),
+		Status:       &status,
+	})
+	if err != nil {
+		return errors.Wrap(err, "list active deployment revisions")
+	}
+	if len(deploymentRevisions) == 0 {
+		return nil
+	}
+	deploymentTargets, _, err := DeploymentTargetService.List(ctx, ListDeploymentTargetOption{
+		DeploymentRevisionId: utils.UintPtr(deploymentRevisions[0].ID),
+	})
+	if err != nil {
+		return errors.Wrap(err, "list deployment targets")
+	}
+
+	oldDeployment := *deployment
+	oldDeployment.SetAssociatedClusterCache(oldCluster)
+	oldManaged := make(map[uint]bool, len(deploymentTargets))
+	for _, deploymentTarget := range deploymentTargets {
+		deploymentTarget.SetAssociatedDeploymentCache(&oldDeployment)
+		oldManaged[deploymentTarget.ID], err = DeploymentTargetService.ManagesKubeIngresses(ctx, deploymentTarget)
+		if err != nil {
+			return err
+		}
+	}
+
+	deployOption, err := DeploymentRevisionService.GetDeployOption(ctx, deploymentRevisions[0], false)
+	if err != nil {
+		return errors.Wrap(err, "get deploy option")
+	}
+	newDeployment := *deployment
+	newDeployment.SetAssociatedClusterCache(newCluster)
+	for _, deploymentTarget := range deploymentTargets {
+		deploymentTarget.SetAssociatedDeploymentCache(&newDeployment)
+		_, err = DeploymentTargetService.Deploy(ctx, deploymentTarget, deployOption)
+		if err != nil {
+			return errors.Wrapf(err, "deploy deployment target %d", deploymentTarget.ID)
+		}
+	}
+
+	// the objects of the old provider are deleted after all the targets are routed by the new provider,
+	// the targets which were not managed by yatai have no objects of the old provider, the ingresses of the operator are kept
+	dynamicCli, err := ClusterService.GetDynamicClient(ctx, oldCluster)
+	if err != nil {
+		return errors.Wrap(err, "get dynamic client")
+	}
+	kubeNs := DeploymentService.GetKubeNamespace(deployment)
+	for _, deploymentTarget := range deploymentTargets {
+		if !oldManaged[deploymentTarget.ID] {
+			continue
+		}
+		kubeName, err := KubeBentoDeploymentService.GetKubeName(ctx, deploymentTarget)
+		if err != nil {
+			return err
+		}
+		for _, resource := range oldProvider.kubeResources() {
+			err = dynamicCli.Resource(resource).Namespace(kubeNs).Delete(ctx, kubeName, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "delete k8s %s %s", resource.Resource, kubeName)
 			}
 		}
 	}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+var gatewayAPIGroupVersion = schema.GroupVersion{Group: "gateway.networking.k8s.io", Version: "v1beta1"}
+
+// gatewayAPIIngressProvider routes all the targets of a deployment with one HTTPRoute,
+// the gateway api merges the rules of the http routes with the same hostname and the oldest route wins the conflicts,
+// so the weights of the stable and the canary targets can not be split into several routes
+type gatewayAPIIngressProvider struct{}
+
+func gatewayAPIBackendRef(name string, port int32, weight *int64) map[string]interface{} {
+	backendRef := map[string]interface{}{
+		"name": name,
+		"port": int64(port),
+	}
+	if weight != nil {
+		backendRef["weight"] = *weight
+	}
+	return backendRef
+}
+
+func gatewayAPIRule(headers []map[string]interface{}, backendRefs ...map[string]interface{}) map[string]interface{} {
+	match := map[string]interface{}{
+		"path": map[string]interface{}{
+			"type":  "PathPrefix",
+			"value": "/",
+		},
+	}
+	if len(headers) > 0 {
+		headers_ := make([]interface{}, 0, len(headers))
+		for _, header := range headers {
+			headers_ = append(headers_, header)
+		}
+		match["headers"] = headers_
+	}
+	backendRefs_ := make([]interface{}, 0, len(backendRefs))
+	for _, backendRef := range backendRefs {
+		backendRefs_ = append(backendRefs_, backendRef)
+	}
+	return map[string]interface{}{
+		"matches":     []interface{}{match},
+		"backendRefs": backendRefs_,
+	}
+}
+
+func gatewayAPIHeaderMatch(name, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  "Exact",
+		"name":  name,
+		"value": value,
+	}
+}
+
+func gatewayAPICookieMatch(cookie, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  "RegularExpression",
+		"name":  "Cookie",
+		"value": fmt.Sprintf("(^|;\\s*)%s=%s(;|$)", regexp.QuoteMeta(cookie), regexp.QuoteMeta(value)),
+	}
+}
+
+func (gatewayAPIIngressProvider) toKubeObjects(cluster *models.Cluster, route *ingressRoute) ([]*unstructured.Unstructured, error) {
+	// the http routes can not authenticate the requests, the deployments which require authentication are never exposed without it
+	if route.AuthURL != "" {
+		return nil, errors.New("the gateway-api ingress provider does not support the deployment authentication")
+	}
+	// the rules with header matches take precedence over the rule which only matches the path
+	rules := make([]interface{}, 0)
+	stableBackendRef := gatewayAPIBackendRef(route.ServiceName, route.ServicePort, nil)
+	stableWeight := int64(100)
+	weightedBackendRefs := make([]map[string]interface{}, 0)
+	for _, canaryRoute := range route.Canaries {
+		canary := canaryRoute.Canary
+		if canary == nil {
+			continue
+		}
+		canaryBackendRef := gatewayAPIBackendRef(canaryRoute.ServiceName, canaryRoute.ServicePort, nil)
+		if canary.Header != nil {
+			if canary.HeaderValue != nil {
+				rules = append(rules, gatewayAPIRule([]map[string]interface{}{gatewayAPIHeaderMatch(*canary.Header, *canary.HeaderValue)}, canaryBackendRef))
+			} else {
+				rules = append(rules, gatewayAPIRule([]map[string]interface{}{gatewayAPIHeaderMatch(*canary.Header, "always")}, canaryBackendRef))
+				rules = append(rules, gatewayAPIRule([]map[string]interface{}{gatewayAPIHeaderMatch(*canary.Header, "never")}, stableBackendRef))
+			}
+		}
+		if canary.Cookie != nil {
+			rules = append(rules, gatewayAPIRule([]map[string]interface{}{gatewayAPICookieMatch(*canary.Cookie, "always")}, canaryBackendRef))
+			rules = append(rules, gatewayAPIRule([]map[string]interface{}{gatewayAPICookieMatch(*canary.Cookie, "never")}, stableBackendRef))
+		}
+		if canary.Weight != nil && *canary.Weight > 0 {
+			weight := int64(*canary.Weight)
+			if weight > stableWeight {
+				weight = stableWeight
+			}
+			stableWeight -= weight
+			weightedBackendRefs = append(weightedBackendRefs, gatewayAPIBackendRef(canaryRoute.ServiceName, canaryRoute.ServicePort, &weight))
+		}
+	}
+	weightedBackendRefs = append([]map[string]interface{}{gatewayAPIBackendRef(route.ServiceName, route.ServicePort, &stableWeight)}, weightedBackendRefs...)
+	rules = append(rules, gatewayAPIRule(nil, weightedBackendRefs...))
+
+	parentRef := map[string]interface{}{}
+	if cluster.IngressConfig != nil {
+		parentRef["name"] = cluster.IngressConfig.GatewayName
+		if cluster.IngressConfig.GatewayNamespace != "" {
+			parentRef["namespace"] = cluster.IngressConfig.GatewayNamespace
+		}
+	}
+
+	httpRoute := &unstructured.Unstructured{}
+	httpRoute.SetGroupVersionKind(gatewayAPIGroupVersion.WithKind("HTTPRoute"))
+	httpRoute.SetName(route.Name)
+	httpRoute.SetNamespace(route.Namespace)
+	httpRoute.SetLabels(route.Labels)
+	httpRoute.SetAnnotations(route.Annotations)
+	httpRoute.SetOwnerReferences(route.OwnerReferences)
+	httpRoute.Object["spec"] = map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"hostnames":  []interface{}{route.Host},
+		"rules":      rules,
+	}
+	return []*unstructured.Unstructured{httpRoute}, nil
+}
+
+func (gatewayAPIIngressProvider) kubeResources() []schema.GroupVersionResource {
+	return []schema.GroupVersionResource{
+		gatewayAPIGroupVersion.WithResource("httproutes"),
+	}
+}
+
+func (gatewayAPIIngressProvider) routesAllTargets() bool {
+	return true
+}
+
+// the http routes can not refer to certificates, the https listeners of the gateway must serve the certificates of the deployment hostnames
+func (gatewayAPIIngressProvider) terminatesTLS() bool {
+	return false
+}
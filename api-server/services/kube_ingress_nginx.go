@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	commonconsts "github.com/bentoml/yatai-common/consts"
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+// nginxIngressProvider routes the canary targets with the canary annotations of ingress-nginx
+type nginxIngressProvider struct{}
+
+func getIngressClassName(cluster *models.Cluster) string {
+	if cluster.IngressConfig != nil && cluster.IngressConfig.IngressClassName != "" {
+		return cluster.IngressConfig.IngressClassName
+	}
+	return commonconsts.KubeIngressClassName
+}
+
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "convert to unstructured")
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+func (nginxIngressProvider) toKubeObjects(cluster *models.Cluster, route *ingressRoute) ([]*unstructured.Unstructured, error) {
+	annotations := copyStringMap(route.Annotations)
+
+	headerNames := make([]string, 0, len(route.ResponseHeaders))
+	for name := range route.ResponseHeaders {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	var snippet strings.Builder
+	snippet.WriteString("\n")
+	for _, name := range headerNames {
+		snippet.WriteString(fmt.Sprintf("more_set_headers \"%s: %s\";\n", name, route.ResponseHeaders[name]))
+	}
+	annotations["nginx.ingress.kubernetes.io/configuration-snippet"] = snippet.String()
+
+	if route.Canary != nil {
+		// nolint: goconst
+		annotations["nginx.ingress.kubernetes.io/canary"] = "true"
+		if route.Canary.Weight != nil {
+			annotations["nginx.ingress.kubernetes.io/canary-weight"] = strconv.Itoa(int(*route.Canary.Weight))
+		}
+		if route.Canary.Header != nil {
+			annotations["nginx.ingress.kubernetes.io/canary-by-header"] = *route.Canary.Header
+			if route.Canary.HeaderValue != nil {
+				annotations["nginx.ingress.kubernetes.io/canary-by-header-value"] = *route.Canary.HeaderValue
+			}
+		}
+		if route.Canary.Cookie != nil {
+			annotations["nginx.ingress.kubernetes.io/canary-by-cookie"] = *route.Canary.Cookie
+		}
+	}
+
+	if route.AuthURL != "" {
+		annotations["nginx.ingress.kubernetes.io/auth-url"] = route.AuthURL
+		annotations["nginx.ingress.kubernetes.io/auth-response-headers"] = DeploymentAuthApiKeyHeader
+		// the accepted credentials are cached by the host, the auth cache of nginx is shared by all the ingresses.
+		// The requests which are answered from the cache do not reach yatai, so they are not counted in the usage of the api keys
+		annotations["nginx.ingress.kubernetes.io/auth-cache-key"] = "$host$http_authorization"
+		annotations["nginx.ingress.kubernetes.io/auth-cache-duration"] = fmt.Sprintf("200 202 %ds", int(deploymentAuthCacheTTL.Seconds()))
+	}
+
+	annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = strconv.FormatBool(route.TLSSecretName != "")
+
+	pathType := v1.PathTypeImplementationSpecific
+	ingressClassName := getIngressClassName(cluster)
+
+	ing := &v1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "Ingress",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            route.Name,
+			Namespace:       route.Namespace,
+			Labels:          route.Labels,
+			Annotations:     annotations,
+			OwnerReferences: route.OwnerReferences,
+		},
+		Spec: v1.IngressSpec{
+			IngressClassName: &ingressClassName,
+			Rules: []v1.IngressRule{
+				{
+					Host: route.Host,
+					IngressRuleValue: v1.IngressRuleValue{
+						HTTP: &v1.HTTPIngressRuleValue{
+							Paths: []v1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: &pathType,
+									Backend: v1.IngressBackend{
+										Service: &v1.IngressServiceBackend{
+											Name: route.ServiceName,
+											Port: v1.ServiceBackendPort{
+												Number: route.ServicePort,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if route.TLSSecretName != "" {
+		ing.Spec.TLS = []v1.IngressTLS{
+			{
+				Hosts:      []string{route.Host},
+				SecretName: route.TLSSecretName,
+			},
+		}
+	}
+
+	obj, err := toUnstructured(ing)
+	if err != nil {
+		return nil, err
+	}
+	return []*unstructured.Unstructured{obj}, nil
+}
+
+func (nginxIngressProvider) kubeResources() []schema.GroupVersionResource {
+	return []schema.GroupVersionResource{
+		v1.SchemeGroupVersion.WithResource("ingresses"),
+	}
+}
+
+func (nginxIngressProvider) routesAllTargets() bool {
+	return false
+}
+
+func (nginxIngressProvider) terminatesTLS() bool {
+	return true
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+func newTestIngressRoute(serviceName string, canary *ingressCanary) *ingressRoute {
+	return &ingressRoute{
+		Name:              serviceName,
+		Namespace:         "yatai",
+		Host:              "iris.example.com",
+		ResponseHeaders:   map[string]string{"X-Powered-By": "Yatai"},
+		ServiceName:       serviceName,
+		ServicePort:       3000,
+		StableServiceName: "iris",
+		Canary:            canary,
+	}
+}
+
+func getTestKubeObject(t *testing.T, objs []*unstructured.Unstructured, kind string) *unstructured.Unstructured {
+	for _, obj := range objs {
+		if obj.GetKind() == kind {
+			return obj
+		}
+	}
+	t.Fatalf("no %s is rendered", kind)
+	return nil
+}
+
+func getTestSlice(t *testing.T, obj map[string]interface{}, fields ...string) []interface{} {
+	s, found, err := unstructured.NestedSlice(obj, fields...)
+	if err != nil || !found {
+		t.Fatalf("%v is not found: %v", fields, err)
+	}
+	return s
+}
+
+func TestNginxIngressProviderCanary(t *testing.T) {
+	cluster := &models.Cluster{}
+	cases := []struct {
+		name     string
+		canary   *ingressCanary
+		expected map[string]string
+	}{
+		{
+			name:     "stable",
+			expected: map[string]string{},
+		},
+		{
+			name:   "weight",
+			canary: &ingressCanary{Weight: utils.UintPtr(20)},
+			expected: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":        "true",
+				"nginx.ingress.kubernetes.io/canary-weight": "20",
+			},
+		},
+		{
+			name:   "header with value",
+			canary: &ingressCanary{Header: utils.StringPtr("X-Canary"), HeaderValue: utils.StringPtr("yes")},
+			expected: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":                 "true",
+				"nginx.ingress.kubernetes.io/canary-by-header":       "X-Canary",
+				"nginx.ingress.kubernetes.io/canary-by-header-value": "yes",
+			},
+		},
+		{
+			name:   "header and cookie",
+			canary: &ingressCanary{Header: utils.StringPtr("X-Canary"), Cookie: utils.StringPtr("canary")},
+			expected: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":           "true",
+				"nginx.ingress.kubernetes.io/canary-by-header": "X-Canary",
+				"nginx.ingress.kubernetes.io/canary-by-cookie": "canary",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objs, err := nginxIngressProvider{}.toKubeObjects(cluster, newTestIngressRoute("iris-canary", c.canary))
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			annotations := getTestKubeObject(t, objs, "Ingress").GetAnnotations()
+			canaryAnnotations := make(map[string]string)
+			for _, key := range []string{
+				"nginx.ingress.kubernetes.io/canary",
+				"nginx.ingress.kubernetes.io/canary-weight",
+				"nginx.ingress.kubernetes.io/canary-by-header",
+				"nginx.ingress.kubernetes.io/canary-by-header-value",
+				"nginx.ingress.kubernetes.io/canary-by-cookie",
+			} {
+				if value, ok := annotations[key]; ok {
+					canaryAnnotations[key] = value
+				}
+			}
+			if !reflect.DeepEqual(canaryAnnotations, c.expected) {
+				t.Fatalf("the canary annotations are %v, expected %v", canaryAnnotations, c.expected)
+			}
+		})
+	}
+}
+
+// traefikTestRoute is the part of a traefik route which decides where the requests go
+type traefikTestRoute struct {
+	match    string
+	priority int64
+	// service name to weight, the weight is -1 if it is not set
+	services map[string]int64
+}
+
+func getTraefikTestRoutes(t *testing.T, objs []*unstructured.Unstructured) []traefikTestRoute {
+	ingressRoute := getTestKubeObject(t, objs, "IngressRoute")
+	routes := make([]traefikTestRoute, 0)
+	for _, r := range getTestSlice(t, ingressRoute.Object, "spec", "routes") {
+		r_ := r.(map[string]interface{})
+		route := traefikTestRoute{
+			match:    r_["match"].(string),
+			services: make(map[string]int64),
+		}
+		if priority, ok := r_["priority"]; ok {
+			route.priority = priority.(int64)
+		}
+		for _, service := range r_["services"].([]interface{}) {
+			service_ := service.(map[string]interface{})
+			weight := int64(-1)
+			if w, ok := service_["weight"]; ok {
+				weight = w.(int64)
+			}
+			route.services[service_["name"].(string)] = weight
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+func TestTraefikIngressProviderCanary(t *testing.T) {
+	cluster := &models.Cluster{}
+	hostMatch := "Host(`iris.example.com`)"
+	cases := []struct {
+		name     string
+		canary   *ingressCanary
+		expected []traefikTestRoute
+	}{
+		{
+			name: "stable",
+			expected: []traefikTestRoute{
+				{match: hostMatch, services: map[string]int64{"iris-canary": -1}},
+			},
+		},
+		{
+			name:   "weight",
+			canary: &ingressCanary{Weight: utils.UintPtr(30)},
+			expected: []traefikTestRoute{
+				{match: hostMatch, priority: traefikCanaryWeightPriority, services: map[string]int64{"iris": 70, "iris-canary": 30}},
+			},
+		},
+		{
+			name:   "weight over 100",
+			canary: &ingressCanary{Weight: utils.UintPtr(150)},
+			expected: []traefikTestRoute{
+				{match: hostMatch, priority: traefikCanaryWeightPriority, services: map[string]int64{"iris": 0, "iris-canary": 100}},
+			},
+		},
+		{
+			name:   "header with value",
+			canary: &ingressCanary{Header: utils.StringPtr("X-Canary"), HeaderValue: utils.StringPtr("yes")},
+			expected: []traefikTestRoute{
+				{match: hostMatch + " && Headers(`X-Canary`, `yes`)", priority: traefikCanaryHeaderPriority, services: map[string]int64{"iris-canary": -1}},
+			},
+		},
+		{
+			name:   "header and cookie",
+			canary: &ingressCanary{Header: utils.StringPtr("X-Canary"), Cookie: utils.StringPtr("canary")},
+			expected: []traefikTestRoute{
+				{match: hostMatch + " && Headers(`X-Canary`, `always`)", priority: traefikCanaryHeaderPriority, services: map[string]int64{"iris-canary": -1}},
+				{match: hostMatch + " && Headers(`X-Canary`, `never`)", priority: traefikCanaryHeaderPriority, services: map[string]int64{"iris": -1}},
+				{match: hostMatch + " && " + traefikCookieMatch("canary", "always"), priority: traefikCanaryCookiePriority, services: map[string]int64{"iris-canary": -1}},
+				{match: hostMatch + " && " + traefikCookieMatch("canary", "never"), priority: traefikCanaryCookiePriority, services: map[string]int64{"iris": -1}},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objs, err := traefikIngressProvider{}.toKubeObjects(cluster, newTestIngressRoute("iris-canary", c.canary))
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			routes := getTraefikTestRoutes(t, objs)
+			if !reflect.DeepEqual(routes, c.expected) {
+				t.Fatalf("the routes are %+v, expected %+v", routes, c.expected)
+			}
+		})
+	}
+}
+
+func TestTraefikIngressProviderAuthMiddlewareOrder(t *testing.T) {
+	route := newTestIngressRoute("iris", nil)
+	route.AuthURL = "https://yatai.example.com/api/v1/deployment_auth/uid/verify"
+	objs, err := traefikIngressProvider{}.toKubeObjects(&models.Cluster{}, route)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if objs[len(objs)-1].GetKind() != "IngressRoute" {
+		t.Fatal("the ingress route is created before the middlewares it refers to")
+	}
+	r := getTestSlice(t, getTestKubeObject(t, objs, "IngressRoute").Object, "spec", "routes")[0].(map[string]interface{})
+	middlewares := r["middlewares"].([]interface{})
+	if len(middlewares) != 2 || middlewares[0].(map[string]interface{})["name"] != "iris-auth" {
+		t.Fatalf("the requests are not authenticated first, the middlewares are %v", middlewares)
+	}
+	for _, obj := range objs {
+		if obj.GetName() != "iris-auth" {
+			continue
+		}
+		headers := getTestSlice(t, obj.Object, "spec", "forwardAuth", "authResponseHeaders")
+		if !reflect.DeepEqual(headers, []interface{}{DeploymentAuthApiKeyHeader}) {
+			t.Fatalf("the auth response headers are %v, expected the api key header", headers)
+		}
+		return
+	}
+	t.Fatal("no auth middleware is rendered")
+}
+
+func TestNginxIngressProviderAuth(t *testing.T) {
+	route := newTestIngressRoute("iris", nil)
+	route.AuthURL = "https://yatai.example.com/api/v1/deployment_auth/uid/verify"
+	objs, err := nginxIngressProvider{}.toKubeObjects(&models.Cluster{}, route)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	annotations := getTestKubeObject(t, objs, "Ingress").GetAnnotations()
+	expected := map[string]string{
+		"nginx.ingress.kubernetes.io/auth-url":              route.AuthURL,
+		"nginx.ingress.kubernetes.io/auth-response-headers": DeploymentAuthApiKeyHeader,
+		"nginx.ingress.kubernetes.io/auth-cache-key":        "$host$http_authorization",
+		"nginx.ingress.kubernetes.io/auth-cache-duration":   "200 202 10s",
+	}
+	for key, value := range expected {
+		if annotations[key] != value {
+			t.Fatalf("the annotation %s is %q, expected %q", key, annotations[key], value)
+		}
+	}
+}
+
+// gatewayAPITestRule is the part of a gateway api rule which decides where the requests go
+type gatewayAPITestRule struct {
+	// the header name and value of the first header match, they are empty if the rule only matches the path
+	header      string
+	headerValue string
+	// backend name to weight, the weight is -1 if it is not set
+	backends map[string]int64
+}
+
+func getGatewayAPITestRules(t *testing.T, objs []*unstructured.Unstructured) []gatewayAPITestRule {
+	httpRoute := getTestKubeObject(t, objs, "HTTPRoute")
+	rules := make([]gatewayAPITestRule, 0)
+	for _, r := range getTestSlice(t, httpRoute.Object, "spec", "rules") {
+		r_ := r.(map[string]interface{})
+		rule := gatewayAPITestRule{
+			backends: make(map[string]int64),
+		}
+		match := r_["matches"].([]interface{})[0].(map[string]interface{})
+		if headers, ok := match["headers"]; ok {
+			header := headers.([]interface{})[0].(map[string]interface{})
+			rule.header = header["name"].(string)
+			rule.headerValue = header["value"].(string)
+		}
+		for _, backendRef := range r_["backendRefs"].([]interface{}) {
+			backendRef_ := backendRef.(map[string]interface{})
+			weight := int64(-1)
+			if w, ok := backendRef_["weight"]; ok {
+				weight = w.(int64)
+			}
+			rule.backends[backendRef_["name"].(string)] = weight
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func TestGatewayAPIIngressProviderCanary(t *testing.T) {
+	cluster := &models.Cluster{
+		IngressConfig: &models.ClusterIngressConfig{
+			Provider:    models.ClusterIngressProviderGatewayAPI,
+			GatewayName: "yatai-gateway",
+		},
+	}
+	cases := []struct {
+		name     string
+		canaries []*ingressRoute
+		expected []gatewayAPITestRule
+	}{
+		{
+			name: "stable",
+			expected: []gatewayAPITestRule{
+				{backends: map[string]int64{"iris": 100}},
+			},
+		},
+		{
+			name: "weights",
+			canaries: []*ingressRoute{
+				newTestIngressRoute("iris-canary-1", &ingressCanary{Weight: utils.UintPtr(30)}),
+				// the weight is capped by the remaining weight of the stable target
+				newTestIngressRoute("iris-canary-2", &ingressCanary{Weight: utils.UintPtr(80)}),
+			},
+			expected: []gatewayAPITestRule{
+				{backends: map[string]int64{"iris": 0, "iris-canary-1": 30, "iris-canary-2": 70}},
+			},
+		},
+		{
+			name: "header with value",
+			canaries: []*ingressRoute{
+				newTestIngressRoute("iris-canary", &ingressCanary{Header: utils.StringPtr("X-Canary"), HeaderValue: utils.StringPtr("yes")}),
+			},
+			expected: []gatewayAPITestRule{
+				{header: "X-Canary", headerValue: "yes", backends: map[string]int64{"iris-canary": -1}},
+				{backends: map[string]int64{"iris": 100}},
+			},
+		},
+		{
+			name: "header and cookie",
+			canaries: []*ingressRoute{
+				newTestIngressRoute("iris-canary", &ingressCanary{Header: utils.StringPtr("X-Canary"), Cookie: utils.StringPtr("canary")}),
+			},
+			expected: []gatewayAPITestRule{
+				{header: "X-Canary", headerValue: "always", backends: map[string]int64{"iris-canary": -1}},
+				{header: "X-Canary", headerValue: "never", backends: map[string]int64{"iris": -1}},
+				{header: "Cookie", headerValue: gatewayAPICookieMatch("canary", "always")["value"].(string), backends: map[string]int64{"iris-canary": -1}},
+				{header: "Cookie", headerValue: gatewayAPICookieMatch("canary", "never")["value"].(string), backends: map[string]int64{"iris": -1}},
+				{backends: map[string]int64{"iris": 100}},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			route := newTestIngressRoute("iris", nil)
+			route.Canaries = c.canaries
+			objs, err := gatewayAPIIngressProvider{}.toKubeObjects(cluster, route)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			rules := getGatewayAPITestRules(t, objs)
+			if !reflect.DeepEqual(rules, c.expected) {
+				t.Fatalf("the rules are %+v, expected %+v", rules, c.expected)
+			}
+			parentRefs := getTestSlice(t, objs[0].Object, "spec", "parentRefs")
+			if parentRefs[0].(map[string]interface{})["name"] != "yatai-gateway" {
+				t.Fatalf("the http route is attached to %v", parentRefs)
+			}
+		})
+	}
+}
+
+func TestGatewayAPIIngressProviderRejectsAuth(t *testing.T) {
+	route := newTestIngressRoute("iris", nil)
+	route.AuthURL = "https://yatai.example.com/api/v1/deployment_auth/uid/verify"
+	if _, err := (gatewayAPIIngressProvider{}).toKubeObjects(&models.Cluster{}, route); err == nil {
+		t.Fatal("the route which requires authentication is rendered without it")
+	}
+}
+
+func TestIngressCookieMatchEscapesTheCookieName(t *testing.T) {
+	traefikMatch := traefikCookieMatch("canary.v1", "always")
+	// the regexp of traefik is quoted by the backticks after the header name
+	traefikPattern := strings.TrimSuffix(strings.SplitN(traefikMatch, "`, `", 2)[1], "`)")
+	patterns := map[string]string{
+		"traefik":     traefikPattern,
+		"gateway-api": gatewayAPICookieMatch("canary.v1", "always")["value"].(string),
+	}
+	cases := []struct {
+		cookie   string
+		expected bool
+	}{
+		{"canary.v1=always", true},
+		{"session=abc; canary.v1=always", true},
+		{"canaryXv1=always", false},
+		{"canary.v1=never", false},
+	}
+	for provider, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		for _, c := range cases {
+			if matched := re.MatchString(c.cookie); matched != c.expected {
+				t.Fatalf("%s: the match of cookie %q is %v, expected %v", provider, c.cookie, matched, c.expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	commonconsts "github.com/bentoml/yatai-common/consts"
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+var certManagerGroupVersion = schema.GroupVersion{Group: "cert-manager.io", Version: "v1"}
+
+// the copy of the wildcard certificate secret in the namespaces of the deployments, the ingresses can only refer to the secrets in their namespaces
+const wildcardCertificateSecretName = "yatai-wildcard-tls"
+
+// the label of the copies of the wildcard certificate secret, the unused copies are found by it and deleted
+const kubeLabelYataiWildcardCertificateCopy = "yatai.ai/wildcard-certificate-copy"
+
+// ingressTLS is the certificate of the ingress host of a deployment
+type ingressTLS struct {
+	SecretName string
+	// the secret is issued by cert-manager if the issuer is set
+	IssuerName string
+	IssuerKind models.ClusterTLSIssuerKind
+	// the secret is copied from the wildcard certificate secret if it is set
+	WildcardSecretName      string
+	WildcardSecretNamespace string
+}
+
+// getIngressTLS returns nil if the deployment is served over plain http:
+// the certificate secret of the deployment takes precedence over the cert-manager issuer of the cluster,
+// and the wildcard certificate only covers the default hostnames
+func getIngressTLS(cluster *models.Cluster, deployment *models.Deployment) *ingressTLS {
+	if deployment.TLSSecretName != "" {
+		return &ingressTLS{
+			SecretName: deployment.TLSSecretName,
+		}
+	}
+	if cluster.IngressConfig == nil || cluster.IngressConfig.TLS == nil {
+		return nil
+	}
+	tlsConfig := cluster.IngressConfig.TLS
+	if tlsConfig.CertManagerIssuerName != "" {
+		issuerKind := tlsConfig.CertManagerIssuerKind
+		if issuerKind == "" {
+			issuerKind = models.ClusterTLSIssuerKindClusterIssuer
+		}
+		return &ingressTLS{
+			SecretName: fmt.Sprintf("%s-tls", deployment.Name),
+			IssuerName: tlsConfig.CertManagerIssuerName,
+			IssuerKind: issuerKind,
+		}
+	}
+	if tlsConfig.WildcardCertificateSecretName != "" && deployment.IngressHost == "" {
+		return &ingressTLS{
+			SecretName:              wildcardCertificateSecretName,
+			WildcardSecretName:      tlsConfig.WildcardCertificateSecretName,
+			WildcardSecretNamespace: tlsConfig.WildcardCertificateSecretNamespace,
+		}
+	}
+	return nil
+}
+
+// GetIngressTLSSecretName returns the certificate secret of the ingress host of the deployment, it is empty if the deployment is served over plain http
+func (s *kubeIngressService) GetIngressTLSSecretName(ctx context.Context, deployment *models.Deployment) (string, error) {
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		return "", errors.Wrap(err, "get associated cluster")
+	}
+	tls := getIngressTLS(cluster, deployment)
+	if tls == nil {
+		return "", nil
+	}
+	return tls.SecretName, nil
+}
+
+// toCertificate renders the cert-manager certificate which issues the certificate secret of the route
+func toCertificate(route *ingressRoute, tls *ingressTLS) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certManagerGroupVersion.WithKind("Certificate"))
+	certificate.SetName(tls.SecretName)
+	certificate.SetNamespace(route.Namespace)
+	certificate.SetLabels(route.Labels)
+	certificate.SetOwnerReferences(route.OwnerReferences)
+	certificate.Object["spec"] = map[string]interface{}{
+		"secretName": tls.SecretName,
+		"dnsNames":   []interface{}{route.Host},
+		"issuerRef": map[string]interface{}{
+			"group": certManagerGroupVersion.Group,
+			"kind":  string(tls.IssuerKind),
+			"name":  tls.IssuerName,
+		},
+	}
+	return certificate
+}
+
+// syncWildcardCertificateSecret copies the wildcard certificate secret to the namespace of the deployment,
+// the copies are resynced by SyncWildcardCertificateSecrets after the certificate is renewed
+func (s *kubeIngressService) syncWildcardCertificateSecret(ctx context.Context, cluster *models.Cluster, tls *ingressTLS, namespace string) error {
+	clientset, _, err := ClusterService.GetKubeCliSet(ctx, cluster)
+	if err != nil {
+		return errors.Wrap(err, "get k8s cliset")
+	}
+	source, err := clientset.CoreV1().Secrets(tls.WildcardSecretNamespace).Get(ctx, tls.WildcardSecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "get the wildcard certificate secret %s/%s", tls.WildcardSecretNamespace, tls.WildcardSecretName)
+	}
+	return copyWildcardCertificateSecret(ctx, clientset, source, namespace)
+}
+
+func copyWildcardCertificateSecret(ctx context.Context, clientset kubernetes.Interface, source *corev1.Secret, namespace string) error {
+	secretsCli := clientset.CoreV1().Secrets(namespace)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wildcardCertificateSecretName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				kubeLabelYataiWildcardCertificateCopy: commonconsts.KubeLabelTrue,
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: source.Data,
+	}
+	oldSecret, err := secretsCli.Get(ctx, secret.Name, metav1.GetOptions{})
+	notFound := apierrors.IsNotFound(err)
+	if !notFound && err != nil {
+		return errors.Wrapf(err, "get k8s secret %s/%s", namespace, secret.Name)
+	}
+	if notFound {
+		logrus.Infof("create k8s secret %s/%s ...", namespace, secret.Name)
+		_, err = secretsCli.Create(ctx, secret, metav1.CreateOptions{})
+		return errors.Wrapf(err, "create k8s secret %s/%s", namespace, secret.Name)
+	}
+	if reflect.DeepEqual(oldSecret.Data, secret.Data) && oldSecret.Labels[kubeLabelYataiWildcardCertificateCopy] == commonconsts.KubeLabelTrue {
+		return nil
+	}
+	logrus.Infof("update k8s secret %s/%s ...", namespace, secret.Name)
+	secret.ResourceVersion = oldSecret.ResourceVersion
+	_, err = secretsCli.Update(ctx, secret, metav1.UpdateOptions{})
+	return errors.Wrapf(err, "update k8s secret %s/%s", namespace, secret.Name)
+}
+
+// SyncWildcardCertificateSecrets copies the wildcard certificate secret of the cluster to the namespaces of the active deployments which are served with it,
+// so that the renewed certificate is served without deploying them again, and deletes the copies which are no longer used by any deployment
+func (s *kubeIngressService) SyncWildcardCertificateSecrets(ctx context.Context, cluster *models.Cluster) error {
+	clientset, _, err := ClusterService.GetKubeCliSet(ctx, cluster)
+	if err != nil {
+		return errors.Wrap(err, "get k8s cliset")
+	}
+	namespaces := make(map[string]struct{})
+	var source *corev1.Secret
+	provider, ok := ingressProviders[ClusterService.GetIngressProvider(cluster)]
+	if ok && provider.terminatesTLS() {
+		deployments, err := ClusterService.listActiveDeployments(ctx, cluster)
+		if err != nil {
+			return err
+		}
+		for _, deployment := range deployments {
+			tls := getIngressTLS(cluster, deployment)
+			if tls == nil || tls.WildcardSecretName == "" {
+				continue
+			}
+			if source == nil {
+				source, err = clientset.CoreV1().Secrets(tls.WildcardSecretNamespace).Get(ctx, tls.WildcardSecretName, metav1.GetOptions{})
+				if err != nil {
+					return errors.Wrapf(err, "get the wildcard certificate secret %s/%s", tls.WildcardSecretNamespace, tls.WildcardSecretName)
+				}
+			}
+			namespaces[DeploymentService.GetKubeNamespace(deployment)] = struct{}{}
+		}
+	}
+
+	var errs error
+	for namespace := range namespaces {
+		errs = multierr.Append(errs, copyWildcardCertificateSecret(ctx, clientset, source, namespace))
+	}
+	copies, err := clientset.CoreV1().Secrets("").List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", kubeLabelYataiWildcardCertificateCopy, commonconsts.KubeLabelTrue),
+	})
+	if err != nil {
+		return multierr.Append(errs, errors.Wrap(err, "list the copies of the wildcard certificate secret"))
+	}
+	for _, copy_ := range copies.Items {
+		if _, ok := namespaces[copy_.Namespace]; ok {
+			continue
+		}
+		logrus.Infof("delete k8s secret %s/%s ...", copy_.Namespace, copy_.Name)
+		err = clientset.CoreV1().Secrets(copy_.Namespace).Delete(ctx, copy_.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = multierr.Append(errs, errors.Wrapf(err, "delete k8s secret %s/%s", copy_.Namespace, copy_.Name))
+		}
+	}
+	return errs
+}
+
+// SyncAllWildcardCertificateSecrets syncs the wildcard certificate secrets of all the reachable clusters
+func (s *kubeIngressService) SyncAllWildcardCertificateSecrets(ctx context.Context) error {
+	clusters, _, err := ClusterService.List(ctx, ListClusterOption{})
+	if err != nil {
+		return errors.Wrap(err, "list clusters")
+	}
+	var errs error
+	for _, cluster := range clusters {
+		err = s.SyncWildcardCertificateSecrets(ctx, cluster)
+		// the unreachable clusters are synced after they are reachable again
+		if err != nil && !errors.Is(err, ErrClusterUnreachable) {
+			errs = multierr.Append(errs, errors.Wrapf(err, "cluster %s", cluster.Name))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+
+	commonconsts "github.com/bentoml/yatai-common/consts"
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+func TestGetIngressTLS(t *testing.T) {
+	issuerConfig := &models.ClusterTLSConfig{
+		CertManagerIssuerName: "letsencrypt",
+	}
+	wildcardConfig := &models.ClusterTLSConfig{
+		WildcardCertificateSecretName:      "wildcard-tls",
+		WildcardCertificateSecretNamespace: "yatai-system",
+	}
+	cases := []struct {
+		name          string
+		tlsConfig     *models.ClusterTLSConfig
+		ingressHost   string
+		tlsSecretName string
+		expected      *ingressTLS
+	}{
+		{
+			name: "no tls config",
+		},
+		{
+			name:          "deployment secret without tls config",
+			tlsSecretName: "iris-cert",
+			expected:      &ingressTLS{SecretName: "iris-cert"},
+		},
+		{
+			name:          "deployment secret takes precedence over the issuer",
+			tlsConfig:     issuerConfig,
+			tlsSecretName: "iris-cert",
+			expected:      &ingressTLS{SecretName: "iris-cert"},
+		},
+		{
+			name:      "issuer defaults to cluster issuer",
+			tlsConfig: issuerConfig,
+			expected: &ingressTLS{
+				SecretName: "iris-tls",
+				IssuerName: "letsencrypt",
+				IssuerKind: models.ClusterTLSIssuerKindClusterIssuer,
+			},
+		},
+		{
+			name: "namespaced issuer",
+			tlsConfig: &models.ClusterTLSConfig{
+				CertManagerIssuerName: "letsencrypt",
+				CertManagerIssuerKind: models.ClusterTLSIssuerKindIssuer,
+			},
+			expected: &ingressTLS{
+				SecretName: "iris-tls",
+				IssuerName: "letsencrypt",
+				IssuerKind: models.ClusterTLSIssuerKindIssuer,
+			},
+		},
+		{
+			name:        "issuer covers the custom hosts",
+			tlsConfig:   issuerConfig,
+			ingressHost: "iris.example.com",
+			expected: &ingressTLS{
+				SecretName: "iris-tls",
+				IssuerName: "letsencrypt",
+				IssuerKind: models.ClusterTLSIssuerKindClusterIssuer,
+			},
+		},
+		{
+			name:      "wildcard certificate of the default host",
+			tlsConfig: wildcardConfig,
+			expected: &ingressTLS{
+				SecretName:              wildcardCertificateSecretName,
+				WildcardSecretName:      "wildcard-tls",
+				WildcardSecretNamespace: "yatai-system",
+			},
+		},
+		{
+			name:        "wildcard certificate does not cover the custom hosts",
+			tlsConfig:   wildcardConfig,
+			ingressHost: "iris.example.com",
+		},
+		{
+			name:          "deployment secret of the custom host",
+			tlsConfig:     wildcardConfig,
+			ingressHost:   "iris.example.com",
+			tlsSecretName: "iris-cert",
+			expected:      &ingressTLS{SecretName: "iris-cert"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := &models.Cluster{}
+			if c.tlsConfig != nil {
+				cluster.IngressConfig = &models.ClusterIngressConfig{
+					TLS: c.tlsConfig,
+				}
+			}
+			deployment := &models.Deployment{}
+			deployment.Name = "iris"
+			deployment.IngressHost = c.ingressHost
+			deployment.TLSSecretName = c.tlsSecretName
+			tls := getIngressTLS(cluster, deployment)
+			if !reflect.DeepEqual(tls, c.expected) {
+				t.Fatalf("the ingress tls is %+v, expected %+v", tls, c.expected)
+			}
+		})
+	}
+}
+
+func TestToCertificate(t *testing.T) {
+	route := newTestIngressRoute("iris", nil)
+	certificate := toCertificate(route, &ingressTLS{
+		SecretName: "iris-tls",
+		IssuerName: "letsencrypt",
+		IssuerKind: models.ClusterTLSIssuerKindClusterIssuer,
+	})
+	if certificate.GetKind() != "Certificate" || certificate.GetName() != "iris-tls" || certificate.GetNamespace() != route.Namespace {
+		t.Fatalf("the certificate is %s %s/%s", certificate.GetKind(), certificate.GetNamespace(), certificate.GetName())
+	}
+	secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+	if secretName != "iris-tls" {
+		t.Fatalf("the certificate is issued to secret %q", secretName)
+	}
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	if !reflect.DeepEqual(dnsNames, []string{route.Host}) {
+		t.Fatalf("the certificate is issued for %v, expected %v", dnsNames, []string{route.Host})
+	}
+	issuerRef, _, _ := unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+	expectedIssuerRef := map[string]string{
+		"group": "cert-manager.io",
+		"kind":  string(models.ClusterTLSIssuerKindClusterIssuer),
+		"name":  "letsencrypt",
+	}
+	if !reflect.DeepEqual(issuerRef, expectedIssuerRef) {
+		t.Fatalf("the issuer ref is %v, expected %v", issuerRef, expectedIssuerRef)
+	}
+}
+
+func TestCopyWildcardCertificateSecret(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard-tls", Namespace: "yatai-system"},
+		Data:       map[string][]byte{"tls.crt": []byte("cert-1"), "tls.key": []byte("key-1")},
+	}
+	getCopy := func() *corev1.Secret {
+		secret, err := clientset.CoreV1().Secrets("yatai").Get(ctx, wildcardCertificateSecretName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("get the copy: %v", err)
+		}
+		return secret
+	}
+
+	if err := copyWildcardCertificateSecret(ctx, clientset, source, "yatai"); err != nil {
+		t.Fatalf("create the copy: %v", err)
+	}
+	secret := getCopy()
+	if secret.Labels[kubeLabelYataiWildcardCertificateCopy] != commonconsts.KubeLabelTrue {
+		t.Fatalf("the copy is not labeled: %v", secret.Labels)
+	}
+	if !reflect.DeepEqual(secret.Data, source.Data) || secret.Type != corev1.SecretTypeTLS {
+		t.Fatalf("the copy is %+v, expected the data of the source", secret)
+	}
+
+	// the unchanged certificate is not written again
+	clientset.ClearActions()
+	if err := copyWildcardCertificateSecret(ctx, clientset, source, "yatai"); err != nil {
+		t.Fatalf("sync the unchanged copy: %v", err)
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() != "get" {
+			t.Fatalf("the unchanged copy is written: %s", action.GetVerb())
+		}
+	}
+
+	// the renewed certificate is copied
+	source.Data = map[string][]byte{"tls.crt": []byte("cert-2"), "tls.key": []byte("key-2")}
+	if err := copyWildcardCertificateSecret(ctx, clientset, source, "yatai"); err != nil {
+		t.Fatalf("sync the renewed certificate: %v", err)
+	}
+	if secret = getCopy(); !reflect.DeepEqual(secret.Data, source.Data) {
+		t.Fatalf("the data of the copy is %v, expected the renewed certificate", secret.Data)
+	}
+}
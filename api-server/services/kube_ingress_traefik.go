@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+var traefikGroupVersion = schema.GroupVersion{Group: "traefik.containo.us", Version: "v1alpha1"}
+
+// the priorities of the canary routes are higher than the routers of the ingresses, whose priorities are the lengths of their rules
+const (
+	traefikCanaryHeaderPriority = 10030
+	traefikCanaryCookiePriority = 10020
+	traefikCanaryWeightPriority = 10010
+)
+
+// traefikIngressProvider routes the deployment targets with the IngressRoute and the Middleware of traefik v2,
+// the canary weight is translated to the weighted round robin of the stable and the canary services
+type traefikIngressProvider struct{}
+
+func traefikService(name string, port int32, weight *int64) map[string]interface{} {
+	service := map[string]interface{}{
+		"name": name,
+		"port": int64(port),
+	}
+	if weight != nil {
+		service["weight"] = *weight
+	}
+	return service
+}
+
+func traefikCookieMatch(cookie, value string) string {
+	return fmt.Sprintf("HeadersRegexp(`Cookie`, `(^|;\\s*)%s=%s(;|$)`)", regexp.QuoteMeta(cookie), regexp.QuoteMeta(value))
+}
+
+func (traefikIngressProvider) toKubeObjects(cluster *models.Cluster, route *ingressRoute) ([]*unstructured.Unstructured, error) {
+	hostMatch := fmt.Sprintf("Host(`%s`)", route.Host)
+	middlewares := []interface{}{
+		map[string]interface{}{"name": route.Name},
+	}
+	// the requests are authenticated before they get the response headers
+	authMiddlewareName := fmt.Sprintf("%s-auth", route.Name)
+	if route.AuthURL != "" {
+		middlewares = append([]interface{}{map[string]interface{}{"name": authMiddlewareName}}, middlewares...)
+	}
+	toRoute := func(match string, priority int64, services ...map[string]interface{}) map[string]interface{} {
+		services_ := make([]interface{}, 0, len(services))
+		for _, service := range services {
+			services_ = append(services_, service)
+		}
+		r := map[string]interface{}{
+			"kind":        "Rule",
+			"match":       match,
+			"services":    services_,
+			"middlewares": middlewares,
+		}
+		if priority > 0 {
+			r["priority"] = priority
+		}
+		return r
+	}
+	canaryService := traefikService(route.ServiceName, route.ServicePort, nil)
+	stableService := traefikService(route.StableServiceName, route.ServicePort, nil)
+
+	routes := make([]interface{}, 0)
+	if route.Canary == nil {
+		routes = append(routes, toRoute(hostMatch, 0, canaryService))
+	} else {
+		if route.Canary.Header != nil {
+			if route.Canary.HeaderValue != nil {
+				routes = append(routes, toRoute(fmt.Sprintf("%s && Headers(`%s`, `%s`)", hostMatch, *route.Canary.Header, *route.Canary.HeaderValue), traefikCanaryHeaderPriority, canaryService))
+			} else {
+				routes = append(routes, toRoute(fmt.Sprintf("%s && Headers(`%s`, `always`)", hostMatch, *route.Canary.Header), traefikCanaryHeaderPriority, canaryService))
+				routes = append(routes, toRoute(fmt.Sprintf("%s && Headers(`%s`, `never`)", hostMatch, *route.Canary.Header), traefikCanaryHeaderPriority, stableService))
+			}
+		}
+		if route.Canary.Cookie != nil {
+			routes = append(routes, toRoute(fmt.Sprintf("%s && %s", hostMatch, traefikCookieMatch(*route.Canary.Cookie, "always")), traefikCanaryCookiePriority, canaryService))
+			routes = append(routes, toRoute(fmt.Sprintf("%s && %s", hostMatch, traefikCookieMatch(*route.Canary.Cookie, "never")), traefikCanaryCookiePriority, stableService))
+		}
+		if route.Canary.Weight != nil {
+			canaryWeight := int64(*route.Canary.Weight)
+			if canaryWeight > 100 {
+				canaryWeight = 100
+			}
+			stableWeight := 100 - canaryWeight
+			routes = append(routes, toRoute(hostMatch, traefikCanaryWeightPriority,
+				traefikService(route.StableServiceName, route.ServicePort, &stableWeight),
+				traefikService(route.ServiceName, route.ServicePort, &canaryWeight),
+			))
+		}
+	}
+
+	spec := map[string]interface{}{
+		"routes": routes,
+	}
+	if cluster.IngressConfig != nil && len(cluster.IngressConfig.TraefikEntryPoints) > 0 {
+		entryPoints := make([]interface{}, 0, len(cluster.IngressConfig.TraefikEntryPoints))
+		for _, entryPoint := range cluster.IngressConfig.TraefikEntryPoints {
+			entryPoints = append(entryPoints, entryPoint)
+		}
+		spec["entryPoints"] = entryPoints
+	}
+	// the routers with tls only match the requests of the tls entry points
+	if route.TLSSecretName != "" {
+		spec["tls"] = map[string]interface{}{
+			"secretName": route.TLSSecretName,
+		}
+	}
+
+	annotations := copyStringMap(route.Annotations)
+	if cluster.IngressConfig != nil && cluster.IngressConfig.IngressClassName != "" {
+		annotations["kubernetes.io/ingress.class"] = cluster.IngressConfig.IngressClassName
+	}
+
+	ingressRoute := &unstructured.Unstructured{}
+	ingressRoute.SetGroupVersionKind(traefikGroupVersion.WithKind("IngressRoute"))
+	ingressRoute.SetName(route.Name)
+	ingressRoute.SetNamespace(route.Namespace)
+	ingressRoute.SetLabels(route.Labels)
+	ingressRoute.SetAnnotations(annotations)
+	ingressRoute.SetOwnerReferences(route.OwnerReferences)
+	ingressRoute.Object["spec"] = spec
+
+	responseHeaders := make(map[string]interface{}, len(route.ResponseHeaders))
+	for name, value := range route.ResponseHeaders {
+		responseHeaders[name] = value
+	}
+	middleware := &unstructured.Unstructured{}
+	middleware.SetGroupVersionKind(traefikGroupVersion.WithKind("Middleware"))
+	middleware.SetName(route.Name)
+	middleware.SetNamespace(route.Namespace)
+	middleware.SetLabels(route.Labels)
+	middleware.SetOwnerReferences(route.OwnerReferences)
+	middleware.Object["spec"] = map[string]interface{}{
+		"headers": map[string]interface{}{
+			"customResponseHeaders": responseHeaders,
+		},
+	}
+
+	// the middlewares are created first, the routes which refer to a missing middleware are rejected by traefik
+	objs := []*unstructured.Unstructured{middleware}
+	if route.AuthURL != "" {
+		authMiddleware := &unstructured.Unstructured{}
+		authMiddleware.SetGroupVersionKind(traefikGroupVersion.WithKind("Middleware"))
+		authMiddleware.SetName(authMiddlewareName)
+		authMiddleware.SetNamespace(route.Namespace)
+		authMiddleware.SetLabels(route.Labels)
+		authMiddleware.SetOwnerReferences(route.OwnerReferences)
+		authMiddleware.Object["spec"] = map[string]interface{}{
+			"forwardAuth": map[string]interface{}{
+				"address":             route.AuthURL,
+				"authResponseHeaders": []interface{}{DeploymentAuthApiKeyHeader},
+			},
+		}
+		objs = append(objs, authMiddleware)
+	}
+	return append(objs, ingressRoute), nil
+}
+
+func (traefikIngressProvider) kubeResources() []schema.GroupVersionResource {
+	return []schema.GroupVersionResource{
+		traefikGroupVersion.WithResource("ingressroutes"),
+		traefikGroupVersion.WithResource("middlewares"),
+	}
+}
+
+func (traefikIngressProvider) routesAllTargets() bool {
+	return false
+}
+
+func (traefikIngressProvider) terminatesTLS() bool {
+	return true
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/huandu/xstrings"
@@ -143,6 +144,11 @@ func (s *labelService) Delete(ctx context.Context, label *models.Label) (*models
 	return label, s.getBaseDB(ctx).Unscoped().Delete(label).Error
 }
 
+// DeleteByResource deletes all the labels of the resource
+func (s *labelService) DeleteByResource(ctx context.Context, resource models.IResource) error {
+	return s.getBaseDB(ctx).Unscoped().Where("resource_type = ?", resource.GetResourceType()).Where("resource_id = ?", resource.GetId()).Delete(&models.Label{}).Error
+}
+
 func (s *labelService) List(ctx context.Context, opt ListLabelOption) ([]*models.Label, uint, error) {
 	query := getBaseQuery(ctx, s)
 
@@ -211,6 +217,7 @@ func (s *labelService) ListLabelValuesByKey(ctx context.Context, key string, opt
 	return
 }
 
+// ParseQueryLabelsToLabelsList parses the label pieces of q, every piece is a comma separated list of key or key=value
 func ParseQueryLabelsToLabelsList(queryLabels []string) (res [][]modelschemas.LabelItemSchema) {
 	for _, queryLabel := range queryLabels {
 		pieces := strings.Split(queryLabel, ",")
@@ -234,6 +241,33 @@ func ParseQueryLabelsToLabelsList(queryLabels []string) (res [][]modelschemas.La
 	return
 }
 
+// BindQueryWithLabelSelector filters the resources of the query by the label selector,
+// every requirement is compiled into a join on the label table, the negative ones into anti joins
+func (s *labelService) BindQueryWithLabelSelector(query *gorm.DB, resourceType modelschemas.ResourceType, selector LabelSelector) *gorm.DB {
+	quotedResourceType := query.Statement.Quote(string(resourceType))
+	for idx, requirement := range selector {
+		alias := query.Statement.Quote(fmt.Sprintf("label_selector_%d", idx))
+		on := fmt.Sprintf("%s.resource_type = ? AND %s.resource_id = %s.id AND %s.key = ?", alias, alias, quotedResourceType, alias)
+		args := []interface{}{resourceType, requirement.Key}
+		switch requirement.Operator {
+		case LabelSelectorOperatorEquals, LabelSelectorOperatorNotEquals:
+			on += fmt.Sprintf(" AND %s.value = ?", alias)
+			args = append(args, requirement.Values[0])
+		case LabelSelectorOperatorIn, LabelSelectorOperatorNotIn:
+			on += fmt.Sprintf(" AND %s.value IN (?)", alias)
+			args = append(args, requirement.Values)
+		}
+		switch requirement.Operator {
+		case LabelSelectorOperatorEquals, LabelSelectorOperatorIn, LabelSelectorOperatorExists:
+			query = query.Joins(fmt.Sprintf("JOIN label %s ON %s", alias, on), args...)
+		default:
+			// the resources without the key satisfy the negative requirements too
+			query = query.Joins(fmt.Sprintf("LEFT JOIN label %s ON %s", alias, on), args...).Where(fmt.Sprintf("%s.id IS NULL", alias))
+		}
+	}
+	return query
+}
+
 func (s labelService) CreateOrUpdateLabelsFromLabelItemsSchema(ctx context.Context, schema modelschemas.LabelItemsSchema, creatorId, organizationId uint, resource models.IResource) error {
 	oldLabels, _, err := s.List(ctx, ListLabelOption{
 		OrganizationId: &organizationId,
@@ -289,3 +323,188 @@ func (s labelService) CreateOrUpdateLabelsFromLabelItemsSchema(ctx context.Conte
 	}
 	return nil
 }
+
+type BulkLabelOperation string
+
+const (
+	BulkLabelOperationAdd     BulkLabelOperation = "add"
+	BulkLabelOperationRemove  BulkLabelOperation = "remove"
+	BulkLabelOperationReplace BulkLabelOperation = "replace"
+)
+
+type BulkUpdateLabelsOption struct {
+	CreatorId      uint
+	OrganizationId uint
+	ResourceType   modelschemas.ResourceType
+	// the resources are selected either by ResourceUids or by LabelSelector
+	ResourceUids  *[]string
+	LabelSelector *LabelSelector
+	Operation     BulkLabelOperation
+	// only the keys are used by the remove operation
+	Labels modelschemas.LabelItemsSchema
+}
+
+func (s *labelService) listBulkResources(ctx context.Context, opt BulkUpdateLabelsOption) ([]models.IResource, error) {
+	if (opt.ResourceUids == nil) == (opt.LabelSelector == nil) {
+		return nil, errors.New("either resource uids or label selector should be specified")
+	}
+	var ids *[]uint
+	if opt.ResourceUids != nil {
+		ids_ := make([]uint, 0, len(*opt.ResourceUids))
+		for _, uid := range *opt.ResourceUids {
+			resource, err := ResourceService.GetByUid(ctx, opt.ResourceType, uid)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get %s %s", opt.ResourceType, uid)
+			}
+			ids_ = append(ids_, resource.GetId())
+		}
+		ids = &ids_
+	}
+	labelsOpt := BaseListByLabelsOption{
+		LabelSelector: opt.LabelSelector,
+	}
+	resources := make([]models.IResource, 0)
+	// the organization filter drops the resources of other organizations selected by uids
+	switch opt.ResourceType {
+	case modelschemas.ResourceTypeBentoRepository:
+		items, _, err := BentoRepositoryService.List(ctx, ListBentoRepositoryOption{
+			BaseListByLabelsOption: labelsOpt,
+			OrganizationId:         &opt.OrganizationId,
+			Ids:                    ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			resources = append(resources, item)
+		}
+	case modelschemas.ResourceTypeBento:
+		items, _, err := BentoService.List(ctx, ListBentoOption{
+			BaseListByLabelsOption: labelsOpt,
+			OrganizationId:         &opt.OrganizationId,
+			Ids:                    ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			resources = append(resources, item)
+		}
+	case modelschemas.ResourceTypeModelRepository:
+		items, _, err := ModelRepositoryService.List(ctx, ListModelRepositoryOption{
+			BaseListByLabelsOption: labelsOpt,
+			OrganizationId:         &opt.OrganizationId,
+			Ids:                    ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			resources = append(resources, item)
+		}
+	case modelschemas.ResourceTypeModel:
+		items, _, err := ModelService.List(ctx, ListModelOption{
+			BaseListByLabelsOption: labelsOpt,
+			OrganizationId:         &opt.OrganizationId,
+			Ids:                    ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			resources = append(resources, item)
+		}
+	case modelschemas.ResourceTypeDeployment:
+		items, _, err := DeploymentService.List(ctx, ListDeploymentOption{
+			BaseListByLabelsOption: labelsOpt,
+			OrganizationId:         &opt.OrganizationId,
+			Ids:                    ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			resources = append(resources, item)
+		}
+	default:
+		return nil, errors.Errorf("bulk label operations are not supported for resource type %s", opt.ResourceType)
+	}
+	if ids != nil && len(resources) != len(*ids) {
+		return nil, errors.Errorf("some of the %s uids do not belong to the organization", opt.ResourceType)
+	}
+	return resources, nil
+}
+
+// BulkUpdate adds, removes or replaces the labels of the selected resources in one transaction,
+// the new labels of every resource are checked against the label policy of the organization
+func (s *labelService) BulkUpdate(ctx context.Context, opt BulkUpdateLabelsOption) (resources []models.IResource, err error) {
+	switch opt.Operation {
+	case BulkLabelOperationAdd, BulkLabelOperationRemove, BulkLabelOperationReplace:
+	default:
+		err = errors.Errorf("unknown bulk label operation %s", opt.Operation)
+		return
+	}
+
+	// nolint: ineffassign, staticcheck
+	_, ctx, df, err := startTransaction(ctx)
+	if err != nil {
+		return
+	}
+	defer func() { df(err) }()
+
+	resources, err = s.listBulkResources(ctx, opt)
+	if err != nil {
+		return
+	}
+
+	for _, resource := range resources {
+		var oldLabels []*models.Label
+		oldLabels, _, err = s.List(ctx, ListLabelOption{
+			OrganizationId: &opt.OrganizationId,
+			ResourceType:   resource.GetResourceType().Ptr(),
+			ResourceId:     utils.UintPtr(resource.GetId()),
+		})
+		if err != nil {
+			return
+		}
+
+		labels := make(modelschemas.LabelItemsSchema, 0, len(oldLabels)+len(opt.Labels))
+		switch opt.Operation {
+		case BulkLabelOperationAdd:
+			keys := make(map[string]struct{}, len(opt.Labels))
+			for _, label := range opt.Labels {
+				keys[label.Key] = struct{}{}
+			}
+			for _, label := range oldLabels {
+				if _, ok := keys[label.Key]; !ok {
+					labels = append(labels, modelschemas.LabelItemSchema{Key: label.Key, Value: label.Value})
+				}
+			}
+			labels = append(labels, opt.Labels...)
+		case BulkLabelOperationRemove:
+			keys := make(map[string]struct{}, len(opt.Labels))
+			for _, label := range opt.Labels {
+				keys[label.Key] = struct{}{}
+			}
+			for _, label := range oldLabels {
+				if _, ok := keys[label.Key]; !ok {
+					labels = append(labels, modelschemas.LabelItemSchema{Key: label.Key, Value: label.Value})
+				}
+			}
+		case BulkLabelOperationReplace:
+			labels = append(labels, opt.Labels...)
+		}
+
+		err = LabelPolicyService.Validate(ctx, opt.OrganizationId, resource.GetResourceType(), labels)
+		if err != nil {
+			err = errors.Wrapf(err, "%s %s", resource.GetResourceType(), resource.GetUid())
+			return
+		}
+
+		err = s.CreateOrUpdateLabelsFromLabelItemsSchema(ctx, labels, opt.CreatorId, opt.OrganizationId, resource)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/consts"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type labelPolicyService struct{}
+
+var LabelPolicyService = labelPolicyService{}
+
+// LabelPolicyResourceTypes are the resource types which can be guarded by the required labels policies
+var LabelPolicyResourceTypes = []modelschemas.ResourceType{
+	modelschemas.ResourceTypeBento,
+	modelschemas.ResourceTypeModel,
+	modelschemas.ResourceTypeDeployment,
+}
+
+func (s *labelPolicyService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.LabelPolicy{})
+}
+
+type UpsertLabelPolicyOption struct {
+	CreatorId      uint
+	OrganizationId uint
+	ResourceType   modelschemas.ResourceType
+	RequiredKeys   []string
+}
+
+func validateLabelPolicyOption(opt UpsertLabelPolicyOption) error {
+	supported := false
+	for _, resourceType := range LabelPolicyResourceTypes {
+		if resourceType == opt.ResourceType {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return errors.Errorf("label policy is not supported for resource type %s", opt.ResourceType)
+	}
+	if len(opt.RequiredKeys) == 0 {
+		return errors.New("required_keys should not be empty")
+	}
+	for _, key := range opt.RequiredKeys {
+		if strings.TrimSpace(key) == "" {
+			return errors.New("required_keys should not contain empty key")
+		}
+	}
+	return nil
+}
+
+// Upsert creates the label policy of the resource type or replaces its required keys if it already exists
+func (s *labelPolicyService) Upsert(ctx context.Context, opt UpsertLabelPolicyOption) (*models.LabelPolicy, error) {
+	err := validateLabelPolicyOption(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.GetByResourceType(ctx, opt.OrganizationId, opt.ResourceType)
+	if err != nil && !utils.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		err = s.getBaseDB(ctx).Where("id = ?", policy.ID).Updates(map[string]interface{}{
+			"required_keys": pq.StringArray(opt.RequiredKeys),
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+		policy.RequiredKeys = opt.RequiredKeys
+		return policy, nil
+	}
+
+	policy = &models.LabelPolicy{
+		CreatorAssociate: models.CreatorAssociate{
+			CreatorId: opt.CreatorId,
+		},
+		OrganizationAssociate: models.OrganizationAssociate{
+			OrganizationId: opt.OrganizationId,
+		},
+		ResourceType: opt.ResourceType,
+		RequiredKeys: opt.RequiredKeys,
+	}
+	err = mustGetSession(ctx).Create(policy).Error
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *labelPolicyService) GetByResourceType(ctx context.Context, organizationId uint, resourceType modelschemas.ResourceType) (*models.LabelPolicy, error) {
+	var policy models.LabelPolicy
+	err := getBaseQuery(ctx, s).Where("organization_id = ?", organizationId).Where("resource_type = ?", resourceType).First(&policy).Error
+	if err != nil {
+		return nil, err
+	}
+	if policy.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &policy, nil
+}
+
+func (s *labelPolicyService) List(ctx context.Context, organizationId uint) ([]*models.LabelPolicy, error) {
+	policies := make([]*models.LabelPolicy, 0)
+	err := getBaseQuery(ctx, s).Where("organization_id = ?", organizationId).Order("id ASC").Find(&policies).Error
+	return policies, err
+}
+
+func (s *labelPolicyService) Delete(ctx context.Context, policy *models.LabelPolicy) (*models.LabelPolicy, error) {
+	err := s.getBaseDB(ctx).Unscoped().Delete(policy).Error
+	return policy, err
+}
+
+// getMissingLabelKeys returns the sorted required keys which are missing in the labels,
+// a key with an empty value is treated as missing
+func getMissingLabelKeys(requiredKeys []string, labels modelschemas.LabelItemsSchema) []string {
+	values := make(map[string]string, len(labels))
+	for _, label := range labels {
+		values[label.Key] = label.Value
+	}
+	missingKeys := make([]string, 0)
+	for _, key := range requiredKeys {
+		if strings.TrimSpace(values[key]) == "" {
+			missingKeys = append(missingKeys, key)
+		}
+	}
+	sort.Strings(missingKeys)
+	return missingKeys
+}
+
+// Validate rejects the labels of a resource which lack any key required by the label policy of the organization,
+// a key with an empty value is treated as missing
+func (s *labelPolicyService) Validate(ctx context.Context, organizationId uint, resourceType modelschemas.ResourceType, labels modelschemas.LabelItemsSchema) error {
+	policy, err := s.GetByResourceType(ctx, organizationId, resourceType)
+	if utils.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "get label policy")
+	}
+	missingKeys := getMissingLabelKeys(policy.RequiredKeys, labels)
+	if len(missingKeys) > 0 {
+		return errors.Errorf("the %s is missing the labels required by the organization: %s", resourceType, strings.Join(missingKeys, ", "))
+	}
+	return nil
+}
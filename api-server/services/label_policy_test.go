@@ -0,0 +1,54 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+)
+
+func TestValidateLabelPolicyOption(t *testing.T) {
+	cases := []struct {
+		name         string
+		resourceType modelschemas.ResourceType
+		requiredKeys []string
+		wantErr      bool
+	}{
+		{"bento", modelschemas.ResourceTypeBento, []string{"owner"}, false},
+		{"model", modelschemas.ResourceTypeModel, []string{"owner", "team"}, false},
+		{"deployment", modelschemas.ResourceTypeDeployment, []string{"owner"}, false},
+		{"unsupported resource type", modelschemas.ResourceTypeCluster, []string{"owner"}, true},
+		{"no required keys", modelschemas.ResourceTypeBento, nil, true},
+		{"empty key", modelschemas.ResourceTypeBento, []string{"owner", " "}, true},
+	}
+	for _, c := range cases {
+		err := validateLabelPolicyOption(UpsertLabelPolicyOption{
+			ResourceType: c.resourceType,
+			RequiredKeys: c.requiredKeys,
+		})
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%s: the error is %v, expected an error: %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestGetMissingLabelKeys(t *testing.T) {
+	requiredKeys := []string{"team", "owner"}
+	cases := []struct {
+		name     string
+		labels   modelschemas.LabelItemsSchema
+		expected []string
+	}{
+		{"all present", modelschemas.LabelItemsSchema{{Key: "owner", Value: "alice"}, {Key: "team", Value: "ml"}, {Key: "tier", Value: "prod"}}, []string{}},
+		{"one missing", modelschemas.LabelItemsSchema{{Key: "team", Value: "ml"}}, []string{"owner"}},
+		{"all missing in order", nil, []string{"owner", "team"}},
+		{"empty value", modelschemas.LabelItemsSchema{{Key: "owner", Value: ""}, {Key: "team", Value: "ml"}}, []string{"owner"}},
+		{"blank value", modelschemas.LabelItemsSchema{{Key: "owner", Value: "alice"}, {Key: "team", Value: "  "}}, []string{"team"}},
+		{"key case differs", modelschemas.LabelItemsSchema{{Key: "Owner", Value: "alice"}, {Key: "team", Value: "ml"}}, []string{"owner"}},
+	}
+	for _, c := range cases {
+		if missingKeys := getMissingLabelKeys(requiredKeys, c.labels); !reflect.DeepEqual(missingKeys, c.expected) {
+			t.Fatalf("%s: the missing keys are %v, expected %v", c.name, missingKeys, c.expected)
+		}
+	}
+}
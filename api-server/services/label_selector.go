@@ -0,0 +1,234 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+type LabelSelectorOperator string
+
+const (
+	LabelSelectorOperatorEquals       LabelSelectorOperator = "="
+	LabelSelectorOperatorNotEquals    LabelSelectorOperator = "!="
+	LabelSelectorOperatorIn           LabelSelectorOperator = "in"
+	LabelSelectorOperatorNotIn        LabelSelectorOperator = "notin"
+	LabelSelectorOperatorExists       LabelSelectorOperator = "exists"
+	LabelSelectorOperatorDoesNotExist LabelSelectorOperator = "!"
+)
+
+type LabelSelectorRequirement struct {
+	Key      string
+	Operator LabelSelectorOperator
+	Values   []string
+}
+
+// quoteLabelSelectorValue quotes the value only if it can not be parsed as a bare token
+func quoteLabelSelectorValue(value string) string {
+	for _, c := range value {
+		if !isLabelSelectorTokenChar(c) {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
+
+func (r LabelSelectorRequirement) String() string {
+	values := make([]string, 0, len(r.Values))
+	for _, value := range r.Values {
+		values = append(values, quoteLabelSelectorValue(value))
+	}
+	switch r.Operator {
+	case LabelSelectorOperatorExists:
+		return r.Key
+	case LabelSelectorOperatorDoesNotExist:
+		return "!" + r.Key
+	case LabelSelectorOperatorIn, LabelSelectorOperatorNotIn:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(values, ","))
+	default:
+		return fmt.Sprintf("%s%s%s", r.Key, r.Operator, strings.Join(values, ","))
+	}
+}
+
+// LabelSelector matches the resources which satisfy all of its requirements
+type LabelSelector []LabelSelectorRequirement
+
+func (s LabelSelector) String() string {
+	pieces := make([]string, 0, len(s))
+	for _, r := range s {
+		pieces = append(pieces, r.String())
+	}
+	return strings.Join(pieces, ",")
+}
+
+func isLabelSelectorTokenChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("-_./", c)
+}
+
+type labelSelectorParser struct {
+	input string
+	pos   int
+}
+
+func (p *labelSelectorParser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *labelSelectorParser) eof() bool {
+	p.skipSpaces()
+	return p.pos >= len(p.input)
+}
+
+func (p *labelSelectorParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *labelSelectorParser) consume(s string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *labelSelectorParser) token() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.input) {
+		c, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isLabelSelectorTokenChar(c) {
+			break
+		}
+		p.pos += size
+	}
+	return p.input[start:p.pos]
+}
+
+// value reads a bare token or a double quoted string, the value is empty if neither is found
+func (p *labelSelectorParser) value() (string, error) {
+	if p.peek() != '"' {
+		return p.token(), nil
+	}
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			value, err := strconv.Unquote(p.input[start:p.pos])
+			if err != nil {
+				return "", p.errorf("invalid quoted value %s", p.input[start:p.pos])
+			}
+			return value, nil
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated quoted value")
+}
+
+func (p *labelSelectorParser) errorf(format string, args ...interface{}) error {
+	return errors.Errorf("invalid label selector %q at position %d: %s", p.input, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *labelSelectorParser) parseValues() ([]string, error) {
+	if !p.consume("(") {
+		return nil, p.errorf("expected (")
+	}
+	values := make([]string, 0)
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if p.consume(")") {
+			return values, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or )")
+		}
+	}
+}
+
+func (p *labelSelectorParser) parseRequirement() (LabelSelectorRequirement, error) {
+	if p.consume("!") {
+		key := p.token()
+		if key == "" {
+			return LabelSelectorRequirement{}, p.errorf("expected a key after !")
+		}
+		return LabelSelectorRequirement{Key: key, Operator: LabelSelectorOperatorDoesNotExist}, nil
+	}
+	key := p.token()
+	if key == "" {
+		return LabelSelectorRequirement{}, p.errorf("expected a key")
+	}
+	requirement := LabelSelectorRequirement{Key: key}
+	switch {
+	case p.eof() || p.peek() == ',':
+		requirement.Operator = LabelSelectorOperatorExists
+		return requirement, nil
+	case p.consume("!="):
+		requirement.Operator = LabelSelectorOperatorNotEquals
+	case p.consume("=="), p.consume("="):
+		requirement.Operator = LabelSelectorOperatorEquals
+	default:
+		operator := p.token()
+		switch LabelSelectorOperator(operator) {
+		case LabelSelectorOperatorIn, LabelSelectorOperatorNotIn:
+			requirement.Operator = LabelSelectorOperator(operator)
+		default:
+			return LabelSelectorRequirement{}, p.errorf("unknown operator %q", operator)
+		}
+		values, err := p.parseValues()
+		if err != nil {
+			return LabelSelectorRequirement{}, err
+		}
+		requirement.Values = values
+		return requirement, nil
+	}
+	// an empty value matches the labels with the empty value just like kubernetes does
+	value, err := p.value()
+	if err != nil {
+		return LabelSelectorRequirement{}, err
+	}
+	requirement.Values = []string{value}
+	return requirement, nil
+}
+
+// ParseLabelSelector parses the kubernetes style label selector such as "tier=prod,team notin (a,b),!deprecated",
+// the supported requirements are key=value, key==value, key!=value, key in (...), key notin (...), key and !key.
+// The values may be empty or double quoted, e.g. owner="ml platform".
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	p := &labelSelectorParser{input: selector}
+	res := make(LabelSelector, 0)
+	if p.eof() {
+		return res, nil
+	}
+	for {
+		requirement, err := p.parseRequirement()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, requirement)
+		if p.eof() {
+			return res, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ,")
+		}
+	}
+}
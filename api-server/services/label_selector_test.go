@@ -0,0 +1,95 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	cases := []struct {
+		selector string
+		expected LabelSelector
+		wantErr  bool
+	}{
+		{selector: "", expected: LabelSelector{}},
+		{selector: "  ", expected: LabelSelector{}},
+		{selector: "tier=prod", expected: LabelSelector{{Key: "tier", Operator: LabelSelectorOperatorEquals, Values: []string{"prod"}}}},
+		{selector: "tier==prod", expected: LabelSelector{{Key: "tier", Operator: LabelSelectorOperatorEquals, Values: []string{"prod"}}}},
+		{selector: "tier != prod", expected: LabelSelector{{Key: "tier", Operator: LabelSelectorOperatorNotEquals, Values: []string{"prod"}}}},
+		{selector: "team in (a, b.c,d-e)", expected: LabelSelector{{Key: "team", Operator: LabelSelectorOperatorIn, Values: []string{"a", "b.c", "d-e"}}}},
+		{selector: "team notin (a)", expected: LabelSelector{{Key: "team", Operator: LabelSelectorOperatorNotIn, Values: []string{"a"}}}},
+		{selector: "deprecated", expected: LabelSelector{{Key: "deprecated", Operator: LabelSelectorOperatorExists}}},
+		{selector: "!deprecated", expected: LabelSelector{{Key: "deprecated", Operator: LabelSelectorOperatorDoesNotExist}}},
+		{selector: "owner=", expected: LabelSelector{{Key: "owner", Operator: LabelSelectorOperatorEquals, Values: []string{""}}}},
+		{selector: "owner=,tier=prod", expected: LabelSelector{
+			{Key: "owner", Operator: LabelSelectorOperatorEquals, Values: []string{""}},
+			{Key: "tier", Operator: LabelSelectorOperatorEquals, Values: []string{"prod"}},
+		}},
+		{selector: "owner!=", expected: LabelSelector{{Key: "owner", Operator: LabelSelectorOperatorNotEquals, Values: []string{""}}}},
+		{selector: "team in (a,)", expected: LabelSelector{{Key: "team", Operator: LabelSelectorOperatorIn, Values: []string{"a", ""}}}},
+		{selector: `owner="ml platform"`, expected: LabelSelector{{Key: "owner", Operator: LabelSelectorOperatorEquals, Values: []string{"ml platform"}}}},
+		{selector: `owner="a,b",tier=prod`, expected: LabelSelector{
+			{Key: "owner", Operator: LabelSelectorOperatorEquals, Values: []string{"a,b"}},
+			{Key: "tier", Operator: LabelSelectorOperatorEquals, Values: []string{"prod"}},
+		}},
+		{selector: `owner="say \"hi\""`, expected: LabelSelector{{Key: "owner", Operator: LabelSelectorOperatorEquals, Values: []string{`say "hi"`}}}},
+		{selector: `team in ("a b", c)`, expected: LabelSelector{{Key: "team", Operator: LabelSelectorOperatorIn, Values: []string{"a b", "c"}}}},
+		{selector: "tier=prod, team notin (a,b), !deprecated", expected: LabelSelector{
+			{Key: "tier", Operator: LabelSelectorOperatorEquals, Values: []string{"prod"}},
+			{Key: "team", Operator: LabelSelectorOperatorNotIn, Values: []string{"a", "b"}},
+			{Key: "deprecated", Operator: LabelSelectorOperatorDoesNotExist},
+		}},
+		{selector: "团队=机器学习", expected: LabelSelector{{Key: "团队", Operator: LabelSelectorOperatorEquals, Values: []string{"机器学习"}}}},
+		{selector: "équipe in (données, ü)", expected: LabelSelector{{Key: "équipe", Operator: LabelSelectorOperatorIn, Values: []string{"données", "ü"}}}},
+		{selector: `price="5€"`, expected: LabelSelector{{Key: "price", Operator: LabelSelectorOperatorEquals, Values: []string{"5€"}}}},
+		{selector: "=prod", wantErr: true},
+		{selector: "price=5€", wantErr: true},
+		{selector: "!", wantErr: true},
+		{selector: "tier=prod,", wantErr: true},
+		{selector: "tier prod", wantErr: true},
+		{selector: "team in a", wantErr: true},
+		{selector: "team in (a", wantErr: true},
+		{selector: "team in (a b)", wantErr: true},
+		{selector: `owner="unterminated`, wantErr: true},
+		{selector: "tier=prod team=a", wantErr: true},
+	}
+	for _, c := range cases {
+		selector, err := ParseLabelSelector(c.selector)
+		if c.wantErr {
+			if err == nil {
+				t.Fatalf("%q is parsed as %v, expected an error", c.selector, selector)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("parse %q: %v", c.selector, err)
+		}
+		if !reflect.DeepEqual(selector, c.expected) {
+			t.Fatalf("%q is parsed as %#v, expected %#v", c.selector, selector, c.expected)
+		}
+		// the string form is parsed back to the same selector
+		reparsed, err := ParseLabelSelector(selector.String())
+		if err != nil {
+			t.Fatalf("parse %q which is the string form of %q: %v", selector.String(), c.selector, err)
+		}
+		if !reflect.DeepEqual(reparsed, selector) {
+			t.Fatalf("%q is parsed back as %#v, expected %#v", selector.String(), reparsed, selector)
+		}
+	}
+}
+
+func TestParseQueryLabelsToLabelsList(t *testing.T) {
+	labelsList := ParseQueryLabelsToLabelsList([]string{"a=1, b=2", "c", " , ", "d="})
+	if len(labelsList) != 3 {
+		t.Fatalf("%d labels lists are parsed, expected 3", len(labelsList))
+	}
+	if len(labelsList[0]) != 2 || labelsList[0][0].Key != "a" || labelsList[0][0].Value != "1" || labelsList[0][1].Key != "b" || labelsList[0][1].Value != "2" {
+		t.Fatalf("the comma separated labels are parsed as %v", labelsList[0])
+	}
+	if len(labelsList[1]) != 1 || labelsList[1][0].Key != "c" || labelsList[1][0].Value != "" {
+		t.Fatalf("the key only label is parsed as %v", labelsList[1])
+	}
+	if len(labelsList[2]) != 1 || labelsList[2][0].Key != "d" || labelsList[2][0].Value != "" {
+		t.Fatalf("the label with an empty value is parsed as %v", labelsList[2])
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 	commonconsts "github.com/bentoml/yatai-common/consts"
 	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/metrics"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/common/consts"
 )
@@ -109,6 +110,10 @@ func (s *modelService) Create(ctx context.Context, opt CreateModelOption) (model
 	if err != nil {
 		return
 	}
+	err = LabelPolicyService.Validate(ctx, org.ID, modelschemas.ResourceTypeModel, opt.Labels)
+	if err != nil {
+		return
+	}
 	err = LabelService.CreateOrUpdateLabelsFromLabelItemsSchema(ctx, opt.Labels, user.ID, org.ID, model)
 	return
 }
@@ -281,7 +286,7 @@ func (s *modelService) Upload(ctx context.Context, model *models.Model, reader i
 	}
 
 	logrus.Debugf("uploading to s3: %s/%s", bucketName, objectName)
-	_, err = minioClient.PutObject(ctx, bucketName, objectName, reader, objectSize, minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	_, err = minioClient.PutObject(ctx, bucketName, objectName, metrics.NewUploadReader("model", reader), objectSize, minio.PutObjectOptions{ContentType: "application/octet-stream"})
 	if err != nil {
 		err = errors.Wrap(err, "put object")
 		return
@@ -376,7 +381,7 @@ func (s *modelService) Download(ctx context.Context, model *models.Model, writer
 		return
 	}
 
-	_, err = io.Copy(writer, obj)
+	_, err = io.Copy(metrics.NewDownloadWriter("model", writer), obj)
 	if err != nil {
 		err = errors.Wrap(err, "copy object")
 	}
@@ -472,6 +477,22 @@ func (s *modelService) GetTag(ctx context.Context, model *models.Model) (modelsc
 
 func (s *modelService) Update(ctx context.Context, model *models.Model, opt UpdateModelOption) (*models.Model, error) {
 	var err error
+	// the labels are validated against the label policy before anything is written
+	var org *models.Organization
+	if opt.Labels != nil {
+		modelRepository, err := ModelRepositoryService.GetAssociatedModelRepository(ctx, model)
+		if err != nil {
+			return nil, err
+		}
+		org, err = OrganizationService.GetAssociatedOrganization(ctx, modelRepository)
+		if err != nil {
+			return nil, err
+		}
+		err = LabelPolicyService.Validate(ctx, org.ID, modelschemas.ResourceTypeModel, *opt.Labels)
+		if err != nil {
+			return nil, err
+		}
+	}
 	updaters := make(map[string]interface{})
 	if opt.ImageBuildStatus != nil {
 		updaters["image_build_status"] = *opt.ImageBuildStatus
@@ -545,14 +566,6 @@ func (s *modelService) Update(ctx context.Context, model *models.Model, opt Upda
 	}
 
 	if opt.Labels != nil {
-		modelRepository, err := ModelRepositoryService.GetAssociatedModelRepository(ctx, model)
-		if err != nil {
-			return nil, err
-		}
-		org, err := OrganizationService.GetAssociatedOrganization(ctx, modelRepository)
-		if err != nil {
-			return nil, err
-		}
 		user, err := GetCurrentUser(ctx)
 		if err != nil {
 			return nil, err
@@ -570,6 +583,58 @@ func (s *modelService) Update(ctx context.Context, model *models.Model, opt Upda
 	return model, nil
 }
 
+// Delete deletes the model with its labels and removes its object from s3,
+// the db changes are rolled back if the s3 object cannot be removed
+func (s *modelService) Delete(ctx context.Context, model *models.Model) (err error) {
+	bucketName, err := s.GetS3BucketName(ctx, model)
+	if err != nil {
+		return
+	}
+	objectName, err := s.getS3ObjectName(ctx, model)
+	if err != nil {
+		return
+	}
+	modelRepository, err := ModelRepositoryService.GetAssociatedModelRepository(ctx, model)
+	if err != nil {
+		return
+	}
+	org, err := OrganizationService.GetAssociatedOrganization(ctx, modelRepository)
+	if err != nil {
+		return
+	}
+	s3Config, err := OrganizationService.GetS3Config(ctx, org)
+	if err != nil {
+		return
+	}
+	minioClient, err := s3Config.GetMinioClient()
+	if err != nil {
+		err = errors.Wrap(err, "create s3 client")
+		return
+	}
+
+	// nolint: ineffassign,staticcheck
+	db, ctx, df, err := startTransaction(ctx)
+	if err != nil {
+		return
+	}
+	defer func() { df(err) }()
+
+	err = LabelService.DeleteByResource(ctx, model)
+	if err != nil {
+		err = errors.Wrap(err, "delete labels")
+		return
+	}
+	err = db.Unscoped().Delete(model).Error
+	if err != nil {
+		return
+	}
+	err = minioClient.RemoveObject(ctx, bucketName, objectName, minio.RemoveObjectOptions{})
+	if err != nil {
+		err = errors.Wrap(err, "remove object")
+	}
+	return
+}
+
 func (s *modelService) GetImageBuilderKubeName(ctx context.Context, model *models.Model) (string, error) {
 	modelRepository, err := ModelRepositoryService.GetAssociatedModelRepository(ctx, model)
 	if err != nil {
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/config"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+const (
+	oidcDiscoveryCacheTTL = time.Hour
+	oidcClockSkew         = time.Minute
+	// oidcMinRefreshInterval stops the tokens with unknown kids from hammering the provider
+	oidcMinRefreshInterval = time.Minute
+)
+
+var oidcHttpClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+type oidcProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type oidcJSONWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type oidcService struct {
+	jwks jwksCache
+}
+
+var OIDCService = oidcService{}
+
+// OIDCClaims are the verified claims of the id token
+type OIDCClaims map[string]interface{}
+
+func (c OIDCClaims) GetString(name string) string {
+	v, _ := c[name].(string)
+	return v
+}
+
+// GetStrings accepts both a string array and a single string claim
+func (c OIDCClaims) GetStrings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func (s *oidcService) IsEnabled() bool {
+	return config.YataiConfig.OIDC != nil
+}
+
+func (s *oidcService) IsPasswordLoginDisabled() bool {
+	return s.IsEnabled() && config.YataiConfig.OIDC.DisablePasswordLogin
+}
+
+func getOIDCJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := oidcHttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("%s responded with status code %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// parseOIDCJSONWebKeys returns the rsa signing keys by kid, the other keys are skipped
+func parseOIDCJSONWebKeys(jwks []oidcJSONWebKey) (map[string]*rsa.PublicKey, error) {
+	keys := make(map[string]*rsa.PublicKey, len(jwks))
+	for _, key := range jwks {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decode modulus of jwk %s", key.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decode exponent of jwk %s", key.Kid)
+		}
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func (s *oidcService) getMetadata(ctx context.Context) (*oidcProviderMetadata, error) {
+	return s.jwks.getMetadata(ctx, strings.TrimSuffix(config.YataiConfig.OIDC.Issuer, "/"))
+}
+
+// getKey returns the signing key by kid, the keys are re-fetched once when the kid is unknown to follow the key rotation of the provider
+func (s *oidcService) getKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	return s.jwks.getKey(ctx, strings.TrimSuffix(config.YataiConfig.OIDC.Issuer, "/"), kid)
+}
+
+func (s *oidcService) getOAuth2Config(ctx context.Context) (*oauth2.Config, error) {
+	metadata, err := s.getMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	oidcConfig := config.YataiConfig.OIDC
+	return &oauth2.Config{
+		ClientID:     oidcConfig.ClientId,
+		ClientSecret: oidcConfig.ClientSecret,
+		RedirectURL:  oidcConfig.RedirectURL,
+		Scopes:       oidcConfig.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  metadata.AuthorizationEndpoint,
+			TokenURL: metadata.TokenEndpoint,
+		},
+	}, nil
+}
+
+// GetAuthCodeURL returns the url of the authorization endpoint which the browser should be redirected to
+func (s *oidcService) GetAuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	oauth2Config, err := s.getOAuth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauth2Config.AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", nonce)), nil
+}
+
+// Exchange exchanges the authorization code for the tokens and returns the verified claims of the id token
+func (s *oidcService) Exchange(ctx context.Context, code, nonce string) (OIDCClaims, error) {
+	oauth2Config, err := s.getOAuth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := oauth2Config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, oidcHttpClient), code)
+	if err != nil {
+		return nil, errors.Wrap(err, "exchange oidc authorization code")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("no id_token in the oidc token response")
+	}
+	return s.verifyIDToken(ctx, rawIDToken, nonce)
+}
+
+// verifyRS256JWT checks the RS256 signature of the jwt with the key of its kid and returns its claims, the claims themselves are not checked
+func verifyRS256JWT(ctx context.Context, rawJWT string, getKey func(ctx context.Context, kid string) (*rsa.PublicKey, error)) (OIDCClaims, error) {
+	parts := strings.Split(rawJWT, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed jwt")
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "decode jwt header")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err = json.Unmarshal(headerBytes, &header)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal jwt header")
+	}
+	if header.Alg != "RS256" {
+		return nil, errors.Errorf("unsupported jwt signing algorithm %s", header.Alg)
+	}
+	key, err := getKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "decode jwt signature")
+	}
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "verify jwt signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.Wrap(err, "decode jwt payload")
+	}
+	var claims OIDCClaims
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal jwt payload")
+	}
+	return claims, nil
+}
+
+// verifyIDToken checks the RS256 signature, the issuer, the audience, the expiry and the nonce of the id token
+func (s *oidcService) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (OIDCClaims, error) {
+	claims, err := verifyRS256JWT(ctx, rawIDToken, s.getKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "verify id token")
+	}
+
+	issuer := strings.TrimSuffix(config.YataiConfig.OIDC.Issuer, "/")
+	if strings.TrimSuffix(claims.GetString("iss"), "/") != issuer {
+		return nil, errors.Errorf("unexpected id token issuer %s", claims.GetString("iss"))
+	}
+	audienceMatched := false
+	for _, aud := range claims.GetStrings("aud") {
+		if aud == config.YataiConfig.OIDC.ClientId {
+			audienceMatched = true
+			break
+		}
+	}
+	if !audienceMatched {
+		return nil, errors.New("the id token is not issued for yatai")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("no exp in the id token")
+	}
+	if time.Now().Add(-oidcClockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("the id token is expired")
+	}
+	if claims.GetString("nonce") != nonce {
+		return nil, errors.New("id token nonce mismatch")
+	}
+	if claims.GetString("sub") == "" {
+		return nil, errors.New("no sub in the id token")
+	}
+	return claims, nil
+}
+
+// Login returns the user bound to the oidc identity, the user is provisioned on the first login.
+// The users are only identified by the issuer and the subject, an existing user with the same email is never bound to the identity
+// because the email claim is controlled by the provider and its users.
+func (s *oidcService) Login(ctx context.Context, claims OIDCClaims) (user *models.User, err error) {
+	oidcConfig := config.YataiConfig.OIDC
+	issuer := strings.TrimSuffix(oidcConfig.Issuer, "/")
+	subject := claims.GetString("sub")
+	email := claims.GetString(oidcConfig.EmailClaim)
+
+	user, err = UserService.GetByOIDCSubject(ctx, issuer, subject)
+	if err != nil && !utils.IsNotFound(err) {
+		return nil, errors.Wrap(err, "get user by oidc subject")
+	}
+
+	if err != nil {
+		name := claims.GetString(oidcConfig.UsernameClaim)
+		if name == "" {
+			return nil, errors.Errorf("no %s claim in the id token", oidcConfig.UsernameClaim)
+		}
+		_, err = UserService.GetByName(ctx, name)
+		if err == nil {
+			return nil, errors.Errorf("the user name %s is already taken by another user", name)
+		}
+		if !utils.IsNotFound(err) {
+			return nil, errors.Wrap(err, "get user by name")
+		}
+		if email != "" {
+			_, err = UserService.GetByEmail(ctx, email)
+			if err == nil {
+				return nil, errors.Errorf("the email %s is already used by another user", email)
+			}
+			if !utils.IsNotFound(err) {
+				return nil, errors.Wrap(err, "get user by email")
+			}
+		}
+		user, err = UserService.Create(ctx, CreateUserOption{
+			Name:        name,
+			FirstName:   claims.GetString(oidcConfig.FirstNameClaim),
+			LastName:    claims.GetString(oidcConfig.LastNameClaim),
+			Email:       utils.StringPtrWithoutEmpty(email),
+			OidcIssuer:  &issuer,
+			OidcSubject: &subject,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "create user")
+		}
+	}
+
+	err = s.syncOrganizationRoles(ctx, user, claims.GetStrings(oidcConfig.GroupsClaim))
+	if err != nil {
+		return nil, errors.Wrap(err, "sync organization roles")
+	}
+	return user, nil
+}
+
+var memberRoleRanks = map[modelschemas.MemberRole]int{
+	modelschemas.MemberRoleGuest:     1,
+	modelschemas.MemberRoleDeveloper: 2,
+	modelschemas.MemberRoleAdmin:     3,
+}
+
+// getOrganizationRolesFromGroups returns the highest role mapped from the groups in every mapped organization and the names of all the mapped organizations
+func getOrganizationRolesFromGroups(mappings []config.YataiOIDCGroupRoleMappingYaml, groups []string) (map[string]modelschemas.MemberRole, []string, error) {
+	groupsSet := make(map[string]struct{}, len(groups))
+	for _, group := range groups {
+		groupsSet[group] = struct{}{}
+	}
+	roles := make(map[string]modelschemas.MemberRole)
+	orgNames := make([]string, 0, len(mappings))
+	seen := make(map[string]struct{}, len(mappings))
+	for _, mapping := range mappings {
+		role := modelschemas.MemberRole(mapping.Role)
+		if _, ok := memberRoleRanks[role]; !ok {
+			return nil, nil, errors.Errorf("invalid member role %s of the oidc group %s", mapping.Role, mapping.Group)
+		}
+		if _, ok := seen[mapping.Organization]; !ok {
+			seen[mapping.Organization] = struct{}{}
+			orgNames = append(orgNames, mapping.Organization)
+		}
+		if _, ok := groupsSet[mapping.Group]; !ok {
+			continue
+		}
+		if memberRoleRanks[role] > memberRoleRanks[roles[mapping.Organization]] {
+			roles[mapping.Organization] = role
+		}
+	}
+	return roles, orgNames, nil
+}
+
+// syncOrganizationRoles keeps the memberships granted by the group role mappings in sync with the groups of the user:
+// they are created, changed and removed as the groups change. The memberships granted manually are never touched,
+// and changing a mapped membership by hand turns it into a manual one.
+func (s *oidcService) syncOrganizationRoles(ctx context.Context, user *models.User, groups []string) error {
+	roles, orgNames, err := getOrganizationRolesFromGroups(config.YataiConfig.OIDC.GroupRoleMappings, groups)
+	if err != nil {
+		return err
+	}
+	for _, orgName := range orgNames {
+		role, mapped := roles[orgName]
+		org, err := OrganizationService.GetByName(ctx, orgName)
+		if err != nil {
+			if !mapped && utils.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrapf(err, "get organization %s", orgName)
+		}
+		member, err := OrganizationMemberService.GetBy(ctx, user.ID, org.ID)
+		if err != nil && !utils.IsNotFound(err) {
+			return errors.Wrapf(err, "get the membership of user %s in organization %s", user.Name, orgName)
+		}
+		if err != nil {
+			if !mapped {
+				continue
+			}
+			_, err = OrganizationMemberService.Create(ctx, user.ID, CreateOrganizationMemberOption{
+				CreatorId:      user.ID,
+				UserId:         user.ID,
+				OrganizationId: org.ID,
+				Role:           role,
+				OidcManaged:    true,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "grant user %s the role %s in organization %s", user.Name, role, orgName)
+			}
+			continue
+		}
+		if !member.OidcManaged {
+			continue
+		}
+		if !mapped {
+			_, err = OrganizationMemberService.Delete(ctx, member, user.ID)
+			if err != nil {
+				return errors.Wrapf(err, "remove user %s from organization %s", user.Name, orgName)
+			}
+			continue
+		}
+		if member.Role == role {
+			continue
+		}
+		_, err = OrganizationMemberService.Update(ctx, member, user.ID, UpdateOrganizationMemberOption{
+			Role:        role,
+			OidcManaged: true,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "set the role of user %s in organization %s", user.Name, orgName)
+		}
+	}
+	return nil
+}
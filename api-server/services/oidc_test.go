@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/config"
+)
+
+const testOIDCKid = "test-key"
+
+// testIdP is an in-process oidc provider which serves the discovery document and the jwks of its signing key
+type testIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// issuer overrides the issuer in the discovery document, it defaults to the server url
+	issuer string
+	// the number of the requests of the jwks
+	jwksRequests int32
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	idp := &testIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer := idp.issuer
+		if issuer == "" {
+			issuer = idp.server.URL
+		}
+		_ = json.NewEncoder(w).Encode(oidcProviderMetadata{
+			Issuer:                issuer,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JwksURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&idp.jwksRequests, 1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []oidcJSONWebKey{
+				{
+					Kid: testOIDCKid,
+					Kty: "RSA",
+					Alg: "RS256",
+					Use: "sig",
+					N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+					E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+				},
+			},
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// signTestJWT signs the claims with RS256, the header is encoded as it is so the tests can forge it
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, header, claims map[string]interface{}) string {
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		t.Fatalf("marshal jwt header: %v", err)
+	}
+	claimsBytes, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("marshal jwt claims: %v", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(claimsBytes)
+	hashed := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatalf("sign jwt: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func setTestOIDCConfig(t *testing.T, oidcConfig *config.YataiOIDCConfigYaml) {
+	old := config.YataiConfig.OIDC
+	config.YataiConfig.OIDC = oidcConfig
+	t.Cleanup(func() {
+		config.YataiConfig.OIDC = old
+	})
+}
+
+func TestOIDCGetMetadata(t *testing.T) {
+	idp := newTestIdP(t)
+	setTestOIDCConfig(t, &config.YataiOIDCConfigYaml{
+		Issuer: idp.server.URL + "/",
+	})
+
+	s := &oidcService{}
+	metadata, err := s.getMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("get metadata: %v", err)
+	}
+	if metadata.TokenEndpoint != idp.server.URL+"/token" {
+		t.Fatalf("token endpoint is %q, expected %q", metadata.TokenEndpoint, idp.server.URL+"/token")
+	}
+	if _, err := s.getKey(context.Background(), testOIDCKid); err != nil {
+		t.Fatalf("get key: %v", err)
+	}
+	if n := atomic.LoadInt32(&idp.jwksRequests); n != 1 {
+		t.Fatalf("the jwks is requested %d times, expected the signing keys to be fetched with the discovery document", n)
+	}
+}
+
+func TestOIDCGetMetadataRejectsIssuerMismatch(t *testing.T) {
+	idp := newTestIdP(t)
+	idp.issuer = "https://evil.example.com"
+	setTestOIDCConfig(t, &config.YataiOIDCConfigYaml{
+		Issuer: idp.server.URL,
+	})
+
+	s := &oidcService{}
+	_, err := s.getMetadata(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "issuer mismatch") {
+		t.Fatalf("the issuer mismatch is not detected, the error is %v", err)
+	}
+}
+
+func TestOIDCVerifyIDToken(t *testing.T) {
+	idp := newTestIdP(t)
+	setTestOIDCConfig(t, &config.YataiOIDCConfigYaml{
+		Issuer:   idp.server.URL,
+		ClientId: "yatai",
+	})
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	header := map[string]interface{}{"alg": "RS256", "kid": testOIDCKid}
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   idp.server.URL,
+			"aud":   []string{"yatai", "another-client"},
+			"sub":   "user-1",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": "the-nonce",
+		}
+	}
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+	tampered := func() string {
+		parts := strings.Split(signTestJWT(t, idp.key, header, validClaims()), ".")
+		claims := validClaims()
+		claims["sub"] = "admin"
+		payload, _ := json.Marshal(claims)
+		parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+		return strings.Join(parts, ".")
+	}
+
+	cases := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"valid", signTestJWT(t, idp.key, header, validClaims()), ""},
+		{"tampered payload", tampered(), "verify jwt signature"},
+		{"signed by another key", signTestJWT(t, otherKey, header, validClaims()), "verify jwt signature"},
+		{"unknown kid", signTestJWT(t, idp.key, map[string]interface{}{"alg": "RS256", "kid": "rotated"}, validClaims()), "unknown signing key"},
+		{"alg none", signTestJWT(t, idp.key, map[string]interface{}{"alg": "none", "kid": testOIDCKid}, validClaims()), "unsupported jwt signing algorithm"},
+		{"malformed", "not-a-jwt", "malformed jwt"},
+		{"wrong issuer", signTestJWT(t, idp.key, header, withClaim("iss", "https://evil.example.com")), "unexpected id token issuer"},
+		{"wrong audience", signTestJWT(t, idp.key, header, withClaim("aud", "another-client")), "not issued for yatai"},
+		{"expired", signTestJWT(t, idp.key, header, withClaim("exp", time.Now().Add(-time.Hour).Unix())), "expired"},
+		{"nonce mismatch", signTestJWT(t, idp.key, header, withClaim("nonce", "another-nonce")), "nonce mismatch"},
+		{"no sub", signTestJWT(t, idp.key, header, withClaim("sub", nil)), "no sub"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := &oidcService{}
+			claims, err := s.verifyIDToken(context.Background(), c.token, "the-nonce")
+			if c.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verify id token: %v", err)
+				}
+				if claims.GetString("sub") != "user-1" {
+					t.Fatalf("sub is %q, expected %q", claims.GetString("sub"), "user-1")
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("the token is accepted, expected an error containing %q", c.wantErr)
+			}
+			if !strings.Contains(err.Error(), c.wantErr) {
+				t.Fatalf("the error is %q, expected it to contain %q", err.Error(), c.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetOrganizationRolesFromGroups(t *testing.T) {
+	mappings := []config.YataiOIDCGroupRoleMappingYaml{
+		{Group: "ml-devs", Organization: "default", Role: "developer"},
+		{Group: "ml-admins", Organization: "default", Role: "admin"},
+		{Group: "viewers", Organization: "research", Role: "guest"},
+		{Group: "platform", Organization: "platform", Role: "developer"},
+	}
+
+	roles, orgNames, err := getOrganizationRolesFromGroups(mappings, []string{"ml-admins", "ml-devs", "viewers", "unmapped"})
+	if err != nil {
+		t.Fatalf("get organization roles: %v", err)
+	}
+	if strings.Join(orgNames, ",") != "default,research,platform" {
+		t.Fatalf("the mapped organizations are %v", orgNames)
+	}
+	if roles["default"] != modelschemas.MemberRoleAdmin {
+		t.Fatalf("the role in default is %q, expected the highest mapped role %q", roles["default"], modelschemas.MemberRoleAdmin)
+	}
+	if roles["research"] != modelschemas.MemberRoleGuest {
+		t.Fatalf("the role in research is %q, expected %q", roles["research"], modelschemas.MemberRoleGuest)
+	}
+	if _, ok := roles["platform"]; ok {
+		t.Fatal("the user is granted a role in platform without being in the platform group")
+	}
+
+	_, _, err = getOrganizationRolesFromGroups([]config.YataiOIDCGroupRoleMappingYaml{
+		{Group: "ml-devs", Organization: "default", Role: "owner"},
+	}, nil)
+	if err == nil {
+		t.Fatal("the invalid role is accepted")
+	}
+}
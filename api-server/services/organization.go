@@ -4,6 +4,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +18,7 @@ import (
 	"github.com/bentoml/yatai/api-server/config"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/common/consts"
+	"github.com/bentoml/yatai/common/tracing"
 	"github.com/bentoml/yatai/common/utils"
 )
 
@@ -53,6 +55,10 @@ func (s *organizationService) Create(ctx context.Context, opt CreateOrganization
 		return nil, errors.New(strings.Join(errs, ";"))
 	}
 
+	encryptedConfig, err := encryptOrganizationConfig(ctx, opt.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypt config")
+	}
 	org := models.Organization{
 		ResourceMixin: models.ResourceMixin{
 			Name: opt.Name,
@@ -61,12 +67,13 @@ func (s *organizationService) Create(ctx context.Context, opt CreateOrganization
 			CreatorId: opt.CreatorId,
 		},
 		Description: opt.Description,
-		Config:      opt.Config,
+		Config:      encryptedConfig,
 	}
-	err := mustGetSession(ctx).Create(&org).Error
+	err = mustGetSession(ctx).Create(&org).Error
 	if err != nil {
 		return nil, err
 	}
+	org.Config = opt.Config
 	return &org, nil
 }
 
@@ -82,7 +89,13 @@ func (s *organizationService) Update(ctx context.Context, o *models.Organization
 		}()
 	}
 	if opt.Config != nil {
-		updaters["config"] = *opt.Config
+		restoreOrganizationConfigSecrets(*opt.Config, o.Config)
+		var encryptedConfig *modelschemas.OrganizationConfigSchema
+		encryptedConfig, err = encryptOrganizationConfig(ctx, *opt.Config)
+		if err != nil {
+			return nil, errors.Wrap(err, "encrypt config")
+		}
+		updaters["config"] = encryptedConfig
 		defer func() {
 			if err == nil {
 				o.Config = *opt.Config
@@ -311,14 +324,24 @@ func (c *S3Config) getMinioCredential() *credentials.Credentials {
 	return credentials.NewStaticV4(c.AccessKey, c.SecretKey, "")
 }
 
+// getMinioTransport propagates the trace context to the s3 calls, including the proxied uploads and downloads of the bentos and the models
+func (c *S3Config) getMinioTransport() http.RoundTripper {
+	transport, err := minio.DefaultTransport(c.Secure)
+	if err != nil {
+		return tracing.WrapTransport(http.DefaultTransport)
+	}
+	return tracing.WrapTransport(transport)
+}
+
 func (c *S3Config) GetMinioClient() (*minio.Client, error) {
 	endpoint := c.Endpoint
 	if config.YataiConfig.InCluster && !config.YataiConfig.IsSaaS {
 		endpoint = c.EndpointInCluster
 	}
 	return minio.New(endpoint, &minio.Options{
-		Creds:  c.getMinioCredential(),
-		Secure: c.Secure,
+		Creds:     c.getMinioCredential(),
+		Secure:    c.Secure,
+		Transport: c.getMinioTransport(),
 	})
 }
 
@@ -328,8 +351,9 @@ func (c *S3Config) GetMinioCore() (*minio.Core, error) {
 		endpoint = c.EndpointInCluster
 	}
 	return minio.NewCore(endpoint, &minio.Options{
-		Creds:  c.getMinioCredential(),
-		Secure: c.Secure,
+		Creds:     c.getMinioCredential(),
+		Secure:    c.Secure,
+		Transport: c.getMinioTransport(),
 	})
 }
 
@@ -23,10 +23,13 @@ type CreateOrganizationMemberOption struct {
 	UserId         uint
 	OrganizationId uint
 	Role           modelschemas.MemberRole
+	OidcManaged    bool
 }
 
+// UpdateOrganizationMemberOption turns the membership into a manual one unless OidcManaged is set
 type UpdateOrganizationMemberOption struct {
-	Role modelschemas.MemberRole
+	Role        modelschemas.MemberRole
+	OidcManaged bool
 }
 
 type ListOrganizationMemberOption struct {
@@ -43,7 +46,7 @@ func (s *organizationMemberService) Create(ctx context.Context, operatorId uint,
 	}
 
 	if err == nil {
-		return s.Update(ctx, oldMember, operatorId, UpdateOrganizationMemberOption{Role: opt.Role})
+		return s.Update(ctx, oldMember, operatorId, UpdateOrganizationMemberOption{Role: opt.Role, OidcManaged: opt.OidcManaged})
 	}
 
 	// nolint: ineffassign,staticcheck
@@ -62,7 +65,8 @@ func (s *organizationMemberService) Create(ctx context.Context, operatorId uint,
 		OrganizationAssociate: models.OrganizationAssociate{
 			OrganizationId: opt.OrganizationId,
 		},
-		Role: opt.Role,
+		Role:        opt.Role,
+		OidcManaged: opt.OidcManaged,
 	}
 	err = db.Create(member).Error
 	if err != nil {
@@ -86,6 +90,8 @@ func (s *organizationMemberService) GetBy(ctx context.Context, userId, organizat
 func (s *organizationMemberService) List(ctx context.Context, opt ListOrganizationMemberOption) ([]*models.OrganizationMember, error) {
 	members := make([]*models.OrganizationMember, 0)
 	query := getBaseQuery(ctx, s)
+	// the members with a NULL user_id are user group members
+	query = query.Where("user_id IS NOT NULL")
 	if opt.OrganizationId != nil {
 		query = query.Where("organization_id = ?", *opt.OrganizationId)
 	}
@@ -107,9 +113,9 @@ func (s *organizationMemberService) List(ctx context.Context, opt ListOrganizati
 
 func (s *organizationMemberService) ListOrganizationIds(ctx context.Context, userId uint) ([]uint, error) {
 	query := s.getBaseDB(ctx)
-	query = query.Where("user_id = ?", userId)
+	query = query.Where("(user_id = ? OR user_group_id in (?))", userId, UserGroupService.getUserGroupIdsQuery(ctx, userId))
 	res := make([]uint, 0)
-	err := query.Select("organization_id").Find(&res).Error
+	err := query.Distinct("organization_id").Find(&res).Error
 	return res, err
 }
 
@@ -122,8 +128,9 @@ func (s *organizationMemberService) GetOrganization(ctx context.Context, resourc
 }
 
 func (s *organizationMemberService) CheckRoles(ctx context.Context, userId, resourceId uint, roles []modelschemas.MemberRole) (bool, error) {
+	// the user gets the roles either directly or through the user groups the user belongs to
 	q := s.getBaseDB(ctx).
-		Where("user_id = ?", userId).
+		Where("(user_id = ? OR user_group_id in (?))", userId, UserGroupService.getUserGroupIdsQuery(ctx, userId)).
 		Where("organization_id = ?", resourceId).
 		Where("role in (?)", roles)
 	var total int64
@@ -133,10 +140,12 @@ func (s *organizationMemberService) CheckRoles(ctx context.Context, userId, reso
 
 func (s *organizationMemberService) Update(ctx context.Context, m *models.OrganizationMember, operatorId uint, opt UpdateOrganizationMemberOption) (*models.OrganizationMember, error) {
 	err := s.getBaseDB(ctx).Where("id = ?", m.ID).Updates(map[string]interface{}{
-		"role": opt.Role,
+		"role":         opt.Role,
+		"oidc_managed": opt.OidcManaged,
 	}).Error
 	if err == nil {
 		m.Role = opt.Role
+		m.OidcManaged = opt.OidcManaged
 	}
 	return m, err
 }
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type organizationUserGroupMemberService struct{}
+
+var OrganizationUserGroupMemberService = organizationUserGroupMemberService{}
+
+func (s *organizationUserGroupMemberService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.OrganizationUserGroupMember{}).Where("organization_member.user_group_id IS NOT NULL")
+}
+
+type CreateOrganizationUserGroupMemberOption struct {
+	CreatorId      uint
+	UserGroupId    uint
+	OrganizationId uint
+	Role           modelschemas.MemberRole
+}
+
+type UpdateOrganizationUserGroupMemberOption struct {
+	Role modelschemas.MemberRole
+}
+
+type ListOrganizationUserGroupMemberOption struct {
+	UserGroupId    *uint
+	OrganizationId *uint
+	Roles          *[]modelschemas.MemberRole
+}
+
+func (s *organizationUserGroupMemberService) Create(ctx context.Context, operatorId uint, opt CreateOrganizationUserGroupMemberOption) (*models.OrganizationUserGroupMember, error) {
+	oldMember, err := s.GetBy(ctx, opt.UserGroupId, opt.OrganizationId)
+	if err != nil && !utils.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		return s.Update(ctx, oldMember, operatorId, UpdateOrganizationUserGroupMemberOption{Role: opt.Role})
+	}
+
+	// nolint: ineffassign,staticcheck
+	db, ctx, df, err := startTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { df(err) }()
+	member := &models.OrganizationUserGroupMember{
+		CreatorAssociate: models.CreatorAssociate{
+			CreatorId: opt.CreatorId,
+		},
+		UserGroupAssociate: models.UserGroupAssociate{
+			UserGroupId: opt.UserGroupId,
+		},
+		OrganizationAssociate: models.OrganizationAssociate{
+			OrganizationId: opt.OrganizationId,
+		},
+		Role: opt.Role,
+	}
+	err = db.Create(member).Error
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *organizationUserGroupMemberService) GetBy(ctx context.Context, userGroupId, organizationId uint) (*models.OrganizationUserGroupMember, error) {
+	var member models.OrganizationUserGroupMember
+	err := getBaseQuery(ctx, s).Where("organization_id = ?", organizationId).Where("user_group_id = ?", userGroupId).First(&member).Error
+	return &member, err
+}
+
+func (s *organizationUserGroupMemberService) List(ctx context.Context, opt ListOrganizationUserGroupMemberOption) ([]*models.OrganizationUserGroupMember, error) {
+	members := make([]*models.OrganizationUserGroupMember, 0)
+	query := getBaseQuery(ctx, s)
+	if opt.OrganizationId != nil {
+		query = query.Where("organization_id = ?", *opt.OrganizationId)
+	}
+	if opt.UserGroupId != nil {
+		query = query.Where("user_group_id = ?", *opt.UserGroupId)
+	}
+	if opt.Roles != nil {
+		query = query.Where("role in (?)", *opt.Roles)
+	}
+	err := query.Order("id DESC").Find(&members).Error
+	return members, err
+}
+
+func (s *organizationUserGroupMemberService) Update(ctx context.Context, m *models.OrganizationUserGroupMember, operatorId uint, opt UpdateOrganizationUserGroupMemberOption) (*models.OrganizationUserGroupMember, error) {
+	err := s.getBaseDB(ctx).Where("id = ?", m.ID).Updates(map[string]interface{}{
+		"role": opt.Role,
+	}).Error
+	if err == nil {
+		m.Role = opt.Role
+	}
+	return m, err
+}
+
+func (s *organizationUserGroupMemberService) Delete(ctx context.Context, m *models.OrganizationUserGroupMember, operatorId uint) (*models.OrganizationUserGroupMember, error) {
+	err := mustGetSession(ctx).Unscoped().Delete(m).Error
+	return m, err
+}
@@ -0,0 +1,572 @@
+package services
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type PodLogSeverity string
+
+const (
+	PodLogSeverityUnknown  PodLogSeverity = ""
+	PodLogSeverityDebug    PodLogSeverity = "debug"
+	PodLogSeverityInfo     PodLogSeverity = "info"
+	PodLogSeverityWarning  PodLogSeverity = "warning"
+	PodLogSeverityError    PodLogSeverity = "error"
+	PodLogSeverityCritical PodLogSeverity = "critical"
+)
+
+var podLogSeverityLevels = map[PodLogSeverity]int{
+	PodLogSeverityUnknown:  0,
+	PodLogSeverityDebug:    1,
+	PodLogSeverityInfo:     2,
+	PodLogSeverityWarning:  3,
+	PodLogSeverityError:    4,
+	PodLogSeverityCritical: 5,
+}
+
+var podLogSeverityAliases = map[string]PodLogSeverity{
+	"debug":    PodLogSeverityDebug,
+	"info":     PodLogSeverityInfo,
+	"warn":     PodLogSeverityWarning,
+	"warning":  PodLogSeverityWarning,
+	"error":    PodLogSeverityError,
+	"critical": PodLogSeverityCritical,
+	"fatal":    PodLogSeverityCritical,
+	"panic":    PodLogSeverityCritical,
+}
+
+var podLogSeverityRegexp = regexp.MustCompile(`(?i)\b(debug|info|warn|warning|error|critical|fatal|panic)\b`)
+
+const (
+	// the rest of a longer line is dropped
+	podLogMaxLineBytes = 64 * 1024
+	// a pod which has sent no line within the window stops holding back the lines of the other pods in follow mode
+	podLogReorderWindow = time.Second
+)
+
+// detectPodLogSeverity takes the first level keyword of the line, such as [INFO], level=error or "level":"warn"
+func detectPodLogSeverity(content string) PodLogSeverity {
+	match := podLogSeverityRegexp.FindString(content)
+	if match == "" {
+		return PodLogSeverityUnknown
+	}
+	return podLogSeverityAliases[strings.ToLower(match)]
+}
+
+func ParsePodLogSeverity(severity string) (PodLogSeverity, error) {
+	if severity == "" {
+		return PodLogSeverityUnknown, nil
+	}
+	res, ok := podLogSeverityAliases[strings.ToLower(severity)]
+	if !ok {
+		return PodLogSeverityUnknown, errors.Errorf("unknown log severity %s", severity)
+	}
+	return res, nil
+}
+
+type PodLogFilter struct {
+	Pattern     *regexp.Regexp
+	MinSeverity PodLogSeverity
+}
+
+func NewPodLogFilter(pattern, severity string) (*PodLogFilter, error) {
+	filter := &PodLogFilter{}
+	if pattern != "" {
+		pattern_, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "compile log pattern %s", pattern)
+		}
+		filter.Pattern = pattern_
+	}
+	minSeverity, err := ParsePodLogSeverity(severity)
+	if err != nil {
+		return nil, err
+	}
+	filter.MinSeverity = minSeverity
+	return filter, nil
+}
+
+// Match reports whether the line is kept, the lines of unknown severity are dropped when a minimum severity is set
+func (f *PodLogFilter) Match(line *PodLogLine) bool {
+	if f == nil {
+		return true
+	}
+	if f.MinSeverity != PodLogSeverityUnknown && podLogSeverityLevels[line.Severity] < podLogSeverityLevels[f.MinSeverity] {
+		return false
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(line.Content) {
+		return false
+	}
+	return true
+}
+
+type PodLogSource struct {
+	PodName       string
+	ContainerName string
+}
+
+type PodLogLine struct {
+	PodName       string
+	ContainerName string
+	Timestamp     time.Time
+	Severity      PodLogSeverity
+	Content       string
+}
+
+// String renders the line with its timestamp, the pod and the container are prefixed if withSource is true
+func (l *PodLogLine) String(withSource bool) string {
+	if withSource {
+		return fmt.Sprintf("%s [%s] [%s] %s", l.Timestamp.Format(time.RFC3339Nano), l.PodName, l.ContainerName, l.Content)
+	}
+	return fmt.Sprintf("%s %s", l.Timestamp.Format(time.RFC3339Nano), l.Content)
+}
+
+// PodLogCursor is the position of the last line read from the log of a source
+type PodLogCursor struct {
+	Timestamp time.Time
+	// the number of the lines with the timestamp which are read
+	Count int
+}
+
+type PodLogOption struct {
+	Sources   []PodLogSource
+	TailLines *int64
+	SinceTime *time.Time
+	// the cursors are moved as the lines are read, the log of a source with a cursor is resumed after it,
+	// so that a follow after a read neither repeats nor drops the lines of the second the read ends in
+	Cursors map[PodLogSource]*PodLogCursor
+	// the lines after UntilTime are dropped, and the log stream of a pod is closed at its first line after UntilTime
+	UntilTime *time.Time
+	Filter    *PodLogFilter
+	// the lines are handed over in batches of at most BatchSize lines
+	BatchSize int
+}
+
+func (opt PodLogOption) getBatchSize() int {
+	if opt.BatchSize <= 0 {
+		return 100
+	}
+	return opt.BatchSize
+}
+
+type podLogStream struct {
+	index  int
+	source PodLogSource
+	rc     io.ReadCloser
+	reader *bufio.Reader
+	// the continuation lines, such as the stack traces, inherit the timestamp and the severity of the previous line
+	lastTimestamp time.Time
+	lastSeverity  PodLogSeverity
+	// the lines up to resumeFrom are skipped, they have been read before the stream is opened
+	resumeFrom PodLogCursor
+	skipped    int
+	cursor     *PodLogCursor
+}
+
+func newPodLogStream(index int, source PodLogSource, rc io.ReadCloser, cursor *PodLogCursor) *podLogStream {
+	st := &podLogStream{
+		index:  index,
+		source: source,
+		rc:     rc,
+		reader: bufio.NewReaderSize(rc, 64*1024),
+		cursor: cursor,
+	}
+	if cursor != nil {
+		st.resumeFrom = *cursor
+	}
+	return st
+}
+
+func (st *podLogStream) readLine() (string, error) {
+	var sb strings.Builder
+	for {
+		fragment, isPrefix, err := st.reader.ReadLine()
+		if err != nil {
+			if err == io.EOF && sb.Len() > 0 {
+				return sb.String(), nil
+			}
+			return "", err
+		}
+		if sb.Len() < podLogMaxLineBytes {
+			if sb.Len()+len(fragment) > podLogMaxLineBytes {
+				fragment = fragment[:podLogMaxLineBytes-sb.Len()]
+			}
+			sb.Write(fragment)
+		}
+		if !isPrefix {
+			return sb.String(), nil
+		}
+	}
+}
+
+// isRead reports whether the line has been read before the stream is opened
+func (st *podLogStream) isRead(line *PodLogLine) bool {
+	if st.resumeFrom.Timestamp.IsZero() {
+		return false
+	}
+	if line.Timestamp.Before(st.resumeFrom.Timestamp) {
+		return true
+	}
+	if line.Timestamp.Equal(st.resumeFrom.Timestamp) && st.skipped < st.resumeFrom.Count {
+		st.skipped++
+		return true
+	}
+	st.resumeFrom = PodLogCursor{}
+	return false
+}
+
+// next returns the next line of the stream, the lines are requested with the timestamps prefixed by kubernetes
+func (st *podLogStream) next() (*PodLogLine, error) {
+	for {
+		raw, err := st.readLine()
+		if err != nil {
+			return nil, err
+		}
+		line := &PodLogLine{
+			PodName:       st.source.PodName,
+			ContainerName: st.source.ContainerName,
+			Timestamp:     st.lastTimestamp,
+			Content:       raw,
+		}
+		if idx := strings.IndexByte(raw, ' '); idx > 0 {
+			if ts, err := time.Parse(time.RFC3339Nano, raw[:idx]); err == nil {
+				line.Timestamp = ts
+				line.Content = raw[idx+1:]
+			}
+		}
+		line.Severity = detectPodLogSeverity(line.Content)
+		if line.Severity == PodLogSeverityUnknown {
+			line.Severity = st.lastSeverity
+		}
+		st.lastTimestamp = line.Timestamp
+		st.lastSeverity = line.Severity
+		if st.isRead(line) {
+			continue
+		}
+		return line, nil
+	}
+}
+
+// commit moves the cursor of the stream to the line
+func (st *podLogStream) commit(line *PodLogLine) {
+	if st.cursor == nil {
+		return
+	}
+	if line.Timestamp.Equal(st.cursor.Timestamp) {
+		st.cursor.Count++
+		return
+	}
+	st.cursor.Timestamp = line.Timestamp
+	st.cursor.Count = 1
+}
+
+type podLogHeapItem struct {
+	line   *PodLogLine
+	stream *podLogStream
+}
+
+type podLogHeap []*podLogHeapItem
+
+func (h podLogHeap) Len() int { return len(h) }
+
+func (h podLogHeap) Less(i, j int) bool {
+	if !h[i].line.Timestamp.Equal(h[j].line.Timestamp) {
+		return h[i].line.Timestamp.Before(h[j].line.Timestamp)
+	}
+	return h[i].stream.index < h[j].stream.index
+}
+
+func (h podLogHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *podLogHeap) Push(x interface{}) {
+	*h = append(*h, x.(*podLogHeapItem))
+}
+
+func (h *podLogHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+type podLogService struct{}
+
+var PodLogService = podLogService{}
+
+func (s *podLogService) openStreams(ctx context.Context, cliset kubernetes.Interface, namespace string, opt PodLogOption, follow bool) ([]*podLogStream, error) {
+	streams := make([]*podLogStream, 0, len(opt.Sources))
+	for idx, source := range opt.Sources {
+		logOptions := &apiv1.PodLogOptions{
+			Container:  source.ContainerName,
+			TailLines:  opt.TailLines,
+			Timestamps: true,
+			Follow:     follow,
+		}
+		if opt.SinceTime != nil {
+			logOptions.SinceTime = &metav1.Time{
+				Time: *opt.SinceTime,
+			}
+		}
+		var cursor *PodLogCursor
+		if opt.Cursors != nil {
+			cursor = opt.Cursors[source]
+			if cursor == nil {
+				cursor = &PodLogCursor{}
+				opt.Cursors[source] = cursor
+			}
+			if !cursor.Timestamp.IsZero() {
+				// the since time is in seconds, the lines of the second which have been read are skipped by the stream
+				logOptions.TailLines = nil
+				logOptions.SinceTime = &metav1.Time{
+					Time: cursor.Timestamp.Truncate(time.Second),
+				}
+			}
+		}
+		rc, err := cliset.CoreV1().Pods(namespace).GetLogs(source.PodName, logOptions).Stream(ctx)
+		if err != nil {
+			for _, st := range streams {
+				_ = st.rc.Close()
+			}
+			return nil, errors.Wrapf(err, "get pod %s log", source.PodName)
+		}
+		streams = append(streams, newPodLogStream(idx, source, rc, cursor))
+	}
+	return streams, nil
+}
+
+func (s *podLogService) isAfterUntilTime(opt PodLogOption, line *PodLogLine) bool {
+	return opt.UntilTime != nil && line.Timestamp.After(*opt.UntilTime)
+}
+
+// Read merges the current logs of the sources in timestamp order. Only the head line of every source is held,
+// so the logs of many replicas are never buffered as a whole, and a slow onLines slows down the reading
+func (s *podLogService) Read(ctx context.Context, cliset kubernetes.Interface, namespace string, opt PodLogOption, onLines func(lines []*PodLogLine) error) error {
+	streams, err := s.openStreams(ctx, cliset, namespace, opt, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, st := range streams {
+			_ = st.rc.Close()
+		}
+	}()
+	return s.merge(streams, opt, onLines)
+}
+
+// merge hands over the lines of the streams in timestamp order until all the streams end
+func (s *podLogService) merge(streams []*podLogStream, opt PodLogOption, onLines func(lines []*PodLogLine) error) error {
+	h := make(podLogHeap, 0, len(streams))
+	advance := func(st *podLogStream) error {
+		line, err := st.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "read pod %s log", st.source.PodName)
+		}
+		if s.isAfterUntilTime(opt, line) {
+			return nil
+		}
+		st.commit(line)
+		heap.Push(&h, &podLogHeapItem{line: line, stream: st})
+		return nil
+	}
+	for _, st := range streams {
+		if err := advance(st); err != nil {
+			return err
+		}
+	}
+
+	batchSize := opt.getBatchSize()
+	batch := make([]*PodLogLine, 0, batchSize)
+	for h.Len() > 0 {
+		item := heap.Pop(&h).(*podLogHeapItem)
+		if opt.Filter.Match(item.line) {
+			batch = append(batch, item.line)
+			if len(batch) >= batchSize {
+				if err := onLines(batch); err != nil {
+					return err
+				}
+				batch = make([]*PodLogLine, 0, batchSize)
+			}
+		}
+		if err := advance(item.stream); err != nil {
+			return err
+		}
+	}
+	if len(batch) > 0 {
+		return onLines(batch)
+	}
+	return nil
+}
+
+// getPodLogWatermark returns the timestamp up to which the pending lines are in order: the oldest of the latest timestamps of the streams.
+// The timestamps are compared among the streams only, so the clock skew between the nodes and the server does not matter.
+// A stream which has sent nothing for podLogReorderWindow does not hold back the others, all is true when no stream holds them back
+func getPodLogWatermark(latest, arrivedAt []time.Time, now time.Time) (watermark time.Time, all bool) {
+	all = true
+	for i := range latest {
+		if now.Sub(arrivedAt[i]) >= podLogReorderWindow {
+			continue
+		}
+		if all || latest[i].Before(watermark) {
+			watermark = latest[i]
+		}
+		all = false
+	}
+	return watermark, all
+}
+
+// Follow streams the new logs of the sources until ctx is done or all the streams end.
+// The lines of different sources are ordered by the watermark of the streams. The readers only run ahead of onLines
+// by a bounded number of lines, so a slow consumer stalls the kubernetes log streams instead of growing the memory.
+func (s *podLogService) Follow(ctx context.Context, cliset kubernetes.Interface, namespace string, opt PodLogOption, onLines func(lines []*PodLogLine) error) error {
+	streams, err := s.openStreams(ctx, cliset, namespace, opt, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, st := range streams {
+			_ = st.rc.Close()
+		}
+	}()
+	return s.follow(ctx, streams, opt, onLines)
+}
+
+func (s *podLogService) follow(ctx context.Context, streams []*podLogStream, opt PodLogOption, onLines func(lines []*PodLogLine) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batchSize := opt.getBatchSize()
+	maxPending := batchSize * 10
+	itemsCh := make(chan *podLogHeapItem, batchSize)
+	errCh := make(chan error, len(streams))
+	doneCh := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for _, st := range streams {
+		st := st
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				line, err := st.next()
+				if err != nil {
+					if err != io.EOF && ctx.Err() == nil {
+						errCh <- errors.Wrapf(err, "read pod %s log", st.source.PodName)
+					}
+					return
+				}
+				if s.isAfterUntilTime(opt, line) {
+					return
+				}
+				st.commit(line)
+				if !opt.Filter.Match(line) {
+					continue
+				}
+				select {
+				case itemsCh <- &podLogHeapItem{line: line, stream: st}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(doneCh)
+	}()
+
+	h := make(podLogHeap, 0, maxPending)
+	// flush hands over at most maxLines of the pending lines in batches, the lines after the watermark are kept unless all is true
+	flush := func(watermark time.Time, all bool, maxLines int) error {
+		for h.Len() > 0 && maxLines > 0 {
+			n := batchSize
+			if n > maxLines {
+				n = maxLines
+			}
+			batch := make([]*PodLogLine, 0, n)
+			for h.Len() > 0 && len(batch) < n {
+				if !all && h[0].line.Timestamp.After(watermark) {
+					break
+				}
+				batch = append(batch, heap.Pop(&h).(*podLogHeapItem).line)
+			}
+			if len(batch) == 0 {
+				return nil
+			}
+			maxLines -= len(batch)
+			if err := onLines(batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// every stream holds back the others until it sends its first line or the window passes
+	latest := make([]time.Time, len(streams))
+	arrivedAt := make([]time.Time, len(streams))
+	start := time.Now()
+	for i := range arrivedAt {
+		arrivedAt[i] = start
+	}
+	flushInOrder := func(now time.Time) error {
+		watermark, all := getPodLogWatermark(latest, arrivedAt, now)
+		return flush(watermark, all, h.Len())
+	}
+
+	ticker := time.NewTicker(podLogReorderWindow / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			return err
+		case <-doneCh:
+			// the streams are done, drain the lines which are sent before the readers return
+			for {
+				select {
+				case item := <-itemsCh:
+					heap.Push(&h, item)
+				default:
+					return flush(time.Time{}, true, h.Len())
+				}
+			}
+		case item := <-itemsCh:
+			now := time.Now()
+			heap.Push(&h, item)
+			latest[item.stream.index] = item.line.Timestamp
+			arrivedAt[item.stream.index] = now
+			if h.Len() >= maxPending {
+				// the window is too busy to hold, hand over a batch of the oldest lines
+				if err := flush(time.Time{}, true, batchSize); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := flushInOrder(now); err != nil {
+				return err
+			}
+		case now := <-ticker.C:
+			if err := flushInOrder(now); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPodLogStream(index int, podName string, cursor *PodLogCursor, lines ...string) *podLogStream {
+	return newPodLogStream(index, PodLogSource{PodName: podName, ContainerName: "main"}, io.NopCloser(strings.NewReader(strings.Join(lines, "\n")+"\n")), cursor)
+}
+
+func formatTestPodLogLines(lines []*PodLogLine) []string {
+	res := make([]string, 0, len(lines))
+	for _, line := range lines {
+		res = append(res, line.String(true))
+	}
+	return res
+}
+
+func TestDetectPodLogSeverity(t *testing.T) {
+	cases := []struct {
+		content  string
+		expected PodLogSeverity
+	}{
+		{"[INFO] server started", PodLogSeverityInfo},
+		{"level=error msg=\"connection refused\"", PodLogSeverityError},
+		{`{"level":"warn","msg":"slow request"}`, PodLogSeverityWarning},
+		{"FATAL: out of memory", PodLogSeverityCritical},
+		{"DEBUG then ERROR", PodLogSeverityDebug},
+		{"information is not a level", PodLogSeverityUnknown},
+		{"    at main.go:42", PodLogSeverityUnknown},
+	}
+	for _, c := range cases {
+		if severity := detectPodLogSeverity(c.content); severity != c.expected {
+			t.Fatalf("the severity of %q is %q, expected %q", c.content, severity, c.expected)
+		}
+	}
+}
+
+func TestPodLogMerge(t *testing.T) {
+	untilTime := time.Date(2022, 8, 1, 0, 0, 3, 0, time.UTC)
+	streams := []*podLogStream{
+		newTestPodLogStream(0, "pod-a", nil,
+			"2022-08-01T00:00:01Z a1",
+			"2022-08-01T00:00:03Z a3",
+			"2022-08-01T00:00:05Z a5",
+		),
+		newTestPodLogStream(1, "pod-b", nil,
+			"2022-08-01T00:00:01Z b1",
+			"2022-08-01T00:00:02Z b2",
+			"    at the continuation",
+			"2022-08-01T00:00:04Z b4",
+		),
+	}
+	batches := make([][]string, 0)
+	err := PodLogService.merge(streams, PodLogOption{UntilTime: &untilTime, BatchSize: 2}, func(lines []*PodLogLine) error {
+		batches = append(batches, formatTestPodLogLines(lines))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	expected := [][]string{
+		{"2022-08-01T00:00:01Z [pod-a] [main] a1", "2022-08-01T00:00:01Z [pod-b] [main] b1"},
+		{"2022-08-01T00:00:02Z [pod-b] [main] b2", "2022-08-01T00:00:02Z [pod-b] [main]     at the continuation"},
+		{"2022-08-01T00:00:03Z [pod-a] [main] a3"},
+	}
+	if !reflect.DeepEqual(batches, expected) {
+		t.Fatalf("the batches are %v, expected %v", batches, expected)
+	}
+}
+
+func TestPodLogCursorResume(t *testing.T) {
+	logs := []string{
+		"2022-08-01T00:00:01.500Z before",
+		"2022-08-01T00:00:02Z first",
+		"2022-08-01T00:00:02Z second",
+		"2022-08-01T00:00:02Z third",
+		"2022-08-01T00:00:03Z fourth",
+	}
+	cursor := &PodLogCursor{}
+	read := make([]string, 0)
+	onLines := func(lines []*PodLogLine) error {
+		for _, line := range lines {
+			read = append(read, line.Content)
+		}
+		return nil
+	}
+	// the read ends within the second of the repeated timestamps
+	err := PodLogService.merge([]*podLogStream{newTestPodLogStream(0, "pod-a", cursor, logs[:3]...)}, PodLogOption{}, onLines)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	expectedCursor := PodLogCursor{Timestamp: time.Date(2022, 8, 1, 0, 0, 2, 0, time.UTC), Count: 2}
+	if !cursor.Timestamp.Equal(expectedCursor.Timestamp) || cursor.Count != expectedCursor.Count {
+		t.Fatalf("the cursor is %+v, expected %+v", *cursor, expectedCursor)
+	}
+	// the resumed log starts from the second of the cursor
+	err = PodLogService.merge([]*podLogStream{newTestPodLogStream(0, "pod-a", cursor, logs...)}, PodLogOption{}, onLines)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	expected := []string{"before", "first", "second", "third", "fourth"}
+	if !reflect.DeepEqual(read, expected) {
+		t.Fatalf("the lines are %v, expected %v", read, expected)
+	}
+}
+
+func TestGetPodLogWatermark(t *testing.T) {
+	now := time.Date(2022, 8, 1, 0, 0, 10, 0, time.UTC)
+	t1 := time.Date(2022, 8, 1, 0, 0, 1, 0, time.UTC)
+	t2 := time.Date(2022, 8, 1, 0, 0, 2, 0, time.UTC)
+	recent := now.Add(-podLogReorderWindow / 2)
+	quiet := now.Add(-podLogReorderWindow)
+	cases := []struct {
+		name              string
+		latest            []time.Time
+		arrivedAt         []time.Time
+		expectedWatermark time.Time
+		expectedAll       bool
+	}{
+		{"the oldest of the active streams", []time.Time{t2, t1}, []time.Time{recent, recent}, t1, false},
+		{"a quiet stream does not hold back", []time.Time{t2, t1}, []time.Time{recent, quiet}, t2, false},
+		{"a stream which has sent nothing holds back", []time.Time{t2, {}}, []time.Time{recent, recent}, time.Time{}, false},
+		{"all streams are quiet", []time.Time{t2, t1}, []time.Time{quiet, quiet}, time.Time{}, true},
+	}
+	for _, c := range cases {
+		watermark, all := getPodLogWatermark(c.latest, c.arrivedAt, now)
+		if !watermark.Equal(c.expectedWatermark) || all != c.expectedAll {
+			t.Fatalf("%s: the watermark is %s, all: %v, expected %s, all: %v", c.name, watermark, all, c.expectedWatermark, c.expectedAll)
+		}
+	}
+}
+
+func TestPodLogFollowOrdersAcrossStreams(t *testing.T) {
+	// the line of pod-b arrives first, but it is held back until pod-a sends a later line
+	readerA, writerA := io.Pipe()
+	readerB, writerB := io.Pipe()
+	streams := []*podLogStream{
+		newPodLogStream(0, PodLogSource{PodName: "pod-a", ContainerName: "main"}, readerA, nil),
+		newPodLogStream(1, PodLogSource{PodName: "pod-b", ContainerName: "main"}, readerB, nil),
+	}
+	go func() {
+		_, _ = io.WriteString(writerB, "2022-08-01T00:00:02Z b2\n")
+		time.Sleep(podLogReorderWindow / 5)
+		_, _ = io.WriteString(writerA, "2022-08-01T00:00:01Z a1\n")
+		_, _ = io.WriteString(writerA, "2022-08-01T00:00:03Z a3\n")
+		_ = writerA.Close()
+		_ = writerB.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	read := make([]string, 0)
+	err := PodLogService.follow(ctx, streams, PodLogOption{}, func(lines []*PodLogLine) error {
+		for _, line := range lines {
+			read = append(read, line.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	expected := []string{"a1", "b2", "a3"}
+	if !reflect.DeepEqual(read, expected) {
+		t.Fatalf("the lines are %v, expected %v", read, expected)
+	}
+}
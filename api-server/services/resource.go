@@ -54,6 +54,12 @@ func (m *resourceService) Get(ctx context.Context, resourceType modelschemas.Res
 	case modelschemas.ResourceTypeYataiComponent:
 		yataiComponent, err := YataiComponentService.Get(ctx, resourceId)
 		return yataiComponent, err
+	case models.ResourceTypeUserGroup:
+		userGroup, err := UserGroupService.Get(ctx, resourceId)
+		return userGroup, err
+	case models.ResourceTypeWebhook:
+		webhook, err := WebhookService.Get(ctx, resourceId)
+		return webhook, err
 	default:
 		return nil, errors.Errorf("cannot recognize this resource type: %s", resourceType)
 	}
@@ -124,6 +130,16 @@ func (m *resourceService) List(ctx context.Context, resourceType modelschemas.Re
 			Ids: &resourceIds,
 		})
 		return yataiComponents, err
+	case models.ResourceTypeUserGroup:
+		userGroups, _, err := UserGroupService.List(ctx, ListUserGroupOption{
+			Ids: &resourceIds,
+		})
+		return userGroups, err
+	case models.ResourceTypeWebhook:
+		webhooks, _, err := WebhookService.List(ctx, ListWebhookOption{
+			Ids: &resourceIds,
+		})
+		return webhooks, err
 	default:
 		return nil, errors.Errorf("cannot recognize this resource type: %s", resourceType)
 	}
@@ -170,6 +186,12 @@ func (m *resourceService) GetByUid(ctx context.Context, resourceType modelschema
 	case modelschemas.ResourceTypeYataiComponent:
 		yataiComponent, err := YataiComponentService.GetByUid(ctx, resourceUid)
 		return yataiComponent, err
+	case models.ResourceTypeUserGroup:
+		userGroup, err := UserGroupService.GetByUid(ctx, resourceUid)
+		return userGroup, err
+	case models.ResourceTypeWebhook:
+		webhook, err := WebhookService.GetByUid(ctx, resourceUid)
+		return webhook, err
 	default:
 		return nil, errors.Errorf("cannot recognize this resource type: %s", resourceType)
 	}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/consts"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+type retentionPolicyService struct{}
+
+var RetentionPolicyService = retentionPolicyService{}
+
+var retentionPolicyLogger = logrus.WithField("service", "retention policy")
+
+func (s *retentionPolicyService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.RetentionPolicy{})
+}
+
+type UpsertRetentionPolicyOption struct {
+	CreatorId      uint
+	OrganizationId uint
+	Resource       models.IResource
+	KeepLast       *uint
+	MaxAgeDays     *uint
+}
+
+type ListRetentionPolicyOption struct {
+	BaseListOption
+	OrganizationId *uint
+	ResourceType   *modelschemas.ResourceType
+}
+
+func validateRetentionPolicyOption(opt UpsertRetentionPolicyOption) error {
+	resourceType := opt.Resource.GetResourceType()
+	// the retention policy of an organization applies to its terminal records
+	if resourceType != modelschemas.ResourceTypeBentoRepository && resourceType != modelschemas.ResourceTypeModelRepository && resourceType != modelschemas.ResourceTypeOrganization {
+		return errors.Errorf("retention policy is not supported for resource type %s", resourceType)
+	}
+	if opt.KeepLast == nil && opt.MaxAgeDays == nil {
+		return errors.New("at least one of keep_last and max_age_days should be set")
+	}
+	if opt.KeepLast != nil && *opt.KeepLast == 0 {
+		return errors.New("keep_last should be greater than 0")
+	}
+	if opt.MaxAgeDays != nil && *opt.MaxAgeDays == 0 {
+		return errors.New("max_age_days should be greater than 0")
+	}
+	return nil
+}
+
+// Upsert creates the retention policy of the repository or replaces its rules if it already exists
+func (s *retentionPolicyService) Upsert(ctx context.Context, opt UpsertRetentionPolicyOption) (*models.RetentionPolicy, error) {
+	err := validateRetentionPolicyOption(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.GetByResource(ctx, opt.Resource)
+	if err != nil && !utils.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		err = s.getBaseDB(ctx).Where("id = ?", policy.ID).Updates(map[string]interface{}{
+			"keep_last":    opt.KeepLast,
+			"max_age_days": opt.MaxAgeDays,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+		policy.KeepLast = opt.KeepLast
+		policy.MaxAgeDays = opt.MaxAgeDays
+		return policy, nil
+	}
+
+	policy = &models.RetentionPolicy{
+		CreatorAssociate: models.CreatorAssociate{
+			CreatorId: opt.CreatorId,
+		},
+		OrganizationAssociate: models.OrganizationAssociate{
+			OrganizationId: opt.OrganizationId,
+		},
+		ResourceType: opt.Resource.GetResourceType(),
+		ResourceId:   opt.Resource.GetId(),
+		KeepLast:     opt.KeepLast,
+		MaxAgeDays:   opt.MaxAgeDays,
+	}
+	err = mustGetSession(ctx).Create(policy).Error
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *retentionPolicyService) GetByResource(ctx context.Context, resource models.IResource) (*models.RetentionPolicy, error) {
+	var policy models.RetentionPolicy
+	err := getBaseQuery(ctx, s).Where("resource_type = ?", resource.GetResourceType()).Where("resource_id = ?", resource.GetId()).First(&policy).Error
+	if err != nil {
+		return nil, err
+	}
+	if policy.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &policy, nil
+}
+
+func (s *retentionPolicyService) List(ctx context.Context, opt ListRetentionPolicyOption) ([]*models.RetentionPolicy, uint, error) {
+	query := getBaseQuery(ctx, s)
+	if opt.OrganizationId != nil {
+		query = query.Where("retention_policy.organization_id = ?", *opt.OrganizationId)
+	}
+	if opt.ResourceType != nil {
+		query = query.Where("retention_policy.resource_type = ?", *opt.ResourceType)
+	}
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	query = opt.BindQueryWithLimit(query)
+	policies := make([]*models.RetentionPolicy, 0)
+	err = query.Order("retention_policy.id ASC").Find(&policies).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return policies, uint(total), err
+}
+
+func (s *retentionPolicyService) Delete(ctx context.Context, policy *models.RetentionPolicy) (*models.RetentionPolicy, error) {
+	err := s.getBaseDB(ctx).Unscoped().Delete(policy).Error
+	return policy, err
+}
+
+type RetentionRunResult struct {
+	DryRun bool
+	// the versions which are (or would be, in dry run) deleted
+	Bentos          []*models.Bento
+	Models          []*models.Model
+	TerminalRecords []*models.TerminalRecord
+}
+
+// isRetained reports whether the version at the index of the repository, ordered by build time descending,
+// is still protected by any rule of the policy
+func isRetained(policy *models.RetentionPolicy, index int, createdAt time.Time, now time.Time) bool {
+	if policy.KeepLast != nil && index < int(*policy.KeepLast) {
+		return true
+	}
+	if policy.MaxAgeDays != nil && createdAt.After(now.AddDate(0, 0, -int(*policy.MaxAgeDays))) {
+		return true
+	}
+	return false
+}
+
+func (s *retentionPolicyService) listBentoCandidates(ctx context.Context, policy *models.RetentionPolicy, now time.Time) ([]*models.Bento, error) {
+	bentos, _, err := BentoService.List(ctx, ListBentoOption{
+		BentoRepositoryId: utils.UintPtr(policy.ResourceId),
+		Order:             utils.StringPtr("bento.build_at DESC, bento.id DESC"),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list bentos")
+	}
+	candidates := make([]*models.Bento, 0)
+	for idx, bento := range bentos {
+		if isRetained(policy, idx, bento.CreatedAt, now) {
+			continue
+		}
+		if bento.UploadStatus == modelschemas.BentoUploadStatusPending || bento.UploadStatus == modelschemas.BentoUploadStatusUploading {
+			continue
+		}
+		candidates = append(candidates, bento)
+	}
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	candidateIds := make([]uint, 0, len(candidates))
+	for _, bento := range candidates {
+		candidateIds = append(candidateIds, bento.ID)
+	}
+	// deployment targets keep their bentos forever, because deleting a bento cascades to them
+	referencedIds, err := DeploymentTargetService.ListReferencedBentoIds(ctx, candidateIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "list referenced bento ids")
+	}
+	referenced := make(map[uint]struct{}, len(referencedIds))
+	for _, id := range referencedIds {
+		referenced[id] = struct{}{}
+	}
+	res := make([]*models.Bento, 0, len(candidates))
+	for _, bento := range candidates {
+		if _, ok := referenced[bento.ID]; ok {
+			continue
+		}
+		res = append(res, bento)
+	}
+	return res, nil
+}
+
+// listModelCandidates skips the models which are used by any bento that is not in deletedBentoIds
+func (s *retentionPolicyService) listModelCandidates(ctx context.Context, policy *models.RetentionPolicy, now time.Time, deletedBentoIds map[uint]struct{}) ([]*models.Model, error) {
+	models_, _, err := ModelService.List(ctx, ListModelOption{
+		ModelRepositoryId: utils.UintPtr(policy.ResourceId),
+		Order:             utils.StringPtr("model.build_at DESC, model.id DESC"),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list models")
+	}
+	candidates := make([]*models.Model, 0)
+	for idx, model := range models_ {
+		if isRetained(policy, idx, model.CreatedAt, now) {
+			continue
+		}
+		if model.UploadStatus == modelschemas.ModelUploadStatusPending || model.UploadStatus == modelschemas.ModelUploadStatusUploading {
+			continue
+		}
+		candidates = append(candidates, model)
+	}
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	candidateIds := make([]uint, 0, len(candidates))
+	for _, model := range candidates {
+		candidateIds = append(candidateIds, model.ID)
+	}
+	rels := make([]*models.BentoModelRel, 0)
+	err = mustGetSession(ctx).Model(&models.BentoModelRel{}).Where("model_id in (?)", candidateIds).Find(&rels).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "list bento model rels")
+	}
+	return getUnusedModels(candidates, rels, deletedBentoIds), nil
+}
+
+// getUnusedModels returns the models which are not used by any bento except the ones in deletedBentoIds
+func getUnusedModels(candidates []*models.Model, rels []*models.BentoModelRel, deletedBentoIds map[uint]struct{}) []*models.Model {
+	used := make(map[uint]struct{}, len(rels))
+	for _, rel := range rels {
+		if _, ok := deletedBentoIds[rel.BentoId]; ok {
+			continue
+		}
+		used[rel.ModelId] = struct{}{}
+	}
+	res := make([]*models.Model, 0, len(candidates))
+	for _, model := range candidates {
+		if _, ok := used[model.ID]; ok {
+			continue
+		}
+		res = append(res, model)
+	}
+	return res
+}
+
+func (s *retentionPolicyService) listTerminalRecordCandidates(ctx context.Context, policy *models.RetentionPolicy, now time.Time) ([]*models.TerminalRecord, error) {
+	terminalRecords, _, err := TerminalRecordService.List(ctx, ListTerminalRecordOption{
+		OrganizationId: utils.UintPtr(policy.ResourceId),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list terminal records")
+	}
+	candidates := make([]*models.TerminalRecord, 0)
+	for idx, terminalRecord := range terminalRecords {
+		if isRetained(policy, idx, terminalRecord.CreatedAt, now) {
+			continue
+		}
+		// the content is saved when the session ends, the session of a recent record without content may still be open
+		if terminalRecord.ObjectName == nil && len(terminalRecord.Content) == 0 && terminalRecord.CreatedAt.After(now.AddDate(0, 0, -1)) {
+			continue
+		}
+		candidates = append(candidates, terminalRecord)
+	}
+	return candidates, nil
+}
+
+// Run applies all the retention policies of the organization, bentos are collected before models
+// so that the models which are only used by the collected bentos can be collected in the same run.
+// Nothing is deleted in dry run, the result lists what would be deleted.
+func (s *retentionPolicyService) Run(ctx context.Context, organizationId uint, dryRun bool) (*RetentionRunResult, error) {
+	policies, _, err := s.List(ctx, ListRetentionPolicyOption{
+		OrganizationId: &organizationId,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list retention policies")
+	}
+
+	now := time.Now()
+	res := &RetentionRunResult{
+		DryRun:          dryRun,
+		Bentos:          make([]*models.Bento, 0),
+		Models:          make([]*models.Model, 0),
+		TerminalRecords: make([]*models.TerminalRecord, 0),
+	}
+	deletedBentoIds := make(map[uint]struct{})
+
+	for _, policy := range policies {
+		if policy.ResourceType != modelschemas.ResourceTypeBentoRepository {
+			continue
+		}
+		bentos, err := s.listBentoCandidates(ctx, policy, now)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list bento candidates of retention policy %s", policy.Uid)
+		}
+		for _, bento := range bentos {
+			if !dryRun {
+				err = BentoService.Delete(ctx, bento)
+				if err != nil {
+					retentionPolicyLogger.Errorf("delete bento %d: %s", bento.ID, err.Error())
+					continue
+				}
+			}
+			deletedBentoIds[bento.ID] = struct{}{}
+			res.Bentos = append(res.Bentos, bento)
+		}
+	}
+
+	for _, policy := range policies {
+		if policy.ResourceType != modelschemas.ResourceTypeModelRepository {
+			continue
+		}
+		models_, err := s.listModelCandidates(ctx, policy, now, deletedBentoIds)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list model candidates of retention policy %s", policy.Uid)
+		}
+		for _, model := range models_ {
+			if !dryRun {
+				err = ModelService.Delete(ctx, model)
+				if err != nil {
+					retentionPolicyLogger.Errorf("delete model %d: %s", model.ID, err.Error())
+					continue
+				}
+			}
+			res.Models = append(res.Models, model)
+		}
+	}
+
+	for _, policy := range policies {
+		if policy.ResourceType != modelschemas.ResourceTypeOrganization {
+			continue
+		}
+		terminalRecords, err := s.listTerminalRecordCandidates(ctx, policy, now)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list terminal record candidates of retention policy %s", policy.Uid)
+		}
+		for _, terminalRecord := range terminalRecords {
+			if !dryRun {
+				err = TerminalRecordService.Delete(ctx, terminalRecord)
+				if err != nil {
+					retentionPolicyLogger.Errorf("delete terminal record %d: %s", terminalRecord.ID, err.Error())
+					continue
+				}
+			}
+			res.TerminalRecords = append(res.TerminalRecords, terminalRecord)
+		}
+	}
+
+	if !dryRun && len(policies) > 0 {
+		policyIds := make([]uint, 0, len(policies))
+		for _, policy := range policies {
+			policyIds = append(policyIds, policy.ID)
+		}
+		err = s.getBaseDB(ctx).Where("id in (?)", policyIds).Update("last_run_at", now).Error
+		if err != nil {
+			return nil, errors.Wrap(err, "update last run at")
+		}
+	}
+
+	return res, nil
+}
+
+// RunAll applies the retention policies of every organization which has any
+func (s *retentionPolicyService) RunAll(ctx context.Context) error {
+	organizationIds := make([]uint, 0)
+	err := s.getBaseDB(ctx).Distinct("organization_id").Pluck("organization_id", &organizationIds).Error
+	if err != nil {
+		return errors.Wrap(err, "list organization ids")
+	}
+	for _, organizationId := range organizationIds {
+		res, err := s.Run(ctx, organizationId, false)
+		if err != nil {
+			retentionPolicyLogger.Errorf("run retention policies of organization %d: %s", organizationId, err.Error())
+			continue
+		}
+		if len(res.Bentos) > 0 || len(res.Models) > 0 || len(res.TerminalRecords) > 0 {
+			retentionPolicyLogger.Infof("deleted %d bentos, %d models and %d terminal records of organization %d", len(res.Bentos), len(res.Models), len(res.TerminalRecords), organizationId)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/utils"
+)
+
+func TestValidateRetentionPolicyOption(t *testing.T) {
+	cases := []struct {
+		name       string
+		resource   models.IResource
+		keepLast   *uint
+		maxAgeDays *uint
+		wantErr    bool
+	}{
+		{"keep last", &models.BentoRepository{}, utils.UintPtr(3), nil, false},
+		{"max age", &models.ModelRepository{}, nil, utils.UintPtr(30), false},
+		{"both rules", &models.BentoRepository{}, utils.UintPtr(3), utils.UintPtr(30), false},
+		{"terminal records of the organization", &models.Organization{}, nil, utils.UintPtr(30), false},
+		{"no rules", &models.BentoRepository{}, nil, nil, true},
+		{"keep none", &models.BentoRepository{}, utils.UintPtr(0), nil, true},
+		{"zero max age", &models.ModelRepository{}, nil, utils.UintPtr(0), true},
+		{"unsupported resource", &models.Cluster{}, utils.UintPtr(3), nil, true},
+	}
+	for _, c := range cases {
+		err := validateRetentionPolicyOption(UpsertRetentionPolicyOption{
+			Resource:   c.resource,
+			KeepLast:   c.keepLast,
+			MaxAgeDays: c.maxAgeDays,
+		})
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%s: the error is %v, expected an error: %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestIsRetained(t *testing.T) {
+	now := time.Now()
+	recent := now.AddDate(0, 0, -1)
+	old := now.AddDate(0, 0, -60)
+	cases := []struct {
+		name       string
+		keepLast   *uint
+		maxAgeDays *uint
+		index      int
+		createdAt  time.Time
+		expected   bool
+	}{
+		{"within the last versions", utils.UintPtr(2), nil, 1, old, true},
+		{"beyond the last versions", utils.UintPtr(2), nil, 2, recent, false},
+		{"younger than the max age", nil, utils.UintPtr(30), 10, recent, true},
+		{"older than the max age", nil, utils.UintPtr(30), 0, old, false},
+		{"old but within the last versions", utils.UintPtr(2), utils.UintPtr(30), 0, old, true},
+		{"recent but beyond the last versions", utils.UintPtr(2), utils.UintPtr(30), 5, recent, true},
+		{"breaks every rule", utils.UintPtr(2), utils.UintPtr(30), 5, old, false},
+	}
+	for _, c := range cases {
+		policy := &models.RetentionPolicy{KeepLast: c.keepLast, MaxAgeDays: c.maxAgeDays}
+		if retained := isRetained(policy, c.index, c.createdAt, now); retained != c.expected {
+			t.Fatalf("%s: the version is retained: %v, expected %v", c.name, retained, c.expected)
+		}
+	}
+}
+
+func TestGetUnusedModels(t *testing.T) {
+	newModel := func(id uint) *models.Model {
+		model := &models.Model{}
+		model.ID = id
+		return model
+	}
+	newRel := func(bentoId, modelId uint) *models.BentoModelRel {
+		return &models.BentoModelRel{
+			BentoAssociate: models.BentoAssociate{BentoId: bentoId},
+			ModelAssociate: models.ModelAssociate{ModelId: modelId},
+		}
+	}
+	candidates := []*models.Model{newModel(1), newModel(2), newModel(3)}
+	cases := []struct {
+		name            string
+		rels            []*models.BentoModelRel
+		deletedBentoIds map[uint]struct{}
+		expectedIds     []uint
+	}{
+		{"no bentos", nil, nil, []uint{1, 2, 3}},
+		{"used by a kept bento", []*models.BentoModelRel{newRel(10, 2)}, nil, []uint{1, 3}},
+		{"used by a deleted bento", []*models.BentoModelRel{newRel(10, 2)}, map[uint]struct{}{10: {}}, []uint{1, 2, 3}},
+		{"used by a deleted and a kept bento", []*models.BentoModelRel{newRel(10, 2), newRel(11, 2)}, map[uint]struct{}{10: {}}, []uint{1, 3}},
+		{"all used", []*models.BentoModelRel{newRel(10, 1), newRel(10, 2), newRel(11, 3)}, map[uint]struct{}{12: {}}, []uint{}},
+	}
+	for _, c := range cases {
+		ids := make([]uint, 0)
+		for _, model := range getUnusedModels(candidates, c.rels, c.deletedBentoIds) {
+			ids = append(ids, model.ID)
+		}
+		if !reflect.DeepEqual(ids, c.expectedIds) {
+			t.Fatalf("%s: the unused models are %v, expected %v", c.name, ids, c.expectedIds)
+		}
+	}
+}
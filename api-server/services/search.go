@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+type SearchResultType string
+
+const (
+	SearchResultTypeBento      SearchResultType = "bento"
+	SearchResultTypeModel      SearchResultType = "model"
+	SearchResultTypeDeployment SearchResultType = "deployment"
+)
+
+var SearchResultTypes = []SearchResultType{
+	SearchResultTypeBento,
+	SearchResultTypeModel,
+	SearchResultTypeDeployment,
+}
+
+// the documents must be the same expressions as the search indexes in the migrations, otherwise the indexes are not used
+const (
+	bentoSearchDocument      = "to_tsvector('simple', coalesce(bento.version, '') || ' ' || coalesce(bento.description, '') || ' ' || coalesce(bento.manifest::text, ''))"
+	modelSearchDocument      = "to_tsvector('simple', coalesce(model.version, '') || ' ' || coalesce(model.description, '') || ' ' || coalesce(model.manifest::text, ''))"
+	deploymentSearchDocument = "to_tsvector('simple', deployment.name || ' ' || coalesce(deployment.description, ''))"
+)
+
+type searchService struct{}
+
+var SearchService = searchService{}
+
+// SearchOption matches the keywords of BaseListOption against the full text of the versions, the descriptions and the manifests
+type SearchOption struct {
+	BaseListOption
+	BaseListByLabelsOption
+	OrganizationId uint
+	// all the result types are searched if Types is nil
+	Types *[]SearchResultType
+	// the bentos which expose all of the apis
+	Apis *[]string
+	// the models which are saved by any of the modules, the bentoml. prefix can be omitted
+	Modules    *[]string
+	CreatorIds *[]uint
+}
+
+type SearchResult struct {
+	Type       SearchResultType
+	Rank       float64
+	Bento      *models.Bento
+	Model      *models.Model
+	Deployment *models.Deployment
+}
+
+type searchHit struct {
+	Id        uint
+	Rank      float64
+	CreatedAt time.Time
+}
+
+func (opt SearchOption) searches(resultType SearchResultType) bool {
+	if opt.Types == nil {
+		return true
+	}
+	for _, resultType_ := range *opt.Types {
+		if resultType_ == resultType {
+			return true
+		}
+	}
+	return false
+}
+
+// search returns the total count and the hits of the query ordered by rank, the query should have selected the table of the result type
+func (s *searchService) search(query *gorm.DB, opt SearchOption, tableName, document string, resourceType modelschemas.ResourceType) ([]*searchHit, uint, error) {
+	query = opt.BindQueryWithLabels(query, resourceType)
+	if opt.CreatorIds != nil {
+		query = query.Where(fmt.Sprintf("%s.creator_id in (?)", tableName), *opt.CreatorIds)
+	}
+	rank := "0"
+	rankArgs := make([]interface{}, 0, 1)
+	if opt.Keywords != nil && len(*opt.Keywords) > 0 {
+		keywords := strings.Join(*opt.Keywords, " ")
+		query = query.Where(fmt.Sprintf("%s @@ plainto_tsquery('simple', ?)", document), keywords)
+		rank = fmt.Sprintf("ts_rank(%s, plainto_tsquery('simple', ?))", document)
+		rankArgs = append(rankArgs, keywords)
+	}
+
+	var total int64
+	err := query.Session(&gorm.Session{}).Distinct(fmt.Sprintf("%s.id", tableName)).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]*searchHit, 0)
+	// every result type is limited to start + count, the page is cut after merging
+	if opt.Count != nil {
+		start := uint(0)
+		if opt.Start != nil {
+			start = *opt.Start
+		}
+		query = query.Limit(int(start + *opt.Count))
+	}
+	// the label joins yield a row per matched label, the hits are distinct like the count
+	err = query.Select(fmt.Sprintf("DISTINCT %s.id AS id, %s AS rank, %s.created_at AS created_at", tableName, rank, tableName), rankArgs...).
+		Order("rank DESC").
+		Order(fmt.Sprintf("%s.created_at DESC", tableName)).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return hits, uint(total), nil
+}
+
+func (s *searchService) searchBentos(ctx context.Context, opt SearchOption) ([]*searchHit, uint, error) {
+	query := mustGetSession(ctx).Model(&models.Bento{})
+	query = query.Joins("JOIN bento_repository ON bento.bento_repository_id = bento_repository.id")
+	query = query.Where("bento_repository.organization_id = ?", opt.OrganizationId)
+	if opt.Apis != nil {
+		for _, api := range *opt.Apis {
+			// the containment of an empty object is the existence of the key, which can use the manifest index
+			containment, err := json.Marshal(map[string]interface{}{
+				"apis": map[string]interface{}{
+					api: map[string]interface{}{},
+				},
+			})
+			if err != nil {
+				return nil, 0, err
+			}
+			query = query.Where("bento.manifest @> ?::jsonb", string(containment))
+		}
+	}
+	return s.search(query, opt, "bento", bentoSearchDocument, modelschemas.ResourceTypeBento)
+}
+
+func (s *searchService) searchModels(ctx context.Context, opt SearchOption) ([]*searchHit, uint, error) {
+	query := mustGetSession(ctx).Model(&models.Model{})
+	query = query.Joins("JOIN model_repository ON model.model_repository_id = model_repository.id")
+	query = query.Where("model_repository.organization_id = ?", opt.OrganizationId)
+	if opt.Modules != nil {
+		modules := make([]string, 0, len(*opt.Modules)*2)
+		for _, module := range *opt.Modules {
+			modules = append(modules, module)
+			if !strings.HasPrefix(module, "bentoml.") {
+				modules = append(modules, "bentoml."+module)
+			}
+		}
+		query = query.Where("model.manifest->>'module' in (?)", modules)
+	}
+	return s.search(query, opt, "model", modelSearchDocument, modelschemas.ResourceTypeModel)
+}
+
+func (s *searchService) searchDeployments(ctx context.Context, opt SearchOption) ([]*searchHit, uint, error) {
+	query := mustGetSession(ctx).Model(&models.Deployment{})
+	query = query.Joins("JOIN cluster ON cluster.id = deployment.cluster_id")
+	query = query.Where("cluster.organization_id = ?", opt.OrganizationId)
+	return s.search(query, opt, "deployment", deploymentSearchDocument, modelschemas.ResourceTypeDeployment)
+}
+
+type rankedSearchHit struct {
+	searchHit
+	Type SearchResultType
+}
+
+// Search finds the bentos, the models and the deployments of the organization,
+// the results are ordered by the full text rank of the keywords and then by the creation time
+func (s *searchService) Search(ctx context.Context, opt SearchOption) ([]*SearchResult, uint, error) {
+	// the filters which only apply to one result type exclude the other result types
+	if opt.Apis != nil && opt.Modules != nil {
+		return []*SearchResult{}, 0, nil
+	}
+
+	hits := make([]*rankedSearchHit, 0)
+	total := uint(0)
+	searchers := map[SearchResultType]func(context.Context, SearchOption) ([]*searchHit, uint, error){
+		SearchResultTypeBento:      s.searchBentos,
+		SearchResultTypeModel:      s.searchModels,
+		SearchResultTypeDeployment: s.searchDeployments,
+	}
+	for _, resultType := range SearchResultTypes {
+		if !opt.searches(resultType) {
+			continue
+		}
+		if opt.Apis != nil && resultType != SearchResultTypeBento {
+			continue
+		}
+		if opt.Modules != nil && resultType != SearchResultTypeModel {
+			continue
+		}
+		hits_, total_, err := searchers[resultType](ctx, opt)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "search %ss", resultType)
+		}
+		total += total_
+		for _, hit := range hits_ {
+			hits = append(hits, &rankedSearchHit{
+				searchHit: *hit,
+				Type:      resultType,
+			})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].CreatedAt.After(hits[j].CreatedAt)
+	})
+	if opt.Start != nil {
+		if int(*opt.Start) >= len(hits) {
+			hits = hits[:0]
+		} else {
+			hits = hits[*opt.Start:]
+		}
+	}
+	if opt.Count != nil && int(*opt.Count) < len(hits) {
+		hits = hits[:*opt.Count]
+	}
+
+	ids := make(map[SearchResultType][]uint)
+	for _, hit := range hits {
+		ids[hit.Type] = append(ids[hit.Type], hit.Id)
+	}
+	bentos := make(map[uint]*models.Bento)
+	if len(ids[SearchResultTypeBento]) > 0 {
+		bentoIds := ids[SearchResultTypeBento]
+		bentos_, _, err := BentoService.List(ctx, ListBentoOption{
+			Ids: &bentoIds,
+		})
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "list bentos")
+		}
+		for _, bento := range bentos_ {
+			bentos[bento.ID] = bento
+		}
+	}
+	models_ := make(map[uint]*models.Model)
+	if len(ids[SearchResultTypeModel]) > 0 {
+		modelIds := ids[SearchResultTypeModel]
+		modelList, _, err := ModelService.List(ctx, ListModelOption{
+			Ids: &modelIds,
+		})
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "list models")
+		}
+		for _, model := range modelList {
+			models_[model.ID] = model
+		}
+	}
+	deployments := make(map[uint]*models.Deployment)
+	if len(ids[SearchResultTypeDeployment]) > 0 {
+		deploymentIds := ids[SearchResultTypeDeployment]
+		deployments_, _, err := DeploymentService.List(ctx, ListDeploymentOption{
+			Ids: &deploymentIds,
+		})
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "list deployments")
+		}
+		for _, deployment := range deployments_ {
+			deployments[deployment.ID] = deployment
+		}
+	}
+
+	res := make([]*SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		result := &SearchResult{
+			Type: hit.Type,
+			Rank: hit.Rank,
+		}
+		switch hit.Type {
+		case SearchResultTypeBento:
+			result.Bento = bentos[hit.Id]
+		case SearchResultTypeModel:
+			result.Model = models_[hit.Id]
+		case SearchResultTypeDeployment:
+			result.Deployment = deployments[hit.Id]
+		}
+		if result.Bento == nil && result.Model == nil && result.Deployment == nil {
+			// deleted between the search and the listing
+			continue
+		}
+		res = append(res, result)
+	}
+	return res, total, nil
+}
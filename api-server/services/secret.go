@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/envelope"
+)
+
+// SecretMask replaces the secrets in the api responses, the secrets are kept unchanged if it is sent back in the updates
+const SecretMask = "******"
+
+func RedactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return SecretMask
+}
+
+func cloneOrganizationConfig(config *modelschemas.OrganizationConfigSchema) *modelschemas.OrganizationConfigSchema {
+	if config == nil {
+		return nil
+	}
+	config_ := *config
+	if config.AWS != nil {
+		aws := *config.AWS
+		if config.AWS.ECR != nil {
+			ecr := *config.AWS.ECR
+			aws.ECR = &ecr
+		}
+		config_.AWS = &aws
+	}
+	if config.DockerRegistry != nil {
+		dockerRegistry := *config.DockerRegistry
+		config_.DockerRegistry = &dockerRegistry
+	}
+	if config.S3 != nil {
+		s3 := *config.S3
+		config_.S3 = &s3
+	}
+	return &config_
+}
+
+// RedactOrganizationConfig returns a copy of the config whose secrets are replaced by the SecretMask
+func RedactOrganizationConfig(config *modelschemas.OrganizationConfigSchema) *modelschemas.OrganizationConfigSchema {
+	config = cloneOrganizationConfig(config)
+	for _, secret := range models.OrganizationConfigSecrets(config) {
+		*secret = RedactSecret(*secret)
+	}
+	return config
+}
+
+// restoreOrganizationConfigSecrets replaces the masked secrets of the new config with the secrets of the old config
+func restoreOrganizationConfigSecrets(newConfig, oldConfig *modelschemas.OrganizationConfigSchema) {
+	oldSecrets := models.OrganizationConfigSecrets(oldConfig)
+	for field, secret := range models.OrganizationConfigSecrets(newConfig) {
+		if *secret != SecretMask {
+			continue
+		}
+		*secret = ""
+		if oldSecret, ok := oldSecrets[field]; ok {
+			*secret = *oldSecret
+		}
+	}
+}
+
+// encryptOrganizationConfig returns a copy of the config whose secrets are encrypted, the config itself is kept as plaintext
+func encryptOrganizationConfig(ctx context.Context, config *modelschemas.OrganizationConfigSchema) (*modelschemas.OrganizationConfigSchema, error) {
+	config = cloneOrganizationConfig(config)
+	for field, secret := range models.OrganizationConfigSecrets(config) {
+		ciphertext, err := envelope.EncryptString(ctx, *secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "encrypt %s", field)
+		}
+		*secret = ciphertext
+	}
+	return config, nil
+}
+
+type ReencryptSecretsResult struct {
+	Clusters      int
+	Organizations int
+}
+
+// ReencryptSecrets encrypts the plaintext secrets and the secrets which are not encrypted with the primary key with the primary key,
+// the soft deleted records are included so that the old keys can be dropped after it
+func ReencryptSecrets(ctx context.Context) (*ReencryptSecretsResult, error) {
+	encrypter := envelope.Default()
+	if encrypter == nil {
+		return nil, errors.New("the secret encryption is not configured")
+	}
+	// skip the hooks to read the secrets as they are stored
+	db := mustGetSession(ctx).Session(&gorm.Session{SkipHooks: true}).Unscoped()
+	res := &ReencryptSecretsResult{}
+
+	clusters := make([]*models.Cluster, 0)
+	err := db.Model(&models.Cluster{}).Find(&clusters).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "list clusters")
+	}
+	for _, cluster := range clusters {
+		if !encrypter.NeedsReencryption(cluster.KubeConfig) {
+			continue
+		}
+		kubeConfig, err := encrypter.Reencrypt(ctx, cluster.KubeConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reencrypt the kubeconfig of cluster %s", cluster.Name)
+		}
+		err = db.Model(&models.Cluster{}).Where("id = ?", cluster.ID).UpdateColumn("kube_config", kubeConfig).Error
+		if err != nil {
+			return nil, errors.Wrapf(err, "update the kubeconfig of cluster %s", cluster.Name)
+		}
+		res.Clusters++
+	}
+
+	orgs := make([]*models.Organization, 0)
+	err = db.Model(&models.Organization{}).Find(&orgs).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "list organizations")
+	}
+	for _, org := range orgs {
+		changed := false
+		for field, secret := range models.OrganizationConfigSecrets(org.Config) {
+			if !encrypter.NeedsReencryption(*secret) {
+				continue
+			}
+			ciphertext, err := encrypter.Reencrypt(ctx, *secret)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reencrypt the %s of organization %s", field, org.Name)
+			}
+			*secret = ciphertext
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		err = db.Model(&models.Organization{}).Where("id = ?", org.ID).UpdateColumn("config", org.Config).Error
+		if err != nil {
+			return nil, errors.Wrapf(err, "update the config of organization %s", org.Name)
+		}
+		res.Organizations++
+	}
+
+	return res, nil
+}
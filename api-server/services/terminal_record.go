@@ -1,13 +1,23 @@
 package services
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"time"
 
+	"github.com/lib/pq"
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/multierr"
 	"gorm.io/gorm"
 
 	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/config"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/common/consts"
 )
@@ -134,23 +144,318 @@ func (s *terminalRecordService) List(ctx context.Context, opt ListTerminalRecord
 	return terminalRecords, uint(total), err
 }
 
-func (s *terminalRecordService) Append(ctx context.Context, r *models.TerminalRecord, recordType modelschemas.RecordType, value string) error {
-	r.Mu.Lock()
-	defer r.Mu.Unlock()
+// terminalRecordEventTypeResize is the asciicast v2 resize event, its data is COLSxROWS
+const terminalRecordEventTypeResize modelschemas.RecordType = "r"
 
-	n := time.Now()
-	dt := n.Sub(r.CreatedAt)
+func (s *terminalRecordService) appendEvent(r *models.TerminalRecord, recordType modelschemas.RecordType, value string) error {
+	if r.Truncated {
+		return nil
+	}
+	dt := time.Since(r.CreatedAt)
 	arr := []interface{}{dt.Seconds(), recordType, value}
 	c, err := json.Marshal(&arr)
 	if err != nil {
 		return err
 	}
+	// the newline which separates the events is counted as well
+	size := int64(len(c)) + 1
+	if r.Size+size > config.YataiConfig.TerminalRecord.MaxSizeBytes {
+		r.Truncated = true
+		return nil
+	}
 	r.Content = append(r.Content, string(c))
-
+	r.Size += size
+	r.Duration = dt.Seconds()
 	return nil
 }
 
+func (s *terminalRecordService) Append(ctx context.Context, r *models.TerminalRecord, recordType modelschemas.RecordType, value string) error {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	return s.appendEvent(r, recordType, value)
+}
+
+// Resize sets the size of the record from the first resize of the terminal, the later ones are recorded as resize events
+func (s *terminalRecordService) Resize(ctx context.Context, r *models.TerminalRecord, width, height uint16) error {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	if r.Meta.Width == 0 && r.Meta.Height == 0 {
+		r.Meta.Width = width
+		r.Meta.Height = height
+		return nil
+	}
+	return s.appendEvent(r, terminalRecordEventTypeResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+type asciicastHeader struct {
+	Version   uint              `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Duration  float64           `json:"duration,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// TerminalRecordEvent is an event line of the asciicast v2 file
+type TerminalRecordEvent struct {
+	Time float64
+	Type modelschemas.RecordType
+	Data string
+}
+
+func (s *terminalRecordService) encodeHeader(r *models.TerminalRecord) ([]byte, error) {
+	header := asciicastHeader{
+		Version:  2,
+		Width:    80,
+		Height:   24,
+		Duration: r.Duration,
+	}
+	if r.Meta != nil {
+		if r.Meta.Width != 0 && r.Meta.Height != 0 {
+			header.Width = r.Meta.Width
+			header.Height = r.Meta.Height
+		}
+		header.Timestamp = r.Meta.Timestamp
+		if r.Meta.Env != nil {
+			header.Env = map[string]string{
+				"TERM":  r.Meta.Env.TERM,
+				"SHELL": r.Meta.Env.SHELL,
+			}
+		}
+	}
+	return json.Marshal(&header)
+}
+
+// encode renders the record in the asciicast v2 format, a header line followed by an event line per event
+func (s *terminalRecordService) encode(r *models.TerminalRecord) ([]byte, error) {
+	header, err := s.encodeHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(header) + int(r.Size) + 1)
+	buf.Write(header)
+	buf.WriteByte('\n')
+	for _, line := range r.Content {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// getS3Location returns the bucket and the object name of the record,
+// the records are stored in the bentos bucket of the organization under the terminal_records prefix
+func (s *terminalRecordService) getS3Location(ctx context.Context, r *models.TerminalRecord) (*S3Config, string, string, error) {
+	org, err := OrganizationService.GetAssociatedNullableOrganization(ctx, r)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if org == nil {
+		return nil, "", "", errors.New("terminal record does not belong to an organization")
+	}
+	s3Config, err := OrganizationService.GetS3Config(ctx, org)
+	if err != nil {
+		return nil, "", "", err
+	}
+	objectName := fmt.Sprintf("terminal_records/%s/%s.cast", org.Name, r.Uid)
+	return s3Config, s3Config.BentosBucketName, objectName, nil
+}
+
+func (s *terminalRecordService) upload(ctx context.Context, r *models.TerminalRecord, content []byte) (string, error) {
+	s3Config, bucketName, objectName, err := s.getS3Location(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	minioClient, err := s3Config.GetMinioClient()
+	if err != nil {
+		return "", errors.Wrap(err, "create s3 client")
+	}
+	err = s3Config.MakeSureBucket(ctx, bucketName)
+	if err != nil {
+		return "", err
+	}
+	_, err = minioClient.PutObject(ctx, bucketName, objectName, bytes.NewReader(content), int64(len(content)), minio.PutObjectOptions{
+		ContentType: "application/x-asciicast",
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "put object")
+	}
+	return objectName, nil
+}
+
+// SaveContent uploads the finished record to the object storage of the organization,
+// the events are kept in the database instead if the upload fails, so that the session stays auditable
 func (s *terminalRecordService) SaveContent(ctx context.Context, r *models.TerminalRecord) error {
-	db := s.getBaseDB(ctx)
-	return db.Where("id = ?", r.ID).Updates(&models.TerminalRecord{Content: r.Content, Meta: r.Meta}).Error
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	updates := map[string]interface{}{
+		"meta":      r.Meta,
+		"size":      r.Size,
+		"duration":  r.Duration,
+		"truncated": r.Truncated,
+	}
+	content, err := s.encode(r)
+	if err != nil {
+		return err
+	}
+	objectName, err := s.upload(ctx, r, content)
+	if err != nil {
+		logrus.Errorf("upload terminal record %s failed, save it to the database instead: %s", r.Uid, err.Error())
+		updates["content"] = r.Content
+	} else {
+		r.ObjectName = &objectName
+		r.Content = nil
+		updates["object_name"] = objectName
+		updates["content"] = pq.StringArray{}
+	}
+	return s.getBaseDB(ctx).Where("id = ?", r.ID).Updates(updates).Error
+}
+
+// Open returns the asciicast v2 file of the record and its size,
+// the object is stat-ed before it is returned so that a missing or unreadable object fails here instead of in the middle of the read
+func (s *terminalRecordService) Open(ctx context.Context, r *models.TerminalRecord) (io.ReadCloser, int64, error) {
+	if r.ObjectName == nil {
+		content, err := s.encode(r)
+		if err != nil {
+			return nil, 0, err
+		}
+		return io.NopCloser(bytes.NewReader(content)), int64(len(content)), nil
+	}
+	s3Config, bucketName, _, err := s.getS3Location(ctx, r)
+	if err != nil {
+		return nil, 0, err
+	}
+	minioClient, err := s3Config.GetMinioClient()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "create s3 client")
+	}
+	obj, err := minioClient.GetObject(ctx, bucketName, *r.ObjectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "get object")
+	}
+	info, err := obj.Stat()
+	if err != nil {
+		_ = obj.Close()
+		return nil, 0, errors.Wrap(err, "stat object")
+	}
+	return obj, info.Size, nil
+}
+
+// ReadEvents reads the asciicast v2 file of the record, onEvent is called for every event in order until it returns an error
+func (s *terminalRecordService) ReadEvents(ctx context.Context, r *models.TerminalRecord, onEvent func(event TerminalRecordEvent) error) error {
+	reader, _, err := s.Open(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), int(config.YataiConfig.TerminalRecord.MaxSizeBytes)+64*1024)
+	// the first line is the header
+	scanner.Scan()
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var arr []interface{}
+		err = json.Unmarshal(line, &arr)
+		if err != nil {
+			return errors.Wrap(err, "unmarshal terminal record event")
+		}
+		if len(arr) != 3 {
+			return errors.Errorf("invalid terminal record event: %s", string(line))
+		}
+		t, _ := arr[0].(float64)
+		recordType, _ := arr[1].(string)
+		data, _ := arr[2].(string)
+		err = onEvent(TerminalRecordEvent{
+			Time: t,
+			Type: modelschemas.RecordType(recordType),
+			Data: data,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// GetHeader returns the header line of the asciicast v2 file of the record
+func (s *terminalRecordService) GetHeader(ctx context.Context, r *models.TerminalRecord) (map[string]interface{}, error) {
+	header, err := s.encodeHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]interface{})
+	err = json.Unmarshal(header, &res)
+	return res, err
+}
+
+func (s *terminalRecordService) removeObject(ctx context.Context, r *models.TerminalRecord) error {
+	s3Config, bucketName, _, err := s.getS3Location(ctx, r)
+	if err != nil {
+		return err
+	}
+	minioClient, err := s3Config.GetMinioClient()
+	if err != nil {
+		return errors.Wrap(err, "create s3 client")
+	}
+	err = minioClient.RemoveObject(ctx, bucketName, *r.ObjectName, minio.RemoveObjectOptions{})
+	if err != nil {
+		return errors.Wrap(err, "remove object")
+	}
+	return nil
+}
+
+// Delete removes the record and then its asciicast v2 file,
+// the file is left behind if it can not be removed, which only wastes the storage, instead of a record without its file
+func (s *terminalRecordService) Delete(ctx context.Context, r *models.TerminalRecord) error {
+	err := s.getBaseDB(ctx).Unscoped().Delete(r).Error
+	if err != nil {
+		return err
+	}
+	if r.ObjectName == nil {
+		return nil
+	}
+	err = s.removeObject(ctx, r)
+	if err != nil {
+		logrus.Errorf("remove the file %s of the deleted terminal record %s failed: %s", *r.ObjectName, r.Uid, err.Error())
+	}
+	return nil
+}
+
+// MigrateContentToObjectStorage uploads the records whose events are saved in the database to the object storage of their organizations,
+// they are the records from before the object storage was used and the ones whose upload failed when the session ended.
+// The records which still can not be uploaded are kept in the database and retried in the next run
+func (s *terminalRecordService) MigrateContentToObjectStorage(ctx context.Context) error {
+	terminalRecords := make([]*models.TerminalRecord, 0)
+	err := getBaseQuery(ctx, s).Where("object_name IS NULL").Where("cardinality(content) > 0").Order("id ASC").Limit(100).Find(&terminalRecords).Error
+	if err != nil {
+		return errors.Wrap(err, "list terminal records saved in the database")
+	}
+	var errs error
+	for _, r := range terminalRecords {
+		content, err := s.encode(r)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "encode terminal record %s", r.Uid))
+			continue
+		}
+		objectName, err := s.upload(ctx, r, content)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "upload terminal record %s", r.Uid))
+			continue
+		}
+		err = s.getBaseDB(ctx).Where("id = ?", r.ID).Where("object_name IS NULL").Updates(map[string]interface{}{
+			"object_name": objectName,
+			"content":     pq.StringArray{},
+		}).Error
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "update terminal record %s", r.Uid))
+		}
+	}
+	return errs
 }
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/config"
+	"github.com/bentoml/yatai/api-server/models"
+)
+
+func setTestTerminalRecordMaxSize(t *testing.T, maxSizeBytes int64) {
+	old := config.YataiConfig.TerminalRecord.MaxSizeBytes
+	config.YataiConfig.TerminalRecord.MaxSizeBytes = maxSizeBytes
+	t.Cleanup(func() {
+		config.YataiConfig.TerminalRecord.MaxSizeBytes = old
+	})
+}
+
+func newTestTerminalRecord() *models.TerminalRecord {
+	r := &models.TerminalRecord{
+		Meta: &modelschemas.TerminalRecordMeta{
+			Version:   2,
+			Timestamp: 1660000000,
+			Env:       &modelschemas.TerminalRecordEnv{TERM: "xterm-256color", SHELL: "sh"},
+		},
+	}
+	r.Uid = "record-1"
+	r.CreatedAt = time.Now()
+	return r
+}
+
+func TestTerminalRecordAppendTruncates(t *testing.T) {
+	setTestTerminalRecordMaxSize(t, 64)
+	r := newTestTerminalRecord()
+	s := &terminalRecordService{}
+	for _, value := range []string{"ls\r\n", "pwd\r\n", strings.Repeat("x", 64), "exit\r\n"} {
+		if err := s.Append(context.Background(), r, "o", value); err != nil {
+			t.Fatalf("append %q: %v", value, err)
+		}
+	}
+	if !r.Truncated {
+		t.Fatal("the record is not truncated at the size limit")
+	}
+	if len(r.Content) != 2 {
+		t.Fatalf("%d events are recorded, expected the 2 events before the limit", len(r.Content))
+	}
+	size := int64(0)
+	for _, line := range r.Content {
+		size += int64(len(line)) + 1
+	}
+	if r.Size != size || r.Size > 64 {
+		t.Fatalf("the size is %d, expected %d within the limit", r.Size, size)
+	}
+}
+
+func TestTerminalRecordResize(t *testing.T) {
+	setTestTerminalRecordMaxSize(t, 1024)
+	r := newTestTerminalRecord()
+	s := &terminalRecordService{}
+	_ = s.Resize(context.Background(), r, 120, 40)
+	_ = s.Resize(context.Background(), r, 100, 30)
+	if r.Meta.Width != 120 || r.Meta.Height != 40 {
+		t.Fatalf("the size of the record is %dx%d, expected the first resize 120x40", r.Meta.Width, r.Meta.Height)
+	}
+	if len(r.Content) != 1 || !strings.Contains(r.Content[0], `"r","100x30"`) {
+		t.Fatalf("the later resize is recorded as %v", r.Content)
+	}
+}
+
+func TestTerminalRecordOpenAndReadEvents(t *testing.T) {
+	setTestTerminalRecordMaxSize(t, 1024)
+	r := newTestTerminalRecord()
+	s := &terminalRecordService{}
+	_ = s.Resize(context.Background(), r, 120, 40)
+	_ = s.Append(context.Background(), r, "i", "ls\r")
+	_ = s.Append(context.Background(), r, "o", "bento.yaml\r\n")
+
+	reader, size, err := s.Open(context.Background(), r)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	content, err := io.ReadAll(reader)
+	_ = reader.Close()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if int64(len(content)) != size {
+		t.Fatalf("the size is %d, expected the length of the content %d", size, len(content))
+	}
+	var header asciicastHeader
+	if err = json.Unmarshal([]byte(strings.SplitN(string(content), "\n", 2)[0]), &header); err != nil {
+		t.Fatalf("unmarshal header: %v", err)
+	}
+	expectedHeader := asciicastHeader{
+		Version:   2,
+		Width:     120,
+		Height:    40,
+		Timestamp: 1660000000,
+		Duration:  r.Duration,
+		Env:       map[string]string{"TERM": "xterm-256color", "SHELL": "sh"},
+	}
+	if !reflect.DeepEqual(header, expectedHeader) {
+		t.Fatalf("the header is %+v, expected %+v", header, expectedHeader)
+	}
+
+	events := make([]TerminalRecordEvent, 0)
+	err = s.ReadEvents(context.Background(), r, func(event TerminalRecordEvent) error {
+		event.Time = 0
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	expected := []TerminalRecordEvent{
+		{Type: "i", Data: "ls\r"},
+		{Type: "o", Data: "bento.yaml\r\n"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("the events are %+v, expected %+v", events, expected)
+	}
+}
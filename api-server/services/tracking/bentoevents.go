@@ -43,4 +43,5 @@ func TrackBentoEvent(ctx context.Context, bentoModel *models.Bento, eventType Ya
 		bentoEvent.BentoSizeBytes = bentoschema.Manifest.SizeBytes
 	}
 	track(ctx, bentoEvent, eventType)
+	services.WebhookService.Dispatch(ctx, org.ID, services.WebhookEventType(eventType), bentoEvent)
 }
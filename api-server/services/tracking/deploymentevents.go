@@ -69,4 +69,10 @@ func TrackDeploymentEvent(ctx context.Context, deploymentSchema *schemasv1.Deplo
 	}
 
 	track(ctx, deploymentSchemaParsed, eventType)
+	org, err := services.OrganizationService.GetByUid(ctx, deploymentSchema.Cluster.Organization.Uid)
+	if err != nil {
+		trackingLogger.Error(err)
+		return
+	}
+	services.WebhookService.Dispatch(ctx, org.ID, services.WebhookEventType(eventType), deploymentSchemaParsed)
 }
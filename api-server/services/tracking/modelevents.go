@@ -48,4 +48,7 @@ func TrackModelEvent(ctx context.Context, modelModel *models.Model, eventType Ya
 		modelEvent.ModelSizeBytes = modelschema.Manifest.SizeBytes
 	}
 	track(ctx, modelEvent, eventType)
+	if org != nil {
+		services.WebhookService.Dispatch(ctx, org.ID, services.WebhookEventType(eventType), modelEvent)
+	}
 }
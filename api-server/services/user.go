@@ -34,6 +34,9 @@ type CreateUserOption struct {
 	Email     *string
 	Password  string
 	Perm      *modelschemas.UserPerm
+	// OidcIssuer and OidcSubject bind the user to the identity of the oidc provider
+	OidcIssuer  *string
+	OidcSubject *string
 }
 
 type UpdateUserOption struct {
@@ -59,11 +62,13 @@ func (s *userService) Create(ctx context.Context, opt CreateUserOption) (*models
 		ResourceMixin: models.ResourceMixin{
 			Name: opt.Name,
 		},
-		FirstName: opt.FirstName,
-		LastName:  opt.LastName,
-		Email:     opt.Email,
-		Password:  string(hashedPassword),
-		Perm:      modelschemas.UserPermDefault,
+		FirstName:   opt.FirstName,
+		LastName:    opt.LastName,
+		Email:       opt.Email,
+		Password:    string(hashedPassword),
+		Perm:        modelschemas.UserPermDefault,
+		OidcIssuer:  opt.OidcIssuer,
+		OidcSubject: opt.OidcSubject,
 	}
 	if opt.Perm != nil {
 		user.Perm = *opt.Perm
@@ -222,6 +227,18 @@ func (*userService) GetByEmail(ctx context.Context, email string) (*models.User,
 	return &user, nil
 }
 
+func (*userService) GetByOIDCSubject(ctx context.Context, issuer, subject string) (*models.User, error) {
+	var user models.User
+	err := mustGetSession(ctx).Where("oidc_issuer = ?", issuer).Where("oidc_subject = ?", subject).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &user, nil
+}
+
 func (s *userService) GetDefaultAdmin(ctx context.Context) (*models.User, error) {
 	var adminUser *models.User
 	users, total, err := s.List(ctx, ListUserOption{
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/consts"
+)
+
+type userGroupService struct{}
+
+var UserGroupService = userGroupService{}
+
+func (s *userGroupService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.UserGroup{})
+}
+
+func (s *userGroupService) getRelationBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.UserGroupUserRelation{})
+}
+
+type CreateUserGroupOption struct {
+	CreatorId      uint
+	OrganizationId uint
+	Name           string
+}
+
+type UpdateUserGroupOption struct {
+	Name *string
+}
+
+type ListUserGroupOption struct {
+	BaseListOption
+	OrganizationId *uint
+	UserId         *uint
+	Ids            *[]uint
+	Names          *[]string
+}
+
+func (s *userGroupService) Create(ctx context.Context, opt CreateUserGroupOption) (*models.UserGroup, error) {
+	errs := validation.IsDNS1035Label(opt.Name)
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, ";"))
+	}
+
+	userGroup := models.UserGroup{
+		ResourceMixin: models.ResourceMixin{
+			Name: opt.Name,
+		},
+		OrganizationAssociate: models.OrganizationAssociate{
+			OrganizationId: opt.OrganizationId,
+		},
+		CreatorAssociate: models.CreatorAssociate{
+			CreatorId: opt.CreatorId,
+		},
+	}
+	err := mustGetSession(ctx).Create(&userGroup).Error
+	if err != nil {
+		return nil, err
+	}
+	return &userGroup, err
+}
+
+func (s *userGroupService) Update(ctx context.Context, g *models.UserGroup, opt UpdateUserGroupOption) (*models.UserGroup, error) {
+	var err error
+	updaters := make(map[string]interface{})
+	if opt.Name != nil {
+		errs := validation.IsDNS1035Label(*opt.Name)
+		if len(errs) > 0 {
+			return nil, errors.New(strings.Join(errs, ";"))
+		}
+		updaters["name"] = *opt.Name
+		defer func() {
+			if err == nil {
+				g.Name = *opt.Name
+			}
+		}()
+	}
+
+	if len(updaters) == 0 {
+		return g, nil
+	}
+
+	err = s.getBaseDB(ctx).Where("id = ?", g.ID).Updates(updaters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return g, err
+}
+
+func (s *userGroupService) Get(ctx context.Context, id uint) (*models.UserGroup, error) {
+	var userGroup models.UserGroup
+	err := getBaseQuery(ctx, s).Where("id = ?", id).First(&userGroup).Error
+	if err != nil {
+		return nil, err
+	}
+	if userGroup.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &userGroup, nil
+}
+
+func (s *userGroupService) GetByUid(ctx context.Context, uid string) (*models.UserGroup, error) {
+	var userGroup models.UserGroup
+	err := getBaseQuery(ctx, s).Where("uid = ?", uid).First(&userGroup).Error
+	if err != nil {
+		return nil, err
+	}
+	if userGroup.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &userGroup, nil
+}
+
+func (s *userGroupService) GetByName(ctx context.Context, organizationId uint, name string) (*models.UserGroup, error) {
+	var userGroup models.UserGroup
+	err := getBaseQuery(ctx, s).Where("organization_id = ?", organizationId).Where("name = ?", name).First(&userGroup).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "get user group %s", name)
+	}
+	if userGroup.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &userGroup, nil
+}
+
+func (s *userGroupService) List(ctx context.Context, opt ListUserGroupOption) ([]*models.UserGroup, uint, error) {
+	userGroups := make([]*models.UserGroup, 0)
+	query := getBaseQuery(ctx, s)
+	if opt.OrganizationId != nil {
+		query = query.Where("user_group.organization_id = ?", *opt.OrganizationId)
+	}
+	if opt.UserId != nil {
+		query = query.Where("user_group.id in (?)", s.getUserGroupIdsQuery(ctx, *opt.UserId))
+	}
+	if opt.Ids != nil {
+		if len(*opt.Ids) == 0 {
+			return userGroups, 0, nil
+		}
+		query = query.Where("user_group.id in (?)", *opt.Ids)
+	}
+	if opt.Names != nil {
+		if len(*opt.Names) == 0 {
+			return userGroups, 0, nil
+		}
+		query = query.Where("user_group.name in (?)", *opt.Names)
+	}
+	query = opt.BindQueryWithKeywords(query, "user_group")
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	query = opt.BindQueryWithLimit(query)
+	err = query.Order("user_group.id DESC").Find(&userGroups).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return userGroups, uint(total), err
+}
+
+func (s *userGroupService) Delete(ctx context.Context, g *models.UserGroup) (*models.UserGroup, error) {
+	err := s.getBaseDB(ctx).Unscoped().Delete(g).Error
+	return g, err
+}
+
+// getUserGroupIdsQuery returns a sub query which selects the ids of the user groups the user belongs to
+func (s *userGroupService) getUserGroupIdsQuery(ctx context.Context, userId uint) *gorm.DB {
+	return s.getRelationBaseDB(ctx).Select("user_group_id").Where("user_id = ?", userId)
+}
+
+func (s *userGroupService) AddUsers(ctx context.Context, g *models.UserGroup, creatorId uint, userIds []uint) (err error) {
+	if len(userIds) == 0 {
+		return nil
+	}
+	existingUserIds, err := s.ListUserIds(ctx, g.ID)
+	if err != nil {
+		return
+	}
+	// nolint: ineffassign,staticcheck
+	db, ctx, df, err := startTransaction(ctx)
+	if err != nil {
+		return
+	}
+	defer func() { df(err) }()
+	for _, userId := range getNewUserGroupUserIds(existingUserIds, userIds) {
+		err = db.Create(&models.UserGroupUserRelation{
+			UserGroupAssociate: models.UserGroupAssociate{
+				UserGroupId: g.ID,
+			},
+			UserAssociate: models.UserAssociate{
+				UserId: userId,
+			},
+			CreatorAssociate: models.CreatorAssociate{
+				CreatorId: creatorId,
+			},
+		}).Error
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// getNewUserGroupUserIds returns the user ids which are not in the user group yet, without duplicates
+func getNewUserGroupUserIds(existingUserIds, userIds []uint) []uint {
+	userIdsSet := make(map[uint]struct{}, len(existingUserIds)+len(userIds))
+	for _, userId := range existingUserIds {
+		userIdsSet[userId] = struct{}{}
+	}
+	res := make([]uint, 0, len(userIds))
+	for _, userId := range userIds {
+		if _, ok := userIdsSet[userId]; ok {
+			continue
+		}
+		userIdsSet[userId] = struct{}{}
+		res = append(res, userId)
+	}
+	return res
+}
+
+func (s *userGroupService) RemoveUsers(ctx context.Context, g *models.UserGroup, userIds []uint) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	return s.getRelationBaseDB(ctx).Unscoped().Where("user_group_id = ?", g.ID).Where("user_id in (?)", userIds).Delete(&models.UserGroupUserRelation{}).Error
+}
+
+func (s *userGroupService) ListUserIds(ctx context.Context, userGroupId uint) ([]uint, error) {
+	res := make([]uint, 0)
+	err := s.getRelationBaseDB(ctx).Where("user_group_id = ?", userGroupId).Order("id ASC").Pluck("user_id", &res).Error
+	return res, err
+}
+
+func (s *userGroupService) ListUsers(ctx context.Context, userGroupId uint) ([]*models.User, error) {
+	userIds, err := s.ListUserIds(ctx, userGroupId)
+	if err != nil {
+		return nil, err
+	}
+	if len(userIds) == 0 {
+		return make([]*models.User, 0), nil
+	}
+	return UserService.ListByIds(ctx, userIds)
+}
+
+type IUserGroupAssociate interface {
+	GetAssociatedUserGroupId() uint
+	GetAssociatedUserGroupCache() *models.UserGroup
+	SetAssociatedUserGroupCache(userGroup *models.UserGroup)
+}
+
+func (s *userGroupService) GetAssociatedUserGroup(ctx context.Context, associate IUserGroupAssociate) (*models.UserGroup, error) {
+	cache := associate.GetAssociatedUserGroupCache()
+	if cache != nil {
+		return cache, nil
+	}
+	userGroup, err := s.Get(ctx, associate.GetAssociatedUserGroupId())
+	associate.SetAssociatedUserGroupCache(userGroup)
+	return userGroup, err
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetNewUserGroupUserIds(t *testing.T) {
+	cases := []struct {
+		name            string
+		existingUserIds []uint
+		userIds         []uint
+		expected        []uint
+	}{
+		{"empty group", nil, []uint{3, 1}, []uint{3, 1}},
+		{"existing users are skipped", []uint{1, 2}, []uint{2, 3, 1, 4}, []uint{3, 4}},
+		{"duplicated users are added once", []uint{1}, []uint{2, 2, 1, 2}, []uint{2}},
+		{"all users exist", []uint{1, 2}, []uint{2, 1}, []uint{}},
+		{"no users", []uint{1}, nil, []uint{}},
+	}
+	for _, c := range cases {
+		if actual := getNewUserGroupUserIds(c.existingUserIds, c.userIds); !reflect.DeepEqual(actual, c.expected) {
+			t.Fatalf("%s: the new user ids are %v, expected %v", c.name, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,534 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/consts"
+)
+
+type WebhookEventType string
+
+// The lifecycle event types share the names of the tracking event types in services/tracking
+const (
+	WebhookEventTypeDeploymentCreate            WebhookEventType = "yatai_deployment_create"
+	WebhookEventTypeDeploymentUpdate            WebhookEventType = "yatai_deployment_update"
+	WebhookEventTypeDeploymentTerminate         WebhookEventType = "yatai_deployment_terminate"
+	WebhookEventTypeDeploymentDelete            WebhookEventType = "yatai_deployment_delete"
+	WebhookEventTypeDeploymentStatusChange      WebhookEventType = "yatai_deployment_status_change"
+	WebhookEventTypeBentoPull                   WebhookEventType = "yatai_bento_pull"
+	WebhookEventTypeBentoPush                   WebhookEventType = "yatai_bento_push"
+	WebhookEventTypeBentoImageBuildStatusChange WebhookEventType = "yatai_bento_image_build_status_change"
+	WebhookEventTypeModelPull                   WebhookEventType = "yatai_model_pull"
+	WebhookEventTypeModelPush                   WebhookEventType = "yatai_model_push"
+	WebhookEventTypeYataiComponentHealthChange  WebhookEventType = "yatai_component_health_change"
+)
+
+var WebhookEventTypes = []WebhookEventType{
+	WebhookEventTypeDeploymentCreate,
+	WebhookEventTypeDeploymentUpdate,
+	WebhookEventTypeDeploymentTerminate,
+	WebhookEventTypeDeploymentDelete,
+	WebhookEventTypeDeploymentStatusChange,
+	WebhookEventTypeBentoPull,
+	WebhookEventTypeBentoPush,
+	WebhookEventTypeBentoImageBuildStatusChange,
+	WebhookEventTypeModelPull,
+	WebhookEventTypeModelPush,
+	WebhookEventTypeYataiComponentHealthChange,
+}
+
+func validateWebhookEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		valid := false
+		for _, eventType_ := range WebhookEventTypes {
+			if eventType == string(eventType_) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return errors.Errorf("unknown webhook event type: %s", eventType)
+		}
+	}
+	return nil
+}
+
+func validateWebhookUrl(ctx context.Context, rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return errors.Wrapf(err, "parse webhook url %s", rawUrl)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("webhook url scheme should be http or https: %s", rawUrl)
+	}
+	if u.Host == "" {
+		return errors.Errorf("webhook url host is empty: %s", rawUrl)
+	}
+	return validateWebhookHost(ctx, u.Hostname())
+}
+
+// webhookBlockedNetworks are the internal networks which are not covered by the net.IP helpers
+var webhookBlockedNetworks = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",     // this network
+		"100.64.0.0/10", // carrier-grade NAT, which also hosts the metadata services of some clouds
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved
+	}
+	res := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, network)
+	}
+	return res
+}()
+
+// isWebhookIPAllowed reports whether the webhooks could be sent to the ip, the loopback, private, link-local
+// (including the cloud metadata endpoints) and other internal addresses are not allowed
+func isWebhookIPAllowed(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range webhookBlockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateWebhookHost rejects the hosts which resolve to internal addresses,
+// the addresses are checked again when the deliveries are sent, because the host could resolve differently by then
+func validateWebhookHost(ctx context.Context, host string) error {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return errors.Wrapf(err, "resolve webhook host %s", host)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	for _, ip := range ips {
+		if !isWebhookIPAllowed(ip) {
+			return errors.Errorf("webhook host %s resolves to the internal address %s", host, ip)
+		}
+	}
+	return nil
+}
+
+type webhookService struct{}
+
+var WebhookService = webhookService{}
+
+func (s *webhookService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.Webhook{})
+}
+
+type CreateWebhookOption struct {
+	CreatorId      uint
+	OrganizationId uint
+	Name           string
+	Description    string
+	Url            string
+	Secret         string
+	EventTypes     []string
+}
+
+type UpdateWebhookOption struct {
+	Description *string
+	Url         *string
+	Secret      *string
+	EventTypes  *[]string
+	Disabled    *bool
+}
+
+type ListWebhookOption struct {
+	BaseListOption
+	OrganizationId *uint
+	Ids            *[]uint
+	Disabled       *bool
+}
+
+func (s *webhookService) Create(ctx context.Context, opt CreateWebhookOption) (*models.Webhook, error) {
+	errs := validation.IsDNS1035Label(opt.Name)
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, ";"))
+	}
+	if err := validateWebhookUrl(ctx, opt.Url); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEventTypes(opt.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret := opt.Secret
+	if secret == "" {
+		var err error
+		secret, err = randomHexString(32)
+		if err != nil {
+			return nil, errors.Wrap(err, "generate webhook secret")
+		}
+	}
+
+	webhook := models.Webhook{
+		ResourceMixin: models.ResourceMixin{
+			Name: opt.Name,
+		},
+		OrganizationAssociate: models.OrganizationAssociate{
+			OrganizationId: opt.OrganizationId,
+		},
+		CreatorAssociate: models.CreatorAssociate{
+			CreatorId: opt.CreatorId,
+		},
+		Description: opt.Description,
+		Url:         opt.Url,
+		Secret:      secret,
+		EventTypes:  opt.EventTypes,
+	}
+	err := mustGetSession(ctx).Create(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, err
+}
+
+func (s *webhookService) Update(ctx context.Context, w *models.Webhook, opt UpdateWebhookOption) (*models.Webhook, error) {
+	var err error
+	updaters := make(map[string]interface{})
+	if opt.Description != nil {
+		updaters["description"] = *opt.Description
+		defer func() {
+			if err == nil {
+				w.Description = *opt.Description
+			}
+		}()
+	}
+	if opt.Url != nil {
+		if err = validateWebhookUrl(ctx, *opt.Url); err != nil {
+			return nil, err
+		}
+		updaters["url"] = *opt.Url
+		defer func() {
+			if err == nil {
+				w.Url = *opt.Url
+			}
+		}()
+	}
+	if opt.Secret != nil {
+		updaters["secret"] = *opt.Secret
+		defer func() {
+			if err == nil {
+				w.Secret = *opt.Secret
+			}
+		}()
+	}
+	if opt.EventTypes != nil {
+		if err = validateWebhookEventTypes(*opt.EventTypes); err != nil {
+			return nil, err
+		}
+		updaters["event_types"] = pq.StringArray(*opt.EventTypes)
+		defer func() {
+			if err == nil {
+				w.EventTypes = *opt.EventTypes
+			}
+		}()
+	}
+	if opt.Disabled != nil {
+		updaters["disabled"] = *opt.Disabled
+		defer func() {
+			if err == nil {
+				w.Disabled = *opt.Disabled
+			}
+		}()
+	}
+
+	if len(updaters) == 0 {
+		return w, nil
+	}
+
+	err = s.getBaseDB(ctx).Where("id = ?", w.ID).Updates(updaters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return w, err
+}
+
+func (s *webhookService) Get(ctx context.Context, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := getBaseQuery(ctx, s).Where("id = ?", id).First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	if webhook.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &webhook, nil
+}
+
+func (s *webhookService) GetByUid(ctx context.Context, uid string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := getBaseQuery(ctx, s).Where("uid = ?", uid).First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	if webhook.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &webhook, nil
+}
+
+func (s *webhookService) GetByName(ctx context.Context, organizationId uint, name string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := getBaseQuery(ctx, s).Where("organization_id = ?", organizationId).Where("name = ?", name).First(&webhook).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "get webhook %s", name)
+	}
+	if webhook.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &webhook, nil
+}
+
+func (s *webhookService) List(ctx context.Context, opt ListWebhookOption) ([]*models.Webhook, uint, error) {
+	query := getBaseQuery(ctx, s)
+	if opt.OrganizationId != nil {
+		query = query.Where("webhook.organization_id = ?", *opt.OrganizationId)
+	}
+	if opt.Ids != nil {
+		if len(*opt.Ids) == 0 {
+			return make([]*models.Webhook, 0), 0, nil
+		}
+		query = query.Where("webhook.id in (?)", *opt.Ids)
+	}
+	if opt.Disabled != nil {
+		query = query.Where("webhook.disabled = ?", *opt.Disabled)
+	}
+	query = opt.BindQueryWithKeywords(query, "webhook")
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	query = opt.BindQueryWithLimit(query)
+	webhooks := make([]*models.Webhook, 0)
+	err = query.Order("webhook.id DESC").Find(&webhooks).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return webhooks, uint(total), err
+}
+
+func (s *webhookService) Delete(ctx context.Context, w *models.Webhook) (*models.Webhook, error) {
+	err := s.getBaseDB(ctx).Unscoped().Delete(w).Error
+	return w, err
+}
+
+// Dispatch records a delivery for every enabled webhook of the organization which subscribes the event type,
+// the deliveries are sent in the background and retried by the webhook delivery cron
+func (s *webhookService) Dispatch(ctx context.Context, organizationId uint, eventType WebhookEventType, payload interface{}) {
+	logger := logrus.WithField("webhook event type", eventType)
+	disabled := false
+	webhooks, _, err := s.List(ctx, ListWebhookOption{
+		OrganizationId: &organizationId,
+		Disabled:       &disabled,
+	})
+	if err != nil {
+		logger.Errorf("list webhooks: %s", err.Error())
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		logger.Errorf("marshal webhook payload: %s", err.Error())
+		return
+	}
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(string(eventType)) {
+			continue
+		}
+		delivery, err := WebhookDeliveryService.Create(ctx, CreateWebhookDeliveryOption{
+			WebhookId: webhook.ID,
+			EventType: string(eventType),
+			Payload:   string(payloadBytes),
+		})
+		if err != nil {
+			logger.Errorf("create webhook %s delivery: %s", webhook.Name, err.Error())
+			continue
+		}
+		delivery.SetAssociatedWebhookCache(webhook)
+		go func() {
+			// the delivery outlives the request which dispatches it
+			_, err := WebhookDeliveryService.Deliver(context.Background(), delivery)
+			if err != nil {
+				logger.Errorf("deliver webhook %s: %s", delivery.Uid, err.Error())
+			}
+		}()
+	}
+}
+
+type IWebhookAssociate interface {
+	GetAssociatedWebhookId() uint
+	GetAssociatedWebhookCache() *models.Webhook
+	SetAssociatedWebhookCache(webhook *models.Webhook)
+}
+
+func (s *webhookService) GetAssociatedWebhook(ctx context.Context, associate IWebhookAssociate) (*models.Webhook, error) {
+	cache := associate.GetAssociatedWebhookCache()
+	if cache != nil {
+		return cache, nil
+	}
+	webhook, err := s.Get(ctx, associate.GetAssociatedWebhookId())
+	associate.SetAssociatedWebhookCache(webhook)
+	return webhook, err
+}
+
+type WebhookCommonPayload struct {
+	EventType       WebhookEventType `json:"event_type"`
+	OrganizationUID string           `json:"organization_uid"`
+	Timestamp       time.Time        `json:"timestamp"`
+}
+
+type DeploymentStatusChangeWebhookPayload struct {
+	WebhookCommonPayload
+	ClusterUID     string                        `json:"cluster_uid"`
+	DeploymentUID  string                        `json:"deployment_uid"`
+	DeploymentName string                        `json:"deployment_name"`
+	KubeNamespace  string                        `json:"kube_namespace"`
+	OldStatus      modelschemas.DeploymentStatus `json:"old_status"`
+	NewStatus      modelschemas.DeploymentStatus `json:"new_status"`
+}
+
+type BentoImageBuildStatusChangeWebhookPayload struct {
+	WebhookCommonPayload
+	BentoRepositoryUID  string                        `json:"bento_repository_uid"`
+	BentoRepositoryName string                        `json:"bento_repository_name"`
+	BentoUID            string                        `json:"bento_uid"`
+	BentoVersion        string                        `json:"bento_version"`
+	OldStatus           modelschemas.ImageBuildStatus `json:"old_status"`
+	NewStatus           modelschemas.ImageBuildStatus `json:"new_status"`
+}
+
+type YataiComponentHealthChangeWebhookPayload struct {
+	WebhookCommonPayload
+	ClusterUID            string                            `json:"cluster_uid"`
+	ClusterName           string                            `json:"cluster_name"`
+	YataiComponentUID     string                            `json:"yatai_component_uid"`
+	YataiComponentName    string                            `json:"yatai_component_name"`
+	YataiComponentVersion string                            `json:"yatai_component_version"`
+	LatestHeartbeatAt     *time.Time                        `json:"latest_heartbeat_at"`
+	OldHealthStatus       models.YataiComponentHealthStatus `json:"old_health_status"`
+	NewHealthStatus       models.YataiComponentHealthStatus `json:"new_health_status"`
+}
+
+func (s *webhookService) DispatchDeploymentStatusChange(ctx context.Context, deployment *models.Deployment, oldStatus modelschemas.DeploymentStatus) {
+	if deployment.Status == oldStatus {
+		return
+	}
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, deployment)
+	if err != nil {
+		logrus.Errorf("get deployment %s associated cluster: %s", deployment.Name, err.Error())
+		return
+	}
+	org, err := OrganizationService.GetAssociatedOrganization(ctx, cluster)
+	if err != nil {
+		logrus.Errorf("get cluster %s associated organization: %s", cluster.Name, err.Error())
+		return
+	}
+	s.Dispatch(ctx, org.ID, WebhookEventTypeDeploymentStatusChange, DeploymentStatusChangeWebhookPayload{
+		WebhookCommonPayload: WebhookCommonPayload{
+			EventType:       WebhookEventTypeDeploymentStatusChange,
+			OrganizationUID: org.Uid,
+			Timestamp:       time.Now(),
+		},
+		ClusterUID:     cluster.Uid,
+		DeploymentUID:  deployment.Uid,
+		DeploymentName: deployment.Name,
+		KubeNamespace:  deployment.KubeNamespace,
+		OldStatus:      oldStatus,
+		NewStatus:      deployment.Status,
+	})
+}
+
+func (s *webhookService) DispatchBentoImageBuildStatusChange(ctx context.Context, bento *models.Bento, oldStatus modelschemas.ImageBuildStatus) {
+	if bento.ImageBuildStatus == oldStatus {
+		return
+	}
+	bentoRepository, err := BentoRepositoryService.GetAssociatedBentoRepository(ctx, bento)
+	if err != nil {
+		logrus.Errorf("get bento %s associated bento repository: %s", bento.Version, err.Error())
+		return
+	}
+	org, err := OrganizationService.GetAssociatedOrganization(ctx, bentoRepository)
+	if err != nil {
+		logrus.Errorf("get bento repository %s associated organization: %s", bentoRepository.Name, err.Error())
+		return
+	}
+	s.Dispatch(ctx, org.ID, WebhookEventTypeBentoImageBuildStatusChange, BentoImageBuildStatusChangeWebhookPayload{
+		WebhookCommonPayload: WebhookCommonPayload{
+			EventType:       WebhookEventTypeBentoImageBuildStatusChange,
+			OrganizationUID: org.Uid,
+			Timestamp:       time.Now(),
+		},
+		BentoRepositoryUID:  bentoRepository.Uid,
+		BentoRepositoryName: bentoRepository.Name,
+		BentoUID:            bento.Uid,
+		BentoVersion:        bento.Version,
+		OldStatus:           oldStatus,
+		NewStatus:           bento.ImageBuildStatus,
+	})
+}
+
+func (s *webhookService) DispatchYataiComponentHealthChange(ctx context.Context, yataiComponent *models.YataiComponent, oldHealthStatus models.YataiComponentHealthStatus) {
+	if yataiComponent.HealthStatus == oldHealthStatus {
+		return
+	}
+	cluster, err := ClusterService.GetAssociatedCluster(ctx, yataiComponent)
+	if err != nil {
+		logrus.Errorf("get yatai component %s associated cluster: %s", yataiComponent.Name, err.Error())
+		return
+	}
+	org, err := OrganizationService.GetAssociatedOrganization(ctx, cluster)
+	if err != nil {
+		logrus.Errorf("get cluster %s associated organization: %s", cluster.Name, err.Error())
+		return
+	}
+	s.Dispatch(ctx, org.ID, WebhookEventTypeYataiComponentHealthChange, YataiComponentHealthChangeWebhookPayload{
+		WebhookCommonPayload: WebhookCommonPayload{
+			EventType:       WebhookEventTypeYataiComponentHealthChange,
+			OrganizationUID: org.Uid,
+			Timestamp:       time.Now(),
+		},
+		ClusterUID:            cluster.Uid,
+		ClusterName:           cluster.Name,
+		YataiComponentUID:     yataiComponent.Uid,
+		YataiComponentName:    yataiComponent.Name,
+		YataiComponentVersion: yataiComponent.Version,
+		LatestHeartbeatAt:     yataiComponent.LatestHeartbeatAt,
+		OldHealthStatus:       oldHealthStatus,
+		NewHealthStatus:       yataiComponent.HealthStatus,
+	})
+}
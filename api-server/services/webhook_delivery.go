@@ -0,0 +1,281 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/common/consts"
+)
+
+const (
+	webhookDeliveryTimeout         = 10 * time.Second
+	webhookDeliveryMaxAttempts     = 6
+	webhookDeliveryBaseBackoff     = 30 * time.Second
+	webhookDeliveryMaxBackoff      = time.Hour
+	webhookDeliveryMaxResponseSize = 1024
+
+	WebhookHeaderEvent     = "X-Yatai-Event"
+	WebhookHeaderDelivery  = "X-Yatai-Delivery"
+	WebhookHeaderSignature = "X-Yatai-Signature-256"
+)
+
+// webhookHttpClient refuses to connect to the internal addresses, whatever the webhook host resolves to at delivery time
+// or wherever the webhook redirects to, the deliveries are not sent through a proxy, since the proxy could reach them
+var webhookHttpClient = func() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: webhookDeliveryTimeout,
+		Control: webhookDialControl,
+	}).DialContext
+	return &http.Client{
+		Timeout:   webhookDeliveryTimeout,
+		Transport: transport,
+	}
+}()
+
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrapf(err, "split webhook address %s", address)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isWebhookIPAllowed(ip) {
+		return errors.Errorf("webhook address %s is not allowed", address)
+	}
+	return nil
+}
+
+type webhookDeliveryService struct{}
+
+var WebhookDeliveryService = webhookDeliveryService{}
+
+func (s *webhookDeliveryService) getBaseDB(ctx context.Context) *gorm.DB {
+	return mustGetSession(ctx).Model(&models.WebhookDelivery{})
+}
+
+type CreateWebhookDeliveryOption struct {
+	WebhookId uint
+	EventType string
+	Payload   string
+}
+
+type ListWebhookDeliveryOption struct {
+	BaseListOption
+	WebhookId *uint
+	Statuses  *[]models.WebhookDeliveryStatus
+}
+
+func (s *webhookDeliveryService) Create(ctx context.Context, opt CreateWebhookDeliveryOption) (*models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{
+		WebhookAssociate: models.WebhookAssociate{
+			WebhookId: opt.WebhookId,
+		},
+		EventType: opt.EventType,
+		Payload:   opt.Payload,
+		Status:    models.WebhookDeliveryStatusPending,
+	}
+	err := mustGetSession(ctx).Create(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, err
+}
+
+func (s *webhookDeliveryService) Get(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := getBaseQuery(ctx, s).Where("id = ?", id).First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	if delivery.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &delivery, nil
+}
+
+func (s *webhookDeliveryService) GetByUid(ctx context.Context, uid string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := getBaseQuery(ctx, s).Where("uid = ?", uid).First(&delivery).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "get webhook delivery %s", uid)
+	}
+	if delivery.ID == 0 {
+		return nil, consts.ErrNotFound
+	}
+	return &delivery, nil
+}
+
+func (s *webhookDeliveryService) List(ctx context.Context, opt ListWebhookDeliveryOption) ([]*models.WebhookDelivery, uint, error) {
+	query := getBaseQuery(ctx, s)
+	if opt.WebhookId != nil {
+		query = query.Where("webhook_delivery.webhook_id = ?", *opt.WebhookId)
+	}
+	if opt.Statuses != nil {
+		query = query.Where("webhook_delivery.status in (?)", *opt.Statuses)
+	}
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	query = opt.BindQueryWithLimit(query)
+	deliveries := make([]*models.WebhookDelivery, 0)
+	err = query.Order("webhook_delivery.id DESC").Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, uint(total), err
+}
+
+// ListDue lists the pending deliveries whose retry time has come
+func (s *webhookDeliveryService) ListDue(ctx context.Context) ([]*models.WebhookDelivery, error) {
+	deliveries := make([]*models.WebhookDelivery, 0)
+	err := getBaseQuery(ctx, s).Where("status = ?", models.WebhookDeliveryStatusPending).Where("next_retry_at is null or next_retry_at <= ?", time.Now()).Order("id ASC").Find(&deliveries).Error
+	return deliveries, err
+}
+
+func getWebhookDeliveryBackoff(attempts uint) time.Duration {
+	backoff := webhookDeliveryBaseBackoff
+	for i := uint(1); i < attempts; i++ {
+		backoff *= 2
+		if backoff >= webhookDeliveryMaxBackoff {
+			return webhookDeliveryMaxBackoff
+		}
+	}
+	return backoff
+}
+
+func signWebhookPayload(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// claim pushes the retry time of the pending delivery forward, so the cron and the dispatcher never send it concurrently
+func (s *webhookDeliveryService) claim(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	now := time.Now()
+	leaseUntil := now.Add(2 * webhookDeliveryTimeout)
+	res := s.getBaseDB(ctx).Where("id = ?", delivery.ID).Where("status = ?", models.WebhookDeliveryStatusPending).Where("next_retry_at is null or next_retry_at <= ?", now).Updates(map[string]interface{}{
+		"next_retry_at": leaseUntil,
+	})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Deliver sends the delivery payload to the webhook url and records the result,
+// failed deliveries are retried with exponential backoff until webhookDeliveryMaxAttempts is reached
+func (s *webhookDeliveryService) Deliver(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	claimed, err := s.claim(ctx, delivery)
+	if err != nil {
+		return nil, errors.Wrap(err, "claim webhook delivery")
+	}
+	if !claimed {
+		return delivery, nil
+	}
+
+	webhook, err := WebhookService.GetAssociatedWebhook(ctx, delivery)
+	if err != nil {
+		return nil, errors.Wrap(err, "get associated webhook")
+	}
+
+	var statusCode *int
+	var responseBody string
+	var deliverErr error
+
+	ctx_, cancel := context.WithTimeout(ctx, webhookDeliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx_, http.MethodPost, webhook.Url, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		deliverErr = errors.Wrap(err, "new webhook request")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+		req.Header.Set(WebhookHeaderDelivery, delivery.Uid)
+		req.Header.Set(WebhookHeaderSignature, signWebhookPayload(webhook.Secret, delivery.Payload))
+		var resp *http.Response
+		resp, deliverErr = webhookHttpClient.Do(req)
+		if deliverErr == nil {
+			defer resp.Body.Close()
+			statusCode = &resp.StatusCode
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookDeliveryMaxResponseSize))
+			responseBody = string(body)
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				deliverErr = errors.Errorf("webhook responded with status code %d", resp.StatusCode)
+			}
+		}
+	}
+
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	updaters := map[string]interface{}{
+		"attempts":             attempts,
+		"response_status_code": statusCode,
+		"response_body":        responseBody,
+		"error":                "",
+	}
+	status := models.WebhookDeliveryStatusSuccess
+	var nextRetryAt *time.Time
+	if deliverErr != nil {
+		updaters["error"] = deliverErr.Error()
+		if attempts >= webhookDeliveryMaxAttempts {
+			status = models.WebhookDeliveryStatusFailed
+		} else {
+			status = models.WebhookDeliveryStatusPending
+			nextRetryAt_ := now.Add(getWebhookDeliveryBackoff(attempts))
+			nextRetryAt = &nextRetryAt_
+		}
+	} else {
+		updaters["delivered_at"] = now
+		delivery.DeliveredAt = &now
+	}
+	updaters["status"] = status
+	updaters["next_retry_at"] = nextRetryAt
+
+	err = s.getBaseDB(ctx).Where("id = ?", delivery.ID).Updates(updaters).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "update webhook delivery")
+	}
+	delivery.Attempts = attempts
+	delivery.Status = status
+	delivery.ResponseStatusCode = statusCode
+	delivery.ResponseBody = responseBody
+	delivery.NextRetryAt = nextRetryAt
+	if deliverErr != nil {
+		delivery.Error = deliverErr.Error()
+	} else {
+		delivery.Error = ""
+	}
+	return delivery, nil
+}
+
+// Redeliver resets the delivery to pending and sends it again immediately
+func (s *webhookDeliveryService) Redeliver(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	err := s.getBaseDB(ctx).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+		"status":        models.WebhookDeliveryStatusPending,
+		"attempts":      0,
+		"next_retry_at": nil,
+	}).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "reset webhook delivery")
+	}
+	delivery.Status = models.WebhookDeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextRetryAt = nil
+	return s.Deliver(ctx, delivery)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	signature := signWebhookPayload("secret", `{"event_type":"yatai_deployment_create"}`)
+	// the signature is the hex encoded HMAC-SHA256 of the payload, which the receivers compute with any HMAC library
+	expected := "sha256=fbf89d165fe7305c200eb964c751177eb7ec4538b52f11af13fb1db46c571b82"
+	if signature != expected {
+		t.Fatalf("the signature is %q, expected %q", signature, expected)
+	}
+	cases := []struct {
+		name    string
+		secret  string
+		payload string
+	}{
+		{"another secret", "another-secret", `{"event_type":"yatai_deployment_create"}`},
+		{"another payload", "secret", `{"event_type":"yatai_deployment_delete"}`},
+		{"empty secret", "", `{"event_type":"yatai_deployment_create"}`},
+	}
+	for _, c := range cases {
+		if signWebhookPayload(c.secret, c.payload) == signature {
+			t.Fatalf("%s: the signature is not changed", c.name)
+		}
+	}
+}
+
+func TestGetWebhookDeliveryBackoff(t *testing.T) {
+	cases := []struct {
+		attempts uint
+		expected time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+	for _, c := range cases {
+		if backoff := getWebhookDeliveryBackoff(c.attempts); backoff != c.expected {
+			t.Fatalf("the backoff after %d attempts is %s, expected %s", c.attempts, backoff, c.expected)
+		}
+	}
+}
+
+func TestWebhookHttpClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	resp, err := webhookHttpClient.Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("the webhook is sent to the loopback address")
+	}
+	if !strings.Contains(err.Error(), "is not allowed") {
+		t.Fatalf("the error is %v, expected the address to be refused", err)
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestIsWebhookIPAllowed(t *testing.T) {
+	cases := []struct {
+		ip       string
+		expected bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"100.100.100.200", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+	for _, c := range cases {
+		if allowed := isWebhookIPAllowed(net.ParseIP(c.ip)); allowed != c.expected {
+			t.Fatalf("%s is allowed: %v, expected %v", c.ip, allowed, c.expected)
+		}
+	}
+}
+
+func TestValidateWebhookUrl(t *testing.T) {
+	cases := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://93.184.216.34/hooks", false},
+		{"http://93.184.216.34:8080/hooks", false},
+		{"ftp://93.184.216.34/hooks", true},
+		{"https:///hooks", true},
+		{"http://127.0.0.1:3000/hooks", true},
+		{"http://localhost/hooks", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://[::1]/hooks", true},
+		{"http://10.0.0.1/hooks", true},
+	}
+	for _, c := range cases {
+		err := validateWebhookUrl(context.Background(), c.url)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%s: the error is %v, expected an error: %v", c.url, err, c.wantErr)
+		}
+	}
+}
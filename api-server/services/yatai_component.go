@@ -6,10 +6,13 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/multierr"
 	"gorm.io/gorm"
 	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/config"
 	"github.com/bentoml/yatai/api-server/models"
 	"github.com/bentoml/yatai/common/consts"
 )
@@ -74,6 +77,7 @@ func (*yataiComponentService) Create(ctx context.Context, opt CreateYataiCompone
 		Version:           opt.Version,
 		LatestInstalledAt: &now,
 		LatestHeartbeatAt: &now,
+		HealthStatus:      models.YataiComponentHealthStatusHealthy,
 	}
 	err := mustGetSession(ctx).Create(&yataiComponent).Error
 	if err != nil {
@@ -220,3 +224,95 @@ func (s *yataiComponentService) List(ctx context.Context, opt ListYataiComponent
 	}
 	return yataiComponents, err
 }
+
+// GetHealthStatus calculates the health status of the yatai component from its latest heartbeat
+func (s *yataiComponentService) GetHealthStatus(yataiComponent *models.YataiComponent, now time.Time) models.YataiComponentHealthStatus {
+	if yataiComponent.LatestHeartbeatAt == nil {
+		return models.YataiComponentHealthStatusOffline
+	}
+	silence := now.Sub(*yataiComponent.LatestHeartbeatAt)
+	if silence >= time.Duration(config.YataiConfig.ComponentHealth.OfflineAfterSeconds)*time.Second {
+		return models.YataiComponentHealthStatusOffline
+	}
+	if silence >= time.Duration(config.YataiConfig.ComponentHealth.StaleAfterSeconds)*time.Second {
+		return models.YataiComponentHealthStatusStale
+	}
+	return models.YataiComponentHealthStatusHealthy
+}
+
+// SetHealthStatus persists the health status of the yatai component,
+// an event is recorded and the organization webhooks are notified when the health status changes.
+// Leaving the unknown status is not a change, it is the first status found by a health check or a heartbeat.
+// The status is only changed if nobody else has changed it since the component was loaded,
+// so the concurrent health checks and heartbeats never notify the same change twice.
+func (s *yataiComponentService) SetHealthStatus(ctx context.Context, yataiComponent *models.YataiComponent, healthStatus models.YataiComponentHealthStatus) (*models.YataiComponent, error) {
+	oldHealthStatus := yataiComponent.HealthStatus
+	if oldHealthStatus == healthStatus {
+		return yataiComponent, nil
+	}
+	now := time.Now()
+	res := s.getBaseDB(ctx).Where("id = ?", yataiComponent.ID).Where("health_status = ?", oldHealthStatus).Updates(map[string]interface{}{
+		"health_status":            healthStatus,
+		"health_status_changed_at": now,
+	})
+	if res.Error != nil {
+		return nil, errors.Wrapf(res.Error, "update yatai component %s health status", yataiComponent.Name)
+	}
+	if res.RowsAffected == 0 {
+		return yataiComponent, nil
+	}
+	yataiComponent.HealthStatus = healthStatus
+	yataiComponent.HealthStatusChangedAt = &now
+	if oldHealthStatus == models.YataiComponentHealthStatusUnknown {
+		return yataiComponent, nil
+	}
+
+	operationName := "recovered"
+	status := modelschemas.EventStatusSuccess
+	switch healthStatus {
+	case models.YataiComponentHealthStatusStale:
+		operationName = "went stale"
+		status = modelschemas.EventStatusFailed
+	case models.YataiComponentHealthStatusOffline:
+		operationName = "went offline"
+		status = modelschemas.EventStatusFailed
+	}
+	_, err := EventService.Create(ctx, CreateEventOption{
+		CreatorId:      yataiComponent.CreatorId,
+		OrganizationId: &yataiComponent.OrganizationId,
+		ClusterId:      &yataiComponent.ClusterId,
+		ResourceType:   modelschemas.ResourceTypeYataiComponent,
+		ResourceId:     yataiComponent.ID,
+		Status:         status,
+		OperationName:  operationName,
+	})
+	if err != nil {
+		logrus.Errorf("create event failed: %v", err)
+	}
+
+	WebhookService.DispatchYataiComponentHealthChange(ctx, yataiComponent, oldHealthStatus)
+	return yataiComponent, nil
+}
+
+// CheckHealth marks the yatai components which stop sending heartbeats as stale or offline,
+// a failure of one component does not stop the others from being checked
+func (s *yataiComponentService) CheckHealth(ctx context.Context) error {
+	yataiComponents, err := s.List(ctx, ListYataiComponentOption{})
+	if err != nil {
+		return errors.Wrap(err, "list yatai components")
+	}
+	now := time.Now()
+	var errs error
+	for _, yataiComponent := range yataiComponents {
+		// the recovery is handled by the heartbeat itself
+		healthStatus := s.GetHealthStatus(yataiComponent, now)
+		if healthStatus == models.YataiComponentHealthStatusHealthy {
+			continue
+		}
+		_, err = s.SetHealthStatus(ctx, yataiComponent, healthStatus)
+		if err != nil {
+			errs = multierr.Append(errs, errors.Wrapf(err, "check the health of yatai component %d", yataiComponent.ID))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,70 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToAuditLogSchemas(ctx context.Context, events []*models.Event) ([]*schemas.AuditLogSchema, error) {
+	creatorIds := make([]uint, 0, len(events))
+	clusterIds := make([]uint, 0, len(events))
+	for _, event := range events {
+		if event.CreatorId != nil {
+			creatorIds = append(creatorIds, *event.CreatorId)
+		}
+		if event.ClusterId != nil {
+			clusterIds = append(clusterIds, *event.ClusterId)
+		}
+	}
+	users, err := services.UserService.ListByIds(ctx, creatorIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "list users")
+	}
+	usersMap := make(map[uint]*models.User, len(users))
+	for _, user := range users {
+		usersMap[user.ID] = user
+	}
+	clusterNamesMap := make(map[uint]string, len(clusterIds))
+	if len(clusterIds) > 0 {
+		clusters, _, err := services.ClusterService.List(ctx, services.ListClusterOption{
+			Ids: &clusterIds,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "list clusters")
+		}
+		for _, cluster := range clusters {
+			clusterNamesMap[cluster.ID] = cluster.Name
+		}
+	}
+	res := make([]*schemas.AuditLogSchema, 0, len(events))
+	for _, event := range events {
+		auditLog := &schemas.AuditLogSchema{
+			Uid:           event.Uid,
+			CreatedAt:     event.CreatedAt,
+			ApiTokenName:  event.ApiTokenName,
+			ResourceType:  event.ResourceType,
+			OperationName: event.OperationName,
+			Detail:        event.Name,
+			Status:        event.Status,
+		}
+		if event.CreatorId != nil {
+			if user, ok := usersMap[*event.CreatorId]; ok {
+				auditLog.Actor = user.Name
+				auditLog.ActorUid = user.Uid
+			}
+		}
+		if event.ClusterId != nil {
+			auditLog.Cluster = clusterNamesMap[*event.ClusterId]
+		}
+		if event.Info != nil {
+			auditLog.ResourceName = event.Info.ResourceName
+		}
+		res = append(res, auditLog)
+	}
+	return res, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
 	"github.com/bentoml/yatai/api-server/services"
 )
 
@@ -47,6 +48,24 @@ func ToClusterSchemas(ctx context.Context, clusters []*models.Cluster) ([]*schem
 	return res, nil
 }
 
+func ToClusterHealthSchemas(ctx context.Context, clusters []*models.Cluster) ([]*schemas.ClusterHealthSchema, error) {
+	ss, err := ToClusterSchemas(ctx, clusters)
+	if err != nil {
+		return nil, errors.Wrap(err, "ToClusterSchemas")
+	}
+	res := make([]*schemas.ClusterHealthSchema, 0, len(clusters))
+	for idx, cluster := range clusters {
+		res = append(res, &schemas.ClusterHealthSchema{
+			ClusterSchema:  ss[idx],
+			HealthStatus:   string(cluster.HealthStatus),
+			HealthMessage:  cluster.HealthMessage,
+			HealthProbedAt: cluster.HealthProbedAt,
+			KubeVersion:    cluster.KubeVersion,
+		})
+	}
+	return res, nil
+}
+
 func ToClusterFullSchema(ctx context.Context, cluster *models.Cluster) (*schemasv1.ClusterFullSchema, error) {
 	if cluster == nil {
 		return nil, nil
@@ -74,7 +93,9 @@ func ToClusterFullSchema(ctx context.Context, cluster *models.Cluster) (*schemas
 			return nil, err
 		}
 	} else {
-		kubeConfig = &cluster.KubeConfig
+		// the kubeconfig contains the credentials of the cluster, it is never returned
+		kubeConfig_ := services.RedactSecret(cluster.KubeConfig)
+		kubeConfig = &kubeConfig_
 		config = &cluster.Config
 	}
 	grafanaRootPath, err := services.ClusterService.GetGrafanaRootPath(ctx, cluster)
@@ -138,3 +159,24 @@ func GetAssociatedClusterFullSchema(ctx context.Context, associate IClusterAssoc
 	}
 	return clusterSchema, nil
 }
+
+func ToClusterIngressConfigSchema(ctx context.Context, cluster *models.Cluster) (*schemas.ClusterIngressConfigSchema, error) {
+	res := &schemas.ClusterIngressConfigSchema{
+		Provider: string(services.ClusterService.GetIngressProvider(cluster)),
+	}
+	if cluster.IngressConfig != nil {
+		res.IngressClassName = cluster.IngressConfig.IngressClassName
+		res.TraefikEntryPoints = cluster.IngressConfig.TraefikEntryPoints
+		res.GatewayName = cluster.IngressConfig.GatewayName
+		res.GatewayNamespace = cluster.IngressConfig.GatewayNamespace
+		if tls := cluster.IngressConfig.TLS; tls != nil {
+			res.TLS = &schemas.ClusterTLSConfigSchema{
+				CertManagerIssuerName:              tls.CertManagerIssuerName,
+				CertManagerIssuerKind:              string(tls.CertManagerIssuerKind),
+				WildcardCertificateSecretName:      tls.WildcardCertificateSecretName,
+				WildcardCertificateSecretNamespace: tls.WildcardCertificateSecretNamespace,
+			}
+		}
+	}
+	return res, nil
+}
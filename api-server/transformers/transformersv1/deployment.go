@@ -8,7 +8,9 @@ import (
 	"github.com/bentoml/yatai-schemas/modelschemas"
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
 	"github.com/bentoml/yatai/api-server/services"
+	"github.com/bentoml/yatai/common/tracing"
 	"github.com/bentoml/yatai/common/utils"
 )
 
@@ -24,6 +26,8 @@ func ToDeploymentSchema(ctx context.Context, deployment *models.Deployment) (*sc
 }
 
 func ToDeploymentSchemas(ctx context.Context, deployments []*models.Deployment) ([]*schemasv1.DeploymentSchema, error) {
+	ctx, span := tracing.StartSpan(ctx, "ToDeploymentSchemas")
+	defer span.End()
 	status_ := modelschemas.DeploymentRevisionStatusActive
 	deploymentIds := make([]uint, 0, len(deployments))
 
@@ -128,3 +132,37 @@ func GetAssociatedNullableDeploymentSchema(ctx context.Context, associate INulla
 	}
 	return deploymentSchema, nil
 }
+
+func ToDeploymentDomainSchema(ctx context.Context, deployment *models.Deployment) (*schemas.DeploymentDomainSchema, error) {
+	urls, err := services.DeploymentService.GetURLs(ctx, deployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "get deployment urls")
+	}
+	return &schemas.DeploymentDomainSchema{
+		IngressHost:   deployment.IngressHost,
+		TLSSecretName: deployment.TLSSecretName,
+		URLs:          urls,
+	}, nil
+}
+
+func ToDeploymentAuthConfigSchema(ctx context.Context, deployment *models.Deployment) (*schemas.DeploymentAuthConfigSchema, error) {
+	res := &schemas.DeploymentAuthConfigSchema{}
+	if deployment.AuthConfig != nil {
+		res.Mode = string(deployment.AuthConfig.Mode)
+		res.JWTIssuer = deployment.AuthConfig.JWTIssuer
+		res.JWTAudience = deployment.AuthConfig.JWTAudience
+	}
+	return res, nil
+}
+
+func ToDeploymentApiKeyUsageSchemas(ctx context.Context, usage []*models.ApiTokenUsage) ([]*schemas.DeploymentApiKeyUsageSchema, error) {
+	res := make([]*schemas.DeploymentApiKeyUsageSchema, 0, len(usage))
+	for _, u := range usage {
+		res = append(res, &schemas.DeploymentApiKeyUsageSchema{
+			Day:          u.Day.Format("2006-01-02"),
+			RequestCount: u.RequestCount,
+			LastUsedAt:   u.LastUsedAt,
+		})
+	}
+	return res, nil
+}
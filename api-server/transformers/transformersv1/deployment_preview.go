@@ -0,0 +1,41 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToKubeObjectFieldDiffSchemas(ctx context.Context, diffs []*services.KubeObjectFieldDiff) []*schemas.KubeObjectFieldDiffSchema {
+	res := make([]*schemas.KubeObjectFieldDiffSchema, 0, len(diffs))
+	for _, diff := range diffs {
+		res = append(res, &schemas.KubeObjectFieldDiffSchema{
+			Path:      diff.Path,
+			Operation: string(diff.Operation),
+			Old:       diff.Old,
+			New:       diff.New,
+		})
+	}
+	return res
+}
+
+func ToDeploymentPreviewSchema(ctx context.Context, previews []*services.KubeObjectPreview) (*schemas.DeploymentPreviewSchema, error) {
+	objects := make([]*schemas.KubeObjectPreviewSchema, 0, len(previews))
+	for _, preview := range previews {
+		objects = append(objects, &schemas.KubeObjectPreviewSchema{
+			ApiVersion:           preview.ApiVersion,
+			Kind:                 preview.Kind,
+			Namespace:            preview.Namespace,
+			Name:                 preview.Name,
+			Object:               preview.Object,
+			ActiveRevisionChange: string(preview.ActiveRevisionChange),
+			ActiveRevisionDiffs:  ToKubeObjectFieldDiffSchemas(ctx, preview.ActiveRevisionDiffs),
+			LiveChange:           string(preview.LiveChange),
+			LiveDiffs:            ToKubeObjectFieldDiffSchemas(ctx, preview.LiveDiffs),
+		})
+	}
+	return &schemas.DeploymentPreviewSchema{
+		Objects: objects,
+	}, nil
+}
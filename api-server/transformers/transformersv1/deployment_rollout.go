@@ -0,0 +1,67 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToDeploymentRolloutSchema(ctx context.Context, rollout *models.DeploymentRollout) (*schemas.DeploymentRolloutSchema, error) {
+	if rollout == nil {
+		return nil, nil
+	}
+	ss, err := ToDeploymentRolloutSchemas(ctx, []*models.DeploymentRollout{rollout})
+	if err != nil {
+		return nil, errors.Wrap(err, "ToDeploymentRolloutSchemas")
+	}
+	return ss[0], nil
+}
+
+func ToDeploymentRolloutSchemas(ctx context.Context, rollouts []*models.DeploymentRollout) ([]*schemas.DeploymentRolloutSchema, error) {
+	res := make([]*schemas.DeploymentRolloutSchema, 0, len(rollouts))
+	for _, rollout := range rollouts {
+		creator, err := services.UserService.GetAssociatedCreator(ctx, rollout)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated creator")
+		}
+		creatorSchema, err := ToUserSchema(ctx, creator)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserSchema")
+		}
+		deploymentRevision, err := services.DeploymentRevisionService.GetAssociatedDeploymentRevision(ctx, rollout)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated deployment revision")
+		}
+		deploymentTarget, err := services.DeploymentTargetService.GetAssociatedDeploymentTarget(ctx, rollout)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated deployment target")
+		}
+		steps := make([]uint, 0, len(rollout.Steps))
+		for _, step := range rollout.Steps {
+			steps = append(steps, uint(step))
+		}
+		var currentWeight uint
+		if int(rollout.CurrentStep) < len(steps) {
+			currentWeight = steps[rollout.CurrentStep]
+		}
+		res = append(res, &schemas.DeploymentRolloutSchema{
+			BaseSchema:            ToBaseSchema(rollout),
+			Creator:               creatorSchema,
+			DeploymentRevisionUid: deploymentRevision.Uid,
+			DeploymentTargetUid:   deploymentTarget.Uid,
+			Status:                string(rollout.Status),
+			Steps:                 steps,
+			StepIntervalSeconds:   rollout.StepIntervalSeconds,
+			CurrentStep:           rollout.CurrentStep,
+			CurrentWeight:         currentWeight,
+			StepStartedAt:         rollout.StepStartedAt,
+			NextStepAt:            rollout.NextStepAt,
+			Message:               rollout.Message,
+		})
+	}
+	return res, nil
+}
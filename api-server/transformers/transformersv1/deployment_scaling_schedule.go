@@ -0,0 +1,53 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToDeploymentScalingScheduleSchema(ctx context.Context, schedule *models.DeploymentScalingSchedule) (*schemas.DeploymentScalingScheduleSchema, error) {
+	if schedule == nil {
+		return nil, nil
+	}
+	ss, err := ToDeploymentScalingScheduleSchemas(ctx, []*models.DeploymentScalingSchedule{schedule})
+	if err != nil {
+		return nil, errors.Wrap(err, "ToDeploymentScalingScheduleSchemas")
+	}
+	return ss[0], nil
+}
+
+func ToDeploymentScalingScheduleSchemas(ctx context.Context, schedules []*models.DeploymentScalingSchedule) ([]*schemas.DeploymentScalingScheduleSchema, error) {
+	res := make([]*schemas.DeploymentScalingScheduleSchema, 0, len(schedules))
+	for _, schedule := range schedules {
+		creator, err := services.UserService.GetAssociatedCreator(ctx, schedule)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated creator")
+		}
+		creatorSchema, err := ToUserSchema(ctx, creator)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserSchema")
+		}
+		res = append(res, &schemas.DeploymentScalingScheduleSchema{
+			BaseSchema:     ToBaseSchema(schedule),
+			Creator:        creatorSchema,
+			Name:           schedule.Name,
+			Cron:           schedule.Cron,
+			Timezone:       schedule.Timezone,
+			Action:         string(schedule.Action),
+			MinReplicas:    schedule.MinReplicas,
+			MaxReplicas:    schedule.MaxReplicas,
+			Disabled:       schedule.Disabled,
+			NextRunAt:      schedule.NextRunAt,
+			LastRunAt:      schedule.LastRunAt,
+			LastRunMessage: schedule.LastRunMessage,
+			ScaleApiServer: schedule.ScaleApiServer,
+			RunnerNames:    schedule.RunnerNames,
+		})
+	}
+	return res, nil
+}
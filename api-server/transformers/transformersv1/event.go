@@ -12,7 +12,9 @@ import (
 func ToEventSchemas(ctx context.Context, events []*models.Event) ([]*schemasv1.EventSchema, error) {
 	creatorIds := make([]uint, 0, len(events))
 	for _, event := range events {
-		creatorIds = append(creatorIds, event.CreatorId)
+		if event.CreatorId != nil {
+			creatorIds = append(creatorIds, *event.CreatorId)
+		}
 	}
 	users, err := services.UserService.ListByIds(ctx, creatorIds)
 	if err != nil {
@@ -204,10 +206,11 @@ func ToEventSchemas(ctx context.Context, events []*models.Event) ([]*schemasv1.E
 	}
 	eventSchemas := make([]*schemasv1.EventSchema, 0, len(events))
 	for _, event := range events {
-		userUid, ok := userUidsMap[event.CreatorId]
 		var userSchema *schemasv1.UserSchema
-		if ok {
-			userSchema = userSchemasMap[userUid]
+		if event.CreatorId != nil {
+			if userUid, ok := userUidsMap[*event.CreatorId]; ok {
+				userSchema = userSchemasMap[userUid]
+			}
 		}
 		eventSchema := &schemasv1.EventSchema{
 			BaseSchema: schemasv1.BaseSchema{
@@ -0,0 +1,110 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToFederatedDeploymentSchema(ctx context.Context, federatedDeployment *models.FederatedDeployment) (*schemas.FederatedDeploymentSchema, error) {
+	if federatedDeployment == nil {
+		return nil, nil
+	}
+	ss, err := ToFederatedDeploymentSchemas(ctx, []*models.FederatedDeployment{federatedDeployment})
+	if err != nil {
+		return nil, errors.Wrap(err, "ToFederatedDeploymentSchemas")
+	}
+	return ss[0], nil
+}
+
+func ToFederatedDeploymentSchemas(ctx context.Context, federatedDeployments []*models.FederatedDeployment) ([]*schemas.FederatedDeploymentSchema, error) {
+	res := make([]*schemas.FederatedDeploymentSchema, 0, len(federatedDeployments))
+	for _, federatedDeployment := range federatedDeployments {
+		creator, err := services.UserService.GetAssociatedCreator(ctx, federatedDeployment)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated creator")
+		}
+		creatorSchema, err := ToUserSchema(ctx, creator)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserSchema")
+		}
+		targetSchemas := make([]*schemasv1.CreateDeploymentTargetSchema, 0)
+		if federatedDeployment.Targets != nil {
+			for _, target := range *federatedDeployment.Targets {
+				targetSchemas = append(targetSchemas, &schemasv1.CreateDeploymentTargetSchema{
+					DeploymentTargetTypeSchema: schemasv1.DeploymentTargetTypeSchema{
+						Type: target.Type,
+					},
+					BentoRepository: target.BentoRepository,
+					Bento:           target.Bento,
+					CanaryRules:     target.CanaryRules,
+					Config:          target.Config,
+				})
+			}
+		}
+		clusterSchemas := make([]*schemas.FederatedDeploymentClusterSchema, 0)
+		if federatedDeployment.Clusters != nil {
+			for _, c := range *federatedDeployment.Clusters {
+				cluster, err := services.ClusterService.Get(ctx, c.ClusterId)
+				if err != nil {
+					return nil, errors.Wrapf(err, "get cluster %d", c.ClusterId)
+				}
+				clusterSchema, err := ToClusterSchema(ctx, cluster)
+				if err != nil {
+					return nil, errors.Wrap(err, "ToClusterSchema")
+				}
+				clusterSchemas = append(clusterSchemas, &schemas.FederatedDeploymentClusterSchema{
+					Cluster:       clusterSchema,
+					KubeNamespace: c.KubeNamespace,
+					MinReplicas:   c.MinReplicas,
+					MaxReplicas:   c.MaxReplicas,
+					Resources:     c.Resources,
+					IngressHost:   c.IngressHost,
+				})
+			}
+		}
+		labels := make(modelschemas.LabelItemsSchema, 0)
+		if federatedDeployment.Labels != nil {
+			labels = modelschemas.LabelItemsSchema(*federatedDeployment.Labels)
+		}
+		res = append(res, &schemas.FederatedDeploymentSchema{
+			BaseSchema:      ToBaseSchema(federatedDeployment),
+			Creator:         creatorSchema,
+			Name:            federatedDeployment.Name,
+			Description:     federatedDeployment.Description,
+			KubeNamespace:   federatedDeployment.KubeNamespace,
+			Status:          federatedDeployment.Status,
+			StatusSyncingAt: federatedDeployment.StatusSyncingAt,
+			StatusUpdatedAt: federatedDeployment.StatusUpdatedAt,
+			Targets:         targetSchemas,
+			Clusters:        clusterSchemas,
+			Labels:          labels,
+		})
+	}
+	return res, nil
+}
+
+func ToFederatedDeploymentFullSchema(ctx context.Context, federatedDeployment *models.FederatedDeployment) (*schemas.FederatedDeploymentFullSchema, error) {
+	federatedDeploymentSchema, err := ToFederatedDeploymentSchema(ctx, federatedDeployment)
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := services.FederatedDeploymentService.ListChildren(ctx, federatedDeployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "list child deployments")
+	}
+	deploymentSchemas, err := ToDeploymentSchemas(ctx, deployments)
+	if err != nil {
+		return nil, errors.Wrap(err, "ToDeploymentSchemas")
+	}
+	return &schemas.FederatedDeploymentFullSchema{
+		FederatedDeploymentSchema: *federatedDeploymentSchema,
+		Deployments:               deploymentSchemas,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToLabelPolicySchema(ctx context.Context, policy *models.LabelPolicy) (*schemas.LabelPolicySchema, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	creator, err := services.UserService.GetAssociatedCreator(ctx, policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "get associated creator")
+	}
+	creatorSchema, err := ToUserSchema(ctx, creator)
+	if err != nil {
+		return nil, errors.Wrap(err, "ToUserSchema")
+	}
+	requiredKeys := make([]string, 0, len(policy.RequiredKeys))
+	requiredKeys = append(requiredKeys, policy.RequiredKeys...)
+	return &schemas.LabelPolicySchema{
+		BaseSchema:   ToBaseSchema(policy),
+		ResourceType: policy.ResourceType,
+		RequiredKeys: requiredKeys,
+		Creator:      creatorSchema,
+	}, nil
+}
+
+func ToLabelPolicySchemas(ctx context.Context, policies []*models.LabelPolicy) ([]*schemas.LabelPolicySchema, error) {
+	res := make([]*schemas.LabelPolicySchema, 0, len(policies))
+	for _, policy := range policies {
+		policySchema, err := ToLabelPolicySchema(ctx, policy)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, policySchema)
+	}
+	return res, nil
+}
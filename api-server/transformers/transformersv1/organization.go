@@ -41,7 +41,8 @@ func ToOrganizationFullSchema(ctx context.Context, org *models.Organization) (*s
 			return nil, err
 		}
 	} else {
-		config = &org.Config
+		config_ := services.RedactOrganizationConfig(org.Config)
+		config = &config_
 	}
 	return &schemasv1.OrganizationFullSchema{
 		OrganizationSchema: *s,
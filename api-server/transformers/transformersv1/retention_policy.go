@@ -0,0 +1,73 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai-schemas/modelschemas"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToRetentionPolicySchema(ctx context.Context, policy *models.RetentionPolicy) (*schemas.RetentionPolicySchema, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	creator, err := services.UserService.GetAssociatedCreator(ctx, policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "get associated creator")
+	}
+	creatorSchema, err := ToUserSchema(ctx, creator)
+	if err != nil {
+		return nil, errors.Wrap(err, "ToUserSchema")
+	}
+	return &schemas.RetentionPolicySchema{
+		BaseSchema:   ToBaseSchema(policy),
+		ResourceType: policy.ResourceType,
+		KeepLast:     policy.KeepLast,
+		MaxAgeDays:   policy.MaxAgeDays,
+		LastRunAt:    policy.LastRunAt,
+		Creator:      creatorSchema,
+	}, nil
+}
+
+func ToRetentionRunReportSchema(ctx context.Context, result *services.RetentionRunResult) (*schemas.RetentionRunReportSchema, error) {
+	items := make([]*schemas.RetentionRunItemSchema, 0, len(result.Bentos)+len(result.Models)+len(result.TerminalRecords))
+	for _, bento := range result.Bentos {
+		bentoRepository, err := services.BentoRepositoryService.GetAssociatedBentoRepository(ctx, bento)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated bento repository")
+		}
+		items = append(items, &schemas.RetentionRunItemSchema{
+			ResourceType:   modelschemas.ResourceTypeBento,
+			RepositoryName: bentoRepository.Name,
+			Version:        bento.Version,
+			CreatedAt:      bento.CreatedAt,
+		})
+	}
+	for _, model := range result.Models {
+		modelRepository, err := services.ModelRepositoryService.GetAssociatedModelRepository(ctx, model)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated model repository")
+		}
+		items = append(items, &schemas.RetentionRunItemSchema{
+			ResourceType:   modelschemas.ResourceTypeModel,
+			RepositoryName: modelRepository.Name,
+			Version:        model.Version,
+			CreatedAt:      model.CreatedAt,
+		})
+	}
+	for _, terminalRecord := range result.TerminalRecords {
+		items = append(items, &schemas.RetentionRunItemSchema{
+			ResourceType: modelschemas.ResourceTypeTerminalRecord,
+			Uid:          terminalRecord.Uid,
+			CreatedAt:    terminalRecord.CreatedAt,
+		})
+	}
+	return &schemas.RetentionRunReportSchema{
+		DryRun: result.DryRun,
+		Items:  items,
+	}, nil
+}
@@ -0,0 +1,67 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/bentoml/yatai-schemas/schemasv1"
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToSearchResultSchemas(ctx context.Context, results []*services.SearchResult) ([]*schemas.SearchResultSchema, error) {
+	bentos := make([]*models.Bento, 0)
+	models_ := make([]*models.Model, 0)
+	deployments := make([]*models.Deployment, 0)
+	for _, result := range results {
+		switch result.Type {
+		case services.SearchResultTypeBento:
+			bentos = append(bentos, result.Bento)
+		case services.SearchResultTypeModel:
+			models_ = append(models_, result.Model)
+		case services.SearchResultTypeDeployment:
+			deployments = append(deployments, result.Deployment)
+		}
+	}
+	bentoSchemas, err := ToBentoWithRepositorySchemas(ctx, bentos)
+	if err != nil {
+		return nil, err
+	}
+	modelSchemas, err := ToModelWithRepositorySchemas(ctx, models_)
+	if err != nil {
+		return nil, err
+	}
+	deploymentSchemas, err := ToDeploymentSchemas(ctx, deployments)
+	if err != nil {
+		return nil, err
+	}
+	bentoSchemasMap := make(map[string]*schemasv1.BentoWithRepositorySchema, len(bentoSchemas))
+	for _, schema := range bentoSchemas {
+		bentoSchemasMap[schema.Uid] = schema
+	}
+	modelSchemasMap := make(map[string]*schemasv1.ModelWithRepositorySchema, len(modelSchemas))
+	for _, schema := range modelSchemas {
+		modelSchemasMap[schema.Uid] = schema
+	}
+	deploymentSchemasMap := make(map[string]*schemasv1.DeploymentSchema, len(deploymentSchemas))
+	for _, schema := range deploymentSchemas {
+		deploymentSchemasMap[schema.Uid] = schema
+	}
+	res := make([]*schemas.SearchResultSchema, 0, len(results))
+	for _, result := range results {
+		resultSchema := &schemas.SearchResultSchema{
+			Type: string(result.Type),
+			Rank: result.Rank,
+		}
+		switch result.Type {
+		case services.SearchResultTypeBento:
+			resultSchema.Bento = bentoSchemasMap[result.Bento.GetUid()]
+		case services.SearchResultTypeModel:
+			resultSchema.Model = modelSchemasMap[result.Model.GetUid()]
+		case services.SearchResultTypeDeployment:
+			resultSchema.Deployment = deploymentSchemasMap[result.Deployment.GetUid()]
+		}
+		res = append(res, resultSchema)
+	}
+	return res, nil
+}
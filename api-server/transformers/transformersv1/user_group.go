@@ -0,0 +1,164 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToUserGroupSchema(ctx context.Context, userGroup *models.UserGroup) (*schemas.UserGroupSchema, error) {
+	if userGroup == nil {
+		return nil, nil
+	}
+	ss, err := ToUserGroupSchemas(ctx, []*models.UserGroup{userGroup})
+	if err != nil {
+		return nil, errors.Wrap(err, "ToUserGroupSchemas")
+	}
+	return ss[0], nil
+}
+
+func ToUserGroupSchemas(ctx context.Context, userGroups []*models.UserGroup) ([]*schemas.UserGroupSchema, error) {
+	res := make([]*schemas.UserGroupSchema, 0, len(userGroups))
+	for _, userGroup := range userGroups {
+		creator, err := services.UserService.GetAssociatedCreator(ctx, userGroup)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated creator")
+		}
+		creatorSchema, err := ToUserSchema(ctx, creator)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserSchema")
+		}
+		organization, err := services.OrganizationService.GetAssociatedOrganization(ctx, userGroup)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated organization")
+		}
+		organizationSchema, err := ToOrganizationSchema(ctx, organization)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToOrganizationSchema")
+		}
+		users, err := services.UserGroupService.ListUsers(ctx, userGroup.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "list user group users")
+		}
+		userSchemas, err := ToUserSchemas(ctx, users)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserSchemas")
+		}
+		res = append(res, &schemas.UserGroupSchema{
+			BaseSchema:   ToBaseSchema(userGroup),
+			Name:         userGroup.Name,
+			Creator:      creatorSchema,
+			Organization: organizationSchema,
+			Users:        userSchemas,
+		})
+	}
+	return res, nil
+}
+
+func ToOrganizationUserGroupMemberSchema(ctx context.Context, member *models.OrganizationUserGroupMember) (*schemas.OrganizationUserGroupMemberSchema, error) {
+	if member == nil {
+		return nil, nil
+	}
+	ss, err := ToOrganizationUserGroupMemberSchemas(ctx, []*models.OrganizationUserGroupMember{member})
+	if err != nil {
+		return nil, errors.Wrap(err, "ToOrganizationUserGroupMemberSchemas")
+	}
+	return ss[0], nil
+}
+
+func ToOrganizationUserGroupMemberSchemas(ctx context.Context, members []*models.OrganizationUserGroupMember) ([]*schemas.OrganizationUserGroupMemberSchema, error) {
+	res := make([]*schemas.OrganizationUserGroupMemberSchema, 0, len(members))
+	for _, member := range members {
+		creator, err := services.UserService.GetAssociatedCreator(ctx, member)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated creator")
+		}
+		creatorSchema, err := ToUserSchema(ctx, creator)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserSchema")
+		}
+
+		userGroup, err := services.UserGroupService.GetAssociatedUserGroup(ctx, member)
+		if err != nil {
+			return nil, errors.Wrap(err, "get organization user group member associated user group")
+		}
+		userGroupSchema, err := ToUserGroupSchema(ctx, userGroup)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserGroupSchema")
+		}
+
+		org, err := services.OrganizationService.GetAssociatedOrganization(ctx, member)
+		if err != nil {
+			return nil, errors.Wrap(err, "get organization user group member associated organization")
+		}
+		orgSchema, err := ToOrganizationSchema(ctx, org)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToOrganizationSchema")
+		}
+
+		res = append(res, &schemas.OrganizationUserGroupMemberSchema{
+			BaseSchema:   ToBaseSchema(member),
+			Creator:      creatorSchema,
+			UserGroup:    *userGroupSchema,
+			Organization: *orgSchema,
+			Role:         member.Role,
+		})
+	}
+	return res, nil
+}
+
+func ToClusterUserGroupMemberSchema(ctx context.Context, member *models.ClusterUserGroupMember) (*schemas.ClusterUserGroupMemberSchema, error) {
+	if member == nil {
+		return nil, nil
+	}
+	ss, err := ToClusterUserGroupMemberSchemas(ctx, []*models.ClusterUserGroupMember{member})
+	if err != nil {
+		return nil, errors.Wrap(err, "ToClusterUserGroupMemberSchemas")
+	}
+	return ss[0], nil
+}
+
+func ToClusterUserGroupMemberSchemas(ctx context.Context, members []*models.ClusterUserGroupMember) ([]*schemas.ClusterUserGroupMemberSchema, error) {
+	res := make([]*schemas.ClusterUserGroupMemberSchema, 0, len(members))
+	for _, member := range members {
+		creator, err := services.UserService.GetAssociatedCreator(ctx, member)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated creator")
+		}
+		creatorSchema, err := ToUserSchema(ctx, creator)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserSchema")
+		}
+
+		userGroup, err := services.UserGroupService.GetAssociatedUserGroup(ctx, member)
+		if err != nil {
+			return nil, errors.Wrap(err, "get cluster user group member associated user group")
+		}
+		userGroupSchema, err := ToUserGroupSchema(ctx, userGroup)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserGroupSchema")
+		}
+
+		cluster, err := services.ClusterService.GetAssociatedCluster(ctx, member)
+		if err != nil {
+			return nil, errors.Wrap(err, "get cluster user group member associated cluster")
+		}
+		clusterSchema, err := ToClusterSchema(ctx, cluster)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToClusterSchema")
+		}
+
+		res = append(res, &schemas.ClusterUserGroupMemberSchema{
+			BaseSchema: ToBaseSchema(member),
+			Creator:    creatorSchema,
+			UserGroup:  *userGroupSchema,
+			Cluster:    *clusterSchema,
+			Role:       member.Role,
+		})
+	}
+	return res, nil
+}
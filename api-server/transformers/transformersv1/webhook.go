@@ -0,0 +1,98 @@
+package transformersv1
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
+	"github.com/bentoml/yatai/api-server/services"
+)
+
+func ToWebhookSchema(ctx context.Context, webhook *models.Webhook) (*schemas.WebhookSchema, error) {
+	if webhook == nil {
+		return nil, nil
+	}
+	ss, err := ToWebhookSchemas(ctx, []*models.Webhook{webhook})
+	if err != nil {
+		return nil, errors.Wrap(err, "ToWebhookSchemas")
+	}
+	return ss[0], nil
+}
+
+func ToWebhookSchemas(ctx context.Context, webhooks []*models.Webhook) ([]*schemas.WebhookSchema, error) {
+	res := make([]*schemas.WebhookSchema, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		creator, err := services.UserService.GetAssociatedCreator(ctx, webhook)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated creator")
+		}
+		creatorSchema, err := ToUserSchema(ctx, creator)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToUserSchema")
+		}
+		organization, err := services.OrganizationService.GetAssociatedOrganization(ctx, webhook)
+		if err != nil {
+			return nil, errors.Wrap(err, "get associated organization")
+		}
+		organizationSchema, err := ToOrganizationSchema(ctx, organization)
+		if err != nil {
+			return nil, errors.Wrap(err, "ToOrganizationSchema")
+		}
+		eventTypes := make([]string, 0, len(webhook.EventTypes))
+		eventTypes = append(eventTypes, webhook.EventTypes...)
+		res = append(res, &schemas.WebhookSchema{
+			BaseSchema:   ToBaseSchema(webhook),
+			Name:         webhook.Name,
+			Description:  webhook.Description,
+			Url:          webhook.Url,
+			EventTypes:   eventTypes,
+			Disabled:     webhook.Disabled,
+			Creator:      creatorSchema,
+			Organization: organizationSchema,
+		})
+	}
+	return res, nil
+}
+
+func ToWebhookFullSchema(ctx context.Context, webhook *models.Webhook) (*schemas.WebhookFullSchema, error) {
+	webhookSchema, err := ToWebhookSchema(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	return &schemas.WebhookFullSchema{
+		WebhookSchema: *webhookSchema,
+		Secret:        webhook.Secret,
+	}, nil
+}
+
+func ToWebhookDeliverySchema(ctx context.Context, delivery *models.WebhookDelivery) (*schemas.WebhookDeliverySchema, error) {
+	if delivery == nil {
+		return nil, nil
+	}
+	ss, err := ToWebhookDeliverySchemas(ctx, []*models.WebhookDelivery{delivery})
+	if err != nil {
+		return nil, errors.Wrap(err, "ToWebhookDeliverySchemas")
+	}
+	return ss[0], nil
+}
+
+func ToWebhookDeliverySchemas(ctx context.Context, deliveries []*models.WebhookDelivery) ([]*schemas.WebhookDeliverySchema, error) {
+	res := make([]*schemas.WebhookDeliverySchema, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		res = append(res, &schemas.WebhookDeliverySchema{
+			BaseSchema:         ToBaseSchema(delivery),
+			EventType:          delivery.EventType,
+			Payload:            delivery.Payload,
+			Status:             string(delivery.Status),
+			Attempts:           delivery.Attempts,
+			ResponseStatusCode: delivery.ResponseStatusCode,
+			ResponseBody:       delivery.ResponseBody,
+			Error:              delivery.Error,
+			NextRetryAt:        delivery.NextRetryAt,
+			DeliveredAt:        delivery.DeliveredAt,
+		})
+	}
+	return res, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/bentoml/yatai-schemas/schemasv1"
 	"github.com/bentoml/yatai/api-server/models"
+	"github.com/bentoml/yatai/api-server/schemas"
 )
 
 func ToYataiComponentSchema(ctx context.Context, yataiComponent *models.YataiComponent) (*schemasv1.YataiComponentSchema, error) {
@@ -53,3 +54,19 @@ func ToYataiComponentSchemas(ctx context.Context, yataiComponents []*models.Yata
 	}
 	return res, nil
 }
+
+func ToYataiComponentHealthSchemas(ctx context.Context, yataiComponents []*models.YataiComponent) ([]*schemas.YataiComponentHealthSchema, error) {
+	ss, err := ToYataiComponentSchemas(ctx, yataiComponents)
+	if err != nil {
+		return nil, errors.Wrap(err, "ToYataiComponentSchemas")
+	}
+	res := make([]*schemas.YataiComponentHealthSchema, 0, len(yataiComponents))
+	for idx, yataiComponent := range yataiComponents {
+		res = append(res, &schemas.YataiComponentHealthSchema{
+			YataiComponentSchema:  ss[idx],
+			HealthStatus:          string(yataiComponent.HealthStatus),
+			HealthStatusChangedAt: yataiComponent.HealthStatusChangedAt,
+		})
+	}
+	return res, nil
+}
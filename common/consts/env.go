@@ -40,4 +40,10 @@ const (
 	EnvReadHeaderTimeout = "READ_HEADER_TIMEOUT"
 
 	EnvTransmissionStrategy = "TRANSMISSION_STRATEGY"
+
+	EnvOIDCIssuer   = "OIDC_ISSUER"
+	EnvOIDCClientId = "OIDC_CLIENT_ID"
+	// nolint:gosec
+	EnvOIDCClientSecret = "OIDC_CLIENT_SECRET"
+	EnvOIDCRedirectURL  = "OIDC_REDIRECT_URL"
 )
@@ -0,0 +1,263 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// the prefix of the encrypted values, the values without it are treated as plaintext
+const encryptedPrefix = "enc:v1:"
+
+const dataKeySize = 32
+
+// the number of the unwrapped data keys kept by an encrypter
+const dataKeyCacheSize = 4096
+
+// KeyProvider wraps the data keys with the key encryption keys it manages,
+// every value is encrypted with its own data key and the wrapped data key is stored with the value
+type KeyProvider interface {
+	// PrimaryKeyId returns the id of the key which wraps the new data keys
+	PrimaryKeyId() string
+	WrapKey(ctx context.Context, keyId string, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, keyId string, wrappedDataKey []byte) ([]byte, error)
+}
+
+type Encrypter struct {
+	provider KeyProvider
+	dataKeys dataKeyCache
+}
+
+// dataKeyCache keeps the unwrapped data keys by their wrapped data keys, the values are decrypted on every load of their records,
+// so without it every load would be a round trip to the KMS. The oldest data keys are evicted when it is full
+type dataKeyCache struct {
+	mu    sync.Mutex
+	keys  map[string][]byte
+	order []string
+}
+
+func getDataKeyCacheKey(keyId string, wrappedDataKey []byte) string {
+	return keyId + ":" + string(wrappedDataKey)
+}
+
+func (c *dataKeyCache) get(keyId string, wrappedDataKey []byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dataKey, ok := c.keys[getDataKeyCacheKey(keyId, wrappedDataKey)]
+	return dataKey, ok
+}
+
+func (c *dataKeyCache) add(keyId string, wrappedDataKey, dataKey []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keys == nil {
+		c.keys = make(map[string][]byte)
+	}
+	key := getDataKeyCacheKey(keyId, wrappedDataKey)
+	if _, ok := c.keys[key]; ok {
+		return
+	}
+	if len(c.order) >= dataKeyCacheSize {
+		delete(c.keys, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.keys[key] = dataKey
+	c.order = append(c.order, key)
+}
+
+func NewEncrypter(provider KeyProvider) *Encrypter {
+	return &Encrypter{provider: provider}
+}
+
+func (e *Encrypter) PrimaryKeyId() string {
+	return e.provider.PrimaryKeyId()
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// seal encrypts the plaintext with AES-GCM, the random nonce is prepended to the ciphertext
+func seal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "create aes cipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "create gcm")
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "generate nonce")
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func unseal(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "create aes cipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "create gcm")
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("the ciphertext is too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// Encrypt returns enc:v1:<key id>:<wrapped data key>:<nonce and ciphertext>, all parts are base64 encoded.
+// The empty values are returned as they are, every other value is encrypted even if it looks encrypted,
+// the plaintext is never trusted to be a value encrypted by yatai
+func (e *Encrypter) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	if plaintext == "" {
+		return plaintext, nil
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", errors.Wrap(err, "generate data key")
+	}
+	keyId := e.provider.PrimaryKeyId()
+	wrappedDataKey, err := e.provider.WrapKey(ctx, keyId, dataKey)
+	if err != nil {
+		return "", errors.Wrapf(err, "wrap data key with key %s", keyId)
+	}
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	e.dataKeys.add(keyId, wrappedDataKey, dataKey)
+	return encryptedPrefix + strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString([]byte(keyId)),
+		base64.RawURLEncoding.EncodeToString(wrappedDataKey),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+	}, ":"), nil
+}
+
+func parse(value string) (keyId string, wrappedDataKey, ciphertext []byte, err error) {
+	pieces := strings.Split(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if len(pieces) != 3 {
+		err = errors.New("malformed encrypted value")
+		return
+	}
+	keyId_, err := base64.RawURLEncoding.DecodeString(pieces[0])
+	if err != nil {
+		err = errors.Wrap(err, "decode key id")
+		return
+	}
+	keyId = string(keyId_)
+	wrappedDataKey, err = base64.RawURLEncoding.DecodeString(pieces[1])
+	if err != nil {
+		err = errors.Wrap(err, "decode wrapped data key")
+		return
+	}
+	ciphertext, err = base64.RawURLEncoding.DecodeString(pieces[2])
+	if err != nil {
+		err = errors.Wrap(err, "decode ciphertext")
+		return
+	}
+	return
+}
+
+// Decrypt returns the plaintext values as they are, so the values written before the encryption is enabled are still readable
+func (e *Encrypter) Decrypt(ctx context.Context, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	keyId, wrappedDataKey, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	dataKey, ok := e.dataKeys.get(keyId, wrappedDataKey)
+	if !ok {
+		dataKey, err = e.provider.UnwrapKey(ctx, keyId, wrappedDataKey)
+		if err != nil {
+			return "", errors.Wrapf(err, "unwrap data key with key %s", keyId)
+		}
+		e.dataKeys.add(keyId, wrappedDataKey, dataKey)
+	}
+	plaintext, err := unseal(dataKey, ciphertext)
+	if err != nil {
+		return "", errors.Wrap(err, "decrypt value")
+	}
+	return string(plaintext), nil
+}
+
+// NeedsReencryption returns true if the value is plaintext or it is not encrypted with the primary key
+func (e *Encrypter) NeedsReencryption(value string) bool {
+	if value == "" {
+		return false
+	}
+	if !IsEncrypted(value) {
+		return true
+	}
+	keyId, _, _, err := parse(value)
+	if err != nil {
+		return true
+	}
+	return keyId != e.provider.PrimaryKeyId()
+}
+
+// Reencrypt encrypts the value with the primary key
+func (e *Encrypter) Reencrypt(ctx context.Context, value string) (string, error) {
+	if !e.NeedsReencryption(value) {
+		return value, nil
+	}
+	plaintext, err := e.Decrypt(ctx, value)
+	if err != nil {
+		return "", err
+	}
+	return e.Encrypt(ctx, plaintext)
+}
+
+var (
+	defaultEncrypter   *Encrypter
+	defaultEncrypterMu sync.RWMutex
+)
+
+// SetDefault sets the encrypter of EncryptString and DecryptString, the values are stored as plaintext if it is not set
+func SetDefault(e *Encrypter) {
+	defaultEncrypterMu.Lock()
+	defer defaultEncrypterMu.Unlock()
+	defaultEncrypter = e
+}
+
+func Default() *Encrypter {
+	defaultEncrypterMu.RLock()
+	defer defaultEncrypterMu.RUnlock()
+	return defaultEncrypter
+}
+
+func EncryptString(ctx context.Context, plaintext string) (string, error) {
+	e := Default()
+	if e == nil {
+		// the value would be decrypted when it is read
+		if IsEncrypted(plaintext) {
+			return "", errors.Errorf("the value should not start with %s", encryptedPrefix)
+		}
+		return plaintext, nil
+	}
+	return e.Encrypt(ctx, plaintext)
+}
+
+func DecryptString(ctx context.Context, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	e := Default()
+	if e == nil {
+		return "", errors.New("the value is encrypted but the secret encryption is not configured")
+	}
+	return e.Decrypt(ctx, value)
+}
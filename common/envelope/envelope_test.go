@@ -0,0 +1,279 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestKeyProvider(primaryKeyId string, keyIds ...string) *LocalKeyProvider {
+	provider := &LocalKeyProvider{
+		primaryKeyId: primaryKeyId,
+		keys:         make(map[string][]byte, len(keyIds)),
+	}
+	for i, keyId := range keyIds {
+		provider.keys[keyId] = bytes.Repeat([]byte{byte(i + 1)}, dataKeySize)
+	}
+	return provider
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	e := NewEncrypter(newTestKeyProvider("key-1", "key-1"))
+
+	plaintext := "apiVersion: v1\nkind: Config"
+	encrypted, err := e.Encrypt(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if !IsEncrypted(encrypted) || strings.Contains(encrypted, plaintext) {
+		t.Fatalf("the value is not encrypted: %q", encrypted)
+	}
+	decrypted, err := e.Decrypt(ctx, encrypted)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if decrypted != plaintext {
+		t.Fatalf("the value is decrypted as %q, expected %q", decrypted, plaintext)
+	}
+
+	another, err := e.Encrypt(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if another == encrypted {
+		t.Fatal("the same plaintext is encrypted to the same value, the data key or the nonce is reused")
+	}
+
+	v, err := e.Encrypt(ctx, "")
+	if err != nil || v != "" {
+		t.Fatalf("the empty value is encrypted as %q, %v", v, err)
+	}
+
+	// a plaintext which looks encrypted is encrypted too, it is decrypted as it is
+	v, err = e.Encrypt(ctx, encrypted)
+	if err != nil {
+		t.Fatalf("encrypt the encrypted value: %v", err)
+	}
+	if v == encrypted {
+		t.Fatal("the value which looks encrypted is not encrypted")
+	}
+	decrypted, err = e.Decrypt(ctx, v)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if decrypted != encrypted {
+		t.Fatalf("the value is decrypted as %q, expected %q", decrypted, encrypted)
+	}
+
+	v, err = e.Decrypt(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("decrypt the plaintext: %v", err)
+	}
+	if v != plaintext {
+		t.Fatalf("the plaintext is decrypted as %q", v)
+	}
+}
+
+func TestDecryptRejectsInvalidValues(t *testing.T) {
+	ctx := context.Background()
+	e := NewEncrypter(newTestKeyProvider("key-1", "key-1"))
+	encrypted, err := e.Encrypt(ctx, "secret")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	pieces := strings.Split(strings.TrimPrefix(encrypted, encryptedPrefix), ":")
+	ciphertext, _ := base64.RawURLEncoding.DecodeString(pieces[2])
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	tampered := encryptedPrefix + strings.Join([]string{pieces[0], pieces[1], base64.RawURLEncoding.EncodeToString(ciphertext)}, ":")
+	unknownKey := encryptedPrefix + strings.Join([]string{base64.RawURLEncoding.EncodeToString([]byte("key-2")), pieces[1], pieces[2]}, ":")
+
+	cases := []struct {
+		name  string
+		value string
+	}{
+		{"tampered ciphertext", tampered},
+		{"unknown key", unknownKey},
+		{"missing pieces", encryptedPrefix + pieces[0]},
+		{"invalid base64", encryptedPrefix + "!:!:!"},
+	}
+	for _, c := range cases {
+		if v, err := e.Decrypt(ctx, c.value); err == nil {
+			t.Fatalf("%s: the value is decrypted as %q, expected an error", c.name, v)
+		}
+	}
+}
+
+func TestNeedsReencryption(t *testing.T) {
+	ctx := context.Background()
+	old := NewEncrypter(newTestKeyProvider("key-1", "key-1"))
+	encryptedWithOldKey, err := old.Encrypt(ctx, "secret")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	// the key is rotated, the old key is kept to decrypt the values it has encrypted
+	e := NewEncrypter(newTestKeyProvider("key-2", "key-1", "key-2"))
+	encryptedWithNewKey, err := e.Encrypt(ctx, "secret")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		value    string
+		expected bool
+	}{
+		{"empty", "", false},
+		{"plaintext", "secret", true},
+		{"old key", encryptedWithOldKey, true},
+		{"primary key", encryptedWithNewKey, false},
+		{"malformed", encryptedPrefix + "malformed", true},
+	}
+	for _, c := range cases {
+		if actual := e.NeedsReencryption(c.value); actual != c.expected {
+			t.Fatalf("%s: NeedsReencryption is %v, expected %v", c.name, actual, c.expected)
+		}
+	}
+
+	reencrypted, err := e.Reencrypt(ctx, encryptedWithOldKey)
+	if err != nil {
+		t.Fatalf("reencrypt: %v", err)
+	}
+	if e.NeedsReencryption(reencrypted) {
+		t.Fatal("the reencrypted value is not encrypted with the primary key")
+	}
+	decrypted, err := e.Decrypt(ctx, reencrypted)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if decrypted != "secret" {
+		t.Fatalf("the reencrypted value is decrypted as %q", decrypted)
+	}
+
+	// the api servers which have not loaded the new key could not decrypt the reencrypted value
+	if _, err = old.Decrypt(ctx, reencrypted); err == nil {
+		t.Fatal("the value of the new key is decrypted without the new key")
+	}
+}
+
+type countingKeyProvider struct {
+	*LocalKeyProvider
+	unwraps int
+}
+
+func (p *countingKeyProvider) UnwrapKey(ctx context.Context, keyId string, wrappedDataKey []byte) ([]byte, error) {
+	p.unwraps++
+	return p.LocalKeyProvider.UnwrapKey(ctx, keyId, wrappedDataKey)
+}
+
+func TestDecryptCachesDataKeys(t *testing.T) {
+	ctx := context.Background()
+	provider := &countingKeyProvider{LocalKeyProvider: newTestKeyProvider("key-1", "key-1")}
+	encrypted, err := NewEncrypter(provider).Encrypt(ctx, "secret")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	e := NewEncrypter(provider)
+	for i := 0; i < 3; i++ {
+		decrypted, err := e.Decrypt(ctx, encrypted)
+		if err != nil {
+			t.Fatalf("decrypt: %v", err)
+		}
+		if decrypted != "secret" {
+			t.Fatalf("the value is decrypted as %q", decrypted)
+		}
+	}
+	if provider.unwraps != 1 {
+		t.Fatalf("the data key is unwrapped %d times, expected 1", provider.unwraps)
+	}
+
+	// the data keys of the values it encrypts are cached too
+	another, err := e.Encrypt(ctx, "another secret")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if _, err = e.Decrypt(ctx, another); err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if provider.unwraps != 1 {
+		t.Fatalf("the data key is unwrapped %d times, expected 1", provider.unwraps)
+	}
+}
+
+func TestDataKeyCacheEvictsTheOldest(t *testing.T) {
+	c := &dataKeyCache{}
+	for i := 0; i <= dataKeyCacheSize; i++ {
+		c.add("key-1", []byte{byte(i), byte(i >> 8)}, []byte{byte(i)})
+	}
+	if _, ok := c.get("key-1", []byte{0, 0}); ok {
+		t.Fatal("the oldest data key is not evicted")
+	}
+	if _, ok := c.get("key-1", []byte{1, 0}); !ok {
+		t.Fatal("the second oldest data key is evicted")
+	}
+	if len(c.keys) != dataKeyCacheSize || len(c.order) != dataKeyCacheSize {
+		t.Fatalf("the cache has %d keys in %d orders, expected %d", len(c.keys), len(c.order), dataKeyCacheSize)
+	}
+}
+
+func TestEncryptStringRejectsEncryptedLookingPlaintext(t *testing.T) {
+	SetDefault(nil)
+	if v, err := EncryptString(context.Background(), encryptedPrefix+"a:b:c"); err == nil {
+		t.Fatalf("the value is stored as %q, expected an error", v)
+	}
+	if v, err := EncryptString(context.Background(), "secret"); err != nil || v != "secret" {
+		t.Fatalf("the plaintext is stored as %q, %v", v, err)
+	}
+}
+
+func TestGenerateAndPromoteLocalKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	firstKeyId, err := GenerateLocalKey(path)
+	if err != nil {
+		t.Fatalf("generate the first key: %v", err)
+	}
+	provider, err := NewLocalKeyProvider(path)
+	if err != nil {
+		t.Fatalf("load the key file: %v", err)
+	}
+	if provider.PrimaryKeyId() != firstKeyId {
+		t.Fatalf("the primary key is %s, expected the first key %s", provider.PrimaryKeyId(), firstKeyId)
+	}
+
+	// the key ids are in seconds
+	time.Sleep(time.Second)
+	secondKeyId, err := GenerateLocalKey(path)
+	if err != nil {
+		t.Fatalf("generate the second key: %v", err)
+	}
+	provider, err = NewLocalKeyProvider(path)
+	if err != nil {
+		t.Fatalf("load the key file: %v", err)
+	}
+	if provider.PrimaryKeyId() != firstKeyId {
+		t.Fatalf("the primary key is %s, expected the new key %s not to be promoted", provider.PrimaryKeyId(), secondKeyId)
+	}
+	if _, ok := provider.keys[secondKeyId]; !ok {
+		t.Fatalf("the new key %s is not loaded", secondKeyId)
+	}
+
+	if err = PromoteLocalKey(path, "unknown"); err == nil {
+		t.Fatal("an unknown key is promoted")
+	}
+	if err = PromoteLocalKey(path, secondKeyId); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	provider, err = NewLocalKeyProvider(path)
+	if err != nil {
+		t.Fatalf("load the key file: %v", err)
+	}
+	if provider.PrimaryKeyId() != secondKeyId {
+		t.Fatalf("the primary key is %s, expected the promoted key %s", provider.PrimaryKeyId(), secondKeyId)
+	}
+}
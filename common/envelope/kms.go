@@ -0,0 +1,69 @@
+package envelope
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// KMSClient is implemented by the clients of the external key management services,
+// the key encryption keys never leave the KMS, only the data keys are sent to it
+type KMSClient interface {
+	Encrypt(ctx context.Context, keyId string, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, keyId string, ciphertext []byte) ([]byte, error)
+}
+
+// KMSClientFactory creates a KMS client from the options of the encryption config
+type KMSClientFactory func(options map[string]string) (KMSClient, error)
+
+var (
+	kmsClientFactories   = make(map[string]KMSClientFactory)
+	kmsClientFactoriesMu sync.RWMutex
+)
+
+// RegisterKMSClient makes a KMS client available by name in the encryption config
+func RegisterKMSClient(name string, factory KMSClientFactory) {
+	kmsClientFactoriesMu.Lock()
+	defer kmsClientFactoriesMu.Unlock()
+	kmsClientFactories[name] = factory
+}
+
+// KMSKeyProvider wraps the data keys with a key of an external KMS,
+// the key is rotated by changing the key id, the old key ids still unwrap the data keys they have wrapped
+type KMSKeyProvider struct {
+	client KMSClient
+	keyId  string
+}
+
+func NewKMSKeyProvider(name, keyId string, options map[string]string) (*KMSKeyProvider, error) {
+	kmsClientFactoriesMu.RLock()
+	factory, ok := kmsClientFactories[name]
+	kmsClientFactoriesMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unknown kms %q", name)
+	}
+	if keyId == "" {
+		return nil, errors.New("the kms key id is empty")
+	}
+	client, err := factory(options)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create kms %s client", name)
+	}
+	return &KMSKeyProvider{
+		client: client,
+		keyId:  keyId,
+	}, nil
+}
+
+func (p *KMSKeyProvider) PrimaryKeyId() string {
+	return p.keyId
+}
+
+func (p *KMSKeyProvider) WrapKey(ctx context.Context, keyId string, dataKey []byte) ([]byte, error) {
+	return p.client.Encrypt(ctx, keyId, dataKey)
+}
+
+func (p *KMSKeyProvider) UnwrapKey(ctx context.Context, keyId string, wrappedDataKey []byte) ([]byte, error) {
+	return p.client.Decrypt(ctx, keyId, wrappedDataKey)
+}
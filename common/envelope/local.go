@@ -0,0 +1,170 @@
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type localKey struct {
+	Id        string    `json:"id"`
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type localKeyFile struct {
+	PrimaryKeyId string      `json:"primary_key_id"`
+	Keys         []*localKey `json:"keys"`
+}
+
+// LocalKeyProvider wraps the data keys with the AES-256-GCM keys of a local key file,
+// the old keys are kept in the file after rotation to unwrap the data keys they have wrapped
+type LocalKeyProvider struct {
+	primaryKeyId string
+	keys         map[string][]byte
+}
+
+func readLocalKeyFile(path string) (*localKeyFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read key file %s", path)
+	}
+	var keyFile localKeyFile
+	if err = json.Unmarshal(content, &keyFile); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal key file %s", path)
+	}
+	return &keyFile, nil
+}
+
+func writeLocalKeyFile(path string, keyFile *localKeyFile) error {
+	content, err := json.MarshalIndent(keyFile, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal key file")
+	}
+	// write to a temporary file then rename it, the key file is never left half written
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".yatai-key-*")
+	if err != nil {
+		return errors.Wrap(err, "create temporary key file")
+	}
+	defer os.Remove(tmpFile.Name())
+	if err = tmpFile.Chmod(0600); err != nil {
+		tmpFile.Close()
+		return errors.Wrap(err, "chmod temporary key file")
+	}
+	if _, err = tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return errors.Wrap(err, "write temporary key file")
+	}
+	if err = tmpFile.Close(); err != nil {
+		return errors.Wrap(err, "close temporary key file")
+	}
+	return errors.Wrapf(os.Rename(tmpFile.Name(), path), "rename temporary key file to %s", path)
+}
+
+func NewLocalKeyProvider(path string) (*LocalKeyProvider, error) {
+	keyFile, err := readLocalKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	provider := &LocalKeyProvider{
+		primaryKeyId: keyFile.PrimaryKeyId,
+		keys:         make(map[string][]byte, len(keyFile.Keys)),
+	}
+	for _, key := range keyFile.Keys {
+		key_, err := base64.StdEncoding.DecodeString(key.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decode key %s", key.Id)
+		}
+		if len(key_) != dataKeySize {
+			return nil, errors.Errorf("the size of key %s should be %d bytes", key.Id, dataKeySize)
+		}
+		provider.keys[key.Id] = key_
+	}
+	if _, ok := provider.keys[provider.primaryKeyId]; !ok {
+		return nil, errors.Errorf("the primary key %s is not found in key file %s", provider.primaryKeyId, path)
+	}
+	return provider, nil
+}
+
+// GenerateLocalKey adds a new key to the key file, the key file is created with the key as its primary key if it does not exist.
+// The key is not made the primary key of an existing key file, the api servers which have not loaded it could not decrypt the values it encrypts,
+// promote it with PromoteLocalKey after the key file is rolled out to every api server
+func GenerateLocalKey(path string) (string, error) {
+	keyFile := &localKeyFile{}
+	if _, err := os.Stat(path); err == nil {
+		keyFile, err = readLocalKeyFile(path)
+		if err != nil {
+			return "", err
+		}
+	} else if !os.IsNotExist(err) {
+		return "", errors.Wrapf(err, "stat key file %s", path)
+	}
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", errors.Wrap(err, "generate key")
+	}
+	now := time.Now().UTC()
+	keyId := fmt.Sprintf("local-%s", now.Format("20060102150405"))
+	for _, key_ := range keyFile.Keys {
+		if key_.Id == keyId {
+			return "", errors.Errorf("key %s already exists, try again later", keyId)
+		}
+	}
+	keyFile.Keys = append(keyFile.Keys, &localKey{
+		Id:        keyId,
+		Key:       base64.StdEncoding.EncodeToString(key),
+		CreatedAt: now,
+	})
+	if len(keyFile.Keys) == 1 {
+		keyFile.PrimaryKeyId = keyId
+	}
+	return keyId, writeLocalKeyFile(path, keyFile)
+}
+
+// PromoteLocalKey makes the key of the key file the primary key, the new values are encrypted with it
+func PromoteLocalKey(path, keyId string) error {
+	keyFile, err := readLocalKeyFile(path)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, key := range keyFile.Keys {
+		if key.Id == keyId {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf("key %s is not found in key file %s", keyId, path)
+	}
+	keyFile.PrimaryKeyId = keyId
+	return writeLocalKeyFile(path, keyFile)
+}
+
+func (p *LocalKeyProvider) PrimaryKeyId() string {
+	return p.primaryKeyId
+}
+
+func (p *LocalKeyProvider) WrapKey(ctx context.Context, keyId string, dataKey []byte) ([]byte, error) {
+	key, ok := p.keys[keyId]
+	if !ok {
+		return nil, errors.Errorf("key %s is not found", keyId)
+	}
+	return seal(key, dataKey)
+}
+
+func (p *LocalKeyProvider) UnwrapKey(ctx context.Context, keyId string, wrappedDataKey []byte) ([]byte, error) {
+	key, ok := p.keys[keyId]
+	if !ok {
+		return nil, errors.Errorf("key %s is not found", keyId)
+	}
+	return unseal(key, wrappedDataKey)
+}
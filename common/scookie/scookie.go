@@ -29,3 +29,31 @@ func DeleteUsernameFromCookie(ctx *gin.Context) error {
 	session.Delete(UserNameKey)
 	return session.Save()
 }
+
+const (
+	oidcStateKey    = "oidc_state"
+	oidcNonceKey    = "oidc_nonce"
+	oidcRedirectKey = "oidc_redirect"
+)
+
+// SetOIDCAuthRequestToCookie keeps the state, the nonce and the redirect uri of the pending oidc login
+func SetOIDCAuthRequestToCookie(ctx *gin.Context, state, nonce, redirectUri string) error {
+	session := sessions.Default(ctx)
+	session.Set(oidcStateKey, state)
+	session.Set(oidcNonceKey, nonce)
+	session.Set(oidcRedirectKey, redirectUri)
+	return session.Save()
+}
+
+// PopOIDCAuthRequestFromCookie returns the pending oidc login and removes it from the session, so it can be used only once
+func PopOIDCAuthRequestFromCookie(ctx *gin.Context) (state, nonce, redirectUri string, err error) {
+	session := sessions.Default(ctx)
+	state, _ = session.Get(oidcStateKey).(string)
+	nonce, _ = session.Get(oidcNonceKey).(string)
+	redirectUri, _ = session.Get(oidcRedirectKey).(string)
+	session.Delete(oidcStateKey)
+	session.Delete(oidcNonceKey)
+	session.Delete(oidcRedirectKey)
+	err = session.Save()
+	return
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin starts a client span for every gorm query, the queries should be made with the context of the parent span
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		ctx, span := StartSpan(db.Statement.Context, fmt.Sprintf("gorm.%s", operation),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemPostgreSQL,
+				semconv.DBOperationKey.String(operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (GormPlugin) after(db *gorm.DB) {
+	span_, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := span_.(trace.Span)
+	if !ok {
+		return
+	}
+	span.SetAttributes(semconv.DBStatementKey.String(db.Statement.SQL.String()))
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBSQLTableKey.String(db.Statement.Table))
+	}
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	EndSpan(span, err)
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register("tracing:before_create", p.before("create")); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("tracing:after_create", p.after); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("tracing:before_query", p.before("query")); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register("tracing:after_query", p.after); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("tracing:before_update", p.before("update")); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("tracing:after_update", p.after); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("delete")); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register("tracing:after_delete", p.after); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("tracing:before_row", p.before("row")); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register("tracing:after_row", p.after); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("raw")); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register("tracing:after_raw", p.after)
+}
@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GinMiddleware starts a server span for every request, the span is the parent of the spans started with the gin context
+func GinMiddleware(c *gin.Context) {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	ctx := ExtractHTTPHeaders(c.Request.Context(), c.Request.Header)
+	ctx, span := tracer().Start(ctx, fmt.Sprintf("%s %s", c.Request.Method, route),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(c.Request.Method),
+			semconv.HTTPRouteKey.String(route),
+			semconv.HTTPTargetKey.String(c.Request.URL.Path),
+			semconv.HTTPClientIPKey.String(c.ClientIP()),
+		),
+	)
+	defer span.End()
+
+	c.Request = c.Request.WithContext(ctx)
+	SetSpan(c, span)
+
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+	if len(c.Errors) > 0 {
+		span.SetAttributes(attribute.String("gin.errors", c.Errors.String()))
+	}
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracer().Start(ContextWithSpan(req.Context()), fmt.Sprintf("HTTP %s", req.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPURLKey.String(req.URL.Redacted()),
+			semconv.NetPeerNameKey.String(req.URL.Hostname()),
+		),
+	)
+	// the request should not be modified by the round tripper
+	req = req.Clone(ctx)
+	InjectHTTPHeaders(ctx, req.Header)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		EndSpan(span, err)
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	span.End()
+	return resp, nil
+}
+
+// WrapTransport starts a client span for every request sent through base and propagates the trace context to the server
+func WrapTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+type ExporterProtocol string
+
+const (
+	ExporterProtocolGRPC ExporterProtocol = "grpc"
+	ExporterProtocolHTTP ExporterProtocol = "http"
+)
+
+type InitOption struct {
+	ServiceName    string
+	ServiceVersion string
+	Protocol       ExporterProtocol
+	// the OTEL_EXPORTER_OTLP_ENDPOINT environment variable is used if Endpoint is empty
+	Endpoint    string
+	Insecure    bool
+	Headers     map[string]string
+	SampleRatio float64
+}
+
+func newExporter(ctx context.Context, opt InitOption) (*otlptrace.Exporter, error) {
+	switch opt.Protocol {
+	case ExporterProtocolHTTP:
+		options := []otlptracehttp.Option{otlptracehttp.WithHeaders(opt.Headers)}
+		if opt.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(opt.Endpoint))
+		}
+		if opt.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	case ExporterProtocolGRPC, "":
+		options := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(opt.Headers)}
+		if opt.Endpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(opt.Endpoint))
+		}
+		if opt.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, options...)
+	default:
+		return nil, errors.Errorf("unknown otlp exporter protocol %q", opt.Protocol)
+	}
+}
+
+// Init sets the global tracer provider which exports the spans through otlp,
+// the returned function flushes the pending spans and should be called before exiting
+func Init(ctx context.Context, opt InitOption) (func(context.Context) error, error) {
+	exporter, err := newExporter(ctx, opt)
+	if err != nil {
+		return nil, errors.Wrap(err, "create otlp trace exporter")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(opt.ServiceName),
+		semconv.ServiceVersionKey.String(opt.ServiceVersion),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "create trace resource")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opt.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
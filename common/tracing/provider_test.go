@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// newCollector starts an in-process otlp/http collector which sends every received export request to the channel
+func newCollector(t *testing.T) (*httptest.Server, <-chan *coltracepb.ExportTraceServiceRequest) {
+	requests := make(chan *coltracepb.ExportTraceServiceRequest, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req := &coltracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- req
+		resp, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func getStringAttribute(attrs []*commonpb.KeyValue, key string) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value.GetStringValue(), true
+		}
+	}
+	return "", false
+}
+
+func TestInitExportsSpansOverHTTP(t *testing.T) {
+	server, requests := newCollector(t)
+
+	ctx := context.Background()
+	shutdown, err := Init(ctx, InitOption{
+		ServiceName:    "yatai-test",
+		ServiceVersion: "v0.0.1",
+		Protocol:       ExporterProtocolHTTP,
+		Endpoint:       strings.TrimPrefix(server.URL, "http://"),
+		Insecure:       true,
+		SampleRatio:    1,
+	})
+	if err != nil {
+		t.Fatalf("init tracing: %v", err)
+	}
+
+	_, span := StartSpan(ctx, "test operation")
+	span.SetAttributes(attribute.String("deployment.name", "my-deployment"))
+	EndSpan(span, nil)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := shutdown(shutdownCtx); err != nil {
+		t.Fatalf("shutdown tracing: %v", err)
+	}
+
+	var req *coltracepb.ExportTraceServiceRequest
+	select {
+	case req = <-requests:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no spans are received by the collector")
+	}
+
+	if len(req.ResourceSpans) != 1 {
+		t.Fatalf("%d resource spans are received, expected 1", len(req.ResourceSpans))
+	}
+	resourceSpans := req.ResourceSpans[0]
+	if v, _ := getStringAttribute(resourceSpans.Resource.Attributes, "service.name"); v != "yatai-test" {
+		t.Fatalf("service.name is %q, expected %q", v, "yatai-test")
+	}
+	if v, _ := getStringAttribute(resourceSpans.Resource.Attributes, "service.version"); v != "v0.0.1" {
+		t.Fatalf("service.version is %q, expected %q", v, "v0.0.1")
+	}
+
+	var spanNames []string
+	for _, scopeSpans := range resourceSpans.ScopeSpans {
+		for _, s := range scopeSpans.Spans {
+			spanNames = append(spanNames, s.Name)
+			if s.Name != "test operation" {
+				continue
+			}
+			if v, _ := getStringAttribute(s.Attributes, "deployment.name"); v != "my-deployment" {
+				t.Fatalf("deployment.name is %q, expected %q", v, "my-deployment")
+			}
+			if _, ok := getStringAttribute(s.Attributes, "code.filepath"); !ok {
+				t.Fatal("the span has no code.filepath attribute")
+			}
+			return
+		}
+	}
+	t.Fatalf("the span is not received, the received spans are %v", spanNames)
+}
+
+func TestInitRejectsUnknownProtocol(t *testing.T) {
+	_, err := Init(context.Background(), InitOption{
+		Protocol: "thrift",
+	})
+	if err == nil {
+		t.Fatal("the unknown protocol is accepted")
+	}
+}
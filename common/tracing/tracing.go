@@ -3,74 +3,89 @@ package tracing
 import (
 	"context"
 	"net/http"
-	"runtime"
 
 	"github.com/gin-gonic/gin"
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/ext"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/bentoml/yatai/common/consts"
 	"github.com/bentoml/yatai/common/utils"
 	"github.com/bentoml/yatai/common/yataicontext"
 )
 
-func GetSpan(ctx context.Context) opentracing.Span {
+const instrumentationName = "github.com/bentoml/yatai"
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// GetSpan returns the current span of the context,
+// the gin contexts do not carry the otel context values, so their spans are stored by SetSpan
+func GetSpan(ctx context.Context) trace.Span {
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		return span
+	}
 	sp_ := ctx.Value(consts.TracingContextKey)
 	if sp_ == nil {
 		return nil
 	}
-	if sp, ok := sp_.(opentracing.Span); ok {
+	if sp, ok := sp_.(trace.Span); ok {
 		return sp
 	}
 	return nil
 }
 
-func SetSpan(ctx *gin.Context, span opentracing.Span) {
+func SetSpan(ctx *gin.Context, span trace.Span) {
 	ctx.Set(consts.TracingContextKey, span)
 }
 
-func startSpan(ctx context.Context, operationName string, options_ ...opentracing.StartSpanOption) opentracing.Span {
-	options := append([]opentracing.StartSpanOption{
-		opentracing.Tag{Key: "goroutine", Value: runtime.NumGoroutine()},
-		opentracing.Tag{Key: "file", Value: utils.FileWithLineNum()},
-		opentracing.Tag{Key: "user", Value: yataicontext.GetUserName(ctx)},
-	}, options_...)
-
-	return opentracing.StartSpan(operationName, options...)
+// ContextWithSpan returns the context which carries the current span of ctx in the way the otel instrumentations understand
+func ContextWithSpan(ctx context.Context) context.Context {
+	span := GetSpan(ctx)
+	if span == nil {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, span)
 }
 
-func StartSpan(ctx context.Context, operationName string) (context.Context, opentracing.Span) {
-	var options []opentracing.StartSpanOption
-
-	parentSpan := GetSpan(ctx)
-	if parentSpan != nil {
-		options = append(options, opentracing.ChildOf(parentSpan.Context()))
+// TraceID returns the hex trace id of the current span, it is empty if there is no span
+func TraceID(ctx context.Context) string {
+	span := GetSpan(ctx)
+	if span == nil {
+		return ""
 	}
-
-	span := startSpan(ctx, operationName, options...)
-	// nolint: ineffassign,staticcheck
-	return context.WithValue(ctx, consts.TracingContextKey, span), span
+	return span.SpanContext().TraceID().String()
 }
 
-func StartSpanWithParent(ctx context.Context, parent opentracing.SpanContext, operationName, method, path string) opentracing.Span {
-	options := []opentracing.StartSpanOption{
-		opentracing.Tag{Key: ext.SpanKindRPCServer.Key, Value: ext.SpanKindRPCServer.Value},
-		opentracing.Tag{Key: string(ext.HTTPMethod), Value: method},
-		opentracing.Tag{Key: string(ext.HTTPUrl), Value: path},
-	}
+func StartSpan(ctx context.Context, operationName string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	options = append([]trace.SpanStartOption{
+		trace.WithAttributes(
+			attribute.String("code.filepath", utils.FileWithLineNum()),
+			attribute.String("enduser.id", yataicontext.GetUserName(ctx)),
+		),
+	}, options...)
+	return tracer().Start(ContextWithSpan(ctx), operationName, options...)
+}
 
-	if parent != nil {
-		options = append(options, opentracing.ChildOf(parent))
+// EndSpan records the error if it is not nil and ends the span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
-
-	return startSpan(ctx, operationName, options...)
+	span.End()
 }
 
-func StartSpanWithHeader(ctx context.Context, header *http.Header, operationName, method, path string) opentracing.Span {
-	var wireContext opentracing.SpanContext
-	if header != nil {
-		wireContext, _ = opentracing.GlobalTracer().Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(*header))
-	}
+// InjectHTTPHeaders writes the trace context of ctx into the headers of the outgoing request
+func InjectHTTPHeaders(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ContextWithSpan(ctx), propagation.HeaderCarrier(header))
+}
 
-	return StartSpanWithParent(ctx, wireContext, operationName, method, path)
+// ExtractHTTPHeaders returns the context which carries the remote trace context of the incoming request
+func ExtractHTTPHeaders(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
 }
@@ -26,3 +26,8 @@ export async function deleteApiToken(apiTokenUid: string): Promise<IApiTokenSche
     const resp = await axios.delete<IApiTokenSchema>(`/api/v1/api_tokens/${apiTokenUid}`)
     return resp.data
 }
+
+export async function regenerateApiToken(apiTokenUid: string): Promise<IApiTokenFullSchema> {
+    const resp = await axios.post<IApiTokenFullSchema>(`/api/v1/api_tokens/${apiTokenUid}/regenerate`)
+    return resp.data
+}
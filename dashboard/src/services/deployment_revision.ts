@@ -1,5 +1,6 @@
 import axios from 'axios'
 import { IDeploymentRevisionSchema } from '@/schemas/deployment_revision'
+import { IDeploymentSchema } from '@/schemas/deployment'
 import { IListQuerySchema, IListSchema } from '@/schemas/list'
 
 export async function listDeploymentRevisions(
@@ -28,3 +29,15 @@ export async function fetchDeploymentRevision(
     )
     return resp.data
 }
+
+export async function rollbackDeploymentRevision(
+    clusterName: string,
+    kubeNamespace: string,
+    deploymentName: string,
+    revisionUid: string
+): Promise<IDeploymentSchema> {
+    const resp = await axios.post<IDeploymentSchema>(
+        `/api/v1/clusters/${clusterName}/namespaces/${kubeNamespace}/deployments/${deploymentName}/revisions/${revisionUid}/rollback`
+    )
+    return resp.data
+}